@@ -15,6 +15,14 @@ import (
 )
 
 func main() {
+	if os.Getenv(system.PasswordHelperEnv) != "" {
+		if err := system.RunPasswordHelper(); err != nil {
+			log.Errorf("Password helper failed: %v", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	c, err := conf.Parse()
 	if err != nil {
 		log.Errorf("Failed to parse conf file %s: %s", conf.ConfFile, err)
@@ -38,6 +46,33 @@ func main() {
 					os.Exit(1)
 				}
 
+				if err := system.CreateStateDirs(conf.StatePath, int(u.Uid), int(u.Gid)); err != nil {
+					log.Errorf("Failed to create state dir '%s': %+v", conf.StatePath, err)
+					os.Exit(1)
+				}
+
+				if err := system.CreateStateDirs(conf.LogPath, int(u.Uid), int(u.Gid)); err != nil {
+					log.Errorf("Failed to create log dir '%s': %+v", conf.LogPath, err)
+					os.Exit(1)
+				}
+
+				// Named network namespaces are bind mounted there, which needs
+				// write access after the privileges are dropped.
+				if err := system.CreateStateDirs("/run/netns", int(u.Uid), int(u.Gid)); err != nil {
+					log.Errorf("Failed to create netns dir '/run/netns': %+v", err)
+					os.Exit(1)
+				}
+
+				if err := system.StartPasswordHelper(); err != nil {
+					log.Warningf("Failed to start password helper, password logins will fail: %+v", err)
+				}
+
+				// systemd-networkd reads the WireGuard keys the daemon writes
+				// through this group.
+				if err := system.JoinGroup("systemd-network"); err != nil {
+					log.Warningf("Failed to join group 'systemd-network': %+v", err)
+				}
+
 				if err := system.EnableKeepCapability(); err != nil {
 					log.Warningf("Failed to enable keep capabilities: %+v", err)
 				}
@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"sort"
 
@@ -14,6 +15,7 @@ import (
 
 	"github.com/vmware/pmd-next-gen/pkg/validator"
 	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
 )
 
 func main() {
@@ -37,6 +39,26 @@ func main() {
 			Aliases: []string{"u"},
 			Usage:   "http://localhost:5208",
 		},
+		&cli.StringFlag{
+			Name:  "cert",
+			Usage: "Client certificate file used for HTTPS",
+		},
+		&cli.StringFlag{
+			Name:  "key",
+			Usage: "Client certificate key file used for HTTPS",
+		},
+		&cli.StringFlag{
+			Name:  "cacert",
+			Usage: "CA bundle used to verify the server certificate",
+		},
+	}
+
+	app.Before = func(c *cli.Context) error {
+		if c.String("cert") == "" && c.String("key") == "" && c.String("cacert") == "" {
+			return nil
+		}
+
+		return web.SetClientTLS(c.String("cert"), c.String("key"), c.String("cacert"))
 	}
 
 	app.EnableBashCompletion = true
@@ -440,6 +462,15 @@ func main() {
 			Name:    "network",
 			Aliases: []string{"n"},
 			Usage:   "Network device configuration",
+			Flags: []cli.Flag{
+				&cli.UintFlag{Name: "confirm", Usage: "Roll back the change unless confirmed within the given seconds"},
+			},
+			Before: func(c *cli.Context) error {
+				if c.Uint("confirm") > 0 {
+					token[networkd.ConfirmTimeoutHeader] = fmt.Sprint(c.Uint("confirm"))
+				}
+				return nil
+			},
 			Subcommands: []*cli.Command{
 				{
 					Name:        "set-dhcp",
@@ -932,199 +963,159 @@ func main() {
 					},
 				},
 				{
-					Name:        "create-vlan",
-					UsageText:   "create-vlan [VLAN name] dev [LINK MASTER] id [ID INTEGER]",
-					Description: "Create vlan.",
-
-					Action: func(c *cli.Context) error {
-						if c.NArg() < 5 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
-
-						networkCreateVLan(c.Args(), c.String("url"), token)
-						return nil
-					},
-				},
-				{
-					Name:        "create-bond",
-					UsageText:   "create-bond [BOND name] dev [LINK MASTER] mode [STRING] thp [STRING] ltr [string] mms [STRING]",
-					Description: "Create bond.",
+					Name:        "show-ip-rules",
+					UsageText:   "show-ip-rules family [ipv4|ipv6]",
+					Description: "Show the routing policy rules of the kernel.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 3 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
-
-						networkCreateBond(c.Args(), c.String("url"), token)
+						networkShowIPRules(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-bridge",
-					UsageText:   "create-bridge [BRIDGE name] dev [LINK MASTER]",
-					Description: "Create bridge.",
+					Name:        "add-ip-rule",
+					UsageText:   "add-ip-rule tos [NUMBER] from [ADDRESS] to [ADDRESS] fwmark [STRING] table [STRING] prio [NUMBER] iif [STRING] oif [STRING] srcport [STRING] destport [STRING] ipproto [STRING] invertrule [STRING] family [STRING] usr [STRING] suppressprefixlen [NUMBER] suppressifgrp [NUMBER] type [STRING]",
+					Description: "Add a routing policy rule to the kernel.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 3 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateBridge(c.Args(), c.String("url"), token)
+						networkAddIPRule(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-macvlan",
-					UsageText:   "create-macvlan [MACVLAN name] dev [LINK MASTER] mode [STRING]",
-					Description: "Create macvlan.",
+					Name:        "delete-ip-rule",
+					UsageText:   "delete-ip-rule tos [NUMBER] from [ADDRESS] to [ADDRESS] fwmark [STRING] table [STRING] prio [NUMBER] iif [STRING] oif [STRING] srcport [STRING] destport [STRING] ipproto [STRING] invertrule [STRING] family [STRING] usr [STRING] suppressprefixlen [NUMBER] suppressifgrp [NUMBER] type [STRING]",
+					Description: "Delete a routing policy rule from the kernel.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 5 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateMacVLan(c.Args(), c.String("url"), token)
+						networkRemoveIPRule(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-ipvlan",
-					UsageText:   "create-ipvlan [IPVLAN name] dev [LINK MASTER] mode [STRING] flags [STRING]",
-					Description: "Create ipvlan.",
+					Name:        "show-neighbors",
+					UsageText:   "show-neighbors dev [LINK] family [ipv4|ipv6]",
+					Description: "Show the IPv4 and IPv6 neighbors.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 3 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
-
-						networkCreateIpVLan(c.Args(), c.String("url"), token)
+						networkShowNeighbors(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-vxlan",
-					UsageText:   "create-vxlan [VXLAN name] dev [LINK MASTER] vni [INTEGER] remote [STRING] local [STRING] group [STRING] destport [INTEGER] independent [BOOLEAN]",
-					Description: "Create vxlan.",
+					Name:        "add-neighbor",
+					UsageText:   "add-neighbor dev [LINK] ip [ADDRESS] mac [MACADDRESS] state [STRING] router [BOOLEAN]",
+					Description: "Add a neighbor.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 5 {
+						if c.NArg() < 6 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateVxLan(c.Args(), c.String("url"), token)
+						networkAddNeighbor(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-wg",
-					UsageText:   "create-wg [WIREGUARD name] dev [LINK MASTER] skey [STRING] pkey [STRING] port [string] ips [string] endpoint [STRING]",
-					Description: "Create wg(wireguard).",
+					Name:        "replace-neighbor",
+					UsageText:   "replace-neighbor dev [LINK] ip [ADDRESS] mac [MACADDRESS] state [STRING] router [BOOLEAN]",
+					Description: "Add or replace a neighbor.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 9 {
+						if c.NArg() < 6 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateWireGuard(c.Args(), c.String("url"), token)
+						networkReplaceNeighbor(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-tun",
-					UsageText:   "create-tun [Tun name] dev [LINK MASTER] mq [STRING] pktinfo [STRING] vnet-hdr [string] usr [string] grp [STRING] kc [STRING]",
-					Description: "Create tun(Tun).",
+					Name:        "delete-neighbor",
+					UsageText:   "delete-neighbor dev [LINK] ip [ADDRESS]",
+					Description: "Delete a neighbor.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 3 {
+						if c.NArg() < 4 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateTunOrTap(c.Args(), c.String("tun"), c.String("url"), token)
+						networkRemoveNeighbor(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "create-tap",
-					UsageText:   "create-tap [Tap name] dev [LINK MASTER] mq [STRING] pktinfo [STRING] vnet-hdr [string] usr [string] grp [STRING] kc [STRING]",
-					Description: "Create tap(Tap).",
+					Name:        "flush-neighbors",
+					UsageText:   "flush-neighbors dev [LINK] family [ipv4|ipv6] all [BOOLEAN]",
+					Description: "Flush the neighbors of a link.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 3 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkCreateTunOrTap(c.Args(), c.String("tap"), c.String("url"), token)
+						networkFlushNeighbors(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "remove-netdev",
-					UsageText:   "remove-netdev [NETDEV name] kind [KIND {vlan|bridge|bond|vxlan|macvlan|macvtap|ipvlan|ipvtap|vrf|veth|ipip|sit|vti|gre|wg|tun]",
-					Description: "Removes .netdev and .network files.",
+					Name:        "show-netns",
+					UsageText:   "show-netns",
+					Description: "Show the named network namespaces.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 2 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
-
-						networkRemoveNetDev(c.Args(), c.String("url"), token)
+						networkShowNetNs(c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "add-nft-table",
-					UsageText:   "add-nft-table name [STRING] family [STRING]",
-					Description: "Add NFT tables.",
+					Name:        "create-netns",
+					UsageText:   "create-netns [NAME]",
+					Description: "Create a named network namespace.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 2 {
+						if c.NArg() < 1 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkAddNFTTable(c.Args(), c.String("url"), token)
-						return nil
-					},
-				},
-				{
-					Name:        "show-nft-table",
-					UsageText:   "show-nft-table family [STRING] table-name [STRING]",
-					Description: "Show NFT tables.",
-
-					Action: func(c *cli.Context) error {
-						networkShowNFTTable(c.Args(), c.String("url"), token)
+						networkCreateNetNs(c.Args().First(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "delete-nft-table",
-					UsageText:   "delete-nft-table name [STRING] family [STRING]",
-					Description: "Delete NFT tables.",
+					Name:        "remove-netns",
+					UsageText:   "remove-netns [NAME]",
+					Description: "Remove a named network namespace.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 2 {
+						if c.NArg() < 1 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkDeleteNFTTable(c.Args(), c.String("url"), token)
+						networkRemoveNetNs(c.Args().First(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "add-nft-chain",
-					UsageText:   "add-nft-chain name [STRING] table [STRING] family [STRING] hook [STRING] priority [STRING] type [STRING] policy [STRING]",
-					Description: "Add NFT chain.",
+					Name:        "add-netns-link",
+					UsageText:   "add-netns-link dev [LINK] netns [NAME]",
+					Description: "Move a link into a network namespace.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1132,14 +1123,14 @@ func main() {
 							return nil
 						}
 
-						networkAddNFTChain(c.Args(), c.String("url"), token)
+						networkAddNetNsLink(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "delete-nft-chain",
-					UsageText:   "delete-nft-chain name [STRING] table [STRING] family [STRING]",
-					Description: "Delete NFT chain.",
+					Name:        "remove-netns-link",
+					UsageText:   "remove-netns-link dev [LINK] netns [NAME]",
+					Description: "Move a link out of a network namespace.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1147,51 +1138,24 @@ func main() {
 							return nil
 						}
 
-						networkDeleteNFTChain(c.Args(), c.String("url"), token)
-						return nil
-					},
-				},
-				{
-					Name:        "show-nft-chain",
-					UsageText:   "show-nft-chain name [STRING] table [STRING] family [STRING]",
-					Description: "Show NFT chain.",
-
-					Action: func(c *cli.Context) error {
-						networkShowNFTChain(c.Args(), c.String("url"), token)
-						return nil
-					},
-				},
-				{
-					Name:        "nft-save",
-					UsageText:   "nft-save",
-					Description: "Save NFT configuration to a file.",
-
-					Action: func(c *cli.Context) error {
-						networkSaveNFT(c.String("url"), token)
+						networkRemoveNetNsLink(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "nft-run",
-					UsageText:   "nft-run",
-					Description: "Run NFT configuration command.",
+					Name:        "show-qdiscs",
+					UsageText:   "show-qdiscs dev [LINK]",
+					Description: "Show qdiscs with statistics.",
 
 					Action: func(c *cli.Context) error {
-						networkRunNFT(c.Args(), c.String("url"), token)
+						networkShowQdiscs(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
-			},
-		},
-		{
-			Name:    "link",
-			Aliases: []string{"l"},
-			Usage:   "Network device configuration",
-			Subcommands: []*cli.Command{
 				{
-					Name:        "set-mac",
-					UsageText:   "set-mac dev [LINK] macpolicy [string] macaddr [string]",
-					Description: "Sets the device's MAC configuration.",
+					Name:        "add-qdisc",
+					UsageText:   "add-qdisc dev [LINK] kind [fq_codel|tbf|htb|netem|ingress|clsact] parent [root|HANDLE] handle [HANDLE] limit [NUMBER] target [TIME] interval [TIME] flows [NUMBER] quantum [SIZE] ecn [BOOLEAN] rate [RATE] burst [SIZE] latency [TIME] peakrate [RATE] mtu [SIZE] default [CLASS] delay [TIME] jitter [TIME] loss [PERCENT] duplicate [PERCENT] reorder [PERCENT] corrupt [PERCENT]",
+					Description: "Add qdisc.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1199,14 +1163,14 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkMAC(c.Args(), c.String("url"), token)
+						networkAddQdisc(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-name",
-					UsageText:   "set-name dev [LINK] namepolicy [string] name [string]",
-					Description: "Sets the device's Name configuration.",
+					Name:        "replace-qdisc",
+					UsageText:   "replace-qdisc dev [LINK] kind [fq_codel|tbf|htb|netem|ingress|clsact] parent [root|HANDLE] handle [HANDLE] ...",
+					Description: "Add or replace qdisc.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1214,74 +1178,74 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkName(c.Args(), c.String("url"), token)
+						networkReplaceQdisc(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-alt-name",
-					UsageText:   "set-alt-name dev [LINK] altnamespolicy [string] altname [string]",
-					Description: "Sets the device's AlternativeName configuration.",
+					Name:        "delete-qdisc",
+					UsageText:   "delete-qdisc dev [LINK] parent [root|HANDLE] handle [HANDLE] kind [KIND]",
+					Description: "Delete qdisc.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkAltName(c.Args(), c.String("url"), token)
+						networkRemoveQdisc(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-csum-offload",
-					UsageText:   "set-csum-offload dev [LINK] rxco [string] txco [string]",
-					Description: "Sets the device's ChecksumOffload configuration.",
+					Name:        "show-tc-classes",
+					UsageText:   "show-tc-classes dev [LINK]",
+					Description: "Show tc classes with statistics.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkChecksumOffload(c.Args(), c.String("url"), token)
+						networkShowTCClasses(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-tcp-offload",
-					UsageText:   "set-tcp-offload dev [LINK] tcpso [string] tcp6so [string]",
-					Description: "Sets the device's TCPSegmentationOffload configuration.",
+					Name:        "add-tc-class",
+					UsageText:   "add-tc-class dev [LINK] parent [HANDLE] classid [CLASSID] rate [RATE] ceil [RATE] burst [SIZE] cburst [SIZE] prio [NUMBER] quantum [SIZE]",
+					Description: "Add htb class.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 8 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkTCPOffload(c.Args(), c.String("url"), token)
+						networkAddTCClass(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-generic-offload",
-					UsageText:   "set-generic-offload dev [LINK] gso [string] gro [string] grohw [string] gsomaxbytes [int] gsomaxseg [int]",
-					Description: "Sets the device's GenericOffload configuration.",
+					Name:        "replace-tc-class",
+					UsageText:   "replace-tc-class dev [LINK] parent [HANDLE] classid [CLASSID] rate [RATE] ceil [RATE] burst [SIZE] cburst [SIZE] prio [NUMBER] quantum [SIZE]",
+					Description: "Add or replace htb class.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 8 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkGenericOffload(c.Args(), c.String("url"), token)
+						networkReplaceTCClass(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-vlan-tags",
-					UsageText:   "set-vlan-tags dev [LINK] rxvlanctaghwacl [string] txvlanctaghwacl [string] rxvlanctagfilter [string] txvlanstaghwacl [string]",
-					Description: "Sets the device's VLANTags configuration.",
+					Name:        "delete-tc-class",
+					UsageText:   "delete-tc-class dev [LINK] classid [CLASSID]",
+					Description: "Delete tc class.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1289,29 +1253,29 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkVLANTags(c.Args(), c.String("url"), token)
+						networkRemoveTCClass(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-channel",
-					UsageText:   "set-channel dev [LINK] rxch [int] txch [int] och [int] coch [int]",
-					Description: "Sets the device's Channel configuration.",
+					Name:        "show-tc-filters",
+					UsageText:   "show-tc-filters dev [LINK] parent [HANDLE|ingress|egress]",
+					Description: "Show tc filters.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkChannel(c.Args(), c.String("url"), token)
+						networkShowTCFilters(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-buffer",
-					UsageText:   "set-buffer dev [LINK] rxbufsz [int] rxmbufsz [int] rxjbufsz [int] txbufsz [int]",
-					Description: "Sets the device's Buffer configuration.",
+					Name:        "add-tc-filter",
+					UsageText:   "add-tc-filter dev [LINK] parent [HANDLE|ingress|egress] kind [u32|matchall] prio [NUMBER] protocol [all|ip|ipv6|arp] classid [CLASSID] action [pass|drop] src [ADDRESS] dst [ADDRESS] ipproto [PROTOCOL] sport [PORT] dport [PORT]",
+					Description: "Add tc filter.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1319,14 +1283,14 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkBuffer(c.Args(), c.String("url"), token)
+						networkAddTCFilter(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-flow-ctrl",
-					UsageText:   "set-flow-ctrl dev [LINK] rxfctrl [string] txfctrl [string] anfctrl [string]",
-					Description: "Sets the device's Buffer configuration.",
+					Name:        "delete-tc-filter",
+					UsageText:   "delete-tc-filter dev [LINK] parent [HANDLE|ingress|egress] prio [NUMBER]",
+					Description: "Delete the tc filters of a priority.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1334,14 +1298,14 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkFlowControl(c.Args(), c.String("url"), token)
+						networkRemoveTCFilter(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-adpt-coalesce",
-					UsageText:   "set-adpt-coalesce dev [LINK] uarxc [string] uatxc [string]",
-					Description: "Sets the device's AdpativeCoalesce configuration.",
+					Name:        "add-tc-netem",
+					UsageText:   "add-tc-netem dev [LINK] parent [root|HANDLE] delay [TIME] jitter [TIME] limit [NUMBER] loss [PERCENT] duplicate [PERCENT]",
+					Description: "Add TrafficControlQueueingDiscipline.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1349,44 +1313,44 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkAdaptiveCoalesce(c.Args(), c.String("url"), token)
+						networkAddTCNetem(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-rx-coalesce",
-					UsageText:   "set-rx-coalesce dev [LINK] rxcs [int] rxcsirq [int] rxcslow [int] rxcshigh [int]",
-					Description: "Sets the device's ReceiveCoalesce configuration.",
+					Name:        "add-tc-tbf",
+					UsageText:   "add-tc-tbf dev [LINK] parent [root|HANDLE] handle [HANDLE] rate [RATE] burst [SIZE] latency [TIME] limit [SIZE] mpu [SIZE] peakrate [RATE] mtu [SIZE]",
+					Description: "Add TokenBucketFilter.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 6 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkRxCoalesce(c.Args(), c.String("url"), token)
+						networkAddTCTokenBucketFilter(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-tx-coalesce",
-					UsageText:   "set-tx-coalesce dev [LINK] txcs [int] txcsirq [int] txcslow [int] txcshigh [int]",
-					Description: "Sets the device's TransmitCoalesce configuration.",
+					Name:        "add-tc-htb",
+					UsageText:   "add-tc-htb dev [LINK] parent [root|HANDLE] handle [HANDLE] default [CLASS] r2q [NUMBER]",
+					Description: "Add HierarchyTokenBucket.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 2 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkTxCoalesce(c.Args(), c.String("url"), token)
+						networkAddTCHierarchyTokenBucket(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-rx-coald-frames",
-					UsageText:   "set-rx-coald-frames dev [LINK] rxmcf [int] rxmcfirq [int] rxmcflow [int] rxmcfhigh [int]",
-					Description: "Sets the device's ReceiveCoalescedFrames configuration.",
+					Name:        "add-tc-htb-class",
+					UsageText:   "add-tc-htb-class dev [LINK] parent [HANDLE] classid [CLASSID] prio [NUMBER] quantum [SIZE] mtu [SIZE] overhead [SIZE] rate [RATE] ceil [RATE] buffer [SIZE] cbuffer [SIZE]",
+					Description: "Add HierarchyTokenBucketClass.",
 
 					Action: func(c *cli.Context) error {
 						if c.NArg() < 4 {
@@ -1394,52 +1358,985 @@ func main() {
 							return nil
 						}
 
-						networkConfigureLinkRxCoalescedFrames(c.Args(), c.String("url"), token)
+						networkAddTCHierarchyTokenBucketClass(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-tx-coald-frames",
-					UsageText:   "set-tx-coald-frames dev [LINK] txmcf [int] txmcfirq [int] txmcflow [int] txmcfhigh [int]",
-					Description: "Sets the device's TransmitCoalescedFrames configuration.",
+					Name:        "remove-tc",
+					UsageText:   "remove-tc dev [LINK] netem [PARENT] tbf [PARENT] htb [PARENT] htb-class [CLASSID]",
+					Description: "Remove traffic control sections.",
 
 					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
+						if c.NArg() < 3 {
 							fmt.Printf("Too few arguments.\n")
 							return nil
 						}
 
-						networkConfigureLinkTxCoalescedFrames(c.Args(), c.String("url"), token)
+						networkRemoveTC(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
 				{
-					Name:        "set-coalesce-pkt",
-					UsageText:   "set-coalesce-pkt dev [LINK] cprlow [int] cprhigh [int] cprsis [int]",
-					Description: "Sets the device's CoalescePacketRate configuration.",
-
-					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
+					Name:        "bridge",
+					Description: "Manage bridge forwarding database, port VLANs and STP state.",
+					Subcommands: []*cli.Command{
+						{
+							Name:        "show-fdb",
+							UsageText:   "show-fdb bridge [BRIDGE]",
+							Description: "Show forwarding database entries.",
 
-						networkConfigureLinkCoalescePacketRate(c.Args(), c.String("url"), token)
-						return nil
-					},
-				},
-				{
-					Name:        "set-link",
-					UsageText:   "set-link dev [LINK] alias,desc... [string]",
-					Description: "Sets the device's alias,description,port,duplex,wakeonlan.",
+							Action: func(c *cli.Context) error {
+								networkShowBridgeFDB(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "add-fdb",
+							UsageText:   "add-fdb dev [LINK] mac [MACADDRESS] vlan [ID] self",
+							Description: "Add a static forwarding database entry.",
 
-					Action: func(c *cli.Context) error {
-						if c.NArg() < 4 {
-							fmt.Printf("Too few arguments.\n")
-							return nil
-						}
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
 
-						networkConfigureLink(c.Args(), c.String("url"), token)
+								networkAddBridgeFDB(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "delete-fdb",
+							UsageText:   "delete-fdb dev [LINK] mac [MACADDRESS] vlan [ID] self",
+							Description: "Delete a static forwarding database entry.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkRemoveBridgeFDB(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "show-vlan",
+							UsageText:   "show-vlan dev [LINK]",
+							Description: "Show VLANs of bridges and ports.",
+
+							Action: func(c *cli.Context) error {
+								networkShowBridgePortVLANs(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "add-vlan",
+							UsageText:   "add-vlan dev [LINK] vid [ID or RANGE] pvid untagged",
+							Description: "Add a VLAN to a bridge port.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkAddBridgePortVLAN(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "delete-vlan",
+							UsageText:   "delete-vlan dev [LINK] vid [ID or RANGE]",
+							Description: "Delete a VLAN from a bridge port.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkRemoveBridgePortVLAN(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "add-vlan-config",
+							UsageText:   "add-vlan-config dev [LINK] vlan [ID or RANGE] egress-untagged [ID or RANGE] pvid [ID]",
+							Description: "Configure BridgeVLAN section.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkAddBridgeVLANSection(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "remove-vlan-config",
+							UsageText:   "remove-vlan-config dev [LINK] vlan [ID or RANGE]",
+							Description: "Remove BridgeVLAN section.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkRemoveBridgeVLANSection(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "show-port",
+							UsageText:   "show-port bridge [BRIDGE]",
+							Description: "Show STP state, cost and priority of bridge ports.",
+
+							Action: func(c *cli.Context) error {
+								networkShowBridgePorts(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+						{
+							Name:        "set-port",
+							UsageText:   "set-port dev [LINK] state [disabled|listening|learning|forwarding|blocking] cost [NUMBER] priority [NUMBER]",
+							Description: "Set STP state, cost and priority of a bridge port.",
+
+							Action: func(c *cli.Context) error {
+								if c.NArg() < 4 {
+									fmt.Printf("Too few arguments.\n")
+									return nil
+								}
+
+								networkSetBridgePort(c.Args(), c.String("url"), token)
+								return nil
+							},
+						},
+					},
+				},
+				{
+					Name:        "show-bond",
+					UsageText:   "show-bond [BOND]",
+					Description: "Show the status of a bond and its slaves.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 1 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkShowBond(c.Args().First(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "add-bond-slave",
+					UsageText:   "add-bond-slave [BOND] dev [LINK]",
+					Description: "Enslave a link to a bond.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkAddBondSlave(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "remove-bond-slave",
+					UsageText:   "remove-bond-slave [BOND] dev [LINK]",
+					Description: "Release a link from a bond.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkRemoveBondSlave(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-bond-active-slave",
+					UsageText:   "set-bond-active-slave [BOND] dev [LINK]",
+					Description: "Change the active slave of a bond.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkSetBondActiveSlave(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "generate-wireguard-key",
+					UsageText:   "generate-wireguard-key [WIREGUARD name]",
+					Description: "Generate the key pair of a WireGuard device and show its public key.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 1 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkGenerateWireGuardKey(c.Args().First(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "show-wireguard",
+					UsageText:   "show-wireguard [WIREGUARD name]",
+					Description: "Show the peers, endpoints, latest handshakes and transfer of a WireGuard device.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 1 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkShowWireGuard(c.Args().First(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-vlan",
+					UsageText:   "create-vlan [VLAN name] dev [LINK MASTER] id [ID INTEGER]",
+					Description: "Create vlan.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 5 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateVLan(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-bond",
+					UsageText:   "create-bond [BOND name] dev [LINK MASTER] mode [STRING] thp [STRING] ltr [string] mms [STRING]",
+					Description: "Create bond.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateBond(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-bridge",
+					UsageText:   "create-bridge [BRIDGE name] dev [LINK MASTER]",
+					Description: "Create bridge.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateBridge(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-macvlan",
+					UsageText:   "create-macvlan [MACVLAN name] dev [LINK MASTER] mode [STRING]",
+					Description: "Create macvlan.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 5 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateMacVLan(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-ipvlan",
+					UsageText:   "create-ipvlan [IPVLAN name] dev [LINK MASTER] mode [STRING] flags [STRING]",
+					Description: "Create ipvlan.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateIpVLan(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-vxlan",
+					UsageText:   "create-vxlan [VXLAN name] dev [LINK MASTER] vni [INTEGER] remote [STRING] local [STRING] group [STRING] destport [INTEGER] independent [BOOLEAN]",
+					Description: "Create vxlan.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 5 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateVxLan(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-wg",
+					UsageText:   "create-wg [WIREGUARD name] dev [LINK MASTER] skey [STRING] skeyfile [FILE] pkey [STRING] port [string] ips [string] endpoint [STRING] pkey [STRING] ips [string] endpoint [STRING] ...",
+					Description: "Create wg(wireguard).",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 9 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateWireGuard(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-tun",
+					UsageText:   "create-tun [Tun name] dev [LINK MASTER] mq [STRING] pktinfo [STRING] vnet-hdr [string] usr [string] grp [STRING] kc [STRING]",
+					Description: "Create tun(Tun).",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunOrTap(c.Args(), c.String("tun"), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-tap",
+					UsageText:   "create-tap [Tap name] dev [LINK MASTER] mq [STRING] pktinfo [STRING] vnet-hdr [string] usr [string] grp [STRING] kc [STRING]",
+					Description: "Create tap(Tap).",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunOrTap(c.Args(), c.String("tap"), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-vrf",
+					UsageText:   "create-vrf [VRF name] table [INTEGER] dev [LINK,LINK...]",
+					Description: "Create vrf.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateVRF(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-veth",
+					UsageText:   "create-veth [VETH name] peer [PEER name] peermac [MACADDRESS]",
+					Description: "Create veth pair.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateVeth(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-dummy",
+					UsageText:   "create-dummy [DUMMY name]",
+					Description: "Create dummy.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 1 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateDummy(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-geneve",
+					UsageText:   "create-geneve [GENEVE name] id [INTEGER] remote [STRING] ttl [INTEGER] destport [INTEGER]",
+					Description: "Create geneve.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateGeneve(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-gre",
+					UsageText:   "create-gre [TUNNEL name] dev [LINK] local [STRING] remote [STRING] ttl [INTEGER] key [STRING] ikey [STRING] okey [STRING] independent [BOOLEAN]",
+					Description: "Create gre tunnel.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunnel(c.Args(), "gre", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-gretap",
+					UsageText:   "create-gretap [TUNNEL name] dev [LINK] local [STRING] remote [STRING] ttl [INTEGER] key [STRING] ikey [STRING] okey [STRING] independent [BOOLEAN]",
+					Description: "Create gretap tunnel.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunnel(c.Args(), "gretap", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-ipip",
+					UsageText:   "create-ipip [TUNNEL name] dev [LINK] local [STRING] remote [STRING] ttl [INTEGER] independent [BOOLEAN]",
+					Description: "Create ipip tunnel.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunnel(c.Args(), "ipip", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-sit",
+					UsageText:   "create-sit [TUNNEL name] dev [LINK] local [STRING] remote [STRING] ttl [INTEGER] independent [BOOLEAN]",
+					Description: "Create sit tunnel.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunnel(c.Args(), "sit", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-vti",
+					UsageText:   "create-vti [TUNNEL name] dev [LINK] local [STRING] remote [STRING] ttl [INTEGER] key [STRING] ikey [STRING] okey [STRING] independent [BOOLEAN]",
+					Description: "Create vti tunnel.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateTunnel(c.Args(), "vti", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "create-macsec",
+					UsageText:   "create-macsec [MACSEC name] dev [LINK] port [INTEGER] encrypt [BOOLEAN] txkeyid [INTEGER] txkey [HEX] txkeyfile [FILE] rxmac [MACADDRESS] rxport [INTEGER] rxkeyid [INTEGER] rxkey [HEX] rxkeyfile [FILE]",
+					Description: "Create macsec.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 3 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkCreateMACsec(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "remove-netdev",
+					UsageText:   "remove-netdev [NETDEV name] kind [KIND {vlan|bridge|bond|vxlan|macvlan|macvtap|ipvlan|ipvtap|vrf|veth|dummy|geneve|ipip|sit|vti|gre|gretap|macsec|wg|tun]",
+					Description: "Removes .netdev and .network files.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 2 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkRemoveNetDev(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "add-nft-table",
+					UsageText:   "add-nft-table name [STRING] family [STRING]",
+					Description: "Add NFT tables.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 2 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkAddNFTTable(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "show-nft-table",
+					UsageText:   "show-nft-table family [STRING] table-name [STRING]",
+					Description: "Show NFT tables.",
+
+					Action: func(c *cli.Context) error {
+						networkShowNFTTable(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "delete-nft-table",
+					UsageText:   "delete-nft-table name [STRING] family [STRING]",
+					Description: "Delete NFT tables.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 2 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkDeleteNFTTable(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "add-nft-chain",
+					UsageText:   "add-nft-chain name [STRING] table [STRING] family [STRING] hook [STRING] priority [STRING] type [STRING] policy [STRING]",
+					Description: "Add NFT chain.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkAddNFTChain(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "delete-nft-chain",
+					UsageText:   "delete-nft-chain name [STRING] table [STRING] family [STRING]",
+					Description: "Delete NFT chain.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkDeleteNFTChain(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "show-nft-chain",
+					UsageText:   "show-nft-chain name [STRING] table [STRING] family [STRING]",
+					Description: "Show NFT chain.",
+
+					Action: func(c *cli.Context) error {
+						networkShowNFTChain(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "add-nft-rule",
+					UsageText:   "add-nft-rule table [STRING] family [STRING] chain [STRING] iif [STRING] oif [STRING] saddr [ADDRESS|PREFIX] daddr [ADDRESS|PREFIX] proto [tcp|udp|sctp|icmp|icmpv6] sport [PORT|PORT-PORT] dport [PORT|PORT-PORT] ct-state [new,established,related,invalid,untracked] mark [NUMBER] counter [BOOL] log [BOOL] log-prefix [STRING] verdict [accept|drop|reject] jump [CHAIN]",
+					Description: "Add NFT rule.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 6 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkAddNFTRule(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "delete-nft-rule",
+					UsageText:   "delete-nft-rule table [STRING] family [STRING] chain [STRING] handle [NUMBER]",
+					Description: "Delete NFT rule by handle.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 6 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkDeleteNFTRule(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "show-nft-rule",
+					UsageText:   "show-nft-rule table [STRING] family [STRING] chain [STRING]",
+					Description: "Show NFT rules with handles and counters.",
+
+					Action: func(c *cli.Context) error {
+						networkShowNFTRule(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "nft-save",
+					UsageText:   "nft-save",
+					Description: "Save NFT configuration to a file.",
+
+					Action: func(c *cli.Context) error {
+						networkSaveNFT(c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "nft-run",
+					UsageText:   "nft-run",
+					Description: "Run NFT configuration command. Deprecated, needs the admin role.",
+
+					Action: func(c *cli.Context) error {
+						networkRunNFT(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "show-confirm",
+					UsageText:   "show-confirm",
+					Description: "Show the change awaiting confirmation.",
+
+					Action: func(c *cli.Context) error {
+						networkAcquireConfirmState(c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "confirm",
+					UsageText:   "confirm",
+					Description: "Confirm the change made with --confirm.",
+
+					Action: func(c *cli.Context) error {
+						networkConfirmChange(http.MethodPost, "confirm", c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "rollback",
+					UsageText:   "rollback",
+					Description: "Roll back the change made with --confirm right away.",
+
+					Action: func(c *cli.Context) error {
+						networkConfirmChange(http.MethodDelete, "roll back", c.String("url"), token)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "link",
+			Aliases: []string{"l"},
+			Usage:   "Network device configuration",
+			Subcommands: []*cli.Command{
+				{
+					Name:        "set-mac",
+					UsageText:   "set-mac dev [LINK] macpolicy [string] macaddr [string]",
+					Description: "Sets the device's MAC configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkMAC(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-name",
+					UsageText:   "set-name dev [LINK] namepolicy [string] name [string]",
+					Description: "Sets the device's Name configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkName(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-alt-name",
+					UsageText:   "set-alt-name dev [LINK] altnamespolicy [string] altname [string]",
+					Description: "Sets the device's AlternativeName configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkAltName(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-csum-offload",
+					UsageText:   "set-csum-offload dev [LINK] rxco [string] txco [string]",
+					Description: "Sets the device's ChecksumOffload configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkChecksumOffload(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-tcp-offload",
+					UsageText:   "set-tcp-offload dev [LINK] tcpso [string] tcp6so [string]",
+					Description: "Sets the device's TCPSegmentationOffload configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkTCPOffload(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-generic-offload",
+					UsageText:   "set-generic-offload dev [LINK] gso [string] gro [string] grohw [string] gsomaxbytes [int] gsomaxseg [int]",
+					Description: "Sets the device's GenericOffload configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkGenericOffload(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-vlan-tags",
+					UsageText:   "set-vlan-tags dev [LINK] rxvlanctaghwacl [string] txvlanctaghwacl [string] rxvlanctagfilter [string] txvlanstaghwacl [string]",
+					Description: "Sets the device's VLANTags configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkVLANTags(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-channel",
+					UsageText:   "set-channel dev [LINK] rxch [int] txch [int] och [int] coch [int]",
+					Description: "Sets the device's Channel configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkChannel(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-buffer",
+					UsageText:   "set-buffer dev [LINK] rxbufsz [int] rxmbufsz [int] rxjbufsz [int] txbufsz [int]",
+					Description: "Sets the device's Buffer configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkBuffer(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-flow-ctrl",
+					UsageText:   "set-flow-ctrl dev [LINK] rxfctrl [string] txfctrl [string] anfctrl [string]",
+					Description: "Sets the device's Buffer configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkFlowControl(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-adpt-coalesce",
+					UsageText:   "set-adpt-coalesce dev [LINK] uarxc [string] uatxc [string]",
+					Description: "Sets the device's AdpativeCoalesce configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkAdaptiveCoalesce(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-rx-coalesce",
+					UsageText:   "set-rx-coalesce dev [LINK] rxcs [int] rxcsirq [int] rxcslow [int] rxcshigh [int]",
+					Description: "Sets the device's ReceiveCoalesce configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkRxCoalesce(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-tx-coalesce",
+					UsageText:   "set-tx-coalesce dev [LINK] txcs [int] txcsirq [int] txcslow [int] txcshigh [int]",
+					Description: "Sets the device's TransmitCoalesce configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkTxCoalesce(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-rx-coald-frames",
+					UsageText:   "set-rx-coald-frames dev [LINK] rxmcf [int] rxmcfirq [int] rxmcflow [int] rxmcfhigh [int]",
+					Description: "Sets the device's ReceiveCoalescedFrames configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkRxCoalescedFrames(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-tx-coald-frames",
+					UsageText:   "set-tx-coald-frames dev [LINK] txmcf [int] txmcfirq [int] txmcflow [int] txmcfhigh [int]",
+					Description: "Sets the device's TransmitCoalescedFrames configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkTxCoalescedFrames(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-coalesce-pkt",
+					UsageText:   "set-coalesce-pkt dev [LINK] cprlow [int] cprhigh [int] cprsis [int]",
+					Description: "Sets the device's CoalescePacketRate configuration.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLinkCoalescePacketRate(c.Args(), c.String("url"), token)
+						return nil
+					},
+				},
+				{
+					Name:        "set-link",
+					UsageText:   "set-link dev [LINK] alias,desc... [string]",
+					Description: "Sets the device's alias,description,port,duplex,wakeonlan.",
+
+					Action: func(c *cli.Context) error {
+						if c.NArg() < 4 {
+							fmt.Printf("Too few arguments.\n")
+							return nil
+						}
+
+						networkConfigureLink(c.Args(), c.String("url"), token)
 						return nil
 					},
 				},
@@ -1861,6 +2758,17 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:      "monitor",
+			Aliases:   []string{"m"},
+			Usage:     "Watch link, address, route, systemd, hostname, timedate and job events",
+			UsageText: "monitor [TOPIC ...]",
+
+			Action: func(c *cli.Context) error {
+				monitorEvents(c.Args(), c.String("url"), token)
+				return nil
+			},
+		},
 		{
 			Name:    "proc",
 			Aliases: []string{"p"},
@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/google/nftables"
+	"github.com/vmware/pmd-next-gen/pkg/parser"
 	"github.com/vmware/pmd-next-gen/pkg/validator"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 	"github.com/vmware/pmd-next-gen/plugins/network/firewall"
@@ -28,6 +30,12 @@ type chainStats struct {
 	Errors  string                     `json:"errors"`
 }
 
+type nftRuleStats struct {
+	Success bool                `json:"success"`
+	Message []firewall.RuleInfo `json:"message"`
+	Errors  string              `json:"errors"`
+}
+
 func parseNFTTable(args cli.Args) (*firewall.Nft, error) {
 	argStrings := args.Slice()
 	n := firewall.Nft{}
@@ -97,6 +105,76 @@ func parseNFTChain(args cli.Args) (*firewall.Nft, error) {
 	return &n, nil
 }
 
+func parseNFTRule(args cli.Args) (*firewall.Nft, error) {
+	argStrings := args.Slice()
+	n := firewall.Nft{}
+
+	for i, args := range argStrings {
+		switch args {
+		case "table":
+			if validator.IsEmpty(argStrings[i+1]) {
+				return nil, fmt.Errorf("invalid table: '%s'", argStrings[i+1])
+			}
+			n.Rule.Table = argStrings[i+1]
+		case "family":
+			if !validator.IsNFTFamily(argStrings[i+1]) {
+				return nil, fmt.Errorf("invalid family: '%s'", argStrings[i+1])
+			}
+			n.Rule.Family = argStrings[i+1]
+		case "chain":
+			if validator.IsEmpty(argStrings[i+1]) {
+				return nil, fmt.Errorf("invalid chain: '%s'", argStrings[i+1])
+			}
+			n.Rule.Chain = argStrings[i+1]
+		case "handle":
+			if validator.IsEmpty(argStrings[i+1]) {
+				return nil, fmt.Errorf("invalid handle: '%s'", argStrings[i+1])
+			}
+			n.Rule.Handle = argStrings[i+1]
+		case "iif":
+			n.Rule.IncomingInterface = argStrings[i+1]
+		case "oif":
+			n.Rule.OutgoingInterface = argStrings[i+1]
+		case "saddr":
+			n.Rule.SourceAddress = argStrings[i+1]
+		case "daddr":
+			n.Rule.DestinationAddress = argStrings[i+1]
+		case "proto":
+			n.Rule.Protocol = argStrings[i+1]
+		case "sport":
+			n.Rule.SourcePort = argStrings[i+1]
+		case "dport":
+			n.Rule.DestinationPort = argStrings[i+1]
+		case "ct-state":
+			n.Rule.CtState = strings.Split(argStrings[i+1], ",")
+		case "mark":
+			n.Rule.Mark = argStrings[i+1]
+		case "counter":
+			b, err := parser.ParseBool(argStrings[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid counter: '%s'", argStrings[i+1])
+			}
+			n.Rule.Counter = b
+		case "log":
+			b, err := parser.ParseBool(argStrings[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid log: '%s'", argStrings[i+1])
+			}
+			n.Rule.Log = b
+		case "log-prefix":
+			n.Rule.Log = true
+			n.Rule.LogPrefix = argStrings[i+1]
+		case "verdict":
+			n.Rule.Verdict = argStrings[i+1]
+		case "jump":
+			n.Rule.Verdict = "jump"
+			n.Rule.JumpTarget = argStrings[i+1]
+		}
+	}
+
+	return &n, nil
+}
+
 func networkAddNFTTable(args cli.Args, host string, token map[string]string) {
 	n, err := parseNFTTable(args)
 	if err != nil {
@@ -300,3 +378,112 @@ func networkRunNFT(args cli.Args, host string, token map[string]string) {
 
 	fmt.Printf("%v", m.Message)
 }
+
+func networkAddNFTRule(args cli.Args, host string, token map[string]string) {
+	n, err := parseNFTRule(args)
+	if err != nil {
+		fmt.Printf("Failed to parse rule: %v\n", err)
+		return
+	}
+
+	resp, err := web.DispatchSocket(http.MethodPost, host, "/api/v1/network/firewall/nft/rule/add", token, n)
+	if err != nil {
+		fmt.Printf("Failed to add rule: %v\n", err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to add rule: %v\n", m.Errors)
+	}
+}
+
+func networkDeleteNFTRule(args cli.Args, host string, token map[string]string) {
+	n, err := parseNFTRule(args)
+	if err != nil {
+		fmt.Printf("Failed to parse rule: %v\n", err)
+		return
+	}
+
+	resp, err := web.DispatchSocket(http.MethodDelete, host, "/api/v1/network/firewall/nft/rule/remove", token, n)
+	if err != nil {
+		fmt.Printf("Failed to remove rule: %v\n", err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to remove rule: %v\n", m.Errors)
+	}
+}
+
+func printNFTRuleMatch(name string, value string) {
+	if !validator.IsEmpty(value) {
+		fmt.Printf(" %v %v", color.HiBlueString(name), value)
+	}
+}
+
+func networkShowNFTRule(args cli.Args, host string, token map[string]string) {
+	n, err := parseNFTRule(args)
+	if err != nil {
+		fmt.Printf("Failed to parse rule: %v\n", err)
+		return
+	}
+
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/firewall/nft/rule/show", token, n)
+	if err != nil {
+		fmt.Printf("Failed to show rule: %v\n", err)
+		return
+	}
+
+	rs := nftRuleStats{}
+	if err := json.Unmarshal(resp, &rs); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !rs.Success {
+		fmt.Printf("Failed to acquire rule: %v\n", rs.Errors)
+		return
+	}
+
+	for _, r := range rs.Message {
+		fmt.Printf("%v %v %v %v", color.HiYellowString("handle"), r.Handle, r.Family, r.Table+"/"+r.Chain)
+		printNFTRuleMatch("iif", r.IncomingInterface)
+		printNFTRuleMatch("oif", r.OutgoingInterface)
+		printNFTRuleMatch("saddr", r.SourceAddress)
+		printNFTRuleMatch("daddr", r.DestinationAddress)
+		printNFTRuleMatch("proto", r.Protocol)
+		printNFTRuleMatch("sport", r.SourcePort)
+		printNFTRuleMatch("dport", r.DestinationPort)
+		printNFTRuleMatch("ct-state", strings.Join(r.CtState, ","))
+		printNFTRuleMatch("mark", r.Mark)
+		if r.Counter {
+			fmt.Printf(" %v packets %v bytes %v", color.HiBlueString("counter"), r.Packets, r.Bytes)
+		}
+		if r.Log {
+			fmt.Printf(" %v", color.HiBlueString("log"))
+			printNFTRuleMatch("prefix", r.LogPrefix)
+		}
+		if !validator.IsEmpty(r.Verdict) {
+			fmt.Printf(" %v", r.Verdict)
+		}
+		if !validator.IsEmpty(r.JumpTarget) {
+			fmt.Printf(" %v", r.JumpTarget)
+		}
+		if r.Partial {
+			fmt.Printf(" %v", color.HiYellowString("(partial, see nft list ruleset)"))
+		}
+		fmt.Printf("\n")
+	}
+}
@@ -273,3 +273,92 @@ func TestRunNFT(t *testing.T) {
 	}
 
 }
+
+func TestNFTRule(t *testing.T) {
+	if err := addNFTTable(); err != nil {
+		t.Fatalf("Failed to add table: %v\n", err)
+	}
+	defer deleteNFTTable()
+
+	c := firewall.Nft{
+		Chain: firewall.Chain{
+			Name:   "chaintest99",
+			Table:  "test99",
+			Family: "inet",
+		},
+	}
+
+	resp, err := web.DispatchSocket(http.MethodPost, "", "/api/v1/network/firewall/nft/chain/add", nil, c)
+	if err != nil {
+		t.Fatalf("Failed to add chain: %v\n", err)
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !m.Success {
+		t.Fatalf("Failed to add chain: %v\n", m.Errors)
+	}
+
+	r := firewall.Nft{
+		Rule: firewall.Rule{
+			Table:             "test99",
+			Family:            "inet",
+			Chain:             "chaintest99",
+			IncomingInterface: "test99",
+			SourceAddress:     "192.168.1.0/24",
+			Protocol:          "tcp",
+			DestinationPort:   "8000-8080",
+			CtState:           []string{"new"},
+			Counter:           true,
+			Verdict:           "accept",
+		},
+	}
+
+	resp, err = web.DispatchSocket(http.MethodPost, "", "/api/v1/network/firewall/nft/rule/add", nil, r)
+	if err != nil {
+		t.Fatalf("Failed to add rule: %v\n", err)
+	}
+
+	m = web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !m.Success {
+		t.Fatalf("Failed to add rule: %v\n", m.Errors)
+	}
+
+	resp, err = web.DispatchSocket(http.MethodGet, "", "/api/v1/network/firewall/nft/rule/show", nil, r)
+	if err != nil {
+		t.Fatalf("Failed to acquire rules: %v\n", err)
+	}
+
+	rs := nftRuleStats{}
+	if err := json.Unmarshal(resp, &rs); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !rs.Success || len(rs.Message) != 1 {
+		t.Fatalf("Failed to acquire rules: %v\n", rs.Errors)
+	}
+
+	v := rs.Message[0]
+	if v.IncomingInterface != "test99" || v.SourceAddress != "192.168.1.0/24" || v.DestinationPort != "8000-8080" ||
+		v.Protocol != "tcp" || !v.Counter || v.Verdict != "accept" {
+		t.Fatalf("Invalid rule: %v", v)
+	}
+
+	r.Rule.Handle = v.Handle
+	resp, err = web.DispatchSocket(http.MethodDelete, "", "/api/v1/network/firewall/nft/rule/remove", nil, r)
+	if err != nil {
+		t.Fatalf("Failed to remove rule: %v\n", err)
+	}
+
+	m = web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !m.Success {
+		t.Fatalf("Failed to remove rule: %v\n", m.Errors)
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func displayEvent(e *events.Event) {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%v", e.Data))
+	}
+
+	fmt.Printf("%v %v %s\n", e.Time.Local().Format("15:04:05.000"), color.HiBlueString("%-8s", e.Topic), data)
+}
+
+func monitorEvents(topics cli.Args, host string, token map[string]string) {
+	path := "/api/v1/events"
+	if topics.Len() > 0 {
+		v := url.Values{}
+		v.Set("topic", strings.Join(topics.Slice(), ","))
+		path += "?" + v.Encode()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := web.DispatchStream(ctx, host, path, token, func(line string) error {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			return nil
+		}
+
+		e := events.Event{}
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			fmt.Printf("Failed to decode json message: %v\n", err)
+			return nil
+		}
+
+		displayEvent(&e)
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		fmt.Printf("Failed to monitor events: %v\n", err)
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+var errEventReceived = errors.New("event received")
+
+func TestMonitorLinkEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	received := make(chan error, 1)
+	go func() {
+		received <- web.DispatchStream(ctx, "", "/api/v1/events?topic=link", nil, func(line string) error {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				return nil
+			}
+
+			e := events.Event{}
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				return err
+			}
+
+			if e.Topic != events.TopicLink {
+				return errors.New("unexpected topic " + e.Topic)
+			}
+
+			if strings.Contains(data, "test99") {
+				return errEventReceived
+			}
+			return nil
+		})
+	}()
+
+	time.Sleep(time.Second)
+
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	if err := <-received; !errors.Is(err, errEventReceived) {
+		t.Fatalf("Failed to receive link event: %v\n", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/fatih/color"
@@ -1724,3 +1725,53 @@ func networkConfigureDHCPv6Option(args cli.Args, host string, token map[string]s
 	// Dispatch Request.
 	networkConfigure(&n, host, token)
 }
+
+func networkAcquireConfirmState(host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/networkd/confirm", token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire pending change: %v\n", err)
+		return
+	}
+
+	m := struct {
+		Success bool                  `json:"success"`
+		Message networkd.ConfirmState `json:"message"`
+		Errors  string                `json:"errors"`
+	}{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	p := m.Message.Pending
+	if p == nil {
+		fmt.Println("No change awaits confirmation")
+		return
+	}
+
+	fmt.Printf("%v %v %v\n", color.HiBlueString("  Change:"), p.Method, p.Path)
+	fmt.Printf("%v %v\n", color.HiBlueString(" Started:"), p.Started.Local().Format(time.RFC1123))
+	fmt.Printf("%v %v (%v left)\n", color.HiBlueString("Deadline:"), p.Deadline.Local().Format(time.RFC1123), time.Until(p.Deadline).Round(time.Second))
+	fmt.Printf("%v %v\n", color.HiBlueString("   Files:"), strings.Join(p.Files, " "))
+}
+
+func networkConfirmChange(method string, action string, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(method, host, "/api/v1/network/networkd/confirm", token, nil)
+	if err != nil {
+		fmt.Printf("Failed to %s change: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s change: %v\n", action, m.Errors)
+		return
+	}
+
+	fmt.Println(m.Message)
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/bond"
+)
+
+type bondStats struct {
+	Success bool          `json:"success"`
+	Message bond.BondInfo `json:"message"`
+	Errors  string        `json:"errors"`
+}
+
+func networkDispatchBond(method string, action string, url string, data interface{}, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(method, host, url, token, data)
+	if err != nil {
+		fmt.Printf("Failed to %s: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s: %v\n", action, m.Errors)
+	}
+}
+
+// parseBondSlave takes the bond name followed by the dev argument.
+func parseBondSlave(args cli.Args) (string, string, error) {
+	argStrings := args.Slice()
+
+	dev := ""
+	for i := 1; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			dev = argStrings[i+1]
+		}
+	}
+
+	if args.First() == "" || dev == "" {
+		return "", "", fmt.Errorf("Missing bond or dev\n")
+	}
+
+	return args.First(), dev, nil
+}
+
+func networkAddBondSlave(args cli.Args, host string, token map[string]string) {
+	name, dev, err := parseBondSlave(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBond(http.MethodPost, "enslave link", "/api/v1/network/bond/"+name+"/slave", bond.BondSlave{Link: dev}, host, token)
+}
+
+func networkRemoveBondSlave(args cli.Args, host string, token map[string]string) {
+	name, dev, err := parseBondSlave(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBond(http.MethodDelete, "release link", "/api/v1/network/bond/"+name+"/slave", bond.BondSlave{Link: dev}, host, token)
+}
+
+func networkSetBondActiveSlave(args cli.Args, host string, token map[string]string) {
+	name, dev, err := parseBondSlave(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBond(http.MethodPut, "set active slave", "/api/v1/network/bond/"+name+"/active-slave", bond.BondSlave{Link: dev}, host, token)
+}
+
+func printBondField(indent int, label string, value interface{}) {
+	if fmt.Sprint(value) == "" {
+		return
+	}
+
+	fmt.Printf("%*s%v %v\n", indent-len(label), "", color.HiBlueString(label), value)
+}
+
+func printLACPPort(label string, p *bond.LACPPort) {
+	if p == nil {
+		return
+	}
+
+	printBondField(24, label, fmt.Sprintf("system %v priority %v key %v port %v priority %v state %v",
+		p.SystemMACAddress, p.SystemPriority, p.Key, p.PortNumber, p.PortPriority, p.PortState))
+}
+
+func networkShowBond(name string, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/bond/"+name, token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire bond: %v\n", err)
+		return
+	}
+
+	m := bondStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire bond: %v\n", m.Errors)
+		return
+	}
+
+	b := m.Message
+	printBondField(24, "Name:", b.Name)
+	printBondField(24, "OperState:", b.OperState)
+	printBondField(24, "Mode:", b.Mode)
+	printBondField(24, "MII Status:", b.MIIStatus)
+	printBondField(24, "MII Monitor (ms):", b.MIIMonitorMSec)
+	printBondField(24, "Transmit Hash Policy:", b.TransmitHashPolicy)
+	printBondField(24, "LACP Transmit Rate:", b.LACPTransmitRate)
+	printBondField(24, "Primary Slave:", b.PrimarySlave)
+	printBondField(24, "Active Slave:", b.ActiveSlave)
+	printBondField(24, "System MAC Address:", b.SystemMACAddress)
+	if b.Aggregator != nil {
+		printBondField(24, "Aggregator:", b.Aggregator.AggregatorId+" ports "+b.Aggregator.NumberOfPorts)
+		printBondField(24, "Partner MAC Address:", b.Aggregator.PartnerMACAddress)
+	}
+
+	for _, s := range b.Slaves {
+		fmt.Println()
+		printBondField(24, "Slave:", s.Name)
+		printBondField(24, "State:", s.State)
+		printBondField(24, "MII Status:", s.MIIStatus)
+		printBondField(24, "Speed:", s.Speed)
+		printBondField(24, "Duplex:", s.Duplex)
+		printBondField(24, "Link Failure Count:", s.LinkFailureCount)
+		printBondField(24, "Permanent HW Address:", s.PermHardwareAddr)
+		if s.AggregatorId != 0 {
+			printBondField(24, "Aggregator:", s.AggregatorId)
+		}
+		printLACPPort("Actor:", s.Actor)
+		printLACPPort("Partner:", s.Partner)
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/plugins/network/bond"
+)
+
+func TestBondSlave(t *testing.T) {
+	b := netlink.NewLinkBond(netlink.LinkAttrs{Name: "test-bond"})
+	b.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+	b.Miimon = 100
+	setupLink(t, b)
+	defer removeLink(t, "test-bond")
+
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-slave1"}})
+	defer removeLink(t, "test-slave1")
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-slave2"}})
+	defer removeLink(t, "test-slave2")
+
+	dispatch(t, http.MethodPost, "/api/v1/network/bond/test-bond/slave", bond.BondSlave{Link: "test-slave1"})
+	dispatch(t, http.MethodPost, "/api/v1/network/bond/test-bond/slave", bond.BondSlave{Link: "test-slave2"})
+	dispatch(t, http.MethodPut, "/api/v1/network/bond/test-bond/active-slave", bond.BondSlave{Link: "test-slave2"})
+
+	info, err := bond.AcquireBond("test-bond")
+	if err != nil {
+		t.Fatalf("Failed to acquire bond: %v\n", err)
+	}
+	if info.Mode != "active-backup" || info.MIIMonitorMSec != 100 {
+		t.Fatalf("Invalid bond: %v", info)
+	}
+	if len(info.Slaves) != 2 {
+		t.Fatalf("Failed to enslave links: %v", info.Slaves)
+	}
+	if info.ActiveSlave != "test-slave2" {
+		t.Fatalf("Failed to set active slave: %v", info.ActiveSlave)
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/bond/test-bond/slave", bond.BondSlave{Link: "test-slave1"})
+
+	l, err := netlink.LinkByName("test-slave1")
+	if err != nil {
+		t.Fatalf("Failed to find link test-slave1: %v\n", err)
+	}
+	if l.Attrs().MasterIndex != 0 {
+		t.Fatalf("Failed to release test-slave1")
+	}
+}
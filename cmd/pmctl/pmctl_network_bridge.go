@@ -0,0 +1,354 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/bridge"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
+)
+
+type fdbStats struct {
+	Success bool             `json:"success"`
+	Message []bridge.FDBInfo `json:"message"`
+	Errors  string           `json:"errors"`
+}
+
+type portVLANStats struct {
+	Success bool                  `json:"success"`
+	Message []bridge.PortVLANInfo `json:"message"`
+	Errors  string                `json:"errors"`
+}
+
+type bridgePortStats struct {
+	Success bool              `json:"success"`
+	Message []bridge.PortInfo `json:"message"`
+	Errors  string            `json:"errors"`
+}
+
+func networkDispatchBridge(method string, action string, url string, data interface{}, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(method, host, url, token, data)
+	if err != nil {
+		fmt.Printf("Failed to %s: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s: %v\n", action, m.Errors)
+	}
+}
+
+// bridgeQuery takes the "bridge" or "dev" argument of the listings.
+func bridgeQuery(args cli.Args, key string, param string) string {
+	argStrings := args.Slice()
+
+	for i := 0; i < len(argStrings)-1; i++ {
+		if argStrings[i] == key {
+			return "?" + param + "=" + argStrings[i+1]
+		}
+	}
+
+	return ""
+}
+
+func parseBridgeFDB(args cli.Args) (*bridge.FDBEntry, error) {
+	argStrings := args.Slice()
+
+	f := bridge.FDBEntry{}
+	for i := 0; i < len(argStrings); i++ {
+		switch argStrings[i] {
+		case "self":
+			f.Self = true
+		}
+
+		if i+1 == len(argStrings) {
+			break
+		}
+
+		switch argStrings[i] {
+		case "dev":
+			f.Link = argStrings[i+1]
+		case "mac":
+			if validator.IsNotMAC(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid mac=%s\n", argStrings[i+1])
+			}
+			f.MACAddress = argStrings[i+1]
+		case "vlan":
+			if !validator.IsBridgeVLANId(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid vlan=%s\n", argStrings[i+1])
+			}
+			f.VLAN = argStrings[i+1]
+		}
+	}
+
+	if f.Link == "" || f.MACAddress == "" {
+		return nil, fmt.Errorf("Missing dev or mac\n")
+	}
+
+	return &f, nil
+}
+
+func networkAddBridgeFDB(args cli.Args, host string, token map[string]string) {
+	f, err := parseBridgeFDB(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBridge(http.MethodPost, "add fdb entry", "/api/v1/network/netlink/bridge/fdb", f, host, token)
+}
+
+func networkRemoveBridgeFDB(args cli.Args, host string, token map[string]string) {
+	f, err := parseBridgeFDB(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBridge(http.MethodDelete, "delete fdb entry", "/api/v1/network/netlink/bridge/fdb", f, host, token)
+}
+
+func networkShowBridgeFDB(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/netlink/bridge/fdb"+bridgeQuery(args, "bridge", "bridge"), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire fdb: %v\n", err)
+		return
+	}
+
+	m := fdbStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire fdb: %v\n", m.Errors)
+		return
+	}
+
+	for _, f := range m.Message {
+		s := []string{"dev", f.LinkName}
+		if f.VLAN != 0 {
+			s = append(s, "vlan", fmt.Sprint(f.VLAN))
+		}
+		if f.Master != "" {
+			s = append(s, "master", f.Master)
+		}
+		s = append(s, f.State)
+		s = append(s, f.Flags...)
+
+		fmt.Printf("%v %v\n", color.HiBlueString(f.MACAddress), strings.Join(s, " "))
+	}
+}
+
+func parseBridgePortVLAN(args cli.Args) (*bridge.PortVLAN, error) {
+	argStrings := args.Slice()
+
+	v := bridge.PortVLAN{}
+	for i := 0; i < len(argStrings); i++ {
+		switch argStrings[i] {
+		case "pvid":
+			v.PVID = true
+		case "untagged":
+			v.Untagged = true
+		}
+
+		if i+1 == len(argStrings) {
+			break
+		}
+
+		switch argStrings[i] {
+		case "dev":
+			v.Link = argStrings[i+1]
+		case "vid":
+			if !validator.IsBridgeVLAN(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid vid=%s\n", argStrings[i+1])
+			}
+			v.VLAN = argStrings[i+1]
+		}
+	}
+
+	if v.Link == "" || v.VLAN == "" {
+		return nil, fmt.Errorf("Missing dev or vid\n")
+	}
+
+	return &v, nil
+}
+
+func networkAddBridgePortVLAN(args cli.Args, host string, token map[string]string) {
+	v, err := parseBridgePortVLAN(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBridge(http.MethodPost, "add vlan", "/api/v1/network/netlink/bridge/vlan", v, host, token)
+}
+
+func networkRemoveBridgePortVLAN(args cli.Args, host string, token map[string]string) {
+	v, err := parseBridgePortVLAN(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBridge(http.MethodDelete, "delete vlan", "/api/v1/network/netlink/bridge/vlan", v, host, token)
+}
+
+func networkShowBridgePortVLANs(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/netlink/bridge/vlan"+bridgeQuery(args, "dev", "link"), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire vlans: %v\n", err)
+		return
+	}
+
+	m := portVLANStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire vlans: %v\n", m.Errors)
+		return
+	}
+
+	for _, v := range m.Message {
+		s := []string{fmt.Sprint(v.VLAN)}
+		if v.PVID {
+			s = append(s, "PVID")
+		}
+		if v.Untagged {
+			s = append(s, "Egress Untagged")
+		}
+
+		fmt.Printf("%v %v\n", color.HiBlueString(fmt.Sprintf("%-16s", v.LinkName)), strings.Join(s, " "))
+	}
+}
+
+func networkSetBridgePort(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+
+	p := bridge.Port{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			p.Link = argStrings[i+1]
+		case "state":
+			p.State = argStrings[i+1]
+		case "cost":
+			if !validator.IsUint32(argStrings[i+1]) {
+				fmt.Printf("Invalid cost=%s\n", argStrings[i+1])
+				return
+			}
+			p.Cost = argStrings[i+1]
+		case "priority":
+			if !validator.IsUint8(argStrings[i+1]) {
+				fmt.Printf("Invalid priority=%s\n", argStrings[i+1])
+				return
+			}
+			p.Priority = argStrings[i+1]
+		}
+	}
+
+	if p.Link == "" {
+		fmt.Printf("Missing dev\n")
+		return
+	}
+
+	networkDispatchBridge(http.MethodPut, "set bridge port", "/api/v1/network/netlink/bridge/port", p, host, token)
+}
+
+func networkShowBridgePorts(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/netlink/bridge/port"+bridgeQuery(args, "bridge", "bridge"), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire bridge ports: %v\n", err)
+		return
+	}
+
+	m := bridgePortStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire bridge ports: %v\n", m.Errors)
+		return
+	}
+
+	for _, p := range m.Message {
+		fmt.Printf("%v master %v state %v priority %v cost %v\n", color.HiBlueString(p.LinkName), p.Master, color.HiYellowString(p.State), p.Priority, p.Cost)
+	}
+}
+
+func parseBridgeVLANSection(args cli.Args) (*networkd.Network, error) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	v := networkd.BridgeVLANSection{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "vlan":
+			if !validator.IsBridgeVLAN(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid vlan=%s\n", argStrings[i+1])
+			}
+			v.VLAN = argStrings[i+1]
+		case "egress-untagged":
+			if !validator.IsBridgeVLAN(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid egress-untagged=%s\n", argStrings[i+1])
+			}
+			v.EgressUntagged = argStrings[i+1]
+		case "pvid":
+			if !validator.IsBridgeVLANId(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid pvid=%s\n", argStrings[i+1])
+			}
+			v.PVID = argStrings[i+1]
+		}
+	}
+
+	if n.Link == "" {
+		return nil, fmt.Errorf("Missing dev\n")
+	}
+
+	n.BridgeVLANSections = append(n.BridgeVLANSections, v)
+	return &n, nil
+}
+
+func networkAddBridgeVLANSection(args cli.Args, host string, token map[string]string) {
+	n, err := parseBridgeVLANSection(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkConfigure(n, host, token)
+}
+
+func networkRemoveBridgeVLANSection(args cli.Args, host string, token map[string]string) {
+	n, err := parseBridgeVLANSection(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchBridge(http.MethodDelete, "remove bridge vlan", "/api/v1/network/networkd/network/remove", n, host, token)
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/bridge"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
+)
+
+func TestBridgeFDBVLANPort(t *testing.T) {
+	setupLink(t, &netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: "test-br"}})
+	defer removeLink(t, "test-br")
+
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-port"}})
+	defer removeLink(t, "test-port")
+
+	br, _ := netlink.LinkByName("test-br")
+	port, _ := netlink.LinkByName("test-port")
+	if err := netlink.LinkSetMaster(port, br); err != nil {
+		t.Fatalf("Failed to enslave test-port: %v\n", err)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/bridge/fdb", bridge.FDBEntry{Link: "test-port", MACAddress: "00:a0:de:63:7a:e6", VLAN: "10"})
+
+	fdb, err := bridge.AcquireFDB("test-br")
+	if err != nil {
+		t.Fatalf("Failed to acquire fdb: %v\n", err)
+	}
+
+	found := false
+	for _, f := range fdb {
+		if f.MACAddress == "00:a0:de:63:7a:e6" && f.LinkName == "test-port" && f.VLAN == 10 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Failed to add fdb entry: %v", fdb)
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netlink/bridge/fdb", bridge.FDBEntry{Link: "test-port", MACAddress: "00:a0:de:63:7a:e6", VLAN: "10"})
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/bridge/vlan", bridge.PortVLAN{Link: "test-port", VLAN: "20", PVID: true, Untagged: true})
+
+	vlans, err := bridge.AcquirePortVLANs("test-port")
+	if err != nil {
+		t.Fatalf("Failed to acquire vlans: %v\n", err)
+	}
+
+	found = false
+	for _, v := range vlans {
+		if v.VLAN == 20 && v.PVID && v.Untagged {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Failed to add vlan 20: %v", vlans)
+	}
+
+	dispatch(t, http.MethodPut, "/api/v1/network/netlink/bridge/port", bridge.Port{Link: "test-port", Cost: "50", Priority: "10"})
+
+	ports, err := bridge.AcquirePorts("test-br")
+	if err != nil {
+		t.Fatalf("Failed to acquire bridge ports: %v\n", err)
+	}
+	if len(ports) != 1 || ports[0].Cost != 50 || ports[0].Priority != 10 {
+		t.Fatalf("Failed to set bridge port: %v", ports)
+	}
+}
+
+func TestNetworkBridgeVLAN(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	system.ExecRun("systemctl", "restart", "systemd-networkd")
+	time.Sleep(time.Second * 3)
+
+	n := networkd.Network{
+		Link: "test99",
+		BridgeVLANSections: []networkd.BridgeVLANSection{
+			{
+				VLAN:           "100-200",
+				EgressUntagged: "150",
+				PVID:           "150",
+			},
+		},
+	}
+
+	m, err := configureNetwork(t, n)
+	if err != nil {
+		t.Fatalf("Failed to configure BridgeVLAN: %v\n", err)
+	}
+	defer os.Remove(m.Path)
+
+	if m.GetKeySectionString("BridgeVLAN", "VLAN") != "100-200" {
+		t.Fatalf("Failed to set VLAN")
+	}
+	if m.GetKeySectionString("BridgeVLAN", "EgressUntagged") != "150" {
+		t.Fatalf("Failed to set EgressUntagged")
+	}
+	if m.GetKeySectionString("BridgeVLAN", "PVID") != "150" {
+		t.Fatalf("Failed to set PVID")
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/neighbor"
+)
+
+type neighborStats struct {
+	Success bool                    `json:"success"`
+	Message []neighbor.NeighborInfo `json:"message"`
+	Errors  string                  `json:"errors"`
+}
+
+func parseNeighbor(args cli.Args) (*neighbor.Neighbor, error) {
+	argStrings := args.Slice()
+
+	n := neighbor.Neighbor{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "ip":
+			if !validator.IsIP(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid ip=%s\n", argStrings[i+1])
+			}
+			n.IP = argStrings[i+1]
+		case "mac":
+			if validator.IsNotMAC(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid mac=%s\n", argStrings[i+1])
+			}
+			n.MACAddress = argStrings[i+1]
+		case "state":
+			n.State = argStrings[i+1]
+		case "router":
+			if !validator.IsBool(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid router=%s\n", argStrings[i+1])
+			}
+			n.Router = validator.BoolToString(argStrings[i+1]) == "yes"
+		}
+	}
+
+	if validator.IsEmpty(n.Link) || validator.IsEmpty(n.IP) {
+		return nil, fmt.Errorf("Missing dev or ip\n")
+	}
+
+	return &n, nil
+}
+
+func networkDispatchNeighbor(method string, action string, args cli.Args, host string, token map[string]string) {
+	n, err := parseNeighbor(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	resp, err := web.DispatchSocket(method, host, "/api/v1/network/netlink/neighbor", token, *n)
+	if err != nil {
+		fmt.Printf("Failed to %s neighbor: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s neighbor: %v\n", action, m.Errors)
+	}
+}
+
+func networkAddNeighbor(args cli.Args, host string, token map[string]string) {
+	networkDispatchNeighbor(http.MethodPost, "add", args, host, token)
+}
+
+func networkReplaceNeighbor(args cli.Args, host string, token map[string]string) {
+	networkDispatchNeighbor(http.MethodPut, "replace", args, host, token)
+}
+
+func networkRemoveNeighbor(args cli.Args, host string, token map[string]string) {
+	networkDispatchNeighbor(http.MethodDelete, "delete", args, host, token)
+}
+
+// neighborQuery builds the query of the dev, family and all arguments.
+func neighborQuery(args cli.Args) (string, url.Values) {
+	argStrings := args.Slice()
+
+	link := ""
+	q := url.Values{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			link = argStrings[i+1]
+		case "family":
+			q.Set("family", argStrings[i+1])
+		case "all":
+			q.Set("all", argStrings[i+1])
+		}
+	}
+
+	return link, q
+}
+
+func networkFlushNeighbors(args cli.Args, host string, token map[string]string) {
+	link, q := neighborQuery(args)
+	if validator.IsEmpty(link) {
+		fmt.Printf("Missing dev\n")
+		return
+	}
+
+	resp, err := web.DispatchSocket(http.MethodDelete, host, "/api/v1/network/netlink/neighbor/"+link+"?"+q.Encode(), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to flush neighbors: %v\n", err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to flush neighbors: %v\n", m.Errors)
+	}
+}
+
+func networkShowNeighbors(args cli.Args, host string, token map[string]string) {
+	link, q := neighborQuery(args)
+	if link != "" {
+		q.Set("link", link)
+	}
+
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/netlink/neighbor?"+q.Encode(), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire neighbors: %v\n", err)
+		return
+	}
+
+	m := neighborStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire neighbors: %v\n", m.Errors)
+		return
+	}
+
+	for _, n := range m.Message {
+		mac := n.MACAddress
+		if mac == "" {
+			mac = "-"
+		}
+
+		fmt.Printf("%-40v %-10v %-18v %v %v\n", color.HiBlueString(n.IP), n.LinkName, mac, color.HiGreenString(strings.Join(n.State, ",")), strings.Join(n.Flags, " "))
+	}
+}
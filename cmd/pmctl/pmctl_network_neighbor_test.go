@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/neighbor"
+)
+
+func findNeighbor(t *testing.T, index int, ip string) *netlink.Neigh {
+	neighs, err := netlink.NeighList(index, netlink.FAMILY_V4)
+	if err != nil {
+		t.Fatalf("Failed to list neighbors: %v\n", err)
+	}
+
+	for i := range neighs {
+		if neighs[i].IP.String() == ip {
+			return &neighs[i]
+		}
+	}
+
+	return nil
+}
+
+func TestNeighbor(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-br"}})
+	defer removeLink(t, "test-br")
+
+	l, err := netlink.LinkByName("test-br")
+	if err != nil {
+		t.Fatalf("Failed to find link test-br: %v\n", err)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/neighbor", neighbor.Neighbor{Link: "test-br", IP: "192.168.98.10", MACAddress: "00:11:22:33:44:55"})
+
+	n := findNeighbor(t, l.Attrs().Index, "192.168.98.10")
+	if n == nil {
+		t.Fatalf("Failed to find neighbor 192.168.98.10")
+	}
+	if n.HardwareAddr.String() != "00:11:22:33:44:55" || n.State != netlink.NUD_PERMANENT {
+		t.Fatalf("Invalid neighbor: mac=%v state=%d", n.HardwareAddr, n.State)
+	}
+
+	dispatch(t, http.MethodPut, "/api/v1/network/netlink/neighbor", neighbor.Neighbor{Link: "test-br", IP: "192.168.98.10", MACAddress: "00:11:22:33:44:66", State: "reachable"})
+
+	n = findNeighbor(t, l.Attrs().Index, "192.168.98.10")
+	if n == nil || n.HardwareAddr.String() != "00:11:22:33:44:66" {
+		t.Fatalf("Failed to replace neighbor 192.168.98.10")
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netlink/neighbor", neighbor.Neighbor{Link: "test-br", IP: "192.168.98.10"})
+
+	if findNeighbor(t, l.Attrs().Index, "192.168.98.10") != nil {
+		t.Fatalf("Failed to delete neighbor 192.168.98.10")
+	}
+}
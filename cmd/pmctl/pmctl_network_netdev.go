@@ -340,14 +340,23 @@ func networkCreateWireGuard(args cli.Args, host string, token map[string]string)
 		Kind: "wireguard",
 	}
 
+	// Each pkey after the first starts another peer, which the ips and
+	// endpoint following it belong to.
+	peer := &n.WireGuardPeerSection
 	for i := 1; i < len(argStrings); {
 		switch argStrings[i] {
 		case "dev":
 			n.Links = strings.Fields(argStrings[i+1])
 		case "skey":
 			n.WireGuardSection.PrivateKey = argStrings[i+1]
+		case "skeyfile":
+			n.WireGuardSection.PrivateKeyFile = argStrings[i+1]
 		case "pkey":
-			n.WireGuardPeerSection.PublicKey = argStrings[i+1]
+			if !validator.IsEmpty(peer.PublicKey) {
+				n.WireGuardPeerSections = append(n.WireGuardPeerSections, networkd.WireGuardPeer{})
+				peer = &n.WireGuardPeerSections[len(n.WireGuardPeerSections)-1]
+			}
+			peer.PublicKey = argStrings[i+1]
 		case "port":
 			if validator.IsWireGuardListenPort(argStrings[i+1]) {
 				n.WireGuardSection.ListenPort = argStrings[i+1]
@@ -363,10 +372,10 @@ func networkCreateWireGuard(args cli.Args, host string, token map[string]string)
 					return
 				}
 			}
-			n.WireGuardPeerSection.AllowedIPs = ips
+			peer.AllowedIPs = ips
 		case "endpoint":
 			if validator.IsWireGuardPeerEndpoint(argStrings[i+1]) {
-				n.WireGuardPeerSection.Endpoint = argStrings[i+1]
+				peer.Endpoint = argStrings[i+1]
 			} else {
 				fmt.Printf("Failed to parse endpoint: %s\n", argStrings[i+1])
 				return
@@ -376,9 +385,10 @@ func networkCreateWireGuard(args cli.Args, host string, token map[string]string)
 		i++
 	}
 
-	if validator.IsArrayEmpty(n.Links) || validator.IsEmpty(n.Name) || validator.IsEmpty(n.WireGuardSection.PrivateKey) ||
+	if validator.IsArrayEmpty(n.Links) || validator.IsEmpty(n.Name) ||
+		(validator.IsEmpty(n.WireGuardSection.PrivateKey) && validator.IsEmpty(n.WireGuardSection.PrivateKeyFile)) ||
 		validator.IsEmpty(n.WireGuardPeerSection.PublicKey) || validator.IsEmpty(n.WireGuardPeerSection.Endpoint) {
-		fmt.Printf("Failed to create WireGuard. Missing WireGuard name, skey, pkey or dev\n")
+		fmt.Printf("Failed to create WireGuard. Missing WireGuard name, skey or skeyfile, pkey or dev\n")
 		return
 	}
 
@@ -449,6 +459,270 @@ func networkCreateTunOrTap(args cli.Args, kind, host string, token map[string]st
 	}
 }
 
+func networkConfigureNetDev(n *networkd.NetDev, desc string, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodPost, host, "/api/v1/network/networkd/netdev/configure", token, *n)
+	if err != nil {
+		fmt.Printf("Failed to create %s: %v\n", desc, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to create %s: %v\n", desc, m.Errors)
+	}
+}
+
+func networkCreateVRF(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+	n := networkd.NetDev{
+		Name: argStrings[0],
+		Kind: "vrf",
+	}
+
+	for i := 1; i < len(argStrings); {
+		switch argStrings[i] {
+		case "dev":
+			n.Links = strings.Split(argStrings[i+1], ",")
+		case "table":
+			if !validator.IsVRFTable(argStrings[i+1]) {
+				fmt.Printf("Invalid table: %s\n", argStrings[i+1])
+				return
+			}
+			n.VRFSection.Table = argStrings[i+1]
+		}
+
+		i++
+	}
+
+	if validator.IsEmpty(n.Name) || validator.IsEmpty(n.VRFSection.Table) {
+		fmt.Printf("Failed to create VRF. Missing VRF name or table\n")
+		return
+	}
+
+	networkConfigureNetDev(&n, "VRF", host, token)
+}
+
+func networkCreateVeth(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+	n := networkd.NetDev{
+		Name: argStrings[0],
+		Kind: "veth",
+	}
+
+	for i := 1; i < len(argStrings); {
+		switch argStrings[i] {
+		case "peer":
+			n.PeerSection.Name = argStrings[i+1]
+		case "peermac":
+			if validator.IsNotMAC(argStrings[i+1]) {
+				fmt.Printf("Invalid peermac: %s\n", argStrings[i+1])
+				return
+			}
+			n.PeerSection.MACAddress = argStrings[i+1]
+		}
+
+		i++
+	}
+
+	if validator.IsEmpty(n.Name) || validator.IsEmpty(n.PeerSection.Name) {
+		fmt.Printf("Failed to create Veth. Missing Veth name or peer\n")
+		return
+	}
+
+	networkConfigureNetDev(&n, "Veth", host, token)
+}
+
+func networkCreateDummy(args cli.Args, host string, token map[string]string) {
+	n := networkd.NetDev{
+		Name: args.First(),
+		Kind: "dummy",
+	}
+
+	if validator.IsEmpty(n.Name) {
+		fmt.Printf("Failed to create dummy. Missing dummy name\n")
+		return
+	}
+
+	networkConfigureNetDev(&n, "dummy", host, token)
+}
+
+func networkCreateGeneve(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+	n := networkd.NetDev{
+		Name: argStrings[0],
+		Kind: "geneve",
+	}
+
+	for i := 1; i < len(argStrings); {
+		switch argStrings[i] {
+		case "id":
+			if !validator.IsGeneveId(argStrings[i+1]) {
+				fmt.Printf("Invalid id: %s\n", argStrings[i+1])
+				return
+			}
+			n.GeneveSection.Id = argStrings[i+1]
+		case "remote":
+			if !validator.IsIP(argStrings[i+1]) {
+				fmt.Printf("Invalid remote: %s\n", argStrings[i+1])
+				return
+			}
+			n.GeneveSection.Remote = argStrings[i+1]
+		case "ttl":
+			if !validator.IsTTL(argStrings[i+1]) {
+				fmt.Printf("Invalid ttl: %s\n", argStrings[i+1])
+				return
+			}
+			n.GeneveSection.TTL = argStrings[i+1]
+		case "destport":
+			if !validator.IsPort(argStrings[i+1]) {
+				fmt.Printf("Invalid destport: %s\n", argStrings[i+1])
+				return
+			}
+			n.GeneveSection.DestinationPort = argStrings[i+1]
+		}
+
+		i++
+	}
+
+	if validator.IsEmpty(n.Name) || validator.IsEmpty(n.GeneveSection.Id) {
+		fmt.Printf("Failed to create Geneve. Missing Geneve name or id\n")
+		return
+	}
+
+	networkConfigureNetDev(&n, "Geneve", host, token)
+}
+
+func networkCreateTunnel(args cli.Args, kind string, host string, token map[string]string) {
+	argStrings := args.Slice()
+	n := networkd.NetDev{
+		Name: argStrings[0],
+		Kind: kind,
+	}
+
+	for i := 1; i < len(argStrings); {
+		switch argStrings[i] {
+		case "dev":
+			n.Links = strings.Fields(argStrings[i+1])
+		case "local":
+			if !validator.IsTunnelAddress(argStrings[i+1]) {
+				fmt.Printf("Invalid local: %s\n", argStrings[i+1])
+				return
+			}
+			n.TunnelSection.Local = argStrings[i+1]
+		case "remote":
+			if !validator.IsTunnelAddress(argStrings[i+1]) {
+				fmt.Printf("Invalid remote: %s\n", argStrings[i+1])
+				return
+			}
+			n.TunnelSection.Remote = argStrings[i+1]
+		case "ttl":
+			if !validator.IsTTL(argStrings[i+1]) {
+				fmt.Printf("Invalid ttl: %s\n", argStrings[i+1])
+				return
+			}
+			n.TunnelSection.TTL = argStrings[i+1]
+		case "key", "ikey", "okey":
+			if !validator.IsTunnelKeyKind(kind) {
+				fmt.Printf("%s is not supported with %s\n", argStrings[i], kind)
+				return
+			}
+			if !validator.IsTunnelKey(argStrings[i+1]) {
+				fmt.Printf("Invalid %s: %s\n", argStrings[i], argStrings[i+1])
+				return
+			}
+			switch argStrings[i] {
+			case "key":
+				n.TunnelSection.Key = argStrings[i+1]
+			case "ikey":
+				n.TunnelSection.InputKey = argStrings[i+1]
+			case "okey":
+				n.TunnelSection.OutputKey = argStrings[i+1]
+			}
+		case "independent":
+			if !validator.IsBool(argStrings[i+1]) {
+				fmt.Printf("Invalid independent: %s\n", argStrings[i+1])
+				return
+			}
+			n.TunnelSection.Independent = validator.BoolToString(argStrings[i+1])
+		}
+
+		i++
+	}
+
+	if validator.IsEmpty(n.Name) || (validator.IsArrayEmpty(n.Links) && n.TunnelSection.Independent != "yes") {
+		fmt.Printf("Failed to create %s. Missing tunnel name or dev\n", kind)
+		return
+	}
+
+	networkConfigureNetDev(&n, kind, host, token)
+}
+
+func networkCreateMACsec(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+	n := networkd.NetDev{
+		Name: argStrings[0],
+		Kind: "macsec",
+	}
+
+	for i := 1; i < len(argStrings); {
+		switch argStrings[i] {
+		case "dev":
+			n.Links = strings.Fields(argStrings[i+1])
+		case "port":
+			if !validator.IsMACsecPort(argStrings[i+1]) {
+				fmt.Printf("Invalid port: %s\n", argStrings[i+1])
+				return
+			}
+			n.MACsecSection.Port = argStrings[i+1]
+		case "encrypt":
+			if !validator.IsBool(argStrings[i+1]) {
+				fmt.Printf("Invalid encrypt: %s\n", argStrings[i+1])
+				return
+			}
+			n.MACsecSection.Encrypt = validator.BoolToString(argStrings[i+1])
+		case "txkeyid":
+			n.MACsecTransmitAssociationSection.KeyId = argStrings[i+1]
+		case "txkey":
+			n.MACsecTransmitAssociationSection.Key = argStrings[i+1]
+		case "txkeyfile":
+			n.MACsecTransmitAssociationSection.KeyFile = argStrings[i+1]
+		case "rxmac":
+			n.MACsecReceiveAssociationSection.MACAddress = argStrings[i+1]
+		case "rxport":
+			n.MACsecReceiveAssociationSection.Port = argStrings[i+1]
+		case "rxkeyid":
+			n.MACsecReceiveAssociationSection.KeyId = argStrings[i+1]
+		case "rxkey":
+			n.MACsecReceiveAssociationSection.Key = argStrings[i+1]
+		case "rxkeyfile":
+			n.MACsecReceiveAssociationSection.KeyFile = argStrings[i+1]
+		}
+
+		i++
+	}
+
+	if validator.IsArrayEmpty(n.Links) || validator.IsEmpty(n.Name) {
+		fmt.Printf("Failed to create MACsec. Missing MACsec name or dev\n")
+		return
+	}
+
+	// The associations are used right away.
+	if n.MACsecTransmitAssociationSection != (networkd.MACsecTransmitAssociation{}) {
+		n.MACsecTransmitAssociationSection.Activate = "yes"
+		n.MACsecTransmitAssociationSection.UseForEncoding = "yes"
+	}
+	if n.MACsecReceiveAssociationSection != (networkd.MACsecReceiveAssociation{}) {
+		n.MACsecReceiveAssociationSection.Activate = "yes"
+	}
+
+	networkConfigureNetDev(&n, "MACsec", host, token)
+}
+
 func networkRemoveNetDev(args cli.Args, host string, token map[string]string) {
 	argStrings := args.Slice()
 	n := networkd.NetDev{
@@ -657,3 +657,301 @@ func TestNetDevCreateTap(t *testing.T) {
 		t.Fatalf("Failed to remove .network file='%v'", err)
 	}
 }
+
+func TestNetDevCreateVRF(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	n := networkd.NetDev{
+		Name:  "vrf99",
+		Kind:  "vrf",
+		Links: []string{"test99"},
+		VRFSection: networkd.VRF{
+			Table: "99",
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create VRF: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("vrf99") {
+		t.Fatalf("Failed to create vrf='vrf99'")
+	}
+
+	s, _ := system.ExecAndCapture("ip", "-d", "link", "show", "vrf99")
+	fmt.Println(s)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("vrf99", "vrf")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of vrf='vrf99'")
+	}
+
+	if m.GetKeySectionString("NetDev", "Kind") != "vrf" {
+		t.Fatalf("VRF kind is not 'vrf' in .netdev file of vrf='vrf99'")
+	}
+
+	if m.GetKeySectionString("VRF", "Table") != "99" {
+		t.Fatalf("Invalid VRF table in .netdev file of vrf='vrf99'")
+	}
+
+	m, err = networkd.CreateOrParseNetworkFile("test99")
+	if err != nil {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+	defer os.Remove(m.Path)
+
+	if m.GetKeySectionString("Network", "VRF") != "vrf99" {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
+
+func TestNetDevCreateVeth(t *testing.T) {
+	n := networkd.NetDev{
+		Name: "veth99",
+		Kind: "veth",
+		PeerSection: networkd.Peer{
+			Name:       "veth-peer99",
+			MACAddress: "00:a0:de:63:7a:e6",
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create Veth: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("veth99") || !validator.LinkExists("veth-peer99") {
+		t.Fatalf("Failed to create veth='veth99'")
+	}
+
+	s, _ := system.ExecAndCapture("ip", "-d", "link", "show", "veth99")
+	fmt.Println(s)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("veth99", "veth")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of veth='veth99'")
+	}
+
+	if m.GetKeySectionString("NetDev", "Kind") != "veth" {
+		t.Fatalf("Veth kind is not 'veth' in .netdev file of veth='veth99'")
+	}
+
+	if m.GetKeySectionString("Peer", "Name") != "veth-peer99" {
+		t.Fatalf("Invalid Veth peer name in .netdev file of veth='veth99'")
+	}
+
+	if m.GetKeySectionString("Peer", "MACAddress") != "00:a0:de:63:7a:e6" {
+		t.Fatalf("Invalid Veth peer macaddress in .netdev file of veth='veth99'")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
+
+func TestNetDevCreateDummy(t *testing.T) {
+	n := networkd.NetDev{
+		Name: "dummy99",
+		Kind: "dummy",
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create Dummy: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("dummy99") {
+		t.Fatalf("Failed to create dummy='dummy99'")
+	}
+
+	m, _, err := networkd.CreateOrParseNetDevFile("dummy99", "dummy")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of dummy='dummy99'")
+	}
+
+	if m.GetKeySectionString("NetDev", "Kind") != "dummy" {
+		t.Fatalf("Dummy kind is not 'dummy' in .netdev file of dummy='dummy99'")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
+
+func TestNetDevCreateGeneve(t *testing.T) {
+	n := networkd.NetDev{
+		Name: "geneve99",
+		Kind: "geneve",
+		GeneveSection: networkd.Geneve{
+			Id:              "99",
+			Remote:          "192.168.1.2",
+			TTL:             "64",
+			DestinationPort: "6081",
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create Geneve: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("geneve99") {
+		t.Fatalf("Failed to create geneve='geneve99'")
+	}
+
+	s, _ := system.ExecAndCapture("ip", "-d", "link", "show", "geneve99")
+	fmt.Println(s)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("geneve99", "geneve")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of geneve='geneve99'")
+	}
+
+	if m.GetKeySectionString("GENEVE", "Id") != "99" {
+		t.Fatalf("Invalid Geneve id in .netdev file of geneve='geneve99'")
+	}
+
+	if m.GetKeySectionString("GENEVE", "Remote") != "192.168.1.2" {
+		t.Fatalf("Invalid Geneve remote in .netdev file of geneve='geneve99'")
+	}
+
+	if m.GetKeySectionString("GENEVE", "DestinationPort") != "6081" {
+		t.Fatalf("Invalid Geneve destinationport in .netdev file of geneve='geneve99'")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
+
+func TestNetDevCreateGRE(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	n := networkd.NetDev{
+		Name:  "gre99",
+		Kind:  "gre",
+		Links: []string{"test99"},
+		TunnelSection: networkd.Tunnel{
+			Local:  "192.168.1.1",
+			Remote: "192.168.1.2",
+			TTL:    "64",
+			Key:    "99",
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create GRE: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("gre99") {
+		t.Fatalf("Failed to create gre='gre99'")
+	}
+
+	s, _ := system.ExecAndCapture("ip", "-d", "link", "show", "gre99")
+	fmt.Println(s)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("gre99", "gre")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of gre='gre99'")
+	}
+
+	if m.GetKeySectionString("Tunnel", "Local") != "192.168.1.1" {
+		t.Fatalf("Invalid tunnel local in .netdev file of gre='gre99'")
+	}
+
+	if m.GetKeySectionString("Tunnel", "Remote") != "192.168.1.2" {
+		t.Fatalf("Invalid tunnel remote in .netdev file of gre='gre99'")
+	}
+
+	if m.GetKeySectionString("Tunnel", "Key") != "99" {
+		t.Fatalf("Invalid tunnel key in .netdev file of gre='gre99'")
+	}
+
+	m, err = networkd.CreateOrParseNetworkFile("test99")
+	if err != nil {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+	defer os.Remove(m.Path)
+
+	if m.GetKeySectionString("Network", "Tunnel") != "gre99" {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
+
+func TestNetDevCreateMACsec(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	n := networkd.NetDev{
+		Name:  "macsec99",
+		Kind:  "macsec",
+		Links: []string{"test99"},
+		MACsecSection: networkd.MACsec{
+			Port:    "99",
+			Encrypt: "yes",
+		},
+		MACsecTransmitAssociationSection: networkd.MACsecTransmitAssociation{
+			KeyId:          "01",
+			Key:            "dffafc8d7b9a43d5b9a3dfbbf6a30c16",
+			Activate:       "yes",
+			UseForEncoding: "yes",
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create MACsec: %v\n", err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	if !validator.LinkExists("macsec99") {
+		t.Fatalf("Failed to create macsec='macsec99'")
+	}
+
+	s, _ := system.ExecAndCapture("ip", "-d", "link", "show", "macsec99")
+	fmt.Println(s)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("macsec99", "macsec")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of macsec='macsec99'")
+	}
+
+	if m.GetKeySectionString("MACsec", "Encrypt") != "yes" {
+		t.Fatalf("Invalid MACsec encrypt in .netdev file of macsec='macsec99'")
+	}
+
+	if m.GetKeySectionString("MACsecTransmitAssociation", "KeyId") != "01" {
+		t.Fatalf("Invalid MACsec transmit association keyid in .netdev file of macsec='macsec99'")
+	}
+
+	m, err = networkd.CreateOrParseNetworkFile("test99")
+	if err != nil {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+	defer os.Remove(m.Path)
+
+	if m.GetKeySectionString("Network", "MACsec") != "macsec99" {
+		t.Fatalf("Failed to parse .network file of test99")
+	}
+
+	if err := networkd.RemoveNetDev(n.Name, n.Kind); err != nil {
+		t.Fatalf("Failed to remove .network file='%v'", err)
+	}
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netns"
+)
+
+type netNsStats struct {
+	Success bool              `json:"success"`
+	Message []netns.NetNsInfo `json:"message"`
+	Errors  string            `json:"errors"`
+}
+
+func networkDispatchNetNs(method string, action string, url string, data interface{}, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(method, host, url, token, data)
+	if err != nil {
+		fmt.Printf("Failed to %s: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s: %v\n", action, m.Errors)
+	}
+}
+
+func networkCreateNetNs(name string, host string, token map[string]string) {
+	networkDispatchNetNs(http.MethodPost, "create netns", "/api/v1/network/netns", netns.NetNs{Name: name}, host, token)
+}
+
+func networkRemoveNetNs(name string, host string, token map[string]string) {
+	networkDispatchNetNs(http.MethodDelete, "remove netns", "/api/v1/network/netns/"+name, nil, host, token)
+}
+
+// parseNetNsLink takes the dev and netns arguments.
+func parseNetNsLink(args cli.Args) (string, string, error) {
+	argStrings := args.Slice()
+
+	dev, name := "", ""
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			dev = argStrings[i+1]
+		case "netns":
+			name = argStrings[i+1]
+		}
+	}
+
+	if dev == "" || name == "" {
+		return "", "", fmt.Errorf("Missing dev or netns\n")
+	}
+
+	return dev, name, nil
+}
+
+func networkAddNetNsLink(args cli.Args, host string, token map[string]string) {
+	dev, name, err := parseNetNsLink(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchNetNs(http.MethodPost, "move link", "/api/v1/network/netns/"+name+"/link", netns.NetNsLink{Link: dev}, host, token)
+}
+
+func networkRemoveNetNsLink(args cli.Args, host string, token map[string]string) {
+	dev, name, err := parseNetNsLink(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchNetNs(http.MethodDelete, "move link", "/api/v1/network/netns/"+name+"/link", netns.NetNsLink{Link: dev}, host, token)
+}
+
+func networkShowNetNs(host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/netns", token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire netns: %v\n", err)
+		return
+	}
+
+	m := netNsStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire netns: %v\n", m.Errors)
+		return
+	}
+
+	for _, n := range m.Message {
+		if n.NetNsID >= 0 {
+			fmt.Printf("%v (id: %v)\n", color.HiBlueString(n.Name), n.NetNsID)
+		} else {
+			fmt.Printf("%v\n", color.HiBlueString(n.Name))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	vnetns "github.com/vmware/pmd-next-gen/plugins/network/netns"
+)
+
+func TestNetNs(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-br"}})
+	defer removeLink(t, "test-br")
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netns", vnetns.NetNs{Name: "test-ns"})
+	if _, err := os.Stat("/run/netns/test-ns"); err != nil {
+		t.Fatalf("Failed to create netns test-ns: %v\n", err)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netns/test-ns/link", vnetns.NetNsLink{Link: "test-br"})
+	if _, err := netlink.LinkByName("test-br"); err == nil {
+		t.Fatalf("Failed to move link test-br into netns test-ns")
+	}
+
+	ns, err := netns.GetFromName("test-ns")
+	if err != nil {
+		t.Fatalf("Failed to open netns test-ns: %v\n", err)
+	}
+	h, err := netlink.NewHandleAt(ns)
+	ns.Close()
+	if err != nil {
+		t.Fatalf("Failed to open netlink handle: %v\n", err)
+	}
+	if _, err := h.LinkByName("test-br"); err != nil {
+		t.Fatalf("Failed to find link test-br in netns test-ns: %v\n", err)
+	}
+	h.Close()
+
+	links, err := vnetns.AcquireLinks("test-ns")
+	if err != nil {
+		t.Fatalf("Failed to acquire links of netns test-ns: %v\n", err)
+	}
+	found := false
+	for _, l := range links {
+		if l.Name == "test-br" {
+			found = l.Namespace == "test-ns"
+		}
+	}
+	if !found {
+		t.Fatalf("Failed to acquire link test-br of netns test-ns")
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netns/test-ns/link", vnetns.NetNsLink{Link: "test-br"})
+	if _, err := netlink.LinkByName("test-br"); err != nil {
+		t.Fatalf("Failed to move link test-br out of netns test-ns: %v\n", err)
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netns/test-ns", nil)
+	if _, err := os.Stat("/run/netns/test-ns"); err == nil {
+		t.Fatalf("Failed to remove netns test-ns")
+	}
+}
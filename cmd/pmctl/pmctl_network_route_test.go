@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/route"
+)
+
+func acquireRoutes(t *testing.T, table string, family string) []route.RouteInfo {
+	resp, err := web.DispatchSocket(http.MethodGet, "", "/api/v1/network/netlink/route?table="+table+"&family="+family, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to acquire routes: %v\n", err)
+	}
+
+	m := struct {
+		Success bool              `json:"success"`
+		Message []route.RouteInfo `json:"message"`
+		Errors  string            `json:"errors"`
+	}{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !m.Success {
+		t.Fatalf("Failed to acquire routes: %v\n", m.Errors)
+	}
+
+	return m.Message
+}
+
+func findRoute(t *testing.T, table int, dst string) []netlink.Route {
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		t.Fatalf("Failed to list routes: %v\n", err)
+	}
+
+	var found []netlink.Route
+	for _, r := range routes {
+		if r.Dst != nil && r.Dst.String() == dst {
+			found = append(found, r)
+		}
+	}
+
+	return found
+}
+
+func TestRoute(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-rt"}})
+	defer removeLink(t, "test-rt")
+
+	l, err := netlink.LinkByName("test-rt")
+	if err != nil {
+		t.Fatalf("Failed to find link test-rt: %v\n", err)
+	}
+	if err := netlink.LinkSetUp(l); err != nil {
+		t.Fatalf("Failed to set link test-rt up: %v\n", err)
+	}
+	a, _ := netlink.ParseAddr("192.168.97.1/24")
+	if err := netlink.AddrAdd(l, a); err != nil {
+		t.Fatalf("Failed to add address to link test-rt: %v\n", err)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/route", route.Route{Link: "test-rt", Destination: "10.97.1.0/24", Gateway: "192.168.97.254", Table: "97", Metric: 10})
+
+	rs := findRoute(t, 97, "10.97.1.0/24")
+	if len(rs) != 1 || rs[0].LinkIndex != l.Attrs().Index || rs[0].Gw.String() != "192.168.97.254" || rs[0].Priority != 10 {
+		t.Fatalf("Invalid route 10.97.1.0/24: %v", rs)
+	}
+
+	found := false
+	for _, r := range acquireRoutes(t, "97", "ipv4") {
+		if r.Dst.IP == "10.97.1.0" && r.Dst.Mask == 24 {
+			found = r.Table == 97 && r.LinkName == "test-rt" && r.Gw == "192.168.97.254"
+		}
+	}
+	if !found {
+		t.Fatalf("Failed to acquire route 10.97.1.0/24 of table 97")
+	}
+	for _, r := range acquireRoutes(t, "97", "ipv6") {
+		if r.Dst.IP == "10.97.1.0" {
+			t.Fatalf("Route 10.97.1.0/24 listed as ipv6")
+		}
+	}
+	for _, r := range acquireRoutes(t, "main", "ipv4") {
+		if r.Dst.IP == "10.97.1.0" {
+			t.Fatalf("Route 10.97.1.0/24 listed in table main")
+		}
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/route", route.Route{Destination: "10.97.2.0/24", Type: "blackhole", Table: "97"})
+
+	rs = findRoute(t, 97, "10.97.2.0/24")
+	if len(rs) != 1 || rs[0].Type != unix.RTN_BLACKHOLE {
+		t.Fatalf("Invalid blackhole route 10.97.2.0/24: %v", rs)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/route", route.Route{
+		Destination: "10.97.3.0/24",
+		Table:       "97",
+		MultiPath: []route.NextHop{
+			{Link: "test-rt", Gateway: "192.168.97.2"},
+			{Link: "test-rt", Gateway: "192.168.97.3", Weight: 2},
+		},
+	})
+
+	rs = findRoute(t, 97, "10.97.3.0/24")
+	if len(rs) != 1 || len(rs[0].MultiPath) != 2 {
+		t.Fatalf("Invalid multipath route 10.97.3.0/24: %v", rs)
+	}
+	if rs[0].MultiPath[0].Gw.String() != "192.168.97.2" || rs[0].MultiPath[1].Gw.String() != "192.168.97.3" || rs[0].MultiPath[1].Hops != 1 {
+		t.Fatalf("Invalid next hops of route 10.97.3.0/24: %v", rs[0].MultiPath)
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/route", route.Route{Link: "test-rt", Destination: "10.97.4.0/24", Table: "97", Metric: 10})
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/route", route.Route{Link: "test-rt", Destination: "10.97.4.0/24", Table: "97", Metric: 20})
+
+	resp, err := web.DispatchSocketWithStatus(http.MethodDelete, "", "/api/v1/network/netlink/route", nil, route.Route{Destination: "10.97.4.0/24", Table: "97"})
+	if err != nil {
+		t.Fatalf("Failed to dispatch route: %v\n", err)
+	}
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected status %d deleting an ambiguous route, got %d", http.StatusConflict, resp.StatusCode)
+	}
+	if len(findRoute(t, 97, "10.97.4.0/24")) != 2 {
+		t.Fatalf("Ambiguous delete removed a route 10.97.4.0/24")
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netlink/route", route.Route{Destination: "10.97.4.0/24", Table: "97", Metric: 20})
+
+	rs = findRoute(t, 97, "10.97.4.0/24")
+	if len(rs) != 1 || rs[0].Priority != 10 {
+		t.Fatalf("Failed to delete route 10.97.4.0/24 with metric 20: %v", rs)
+	}
+
+	for _, dst := range []string{"10.97.1.0/24", "10.97.2.0/24", "10.97.3.0/24", "10.97.4.0/24"} {
+		dispatch(t, http.MethodDelete, "/api/v1/network/netlink/route", route.Route{Destination: dst, Table: "97"})
+
+		if len(findRoute(t, 97, dst)) != 0 {
+			t.Fatalf("Failed to delete route %s", dst)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/rule"
+)
+
+type ruleStats struct {
+	Success bool        `json:"success"`
+	Message []rule.Rule `json:"message"`
+	Errors  string      `json:"errors"`
+}
+
+func parseIPRule(args cli.Args) (*rule.Rule, error) {
+	argStrings := args.Slice()
+
+	r := rule.Rule{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "tos":
+			if !validator.IsRoutingTypeOfService(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid tos=%s\n", argStrings[i+1])
+			}
+			r.TypeOfService = argStrings[i+1]
+		case "from":
+			r.From = argStrings[i+1]
+		case "to":
+			r.To = argStrings[i+1]
+		case "fwmark":
+			if !validator.IsRoutingFirewallMark(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid fwmark=%s\n", argStrings[i+1])
+			}
+			r.FirewallMark = argStrings[i+1]
+		case "table":
+			r.Table = argStrings[i+1]
+		case "prio":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid prio=%s\n", argStrings[i+1])
+			}
+			r.Priority = argStrings[i+1]
+		case "iif":
+			r.IncomingInterface = argStrings[i+1]
+		case "oif":
+			r.OutgoingInterface = argStrings[i+1]
+		case "srcport":
+			if !validator.IsRoutingPort(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid srcport=%s\n", argStrings[i+1])
+			}
+			r.SourcePort = argStrings[i+1]
+		case "destport":
+			if !validator.IsRoutingPort(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid destport=%s\n", argStrings[i+1])
+			}
+			r.DestinationPort = argStrings[i+1]
+		case "ipproto":
+			r.IPProtocol = argStrings[i+1]
+		case "invertrule":
+			if !validator.IsBool(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid invertrule=%s\n", argStrings[i+1])
+			}
+			r.InvertRule = validator.BoolToString(argStrings[i+1])
+		case "family":
+			if !validator.IsAddressFamily(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid family=%s\n", argStrings[i+1])
+			}
+			r.Family = argStrings[i+1]
+		case "usr":
+			if !validator.IsRoutingUser(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid usr=%s\n", argStrings[i+1])
+			}
+			r.User = argStrings[i+1]
+		case "suppressprefixlen":
+			if !validator.IsRoutingSuppressPrefixLength(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid suppressprefixlen=%s\n", argStrings[i+1])
+			}
+			r.SuppressPrefixLength = argStrings[i+1]
+		case "suppressifgrp":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid suppressifgrp=%s\n", argStrings[i+1])
+			}
+			r.SuppressInterfaceGroup = argStrings[i+1]
+		case "type":
+			if !validator.IsRoutingType(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid type=%s\n", argStrings[i+1])
+			}
+			r.Type = argStrings[i+1]
+		}
+	}
+
+	return &r, nil
+}
+
+func networkDispatchIPRule(method string, action string, args cli.Args, host string, token map[string]string) {
+	r, err := parseIPRule(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	resp, err := web.DispatchSocket(method, host, "/api/v1/network/netlink/rule", token, *r)
+	if err != nil {
+		fmt.Printf("Failed to %s rule: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s rule: %v\n", action, m.Errors)
+	}
+}
+
+func networkAddIPRule(args cli.Args, host string, token map[string]string) {
+	networkDispatchIPRule(http.MethodPost, "add", args, host, token)
+}
+
+func networkRemoveIPRule(args cli.Args, host string, token map[string]string) {
+	networkDispatchIPRule(http.MethodDelete, "delete", args, host, token)
+}
+
+func displayIPRule(r *rule.Rule) {
+	s := []string{"from"}
+	if r.From != "" {
+		s = append(s, r.From)
+	} else {
+		s = append(s, "all")
+	}
+	if r.To != "" {
+		s = append(s, "to", r.To)
+	}
+	if r.TypeOfService != "" {
+		s = append(s, "tos", r.TypeOfService)
+	}
+	if r.FirewallMark != "" {
+		s = append(s, "fwmark", r.FirewallMark)
+	}
+	if r.IncomingInterface != "" {
+		s = append(s, "iif", r.IncomingInterface)
+	}
+	if r.OutgoingInterface != "" {
+		s = append(s, "oif", r.OutgoingInterface)
+	}
+	if r.IPProtocol != "" {
+		s = append(s, "ipproto", r.IPProtocol)
+	}
+	if r.SourcePort != "" {
+		s = append(s, "sport", r.SourcePort)
+	}
+	if r.DestinationPort != "" {
+		s = append(s, "dport", r.DestinationPort)
+	}
+	if r.User != "" {
+		s = append(s, "uidrange", r.User)
+	}
+	if r.Table != "" {
+		s = append(s, "lookup", r.Table)
+	}
+	if r.SuppressPrefixLength != "" {
+		s = append(s, "suppress_prefixlength", r.SuppressPrefixLength)
+	}
+	if r.SuppressInterfaceGroup != "" {
+		s = append(s, "suppress_ifgroup", r.SuppressInterfaceGroup)
+	}
+	if r.Type != "" {
+		s = append(s, r.Type)
+	}
+	if r.InvertRule == "yes" {
+		s = append([]string{"not"}, s...)
+	}
+
+	fmt.Printf("%v %v %v\n", color.HiBlueString(r.Priority+":"), color.HiYellowString(r.Family), strings.Join(s, " "))
+}
+
+func networkShowIPRules(args cli.Args, host string, token map[string]string) {
+	url := "/api/v1/network/netlink/rule"
+	if family := args.Get(1); args.First() == "family" && family != "" {
+		url += "?family=" + family
+	}
+
+	resp, err := web.DispatchSocket(http.MethodGet, host, url, token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire rules: %v\n", err)
+		return
+	}
+
+	m := ruleStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire rules: %v\n", m.Errors)
+		return
+	}
+
+	for i := range m.Message {
+		displayIPRule(&m.Message[i])
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/rule"
+)
+
+func findIPRule(t *testing.T, priority int) *netlink.Rule {
+	rules, err := netlink.RuleList(netlink.FAMILY_V4)
+	if err != nil {
+		t.Fatalf("Failed to list rules: %v\n", err)
+	}
+
+	for i := range rules {
+		if rules[i].Priority == priority {
+			return &rules[i]
+		}
+	}
+
+	return nil
+}
+
+func TestIPRule(t *testing.T) {
+	r := rule.Rule{
+		Priority:        "9999",
+		From:            "192.168.99.0/24",
+		Table:           "99",
+		FirewallMark:    "7/255",
+		DestinationPort: "1000-2000",
+		IPProtocol:      "tcp",
+	}
+
+	dispatch(t, http.MethodPost, "/api/v1/network/netlink/rule", r)
+
+	nr := findIPRule(t, 9999)
+	if nr == nil {
+		t.Fatalf("Failed to find rule with priority 9999")
+	}
+	if nr.Table != 99 {
+		t.Fatalf("Invalid table of rule: %d", nr.Table)
+	}
+	if nr.Src == nil || nr.Src.String() != "192.168.99.0/24" {
+		t.Fatalf("Invalid from of rule: %v", nr.Src)
+	}
+	if nr.Mark != 7 || nr.Mask == nil || *nr.Mask != 255 {
+		t.Fatalf("Invalid fwmark of rule: %d", nr.Mark)
+	}
+	if nr.Dport == nil || nr.Dport.Start != 1000 || nr.Dport.End != 2000 {
+		t.Fatalf("Invalid destination port of rule: %v", nr.Dport)
+	}
+
+	rules, err := rule.AcquireRules("ipv4")
+	if err != nil {
+		t.Fatalf("Failed to acquire rules: %v\n", err)
+	}
+
+	found := false
+	for _, rr := range rules {
+		if rr.Priority == "9999" {
+			found = rr.Table == "99" && rr.FirewallMark == "7/255" && rr.DestinationPort == "1000-2000" && rr.IPProtocol == "tcp"
+		}
+	}
+	if !found {
+		t.Fatalf("Failed to acquire rule with priority 9999")
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/netlink/rule", rule.Rule{Priority: "9999"})
+
+	if findIPRule(t, 9999) != nil {
+		t.Fatalf("Failed to delete rule with priority 9999")
+	}
+}
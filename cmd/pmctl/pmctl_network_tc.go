@@ -0,0 +1,613 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
+	"github.com/vmware/pmd-next-gen/plugins/network/tc"
+)
+
+type qdiscStats struct {
+	Success bool           `json:"success"`
+	Message []tc.QdiscInfo `json:"message"`
+	Errors  string         `json:"errors"`
+}
+
+type tcClassStats struct {
+	Success bool           `json:"success"`
+	Message []tc.ClassInfo `json:"message"`
+	Errors  string         `json:"errors"`
+}
+
+type tcFilterStats struct {
+	Success bool            `json:"success"`
+	Message []tc.FilterInfo `json:"message"`
+	Errors  string          `json:"errors"`
+}
+
+func networkDispatchTC(method string, action string, url string, data interface{}, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(method, host, url, token, data)
+	if err != nil {
+		fmt.Printf("Failed to %s: %v\n", action, err)
+		return
+	}
+
+	m := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to %s: %v\n", action, m.Errors)
+	}
+}
+
+func parseQdisc(args cli.Args) (*tc.Qdisc, error) {
+	argStrings := args.Slice()
+
+	q := tc.Qdisc{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			q.Link = argStrings[i+1]
+		case "kind":
+			q.Kind = argStrings[i+1]
+		case "parent":
+			q.Parent = argStrings[i+1]
+		case "handle":
+			q.Handle = argStrings[i+1]
+		case "limit":
+			q.Limit = argStrings[i+1]
+		case "target":
+			q.Target = argStrings[i+1]
+		case "interval":
+			q.Interval = argStrings[i+1]
+		case "flows":
+			q.Flows = argStrings[i+1]
+		case "quantum":
+			q.Quantum = argStrings[i+1]
+		case "ecn":
+			if !validator.IsBool(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid ecn=%s\n", argStrings[i+1])
+			}
+			q.ECN = argStrings[i+1]
+		case "rate":
+			q.Rate = argStrings[i+1]
+		case "burst":
+			q.Burst = argStrings[i+1]
+		case "latency":
+			q.Latency = argStrings[i+1]
+		case "peakrate":
+			q.PeakRate = argStrings[i+1]
+		case "mtu":
+			q.MTU = argStrings[i+1]
+		case "default":
+			q.DefaultClass = argStrings[i+1]
+		case "delay":
+			q.Delay = argStrings[i+1]
+		case "jitter":
+			q.Jitter = argStrings[i+1]
+		case "loss":
+			q.Loss = argStrings[i+1]
+		case "duplicate":
+			q.Duplicate = argStrings[i+1]
+		case "reorder":
+			q.Reorder = argStrings[i+1]
+		case "corrupt":
+			q.Corrupt = argStrings[i+1]
+		}
+	}
+
+	if q.Link == "" {
+		return nil, fmt.Errorf("Missing dev\n")
+	}
+
+	return &q, nil
+}
+
+func networkDispatchQdisc(method string, action string, args cli.Args, host string, token map[string]string) {
+	q, err := parseQdisc(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchTC(method, action, "/api/v1/network/tc/qdisc", q, host, token)
+}
+
+func networkAddQdisc(args cli.Args, host string, token map[string]string) {
+	networkDispatchQdisc(http.MethodPost, "add qdisc", args, host, token)
+}
+
+func networkReplaceQdisc(args cli.Args, host string, token map[string]string) {
+	networkDispatchQdisc(http.MethodPut, "replace qdisc", args, host, token)
+}
+
+func networkRemoveQdisc(args cli.Args, host string, token map[string]string) {
+	networkDispatchQdisc(http.MethodDelete, "delete qdisc", args, host, token)
+}
+
+func parseTCClass(args cli.Args) (*tc.Class, error) {
+	argStrings := args.Slice()
+
+	c := tc.Class{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			c.Link = argStrings[i+1]
+		case "parent":
+			c.Parent = argStrings[i+1]
+		case "classid":
+			c.ClassId = argStrings[i+1]
+		case "rate":
+			c.Rate = argStrings[i+1]
+		case "ceil":
+			c.Ceil = argStrings[i+1]
+		case "burst":
+			c.Burst = argStrings[i+1]
+		case "cburst":
+			c.CBurst = argStrings[i+1]
+		case "prio":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid prio=%s\n", argStrings[i+1])
+			}
+			c.Priority = argStrings[i+1]
+		case "quantum":
+			c.Quantum = argStrings[i+1]
+		}
+	}
+
+	if c.Link == "" || c.ClassId == "" {
+		return nil, fmt.Errorf("Missing dev or classid\n")
+	}
+
+	return &c, nil
+}
+
+func networkDispatchTCClass(method string, action string, args cli.Args, host string, token map[string]string) {
+	c, err := parseTCClass(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchTC(method, action, "/api/v1/network/tc/class", c, host, token)
+}
+
+func networkAddTCClass(args cli.Args, host string, token map[string]string) {
+	networkDispatchTCClass(http.MethodPost, "add class", args, host, token)
+}
+
+func networkReplaceTCClass(args cli.Args, host string, token map[string]string) {
+	networkDispatchTCClass(http.MethodPut, "replace class", args, host, token)
+}
+
+func networkRemoveTCClass(args cli.Args, host string, token map[string]string) {
+	networkDispatchTCClass(http.MethodDelete, "delete class", args, host, token)
+}
+
+func parseTCFilter(args cli.Args) (*tc.Filter, error) {
+	argStrings := args.Slice()
+
+	f := tc.Filter{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			f.Link = argStrings[i+1]
+		case "parent":
+			f.Parent = argStrings[i+1]
+		case "kind":
+			f.Kind = argStrings[i+1]
+		case "prio":
+			if !validator.IsUint16(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid prio=%s\n", argStrings[i+1])
+			}
+			f.Priority = argStrings[i+1]
+		case "protocol":
+			f.Protocol = argStrings[i+1]
+		case "classid":
+			f.ClassId = argStrings[i+1]
+		case "action":
+			f.Action = argStrings[i+1]
+		case "src":
+			f.SourceIP = argStrings[i+1]
+		case "dst":
+			f.DestinationIP = argStrings[i+1]
+		case "ipproto":
+			f.IPProtocol = argStrings[i+1]
+		case "sport":
+			if !validator.IsPort(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid sport=%s\n", argStrings[i+1])
+			}
+			f.SourcePort = argStrings[i+1]
+		case "dport":
+			if !validator.IsPort(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid dport=%s\n", argStrings[i+1])
+			}
+			f.DestinationPort = argStrings[i+1]
+		}
+	}
+
+	if f.Link == "" {
+		return nil, fmt.Errorf("Missing dev\n")
+	}
+
+	return &f, nil
+}
+
+func networkDispatchTCFilter(method string, action string, args cli.Args, host string, token map[string]string) {
+	f, err := parseTCFilter(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkDispatchTC(method, action, "/api/v1/network/tc/filter", f, host, token)
+}
+
+func networkAddTCFilter(args cli.Args, host string, token map[string]string) {
+	networkDispatchTCFilter(http.MethodPost, "add filter", args, host, token)
+}
+
+func networkRemoveTCFilter(args cli.Args, host string, token map[string]string) {
+	networkDispatchTCFilter(http.MethodDelete, "delete filter", args, host, token)
+}
+
+// tcQuery builds the query of the listings from the dev and parent
+// arguments.
+func tcQuery(args cli.Args) string {
+	argStrings := args.Slice()
+
+	q := []string{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			q = append(q, "link="+argStrings[i+1])
+		case "parent":
+			q = append(q, "parent="+argStrings[i+1])
+		}
+	}
+
+	if len(q) == 0 {
+		return ""
+	}
+
+	return "?" + strings.Join(q, "&")
+}
+
+func displayTCOptions(o map[string]string) string {
+	keys := make([]string, 0, len(o))
+	for k := range o {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := []string{}
+	for _, k := range keys {
+		s = append(s, k+" "+o[k])
+	}
+
+	return strings.Join(s, " ")
+}
+
+func displayTCStatistics(s *tc.Statistics) {
+	if s == nil {
+		return
+	}
+
+	fmt.Printf("        Sent %v bytes %v pkt (dropped %v, overlimits %v requeues %v)\n", s.Bytes, s.Packets, s.Drops, s.Overlimits, s.Requeues)
+	fmt.Printf("        backlog %vb %vp\n", s.Backlog, s.Qlen)
+}
+
+func networkShowQdiscs(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/tc/qdisc"+tcQuery(args), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire qdiscs: %v\n", err)
+		return
+	}
+
+	m := qdiscStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire qdiscs: %v\n", m.Errors)
+		return
+	}
+
+	for _, q := range m.Message {
+		fmt.Printf("%v %v %v dev %v parent %v %v\n", color.HiBlueString("qdisc"), color.HiYellowString(q.Kind), q.Handle, q.LinkName, q.Parent, displayTCOptions(q.Options))
+		displayTCStatistics(q.Statistics)
+	}
+}
+
+func networkShowTCClasses(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/tc/class"+tcQuery(args), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire classes: %v\n", err)
+		return
+	}
+
+	m := tcClassStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire classes: %v\n", m.Errors)
+		return
+	}
+
+	for _, c := range m.Message {
+		fmt.Printf("%v %v %v dev %v parent %v %v\n", color.HiBlueString("class"), color.HiYellowString(c.Kind), c.ClassId, c.LinkName, c.Parent, displayTCOptions(c.Options))
+		displayTCStatistics(c.Statistics)
+	}
+}
+
+func networkShowTCFilters(args cli.Args, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/tc/filter"+tcQuery(args), token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire filters: %v\n", err)
+		return
+	}
+
+	m := tcFilterStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire filters: %v\n", m.Errors)
+		return
+	}
+
+	for _, f := range m.Message {
+		s := []string{"parent", f.Parent, "protocol", f.Protocol, "prio", fmt.Sprint(f.Priority)}
+		if o := displayTCOptions(f.Match); o != "" {
+			s = append(s, o)
+		}
+		if f.ClassId != "" {
+			s = append(s, "classid", f.ClassId)
+		}
+		if f.Action != "" {
+			s = append(s, "action", f.Action)
+		}
+
+		fmt.Printf("%v %v dev %v %v\n", color.HiBlueString("filter"), color.HiYellowString(f.Kind), f.LinkName, strings.Join(s, " "))
+	}
+}
+
+func parseTCNetem(args cli.Args) (*networkd.Network, error) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	q := networkd.TrafficControlQueueingDisciplineSection{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "parent":
+			q.Parent = argStrings[i+1]
+		case "delay":
+			if !validator.IsTCTime(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid delay=%s\n", argStrings[i+1])
+			}
+			q.NetworkEmulatorDelaySec = argStrings[i+1]
+		case "jitter":
+			if !validator.IsTCTime(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid jitter=%s\n", argStrings[i+1])
+			}
+			q.NetworkEmulatorDelayJitterSec = argStrings[i+1]
+		case "limit":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid limit=%s\n", argStrings[i+1])
+			}
+			q.NetworkEmulatorPacketLimit = argStrings[i+1]
+		case "loss":
+			if !validator.IsTCPercent(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid loss=%s\n", argStrings[i+1])
+			}
+			q.NetworkEmulatorLossRate = argStrings[i+1]
+		case "duplicate":
+			if !validator.IsTCPercent(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid duplicate=%s\n", argStrings[i+1])
+			}
+			q.NetworkEmulatorDuplicateRate = argStrings[i+1]
+		}
+	}
+
+	n.TrafficControlQueueingDisciplineSections = append(n.TrafficControlQueueingDisciplineSections, q)
+	return &n, nil
+}
+
+func parseTCTokenBucketFilter(args cli.Args) (*networkd.Network, error) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	t := networkd.TokenBucketFilterSection{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "parent":
+			t.Parent = argStrings[i+1]
+		case "handle":
+			t.Handle = argStrings[i+1]
+		case "latency":
+			if !validator.IsTCTime(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid latency=%s\n", argStrings[i+1])
+			}
+			t.LatencySec = argStrings[i+1]
+		case "limit":
+			t.LimitBytes = argStrings[i+1]
+		case "burst":
+			t.BurstBytes = argStrings[i+1]
+		case "rate":
+			if !validator.IsTCRate(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid rate=%s\n", argStrings[i+1])
+			}
+			t.Rate = argStrings[i+1]
+		case "mpu":
+			t.MPUBytes = argStrings[i+1]
+		case "peakrate":
+			if !validator.IsTCRate(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid peakrate=%s\n", argStrings[i+1])
+			}
+			t.PeakRate = argStrings[i+1]
+		case "mtu":
+			t.MTUBytes = argStrings[i+1]
+		}
+	}
+
+	n.TokenBucketFilterSections = append(n.TokenBucketFilterSections, t)
+	return &n, nil
+}
+
+func parseTCHierarchyTokenBucket(args cli.Args) (*networkd.Network, error) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	h := networkd.HierarchyTokenBucketSection{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "parent":
+			h.Parent = argStrings[i+1]
+		case "handle":
+			h.Handle = argStrings[i+1]
+		case "default":
+			h.DefaultClass = argStrings[i+1]
+		case "r2q":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid r2q=%s\n", argStrings[i+1])
+			}
+			h.RateToQuantum = argStrings[i+1]
+		}
+	}
+
+	n.HierarchyTokenBucketSections = append(n.HierarchyTokenBucketSections, h)
+	return &n, nil
+}
+
+func parseTCHierarchyTokenBucketClass(args cli.Args) (*networkd.Network, error) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	c := networkd.HierarchyTokenBucketClassSection{}
+	for i := 0; i < len(argStrings)-1; i++ {
+		switch argStrings[i] {
+		case "dev":
+			n.Link = argStrings[i+1]
+		case "parent":
+			c.Parent = argStrings[i+1]
+		case "classid":
+			c.ClassId = argStrings[i+1]
+		case "prio":
+			if !validator.IsUint32(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid prio=%s\n", argStrings[i+1])
+			}
+			c.Priority = argStrings[i+1]
+		case "quantum":
+			c.QuantumBytes = argStrings[i+1]
+		case "mtu":
+			c.MTUBytes = argStrings[i+1]
+		case "overhead":
+			c.OverheadBytes = argStrings[i+1]
+		case "rate":
+			if !validator.IsTCRate(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid rate=%s\n", argStrings[i+1])
+			}
+			c.Rate = argStrings[i+1]
+		case "ceil":
+			if !validator.IsTCRate(argStrings[i+1]) {
+				return nil, fmt.Errorf("Invalid ceil=%s\n", argStrings[i+1])
+			}
+			c.CeilRate = argStrings[i+1]
+		case "buffer":
+			c.BufferBytes = argStrings[i+1]
+		case "cbuffer":
+			c.CeilBufferBytes = argStrings[i+1]
+		}
+	}
+
+	n.HierarchyTokenBucketClassSections = append(n.HierarchyTokenBucketClassSections, c)
+	return &n, nil
+}
+
+func networkConfigureTC(parse func(cli.Args) (*networkd.Network, error), args cli.Args, host string, token map[string]string) {
+	n, err := parse(args)
+	if err != nil {
+		fmt.Printf("%v", err)
+		return
+	}
+
+	networkConfigure(n, host, token)
+}
+
+func networkAddTCNetem(args cli.Args, host string, token map[string]string) {
+	networkConfigureTC(parseTCNetem, args, host, token)
+}
+
+func networkAddTCTokenBucketFilter(args cli.Args, host string, token map[string]string) {
+	networkConfigureTC(parseTCTokenBucketFilter, args, host, token)
+}
+
+func networkAddTCHierarchyTokenBucket(args cli.Args, host string, token map[string]string) {
+	networkConfigureTC(parseTCHierarchyTokenBucket, args, host, token)
+}
+
+func networkAddTCHierarchyTokenBucketClass(args cli.Args, host string, token map[string]string) {
+	networkConfigureTC(parseTCHierarchyTokenBucketClass, args, host, token)
+}
+
+// networkRemoveTC removes a traffic control section: "netem", "tbf" or
+// "htb" by parent, or "htb-class" by class id.
+func networkRemoveTC(args cli.Args, host string, token map[string]string) {
+	argStrings := args.Slice()
+
+	n := networkd.Network{}
+	for i := 0; i < len(argStrings); i++ {
+		v := ""
+		if i+1 < len(argStrings) {
+			v = argStrings[i+1]
+		}
+
+		switch argStrings[i] {
+		case "dev":
+			n.Link = v
+		case "netem":
+			n.TrafficControlQueueingDisciplineSections = append(n.TrafficControlQueueingDisciplineSections, networkd.TrafficControlQueueingDisciplineSection{Parent: v})
+		case "tbf":
+			n.TokenBucketFilterSections = append(n.TokenBucketFilterSections, networkd.TokenBucketFilterSection{Parent: v})
+		case "htb":
+			n.HierarchyTokenBucketSections = append(n.HierarchyTokenBucketSections, networkd.HierarchyTokenBucketSection{Parent: v})
+		case "htb-class":
+			n.HierarchyTokenBucketClassSections = append(n.HierarchyTokenBucketClassSections, networkd.HierarchyTokenBucketClassSection{ClassId: v})
+		}
+	}
+
+	if n.Link == "" {
+		fmt.Printf("Missing dev\n")
+		return
+	}
+
+	networkDispatchTC(http.MethodDelete, "remove traffic control", "/api/v1/network/networkd/network/remove", n, host, token)
+}
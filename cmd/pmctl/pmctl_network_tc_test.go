@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
+	"github.com/vmware/pmd-next-gen/plugins/network/tc"
+)
+
+func findRootQdisc(t *testing.T, name string) netlink.Qdisc {
+	l, err := netlink.LinkByName(name)
+	if err != nil {
+		t.Fatalf("Failed to find link %s: %v\n", name, err)
+	}
+
+	qdiscs, err := netlink.QdiscList(l)
+	if err != nil {
+		t.Fatalf("Failed to list qdiscs: %v\n", err)
+	}
+
+	for _, q := range qdiscs {
+		if q.Attrs().Parent == netlink.HANDLE_ROOT {
+			return q
+		}
+	}
+
+	return nil
+}
+
+func TestTCQdisc(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test-br"}})
+	defer removeLink(t, "test-br")
+
+	dispatch(t, http.MethodPost, "/api/v1/network/tc/qdisc", tc.Qdisc{Link: "test-br", Kind: "tbf", Rate: "10mbit", Burst: "32k"})
+
+	q, ok := findRootQdisc(t, "test-br").(*netlink.Tbf)
+	if !ok {
+		t.Fatalf("Failed to add tbf qdisc")
+	}
+	if q.Rate != 10*1000*1000/8 {
+		t.Fatalf("Invalid rate of tbf: %d", q.Rate)
+	}
+
+	dispatch(t, http.MethodPut, "/api/v1/network/tc/qdisc", tc.Qdisc{Link: "test-br", Kind: "htb", Handle: "1:", DefaultClass: "10"})
+	dispatch(t, http.MethodPost, "/api/v1/network/tc/class", tc.Class{Link: "test-br", Parent: "1:", ClassId: "1:10", Rate: "5mbit", Ceil: "10mbit"})
+	dispatch(t, http.MethodPost, "/api/v1/network/tc/filter", tc.Filter{Link: "test-br", Parent: "1:", Priority: "10", ClassId: "1:10", DestinationIP: "192.168.1.0/24", DestinationPort: "80"})
+
+	classes, err := tc.AcquireClasses("test-br")
+	if err != nil {
+		t.Fatalf("Failed to acquire classes: %v\n", err)
+	}
+	if len(classes) != 1 || classes[0].ClassId != "1:10" || classes[0].Options["Ceil"] != "10Mbit" {
+		t.Fatalf("Failed to acquire class 1:10: %v", classes)
+	}
+
+	filters, err := tc.AcquireFilters("test-br", "1:")
+	if err != nil {
+		t.Fatalf("Failed to acquire filters: %v\n", err)
+	}
+	if len(filters) != 1 || filters[0].Match["DestinationIP"] != "192.168.1.0/24" || filters[0].Match["DestinationPort"] != "80" {
+		t.Fatalf("Failed to acquire filter: %v", filters)
+	}
+
+	dispatch(t, http.MethodDelete, "/api/v1/network/tc/filter", tc.Filter{Link: "test-br", Parent: "1:", Priority: "10"})
+	dispatch(t, http.MethodDelete, "/api/v1/network/tc/class", tc.Class{Link: "test-br", ClassId: "1:10"})
+	dispatch(t, http.MethodDelete, "/api/v1/network/tc/qdisc", tc.Qdisc{Link: "test-br"})
+
+	if q := findRootQdisc(t, "test-br"); q != nil && q.Type() == "htb" {
+		t.Fatalf("Failed to delete htb qdisc")
+	}
+}
+
+func TestNetworkTrafficControl(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	system.ExecRun("systemctl", "restart", "systemd-networkd")
+	time.Sleep(time.Second * 3)
+
+	n := networkd.Network{
+		Link: "test99",
+		TokenBucketFilterSections: []networkd.TokenBucketFilterSection{
+			{
+				Parent:     "root",
+				Handle:     "1:",
+				Rate:       "10M",
+				BurstBytes: "32K",
+				LatencySec: "50ms",
+			},
+		},
+		HierarchyTokenBucketClassSections: []networkd.HierarchyTokenBucketClassSection{
+			{
+				Parent:  "1:",
+				ClassId: "1:10",
+				Rate:    "5M",
+			},
+		},
+	}
+
+	m, err := configureNetwork(t, n)
+	if err != nil {
+		t.Fatalf("Failed to configure TokenBucketFilter: %v\n", err)
+	}
+	defer os.Remove(m.Path)
+
+	if m.GetKeySectionString("TokenBucketFilter", "Rate") != "10M" {
+		t.Fatalf("Failed to set Rate")
+	}
+	if m.GetKeySectionString("TokenBucketFilter", "BurstBytes") != "32K" {
+		t.Fatalf("Failed to set BurstBytes")
+	}
+	if m.GetKeySectionString("TokenBucketFilter", "LatencySec") != "50ms" {
+		t.Fatalf("Failed to set LatencySec")
+	}
+	if m.GetKeySectionString("HierarchyTokenBucketClass", "ClassId") != "1:10" {
+		t.Fatalf("Failed to set ClassId")
+	}
+}
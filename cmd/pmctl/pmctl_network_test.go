@@ -40,6 +40,22 @@ func removeLink(t *testing.T, link string) {
 	netlink.LinkDel(l)
 }
 
+// dispatch sends data to url and fails the test unless the request succeeds.
+func dispatch(t *testing.T, method string, url string, data interface{}) {
+	resp, err := web.DispatchSocket(method, "", url, nil, data)
+	if err != nil {
+		t.Fatalf("Failed to dispatch %s %s: %v\n", method, url, err)
+	}
+
+	j := web.JSONResponseMessage{}
+	if err := json.Unmarshal(resp, &j); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !j.Success {
+		t.Fatalf("Failed to dispatch %s %s: %v\n", method, url, j.Errors)
+	}
+}
+
 func configureNetwork(t *testing.T, n networkd.Network) (*configfile.Meta, error) {
 	var resp []byte
 	var err error
@@ -0,0 +1,93 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/wireguard"
+)
+
+type wireGuardKeyStats struct {
+	Success bool              `json:"success"`
+	Message wireguard.KeyInfo `json:"message"`
+	Errors  string            `json:"errors"`
+}
+
+type wireGuardStats struct {
+	Success bool                 `json:"success"`
+	Message wireguard.DeviceInfo `json:"message"`
+	Errors  string               `json:"errors"`
+}
+
+func networkGenerateWireGuardKey(name string, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodPost, host, "/api/v1/network/wireguard/key", token, wireguard.Key{Name: name})
+	if err != nil {
+		fmt.Printf("Failed to generate wireguard key: %v\n", err)
+		return
+	}
+
+	m := wireGuardKeyStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to generate wireguard key: %v\n", m.Errors)
+		return
+	}
+
+	fmt.Printf("%v %v\n", color.HiBlueString("PrivateKeyFile:"), m.Message.PrivateKeyFile)
+	fmt.Printf("     %v %v\n", color.HiBlueString("PublicKey:"), m.Message.PublicKey)
+}
+
+func networkShowWireGuard(dev string, host string, token map[string]string) {
+	resp, err := web.DispatchSocket(http.MethodGet, host, "/api/v1/network/wireguard/"+dev, token, nil)
+	if err != nil {
+		fmt.Printf("Failed to acquire wireguard: %v\n", err)
+		return
+	}
+
+	m := wireGuardStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		fmt.Printf("Failed to decode json message: %v\n", err)
+		return
+	}
+
+	if !m.Success {
+		fmt.Printf("Failed to acquire wireguard: %v\n", m.Errors)
+		return
+	}
+
+	d := m.Message
+	fmt.Printf("%v %v\n", color.HiBlueString("interface:"), d.Name)
+	fmt.Printf("%v %v\n", color.HiBlueString("public key:"), d.PublicKey)
+	fmt.Printf("%v %v\n", color.HiBlueString("listening port:"), d.ListenPort)
+	if d.FirewallMark != 0 {
+		fmt.Printf("%v %v\n", color.HiBlueString("fwmark:"), d.FirewallMark)
+	}
+
+	for _, p := range d.Peers {
+		fmt.Printf("\n%v %v\n", color.HiYellowString("peer:"), p.PublicKey)
+		if p.Endpoint != "" {
+			fmt.Printf("  %v %v\n", color.HiBlueString("endpoint:"), p.Endpoint)
+		}
+		fmt.Printf("  %v %v\n", color.HiBlueString("allowed ips:"), strings.Join(p.AllowedIPs, ", "))
+		if p.LatestHandshake != nil {
+			fmt.Printf("  %v %v ago\n", color.HiBlueString("latest handshake:"), time.Since(*p.LatestHandshake).Round(time.Second))
+		}
+		fmt.Printf("  %v %v received, %v sent\n", color.HiBlueString("transfer:"), p.ReceiveBytes, p.TransmitBytes)
+		if p.PersistentKeepaliveSec != 0 {
+			fmt.Printf("  %v every %v seconds\n", color.HiBlueString("persistent keepalive:"), p.PersistentKeepaliveSec)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package main
+
+import (
+	"crypto/ecdh"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
+	"github.com/vmware/pmd-next-gen/plugins/network/wireguard"
+)
+
+func TestWireGuardGenerateKey(t *testing.T) {
+	resp, err := web.DispatchSocket(http.MethodPost, "", "/api/v1/network/wireguard/key", nil, wireguard.Key{Name: "test-wg"})
+	if err != nil {
+		t.Fatalf("Failed to generate wireguard key: %v\n", err)
+	}
+
+	m := wireGuardKeyStats{}
+	if err := json.Unmarshal(resp, &m); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !m.Success {
+		t.Fatalf("Failed to generate wireguard key: %v\n", m.Errors)
+	}
+	defer os.Remove(m.Message.PrivateKeyFile)
+
+	fi, err := os.Stat(m.Message.PrivateKeyFile)
+	if err != nil {
+		t.Fatalf("Failed to find private key file: %v\n", err)
+	}
+	if fi.Mode().Perm()&0007 != 0 {
+		t.Fatalf("Private key file is readable by others: %v", fi.Mode())
+	}
+
+	b, err := os.ReadFile(m.Message.PrivateKeyFile)
+	if err != nil {
+		t.Fatalf("Failed to read private key file: %v\n", err)
+	}
+	k, _ := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	priv, err := ecdh.X25519().NewPrivateKey(k)
+	if err != nil {
+		t.Fatalf("Invalid private key: %v\n", err)
+	}
+	if base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()) != m.Message.PublicKey {
+		t.Fatalf("Public key does not match the private key")
+	}
+}
+
+func TestNetDevWireGuardPeers(t *testing.T) {
+	setupLink(t, &netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "test99"}})
+	defer removeLink(t, "test99")
+
+	n := networkd.NetDev{
+		Name:  "wg99",
+		Kind:  "wireguard",
+		Links: []string{"test99"},
+		WireGuardSection: networkd.WireGuard{
+			PrivateKey: "wBN4Hjn5yS2T/Ep4P6nQm5pJw+aW34iF8/oQxWVhq3Q=",
+			ListenPort: "51820",
+		},
+		WireGuardPeerSections: []networkd.WireGuardPeer{
+			{
+				PublicKey:  "0ZcObEz2E2zUjlmJyXqy6KzfcxvxEbGqmS8uEkrcuFs=",
+				Endpoint:   "192.168.1.10:51820",
+				AllowedIPs: []string{"10.0.0.2/32"},
+			},
+			{
+				PublicKey:  "Tp0aGIiGiNy5MvtnKzL+TvXrT6HXYKkJ4sa3r+LuIVY=",
+				Endpoint:   "192.168.1.11:51820",
+				AllowedIPs: []string{"10.0.0.3/32"},
+			},
+		},
+	}
+
+	if err := configureNetDev(t, n); err != nil {
+		t.Fatalf("Failed to create WireGuard: %v\n", err)
+	}
+	defer networkd.RemoveNetDev(n.Name, n.Kind)
+
+	time.Sleep(time.Second * 5)
+
+	m, _, err := networkd.CreateOrParseNetDevFile("wg99", "wireguard")
+	if err != nil {
+		t.Fatalf("Failed to parse .netdev file of wireguard='wg99'")
+	}
+
+	peers, err := m.Cfg.SectionsByName("WireGuardPeer")
+	if err != nil || len(peers) != 2 {
+		t.Fatalf("Failed to write two WireGuardPeer sections: %v", err)
+	}
+	if peers[1].Key("Endpoint").String() != "192.168.1.11:51820" {
+		t.Fatalf("Invalid Endpoint of the second peer")
+	}
+
+	if !validator.LinkExists("wg99") {
+		return
+	}
+
+	resp, err := web.DispatchSocket(http.MethodGet, "", "/api/v1/network/wireguard/wg99", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to acquire wireguard: %v\n", err)
+	}
+
+	s := wireGuardStats{}
+	if err := json.Unmarshal(resp, &s); err != nil {
+		t.Fatalf("Failed to decode json message: %v\n", err)
+	}
+	if !s.Success {
+		t.Fatalf("Failed to acquire wireguard: %v\n", s.Errors)
+	}
+	if s.Message.ListenPort != 51820 || len(s.Message.Peers) != 2 {
+		t.Fatalf("Invalid wireguard device: %v", s.Message)
+	}
+}
@@ -65,9 +65,9 @@ type UpdateInfoSummaryDesc struct {
 }
 
 type AlterResultDesc struct {
-	Success bool             `json:"success"`
-	Message tdnf.AlterResult `json:"message"`
-	Errors  string           `json:"errors"`
+	Success bool                `json:"success"`
+	Message tdnf.AlterJobResult `json:"message"`
+	Errors  string              `json:"errors"`
 }
 
 type VersionDesc struct {
@@ -445,7 +445,12 @@ func displayAlterList(l []tdnf.ListItem, header string) {
 }
 
 func displayTdnfAlterResult(rDesc *AlterResultDesc) {
-	r := rDesc.Message
+	r := rDesc.Message.Planned
+	if r == nil {
+		return
+	}
+
+	fmt.Printf("%v\n", color.HiBlueString("Planned transaction:"))
 	displayAlterList(r.Exist, "Existing Packages")
 	displayAlterList(r.Unavailable, "Unavailable Packages")
 	displayAlterList(r.Install, "Packages to Install")
@@ -717,6 +722,12 @@ func acquireTdnfVersion(options *tdnf.Options, host string, token map[string]str
 	return nil, errors.New(m.Errors)
 }
 
+func displayJobOutput(event, data string) {
+	if event == "log" {
+		fmt.Println(data)
+	}
+}
+
 func acquireTdnfAlterCmd(options *tdnf.Options, cmd string, pkgs string, host string, token map[string]string) (*AlterResultDesc, error) {
 	var req string
 
@@ -726,7 +737,7 @@ func acquireTdnfAlterCmd(options *tdnf.Options, cmd string, pkgs string, host st
 		req = "/api/v1/tdnf/" + cmd + tdnfOptionsQuery(options)
 	}
 
-	msg, err := web.DispatchAndWait(http.MethodGet, host, req, token, nil)
+	msg, err := web.DispatchAndFollow(http.MethodGet, host, req, token, nil, displayJobOutput)
 	if err != nil {
 		return nil, err
 	}
@@ -765,7 +776,7 @@ func acquireTdnfHistoryList(options *tdnf.HistoryCmdOptions, host string, token
 }
 
 func acquireTdnfHistoryAlterCmd(options *tdnf.HistoryCmdOptions, cmd string, host string, token map[string]string) (*AlterResultDesc, error) {
-	msg, err := web.DispatchAndWait(http.MethodGet, host, "/api/v1/tdnf/history/"+cmd+tdnfOptionsQuery(options), token, nil)
+	msg, err := web.DispatchAndFollow(http.MethodGet, host, "/api/v1/tdnf/history/"+cmd+tdnfOptionsQuery(options), token, nil, displayJobOutput)
 	if err != nil {
 		return nil, err
 	}
@@ -4,15 +4,35 @@
 package bus
 
 import (
+	"context"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/godbus/dbus/v5"
+
+	"github.com/vmware/pmd-next-gen/pkg/metrics"
 )
 
+// countErrors accounts the error replies received on the connection.
+func countErrors(msg *dbus.Message) {
+	if msg.Type != dbus.TypeError {
+		return
+	}
+
+	name := "unknown"
+	if v, ok := msg.Headers[dbus.FieldErrorName]; ok {
+		v.Store(&name)
+	}
+
+	metrics.DBusError(name)
+}
+
 func SystemBusPrivateConn() (*dbus.Conn, error) {
-	conn, err := dbus.SystemBusPrivate()
+	conn, err := dbus.SystemBusPrivate(dbus.WithIncomingInterceptor(countErrors))
 	if err != nil {
+		metrics.DBusError("connect")
 		return nil, err
 	}
 
@@ -20,15 +40,101 @@ func SystemBusPrivateConn() (*dbus.Conn, error) {
 
 	err = conn.Auth(methods)
 	if err != nil {
+		metrics.DBusError("auth")
 		conn.Close()
 		conn = nil
 		return conn, err
 	}
 
 	if err = conn.Hello(); err != nil {
+		metrics.DBusError("hello")
 		conn.Close()
-		conn = nil
+		return nil, err
 	}
 
 	return conn, nil
 }
+
+// PropertiesChanged is the decoded org.freedesktop.DBus.Properties.PropertiesChanged signal.
+type PropertiesChanged struct {
+	Path        dbus.ObjectPath
+	Interface   string
+	Changed     map[string]interface{}
+	Invalidated []string
+}
+
+// WatchPropertiesChanged calls f for every PropertiesChanged signal emitted
+// below the path namespace until ctx is cancelled.
+func WatchPropertiesChanged(ctx context.Context, namespace dbus.ObjectPath, f func(*PropertiesChanged)) error {
+	conn, err := SystemBusPrivateConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return WatchPropertiesChangedConn(ctx, conn, namespace, f)
+}
+
+// WatchPropertiesChangedConn is WatchPropertiesChanged on a connection of the
+// caller, for services which emit signals only to subscribed connections.
+func WatchPropertiesChangedConn(ctx context.Context, conn *dbus.Conn, namespace dbus.ObjectPath, f func(*PropertiesChanged)) error {
+	if err := conn.AddMatchSignalContext(ctx,
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchPathNamespace(namespace)); err != nil {
+		return err
+	}
+
+	ch := make(chan *dbus.Signal, 64)
+	conn.Signal(ch)
+	defer conn.RemoveSignal(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case s, ok := <-ch:
+			if !ok {
+				return errors.New("connection closed")
+			}
+
+			if s.Name != "org.freedesktop.DBus.Properties.PropertiesChanged" || len(s.Body) < 2 {
+				continue
+			}
+
+			p := PropertiesChanged{
+				Path:    s.Path,
+				Changed: make(map[string]interface{}),
+			}
+			p.Interface, _ = s.Body[0].(string)
+			if changed, ok := s.Body[1].(map[string]dbus.Variant); ok {
+				for k, v := range changed {
+					p.Changed[k] = v.Value()
+				}
+			}
+			if len(s.Body) > 2 {
+				p.Invalidated, _ = s.Body[2].([]string)
+			}
+
+			f(&p)
+		}
+	}
+}
+
+// UnescapePathLabel reverses the escaping systemd applies to object path
+// labels, e.g. "sshd_2eservice" becomes "sshd.service".
+func UnescapePathLabel(label string) string {
+	var b strings.Builder
+	for i := 0; i < len(label); i++ {
+		if label[i] == '_' && i+2 < len(label) {
+			if c, err := strconv.ParseUint(label[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(label[i])
+	}
+
+	return b.String()
+}
@@ -4,8 +4,13 @@
 package conf
 
 import (
+	"fmt"
+	"reflect"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+
 	"github.com/vmware/pmd-next-gen/pkg/parser"
 )
 
@@ -22,42 +27,190 @@ const (
 	DefaultIP        = "127.0.0.1"
 	DefaultPort      = "5208"
 	ListenUnixSocket = "true"
+	VSockPort        = 5208
 
 	UnixDomainSocketPath = "/run/photon-mgmt/mgmt.sock"
+
+	StatePath              = "/var/lib/photon-mgmt"
+	LogPath                = "/var/log/photon-mgmt"
+	UseAudit               = "true"
+	DefaultAuditFile       = "/var/log/photon-mgmt/audit.log"
+	DefaultAuditMaxSize    = 10
+	DefaultAuditMaxBackups = 5
+
+	DefaultTokenSigningMethod  = "HS256"
+	DefaultTokenLifetime       = "15m"
+	DefaultTokenRevocationFile = "/var/lib/photon-mgmt/revoked-tokens.json"
+
+	DefaultJobResultTTL  = "1h"
+	DefaultJobMaxRunning = 4
+	DefaultJobStateFile  = "/var/lib/photon-mgmt/jobs.json"
 )
 
 type Config struct {
-	System  System  `mapstructure:"System"`
-	Network Network `mapstructure:"Network"`
+	System        System        `mapstructure:"System"`
+	Network       Network       `mapstructure:"Network"`
+	Authorization Authorization `mapstructure:"Authorization"`
+	Audit         Audit         `mapstructure:"Audit"`
+	Token         Token         `mapstructure:"Token"`
+	Metrics       Metrics       `mapstructure:"Metrics"`
+	Jobs          Jobs          `mapstructure:"Jobs"`
 }
 
+// System configures the daemon. With ReloadOnChange the config is reloaded
+// whenever the file is written, in addition to SIGHUP.
 type System struct {
 	LogLevel          string `mapstructure:"LogLevel"`
 	UseAuthentication bool   `mapstructure:"UseAuthentication"`
+	ReloadOnChange    bool   `mapstructure:"ReloadOnChange"`
 }
 type Network struct {
 	Listen           string
 	ListenUnixSocket bool
 	ListenVSock      bool
+	VSockAllowCID    []uint32 `mapstructure:"VSockAllowCID"`
+	TLSClientCA      string   `mapstructure:"TLSClientCA"`
 }
 
-func Parse() (*Config, error) {
-	viper.SetConfigName(ConfFile)
-	viper.AddConfigPath(ConfPath)
+// Role grants access to the routes matched by Allow and not matched by Deny.
+// Rules take the form "METHOD[,METHOD...] PREFIX", for example "GET /api/v1"
+// or "* /api/v1/network". Groups lists the unix groups mapped to the role.
+type Role struct {
+	Groups []string `mapstructure:"Groups"`
+	Allow  []string `mapstructure:"Allow"`
+	Deny   []string `mapstructure:"Deny"`
+}
+
+type Authorization struct {
+	UseAuthorization bool            `mapstructure:"UseAuthorization"`
+	PolicyFile       string          `mapstructure:"PolicyFile"`
+	DefaultRole      string          `mapstructure:"DefaultRole"`
+	VSockRole        string          `mapstructure:"VSockRole"`
+	Roles            map[string]Role `mapstructure:"Roles"`
+}
+
+// Audit configures the log of mutating API calls. MaxSize is given in MiB.
+type Audit struct {
+	UseAudit   bool   `mapstructure:"UseAudit"`
+	File       string `mapstructure:"File"`
+	MaxSize    int    `mapstructure:"MaxSize"`
+	MaxBackups int    `mapstructure:"MaxBackups"`
+}
+
+// Token configures the tokens issued by the daemon. SigningMethod takes one
+// of HS256 (keyed by the JWT_SECRET environment variable), RS256 or EdDSA.
+// Asymmetric methods sign with KeyFile; a daemon which only verifies tokens
+// may set PublicKeyFile instead.
+type Token struct {
+	SigningMethod  string `mapstructure:"SigningMethod"`
+	KeyFile        string `mapstructure:"KeyFile"`
+	PublicKeyFile  string `mapstructure:"PublicKeyFile"`
+	Lifetime       string `mapstructure:"Lifetime"`
+	RevocationFile string `mapstructure:"RevocationFile"`
+}
+
+// Metrics configures the Prometheus endpoint. Each collector family can be
+// switched off on its own.
+type Metrics struct {
+	UseMetrics  bool `mapstructure:"UseMetrics"`
+	CPU         bool `mapstructure:"CPU"`
+	Memory      bool `mapstructure:"Memory"`
+	Disk        bool `mapstructure:"Disk"`
+	NetDev      bool `mapstructure:"NetDev"`
+	Protocol    bool `mapstructure:"Protocol"`
+	Temperature bool `mapstructure:"Temperature"`
+	Daemon      bool `mapstructure:"Daemon"`
+}
+
+// Jobs configures the registry of asynchronous jobs. Finished jobs are kept
+// for ResultTTL. With Persist the registry survives restarts in StateFile.
+type Jobs struct {
+	ResultTTL  string `mapstructure:"ResultTTL"`
+	MaxRunning int    `mapstructure:"MaxRunning"`
+	Persist    bool   `mapstructure:"Persist"`
+	StateFile  string `mapstructure:"StateFile"`
+}
+
+func ParsePolicyFile(path string) (map[string]Role, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	a := Authorization{}
+	if err := v.Unmarshal(&a); err != nil {
+		return nil, err
+	}
+
+	return a.Roles, nil
+}
 
-	viper.SetDefault("System.LogLevel", DefaultLogLevel)
+func newViper() *viper.Viper {
+	v := viper.New()
+	v.SetConfigName(ConfFile)
+	v.AddConfigPath(ConfPath)
 
-	if err := viper.ReadInConfig(); err != nil {
+	v.SetDefault("System.LogLevel", DefaultLogLevel)
+	v.SetDefault("Audit.UseAudit", UseAudit)
+	v.SetDefault("Audit.File", DefaultAuditFile)
+	v.SetDefault("Audit.MaxSize", DefaultAuditMaxSize)
+	v.SetDefault("Audit.MaxBackups", DefaultAuditMaxBackups)
+	v.SetDefault("Token.SigningMethod", DefaultTokenSigningMethod)
+	v.SetDefault("Token.Lifetime", DefaultTokenLifetime)
+	v.SetDefault("Token.RevocationFile", DefaultTokenRevocationFile)
+	v.SetDefault("Jobs.ResultTTL", DefaultJobResultTTL)
+	v.SetDefault("Jobs.MaxRunning", DefaultJobMaxRunning)
+	v.SetDefault("Jobs.StateFile", DefaultJobStateFile)
+	for _, k := range []string{"UseMetrics", "CPU", "Memory", "Disk", "NetDev", "Protocol", "Temperature", "Daemon"} {
+		v.SetDefault("Metrics."+k, true)
+	}
+
+	return v
+}
+
+// complete checks the listen address and merges the roles of the policy file.
+func (c *Config) complete() error {
+	if c.Network.Listen != "" {
+		if _, _, err := parser.ParseIpPort(c.Network.Listen); err != nil {
+			logrus.Errorf("Failed to parse Listen=%s", c.Network.Listen)
+			return err
+		}
+	}
+
+	if c.Authorization.PolicyFile != "" {
+		roles, err := ParsePolicyFile(c.Authorization.PolicyFile)
+		if err != nil {
+			logrus.Errorf("Failed to parse policy file='%s': %v", c.Authorization.PolicyFile, err)
+			return err
+		}
+
+		if c.Authorization.Roles == nil {
+			c.Authorization.Roles = make(map[string]Role)
+		}
+		for k, v := range roles {
+			c.Authorization.Roles[k] = v
+		}
+	}
+
+	return nil
+}
+
+func Parse() (*Config, error) {
+	v := newViper()
+	if err := v.ReadInConfig(); err != nil {
 		logrus.Errorf("Failed to parse config file. Using defaults: %v", err)
 	}
 
 	c := Config{}
-	if err := viper.Unmarshal(&c); err != nil {
+	if err := v.Unmarshal(&c); err != nil {
 		logrus.Errorf("Failed to decode config into struct, %v", err)
 	}
 
 	if l, err := logrus.ParseLevel(c.System.LogLevel); err != nil {
-		logrus.Warn("Failed to parse log level='%s', falling back to 'info': %v", c.System.LogLevel, err)
+		logrus.Warnf("Failed to parse log level='%s', falling back to 'info': %v", c.System.LogLevel, err)
 		c.System.LogLevel = DefaultLogLevel
 	} else {
 		logrus.SetLevel(l)
@@ -65,13 +218,80 @@ func Parse() (*Config, error) {
 
 	logrus.Debugf("Log level set to '%+v'", logrus.GetLevel().String())
 
-	if c.Network.Listen != "" {
-		parser.ParseIpPort(c.Network.Listen)
-		if _, _, err := parser.ParseIpPort(c.Network.Listen); err != nil {
-			logrus.Errorf("Failed to parse Listen=%s", c.Network.Listen)
-			return nil, err
-		}
+	if err := c.complete(); err != nil {
+		return nil, err
 	}
 
 	return &c, nil
 }
+
+// Load reads the config file again for a reload. Unlike Parse it does not
+// fall back to defaults: a config which cannot be read or does not validate
+// is an error, and nothing is applied.
+func Load() (*Config, error) {
+	v := newViper()
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	c := Config{}
+	if err := v.Unmarshal(&c); err != nil {
+		return nil, err
+	}
+
+	if _, err := logrus.ParseLevel(c.System.LogLevel); err != nil {
+		return nil, fmt.Errorf("invalid LogLevel='%s'", c.System.LogLevel)
+	}
+
+	if err := c.complete(); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Watch notifies changed when the config file is written. Bursts of writes
+// may be reported more than once.
+func Watch(changed chan<- struct{}) error {
+	v := newViper()
+	if err := v.ReadInConfig(); err != nil {
+		return err
+	}
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	v.WatchConfig()
+
+	return nil
+}
+
+// Diff lists the settings which differ between a and b, one per line in the
+// form "Section.Key: 'old' -> 'new'".
+func Diff(a *Config, b *Config) []string {
+	var changes []string
+	diffValue("", reflect.ValueOf(*a), reflect.ValueOf(*b), &changes)
+
+	return changes
+}
+
+func diffValue(prefix string, a reflect.Value, b reflect.Value, changes *[]string) {
+	if a.Kind() == reflect.Struct {
+		for i := 0; i < a.NumField(); i++ {
+			name := a.Type().Field(i).Name
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+
+			diffValue(name, a.Field(i), b.Field(i), changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		*changes = append(*changes, fmt.Sprintf("%s: '%v' -> '%v'", prefix, a.Interface(), b.Interface()))
+	}
+}
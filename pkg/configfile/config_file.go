@@ -4,6 +4,7 @@
 package configfile
 
 import (
+	"bytes"
 	"errors"
 	"os"
 	"path"
@@ -19,8 +20,23 @@ type Meta struct {
 	Section *ini.Section
 }
 
+var loadOptions = ini.LoadOptions{AllowNonUniqueSections: true, AllowShadows: true}
+
 func Load(path string) (*Meta, error) {
-	cfg, err := ini.LoadSources(ini.LoadOptions{AllowNonUniqueSections: true, AllowShadows: true}, path)
+	cfg, err := ini.LoadSources(loadOptions, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Meta{
+		Path: path,
+		Cfg:  cfg,
+	}, nil
+}
+
+// New returns an empty file which is written to path on Save.
+func New(path string) (*Meta, error) {
+	cfg, err := ini.LoadSources(loadOptions, []byte(""))
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +51,16 @@ func (m *Meta) Save() error {
 	return m.Cfg.SaveTo(m.Path)
 }
 
+// Bytes returns the content Save writes.
+func (m *Meta) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	if _, err := m.Cfg.WriteTo(&b); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
 func ParseKeyFromSectionString(path string, section string, key string) (string, error) {
 	c, err := Load(path)
 	if err != nil {
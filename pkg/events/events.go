@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/share"
+)
+
+const (
+	TopicLink     = "link"
+	TopicAddress  = "address"
+	TopicRoute    = "route"
+	TopicSystemd  = "systemd"
+	TopicHostname = "hostname"
+	TopicTimedate = "timedate"
+	TopicJob      = "job"
+
+	subscriptionBuffer = 256
+	sourceRetryDelay   = 5 * time.Second
+)
+
+type Event struct {
+	Id    uint64      `json:"Id"`
+	Topic string      `json:"Topic"`
+	Time  time.Time   `json:"Time"`
+	Data  interface{} `json:"Data"`
+}
+
+// Subscription receives the events published on its topics. Events are
+// dropped when the subscriber does not keep up.
+type Subscription struct {
+	C      chan *Event
+	topics []string
+	closed bool
+}
+
+// Source watches the system and publishes events until ctx is cancelled.
+type Source func(ctx context.Context) error
+
+type source struct {
+	name  string
+	watch Source
+}
+
+type Broker struct {
+	subscriptions map[*Subscription]struct{}
+	sources       []source
+	counter       uint64
+	ctx           context.Context
+	cancel        context.CancelFunc
+	mutex         sync.Mutex
+}
+
+var broker = &Broker{
+	subscriptions: make(map[*Subscription]struct{}),
+}
+
+// RegisterSource adds a source. Sources are started with the first
+// subscription and stopped when the last one is closed, so an idle daemon
+// does not watch anything.
+func RegisterSource(name string, watch Source) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	broker.sources = append(broker.sources, source{name: name, watch: watch})
+	if broker.ctx != nil {
+		go runSource(broker.ctx, broker.sources[len(broker.sources)-1])
+	}
+}
+
+// runSource restarts a source which failed until ctx is cancelled.
+func runSource(ctx context.Context, s source) {
+	for {
+		if err := s.watch(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("Failed to watch events of source='%s': %v", s.name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sourceRetryDelay):
+		}
+	}
+}
+
+// startSources runs the sources. Must be called with the mutex held.
+func (b *Broker) startSources() {
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	for _, s := range b.sources {
+		go runSource(b.ctx, s)
+	}
+}
+
+// stopSources cancels the sources. Must be called with the mutex held.
+func (b *Broker) stopSources() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.ctx, b.cancel = nil, nil
+}
+
+func (s *Subscription) matches(topic string) bool {
+	return len(s.topics) == 0 || share.StringContains(s.topics, topic)
+}
+
+// Subscribe returns a subscription for the given topics, all topics when
+// empty.
+func Subscribe(topics []string) *Subscription {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if broker.ctx == nil {
+		broker.startSources()
+	}
+
+	s := &Subscription{
+		C:      make(chan *Event, subscriptionBuffer),
+		topics: topics,
+	}
+	broker.subscriptions[s] = struct{}{}
+
+	return s
+}
+
+func (s *Subscription) Close() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	delete(broker.subscriptions, s)
+	close(s.C)
+
+	if len(broker.subscriptions) == 0 {
+		broker.stopSources()
+	}
+}
+
+// CloseSubscriptions ends all subscriptions, for instance on shutdown.
+func CloseSubscriptions() {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	for s := range broker.subscriptions {
+		s.closed = true
+		close(s.C)
+	}
+	broker.subscriptions = make(map[*Subscription]struct{})
+	broker.stopSources()
+}
+
+func Publish(topic string, data interface{}) {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	if len(broker.subscriptions) == 0 {
+		return
+	}
+
+	broker.counter++
+	e := &Event{
+		Id:    broker.counter,
+		Topic: topic,
+		Time:  time.Now().UTC(),
+		Data:  data,
+	}
+
+	for s := range broker.subscriptions {
+		if !s.matches(topic) {
+			continue
+		}
+
+		select {
+		case s.C <- e:
+		default:
+			log.Debugf("Dropped event id='%d' topic='%s' for slow subscriber", e.Id, topic)
+		}
+	}
+}
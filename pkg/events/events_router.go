@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+func parseTopics(r *http.Request) []string {
+	var topics []string
+	for _, v := range r.URL.Query()["topic"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				topics = append(topics, t)
+			}
+		}
+	}
+
+	return topics
+}
+
+func routerAcquireEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrInternal, "streaming not supported"), w)
+		return
+	}
+
+	s := Subscribe(parseTopics(r))
+	defer s.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\n\n")
+		case e, ok := <-s.C:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Errorf("Failed to encode event id='%d' topic='%s': %v", e.Id, e.Topic, err)
+				continue
+			}
+
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Topic, data)
+		}
+
+		flusher.Flush()
+	}
+}
+
+func RegisterRouterEvents(router *mux.Router) {
+	openapi.Describe(router.HandleFunc("/events", routerAcquireEvents).Methods("GET"), "Stream system changes as server-sent events", nil, nil)
+}
@@ -4,88 +4,432 @@
 package jobs
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/validator"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
-type Result struct {
-	Output interface{}
-	Err    error
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateRunning     State = "running"
+	StateSucceeded   State = "succeeded"
+	StateFailed      State = "failed"
+	StateCancelled   State = "cancelled"
+	StateInterrupted State = "interrupted"
+
+	cleanupInterval   = time.Minute
+	cancelTimeout     = 5 * time.Second
+	heartbeatInterval = 15 * time.Second
+	maxLogLines       = 10000
+)
+
+type contextKey int
+
+const jobContextKey contextKey = iota
+
+func (s State) Finished() bool {
+	return s != StateQueued && s != StateRunning
 }
 
+// Work is the function run by a job. It should return early once ctx is
+// cancelled. Output lines and progress are reported through Log and
+// SetProgress with the same ctx.
+type Work func(ctx context.Context) (interface{}, error)
+
+// Job records an asynchronous operation from its creation until its result
+// expires. The exported fields are guarded by the mutex of the registry.
 type Job struct {
-	ResultChannel chan Result
-	Id            uint64
+	Id         uint64      `json:"Id"`
+	State      State       `json:"State"`
+	Route      string      `json:"Route"`
+	Caller     string      `json:"Caller,omitempty"`
+	CreatedAt  time.Time   `json:"CreatedAt"`
+	StartedAt  *time.Time  `json:"StartedAt,omitempty"`
+	FinishedAt *time.Time  `json:"FinishedAt,omitempty"`
+	Progress   int         `json:"Progress"`
+	Error      string      `json:"Error,omitempty"`
+	ErrorCode  string      `json:"ErrorCode,omitempty"`
+	Output     interface{} `json:"Output,omitempty"`
+
+	// log keeps the last maxLogLines output lines; logOffset counts the
+	// lines dropped before them.
+	log       []string
+	logOffset int
+	changed   chan struct{}
+
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 type Jobs struct {
-	jobMap     map[uint64]Job
-	resultMap  map[uint64]Result
+	jobMap     map[uint64]*Job
 	jobCounter uint64
+	ttl        time.Duration
+	slots      chan struct{}
+	stateFile  string
 	Mutex      *sync.Mutex
 }
 
+type CompletionEvent struct {
+	Id      uint64 `json:"Id"`
+	State   State  `json:"State"`
+	Success bool   `json:"Success"`
+	Error   string `json:"Error,omitempty"`
+}
+
 var jobs *Jobs
 
-func New() *Jobs {
+var running atomic.Int64
+
+var callerFunc = func(r *http.Request) string {
+	return ""
+}
+
+// InFlight returns the number of jobs still executing.
+func InFlight() int64 {
+	return running.Load()
+}
+
+// SetCallerFunc sets the function naming the caller which created a job.
+func SetCallerFunc(f func(r *http.Request) string) {
+	callerFunc = f
+}
+
+// New creates the job registry once. A nil configuration keeps the results
+// in memory with the default TTL.
+func New(c *conf.Jobs) *Jobs {
 	if jobs != nil {
 		return jobs
-	} else {
-		jobs = &Jobs{
-			jobMap:    make(map[uint64]Job),
-			resultMap: make(map[uint64]Result),
-			Mutex:     &sync.Mutex{},
+	}
+
+	if c == nil {
+		c = &conf.Jobs{}
+	}
+
+	ttl, err := time.ParseDuration(c.ResultTTL)
+	if err != nil {
+		ttl, _ = time.ParseDuration(conf.DefaultJobResultTTL)
+	}
+
+	maxRunning := c.MaxRunning
+	if maxRunning <= 0 {
+		maxRunning = conf.DefaultJobMaxRunning
+	}
+
+	jobs = &Jobs{
+		jobMap: make(map[uint64]*Job),
+		ttl:    ttl,
+		slots:  make(chan struct{}, maxRunning),
+		Mutex:  &sync.Mutex{},
+	}
+
+	if c.Persist {
+		jobs.stateFile = c.StateFile
+		if jobs.stateFile == "" {
+			jobs.stateFile = conf.DefaultJobStateFile
 		}
-		return jobs
+
+		if err := jobs.load(); err != nil {
+			log.Errorf("Failed to load jobs from '%s': %v", jobs.stateFile, err)
+		}
+	}
+
+	go jobs.cleanup()
+
+	return jobs
+}
+
+// load restores the jobs of a previous run. Jobs which did not finish are
+// marked interrupted as their work is lost. All of them are done, so they
+// can only be dropped.
+func (j *Jobs) load() error {
+	data, err := os.ReadFile(j.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var list []*Job
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	now := time.Now().UTC()
+	for _, job := range list {
+		if !job.State.Finished() {
+			job.State = StateInterrupted
+			job.Error = "interrupted by daemon restart"
+			job.FinishedAt = &now
+		}
+
+		job.changed = make(chan struct{})
+		job.cancel = func() {}
+		job.done = make(chan struct{})
+		close(job.done)
+
+		j.jobMap[job.Id] = job
+		j.jobCounter = max(j.jobCounter, job.Id)
+	}
+
+	j.save()
+	return nil
+}
+
+// save writes the registry to the state file. Must be called with the mutex
+// held.
+func (j *Jobs) save() {
+	if j.stateFile == "" {
+		return
+	}
+
+	list := make([]*Job, 0, len(j.jobMap))
+	for _, job := range j.jobMap {
+		list = append(list, job)
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		log.Errorf("Failed to encode jobs: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.stateFile), ".jobs-*")
+	if err != nil {
+		log.Errorf("Failed to save jobs to '%s': %v", j.stateFile, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.Errorf("Failed to save jobs to '%s': %v", j.stateFile, err)
+		return
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmp.Name(), j.stateFile); err != nil {
+		log.Errorf("Failed to save jobs to '%s': %v", j.stateFile, err)
+	}
+}
+
+func (j *Jobs) cleanup() {
+	t := time.NewTicker(cleanupInterval)
+	defer t.Stop()
+
+	for range t.C {
+		j.Mutex.Lock()
+
+		removed := false
+		for id, job := range j.jobMap {
+			if job.State.Finished() && job.FinishedAt != nil && time.Since(*job.FinishedAt) > j.ttl {
+				delete(j.jobMap, id)
+				removed = true
+			}
+		}
+
+		if removed {
+			j.save()
+		}
+
+		j.Mutex.Unlock()
 	}
 }
 
-func NewJob() *Job {
+func (j *Jobs) start(job *Job) {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	now := time.Now().UTC()
+	job.State = StateRunning
+	job.StartedAt = &now
+	job.notify()
+	j.save()
+}
+
+func (j *Jobs) finish(ctx context.Context, job *Job, output interface{}, err error) {
+	j.Mutex.Lock()
+	defer j.Mutex.Unlock()
+
+	now := time.Now().UTC()
+	job.FinishedAt = &now
+	job.Output = output
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		job.State = StateCancelled
+		job.Error = "cancelled"
+	case err != nil:
+		job.State = StateFailed
+		job.Error = err.Error()
+		job.ErrorCode = string(web.AsError(err).Code)
+	default:
+		job.State = StateSucceeded
+		job.Progress = 100
+	}
+
+	job.notify()
+	j.save()
+
+	events.Publish(events.TopicJob, CompletionEvent{
+		Id:      job.Id,
+		State:   job.State,
+		Success: job.State == StateSucceeded,
+		Error:   job.Error,
+	})
+}
+
+// notify wakes the readers following the job. Must be called with the mutex
+// held.
+func (job *Job) notify() {
+	if job.changed != nil {
+		close(job.changed)
+	}
+	job.changed = make(chan struct{})
+}
+
+func fromContext(ctx context.Context) *Job {
+	job, _ := ctx.Value(jobContextKey).(*Job)
+	return job
+}
+
+// Log appends an output line to the job running with ctx.
+func Log(ctx context.Context, line string) {
+	job := fromContext(ctx)
+	if job == nil {
+		return
+	}
+
 	jobs.Mutex.Lock()
 	defer jobs.Mutex.Unlock()
 
+	job.log = append(job.log, line)
+	if len(job.log) > maxLogLines {
+		n := len(job.log) - maxLogLines
+		job.log = append([]string(nil), job.log[n:]...)
+		job.logOffset += n
+	}
+	job.notify()
+}
+
+// SetProgress records the percentage of the work done by the job running with
+// ctx.
+func SetProgress(ctx context.Context, percent int) {
+	job := fromContext(ctx)
+	if job == nil {
+		return
+	}
+
+	jobs.Mutex.Lock()
+	defer jobs.Mutex.Unlock()
+
+	if percent = max(0, min(percent, 100)); percent != job.Progress {
+		job.Progress = percent
+		job.notify()
+	}
+}
+
+func (job *Job) run(ctx context.Context, work Work) {
+	defer close(job.done)
+	defer job.cancel()
+
+	select {
+	case jobs.slots <- struct{}{}:
+		defer func() { <-jobs.slots }()
+	case <-ctx.Done():
+		jobs.finish(ctx, job, nil, ctx.Err())
+		return
+	}
+
+	jobs.start(job)
+
+	running.Add(1)
+	output, err := work(ctx)
+	running.Add(-1)
+
+	jobs.finish(ctx, job, output, err)
+}
+
+// snapshot copies the job so it can be encoded without holding the mutex.
+func (job *Job) snapshot(output bool) Job {
+	s := *job
+	if !output {
+		s.Output = nil
+	}
+
+	return s
+}
+
+// CreateJob queues the work on behalf of the request. The work is cancelled
+// through its context when the job is deleted.
+func CreateJob(r *http.Request, work Work) *Job {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	jobs.Mutex.Lock()
 	jobs.jobCounter++
-	job := Job{
-		ResultChannel: make(chan Result),
-		Id:            jobs.jobCounter,
+	job := &Job{
+		Id:        jobs.jobCounter,
+		State:     StateQueued,
+		Route:     r.Method + " " + r.URL.Path,
+		Caller:    callerFunc(r),
+		CreatedAt: time.Now().UTC(),
+		changed:   make(chan struct{}),
+		cancel:    cancel,
+		done:      make(chan struct{}),
 	}
+	jobs.jobMap[job.Id] = job
+	jobs.save()
+	jobs.Mutex.Unlock()
 
-	jobs.jobMap[jobs.jobCounter] = job
+	go job.run(context.WithValue(ctx, jobContextKey, job), work)
 
-	return &job
+	return job
 }
 
-func RemoveJob(id uint64) {
+// Remove drops a finished job from the registry.
+func Remove(id uint64) {
 	jobs.Mutex.Lock()
 	defer jobs.Mutex.Unlock()
 
 	delete(jobs.jobMap, id)
+	jobs.save()
 }
 
-func RemoveResult(id uint64) {
+func acquireJob(id uint64) (Job, bool) {
 	jobs.Mutex.Lock()
 	defer jobs.Mutex.Unlock()
 
-	delete(jobs.resultMap, id)
-}
+	job, ok := jobs.jobMap[id]
+	if !ok {
+		return Job{}, false
+	}
 
-func CreateJob(acquireFunc func() (interface{}, error)) *Job {
-	job := NewJob()
-	go func() {
-		s, err := acquireFunc()
-		result := Result{
-			Output: s,
-			Err:    err,
-		}
-		job.ResultChannel <- result
-	}()
-	return job
+	return job.snapshot(true), true
 }
 
 func AcceptedResponse(w http.ResponseWriter, job *Job) error {
@@ -94,52 +438,238 @@ func AcceptedResponse(w http.ResponseWriter, job *Job) error {
 	return nil
 }
 
-func routerAcquireStatus(w http.ResponseWriter, r *http.Request) {
+func parseId(r *http.Request) (uint64, error) {
 	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
 	if err != nil {
-		web.JSONResponseError(errors.New("invalid id"), w)
+		return 0, web.InvalidArgument("id", mux.Vars(r)["id"])
 	}
-	if job, ok := jobs.jobMap[id]; ok {
+
+	return id, nil
+}
+
+func routerAcquireJobs(w http.ResponseWriter, r *http.Request) {
+	state := State(r.URL.Query().Get("state"))
+
+	jobs.Mutex.Lock()
+	list := make([]Job, 0, len(jobs.jobMap))
+	for _, job := range jobs.jobMap {
+		if state == "" || job.State == state {
+			list = append(list, job.snapshot(false))
+		}
+	}
+	jobs.Mutex.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Id < list[j].Id
+	})
+
+	web.JSONResponse(list, w)
+}
+
+func routerAcquireJob(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	job, ok := acquireJob(id)
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "job id='%d' not found", id), w)
+		return
+	}
+
+	web.JSONResponse(job, w)
+}
+
+func routerCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	jobs.Mutex.Lock()
+	job, ok := jobs.jobMap[id]
+	jobs.Mutex.Unlock()
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "job id='%d' not found", id), w)
+		return
+	}
+
+	select {
+	case <-job.done:
+		// Deleting a finished job drops its result.
+		Remove(id)
+	default:
+		job.cancel()
 		select {
-		case result := <-job.ResultChannel:
-			jobs.resultMap[id] = result
-			RemoveJob(id)
-			web.JSONResponse(
-				web.StatusResponse{
-					Status: "complete",
-					Link:   "/api/v1/_jobs/result/" + strconv.FormatUint(id, 10),
-				},
-				w)
-		default:
-			web.JSONResponse(web.StatusResponse{Status: "inprogress"}, w)
+		case <-job.done:
+		case <-time.After(cancelTimeout):
+			log.Warningf("Job id='%d' did not stop within %v after being cancelled", id, cancelTimeout)
 		}
-	} else {
-		web.JSONResponseError(errors.New("not found"), w)
 	}
+
+	jobs.Mutex.Lock()
+	s := job.snapshot(false)
+	jobs.Mutex.Unlock()
+
+	web.JSONResponse(s, w)
+}
+
+func writeEvent(w http.ResponseWriter, id int, event string, data string) {
+	if id >= 0 {
+		fmt.Fprintf(w, "id: %d\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\n", event)
+	for _, l := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", l)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// routerAcquireJobLog streams the output of a job as server-sent events:
+// "log" for each line, "progress" when the percentage changes and "state"
+// with the job once it finished. With follow the stream stays open until
+// then, otherwise it ends after the lines written so far.
+func routerAcquireJobLog(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrInternal, "streaming not supported"), w)
+		return
+	}
+
+	jobs.Mutex.Lock()
+	job, ok := jobs.jobMap[id]
+	jobs.Mutex.Unlock()
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "job id='%d' not found", id), w)
+		return
+	}
+
+	follow := validator.IsBool(r.URL.Query().Get("follow"))
+
+	next := 0
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			next = n + 1
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	progress := -1
+	for {
+		jobs.Mutex.Lock()
+		next = max(job.logOffset, min(next, job.logOffset+len(job.log)))
+		lines := append([]string(nil), job.log[next-job.logOffset:]...)
+		s := job.snapshot(false)
+		changed := job.changed
+		jobs.Mutex.Unlock()
+
+		for i, l := range lines {
+			writeEvent(w, next+i, "log", l)
+		}
+		next += len(lines)
+
+		if s.Progress != progress {
+			progress = s.Progress
+			writeEvent(w, -1, "progress", strconv.Itoa(progress))
+		}
+
+		if s.State.Finished() || !follow {
+			if data, err := json.Marshal(s); err == nil {
+				writeEvent(w, -1, "state", string(data))
+			}
+			flusher.Flush()
+			return
+		}
+
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-changed:
+		}
+	}
+}
+
+func routerAcquireStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := parseId(r)
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	job, ok := acquireJob(id)
+	if !ok {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "job id='%d' not found", id), w)
+		return
+	}
+
+	if !job.State.Finished() {
+		web.JSONResponse(web.StatusResponse{Status: "inprogress"}, w)
+		return
+	}
+
+	web.JSONResponse(
+		web.StatusResponse{
+			Status: "complete",
+			Link:   "/api/v1/_jobs/result/" + strconv.FormatUint(id, 10),
+		},
+		w)
 }
 
 func routerAcquireResult(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	id, err := parseId(r)
 	if err != nil {
-		web.JSONResponseError(errors.New("invalid id"), w)
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	job, ok := acquireJob(id)
+	if !ok || !job.State.Finished() {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "job id='%d' not found", id), w)
+		return
 	}
-	if result, ok := jobs.resultMap[id]; ok {
-		if result.Err != nil {
-			web.JSONResponseError(result.Err, w)
-		} else {
-			web.JSONResponse(result.Output, w)
+
+	if job.State != StateSucceeded {
+		code := web.ErrorCode(job.ErrorCode)
+		if code == "" {
+			code = web.ErrInternal
 		}
-		RemoveResult(id)
-	} else {
-		web.JSONResponseError(errors.New("not found"), w)
+
+		web.JSONResponseError(web.NewError(code, "%s", job.Error), w)
+		return
 	}
+
+	web.JSONResponse(job.Output, w)
 }
 
 func RegisterRouterJobs(router *mux.Router) {
-	jobs = New()
+	jobs = New(nil)
 
 	n := router.PathPrefix("/_jobs").Subrouter().StrictSlash(false)
 
-	n.HandleFunc("/status/{id}", routerAcquireStatus).Methods("GET")
-	n.HandleFunc("/result/{id}", routerAcquireResult).Methods("GET")
+	openapi.Describe(n.HandleFunc("", routerAcquireJobs).Methods("GET"), "List the jobs", nil, []Job{})
+	openapi.Describe(n.HandleFunc("/status/{id}", routerAcquireStatus).Methods("GET"), "Show whether a job completed", nil, web.StatusResponse{})
+	openapi.Describe(n.HandleFunc("/result/{id}", routerAcquireResult).Methods("GET"), "Show the result of a job", nil, nil)
+	openapi.Describe(n.HandleFunc("/{id}", routerAcquireJob).Methods("GET"), "Show a job", nil, Job{})
+	openapi.Describe(n.HandleFunc("/{id}/log", routerAcquireJobLog).Methods("GET"), "Stream the output of a job as server-sent events", nil, nil)
+	openapi.Describe(n.HandleFunc("/{id}", routerCancelJob).Methods("DELETE"), "Cancel a job or drop a finished one", nil, Job{})
 }
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+)
+
+func TestCancelJobAfterReload(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "jobs.json")
+
+	now := time.Now().UTC()
+	list := []*Job{
+		{Id: 1, State: StateRunning, Route: "POST /api/v1/tdnf/install", CreatedAt: now},
+		{Id: 2, State: StateSucceeded, Route: "POST /api/v1/tdnf/update", CreatedAt: now, FinishedAt: &now},
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatalf("Failed to encode jobs: %v", err)
+	}
+	if err := os.WriteFile(stateFile, data, 0600); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	jobs = nil
+	New(&conf.Jobs{Persist: true, StateFile: stateFile})
+	defer func() { jobs = nil }()
+
+	if s := jobs.jobMap[1].State; s != StateInterrupted {
+		t.Fatalf("Expected job 1 to be interrupted, got state='%s'", s)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v1/_jobs/"+id, nil), map[string]string{"id": id})
+		w := httptest.NewRecorder()
+
+		routerCancelJob(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Failed to delete job id='%s': status=%d body=%s", id, w.Code, w.Body.String())
+		}
+	}
+
+	if len(jobs.jobMap) != 0 {
+		t.Fatalf("Expected the restored jobs to be dropped, %d left", len(jobs.jobMap))
+	}
+
+	// The next job must not reuse the ids of the restored ones.
+	job := CreateJob(httptest.NewRequest(http.MethodPost, "/api/v1/test", nil), func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+	if job.Id != 3 {
+		t.Fatalf("Expected job id 3, got %d", job.Id)
+	}
+	<-job.done
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DurationBuckets are the upper bounds in seconds of the request latency
+// histogram.
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	route  string
+	method string
+}
+
+type requestStats struct {
+	codes   map[int]uint64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+var (
+	requests    = make(map[requestKey]*requestStats)
+	dbusErrors  = make(map[string]uint64)
+	daemonMutex sync.Mutex
+)
+
+// ObserveRequest accounts a served request to its route.
+func ObserveRequest(route, method string, code int, d time.Duration) {
+	daemonMutex.Lock()
+	defer daemonMutex.Unlock()
+
+	k := requestKey{route: route, method: method}
+	s, ok := requests[k]
+	if !ok {
+		s = &requestStats{
+			codes:   make(map[int]uint64),
+			buckets: make([]uint64, len(DurationBuckets)),
+		}
+		requests[k] = s
+	}
+
+	seconds := d.Seconds()
+
+	s.codes[code]++
+	s.count++
+	s.sum += seconds
+	for i, b := range DurationBuckets {
+		if seconds <= b {
+			s.buckets[i]++
+		}
+	}
+}
+
+// DBusError accounts a failed D-Bus call by the name of the error.
+func DBusError(name string) {
+	daemonMutex.Lock()
+	defer daemonMutex.Unlock()
+
+	dbusErrors[name]++
+}
+
+func sortedRequestKeys() []requestKey {
+	keys := make([]requestKey, 0, len(requests))
+	for k := range requests {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	return keys
+}
+
+// WriteDaemon writes the request and D-Bus metrics of the daemon.
+func WriteDaemon(m *Writer) {
+	daemonMutex.Lock()
+	defer daemonMutex.Unlock()
+
+	keys := sortedRequestKeys()
+
+	name := Name("http_requests_total")
+	m.Family(name, "Number of HTTP requests served by route, method and status code.", Counter)
+	for _, k := range keys {
+		s := requests[k]
+
+		codes := make([]int, 0, len(s.codes))
+		for c := range s.codes {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+
+		for _, c := range codes {
+			m.Sample(name, float64(s.codes[c]), "route", k.route, "method", k.method, "code", strconv.Itoa(c))
+		}
+	}
+
+	name = Name("http_request_duration_seconds")
+	m.Family(name, "Latency of HTTP requests by route and method.", Histogram)
+	for _, k := range keys {
+		s := requests[k]
+		for i, b := range DurationBuckets {
+			m.Sample(name+"_bucket", float64(s.buckets[i]), "route", k.route, "method", k.method, "le", formatValue(b))
+		}
+		m.Sample(name+"_bucket", float64(s.count), "route", k.route, "method", k.method, "le", "+Inf")
+		m.Sample(name+"_sum", s.sum, "route", k.route, "method", k.method)
+		m.Sample(name+"_count", float64(s.count), "route", k.route, "method", k.method)
+	}
+
+	names := make([]string, 0, len(dbusErrors))
+	for n := range dbusErrors {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	name = Name("dbus_errors_total")
+	m.Family(name, "Number of failed D-Bus calls by error name.", Counter)
+	for _, n := range names {
+		m.Sample(name, float64(dbusErrors[n]), "name", n)
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	Namespace = "photon_mgmt"
+
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+	Untyped   = "untyped"
+)
+
+// Collector writes one family group of metrics.
+type Collector func(ctx context.Context, w *Writer) error
+
+type collector struct {
+	name    string
+	collect Collector
+}
+
+var (
+	collectors      []collector
+	collectorsMutex sync.Mutex
+)
+
+// Writer emits samples in the Prometheus text exposition format.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: bufio.NewWriter(w),
+	}
+}
+
+func (m *Writer) Flush() error {
+	return m.w.Flush()
+}
+
+func Name(name string) string {
+	return Namespace + "_" + name
+}
+
+// Family starts a metric family. Samples of the family have to follow.
+func (m *Writer) Family(name, help, kind string) {
+	m.w.WriteString("# HELP " + name + " " + strings.NewReplacer("\\", `\\`, "\n", `\n`).Replace(help) + "\n")
+	m.w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+var labelReplacer = strings.NewReplacer("\\", `\\`, "\"", `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Sample writes one sample. Labels are given as name, value pairs.
+func (m *Writer) Sample(name string, value float64, labels ...string) {
+	m.w.WriteString(name)
+	if len(labels) > 1 {
+		m.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			m.w.WriteString(labels[i] + "=\"" + labelReplacer.Replace(labels[i+1]) + "\"")
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteString(" " + formatValue(value) + "\n")
+}
+
+// RegisterCollector adds a collector under the name used to enable or disable
+// it in the configuration.
+func RegisterCollector(name string, c Collector) {
+	collectorsMutex.Lock()
+	defer collectorsMutex.Unlock()
+
+	for i := range collectors {
+		if collectors[i].name == name {
+			collectors[i].collect = c
+			return
+		}
+	}
+
+	collectors = append(collectors, collector{name: name, collect: c})
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name < collectors[j].name })
+}
+
+// Collect runs the enabled collectors. A failing collector does not prevent
+// the others from being written, the first error is returned.
+func Collect(ctx context.Context, w io.Writer, enabled func(name string) bool) error {
+	collectorsMutex.Lock()
+	c := append([]collector(nil), collectors...)
+	collectorsMutex.Unlock()
+
+	m := NewWriter(w)
+
+	var err error
+	for _, e := range c {
+		if !enabled(e.name) {
+			continue
+		}
+
+		if cerr := e.collect(ctx, m); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	if ferr := m.Flush(); ferr != nil && err == nil {
+		err = ferr
+	}
+
+	return err
+}
@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+// Package openapi builds an OpenAPI 3 document from the routes registered
+// with the router and the request and response types attached to them.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const Version = "3.0.3"
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Operation struct {
+	OperationId string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security"`
+}
+
+// description documents what the router does not know about a route.
+type description struct {
+	summary  string
+	request  reflect.Type
+	response reflect.Type
+}
+
+var (
+	descriptions = make(map[*mux.Route]*description)
+	mutex        sync.Mutex
+
+	pathVariable = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+)
+
+func describe(route *mux.Route) *description {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	d, ok := descriptions[route]
+	if !ok {
+		d = &description{}
+		descriptions[route] = d
+	}
+
+	return d
+}
+
+func typeOf(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+
+	return reflect.TypeOf(v)
+}
+
+// Describe attaches the summary and the types of the JSON request body and of
+// the response message to the route. Either type may be nil. The route is
+// returned so the call can wrap HandleFunc.
+func Describe(route *mux.Route, summary string, request, response interface{}) *mux.Route {
+	d := describe(route)
+	d.summary = summary
+	d.request = typeOf(request)
+	d.response = typeOf(response)
+
+	return route
+}
+
+// operationId is derived from the name of the handler, for example
+// "networkd.routerConfigureNetwork".
+func operationId(route *mux.Route, method string) string {
+	h := route.GetHandler()
+	if h == nil {
+		return method
+	}
+
+	v := reflect.ValueOf(h)
+	if f, ok := h.(http.HandlerFunc); ok {
+		v = reflect.ValueOf(f)
+	}
+
+	if v.Kind() != reflect.Func {
+		return method
+	}
+
+	name := runtime.FuncForPC(v.Pointer()).Name()
+	name = path.Base(name)
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.LastIndex(name, ".func"); i > 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func envelope(message *Schema, components map[string]*Schema) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"message": message,
+			"errors":  {Type: "string"},
+			"error":   schemaOf(reflect.TypeOf(web.Error{}), components),
+		},
+		Required: []string{"success", "message", "errors"},
+	}
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{
+		"application/json": {Schema: s},
+	}
+}
+
+// Generate walks the router and returns the document of its routes.
+func Generate(router *mux.Router) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "photon-mgmtd",
+			Description: "REST API to configure and monitor the system, network and packages.",
+			Version:     conf.Version,
+		},
+		Paths: make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				"bearer":       {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"sessionToken": {Type: "apiKey", Name: "X-Session-Token", In: "header"},
+			},
+		},
+		Security: []map[string][]string{
+			{"bearer": {}},
+			{"sessionToken": {}},
+		},
+	}
+
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet, http.MethodPost}
+		}
+
+		mutex.Lock()
+		d := descriptions[route]
+		mutex.Unlock()
+		if d == nil {
+			d = &description{}
+		}
+
+		p := pathVariable.ReplaceAllString(tmpl, "{$1}")
+		var parameters []Parameter
+		for _, m := range pathVariable.FindAllStringSubmatch(tmpl, -1) {
+			parameters = append(parameters, Parameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+
+		tag := ""
+		if s := strings.Split(strings.TrimPrefix(p, "/api/v1/"), "/"); len(s) > 0 {
+			tag = s[0]
+		}
+
+		for _, method := range methods {
+			op := &Operation{
+				OperationId: operationId(route, method),
+				Summary:     d.summary,
+				Parameters:  parameters,
+				Responses:   make(map[string]Response),
+			}
+
+			if tag != "" {
+				op.Tags = []string{tag}
+			}
+
+			if d.request != nil {
+				op.RequestBody = &RequestBody{
+					Required: true,
+					Content:  jsonContent(schemaOf(d.request, doc.Components.Schemas)),
+				}
+			}
+
+			op.Responses["200"] = Response{
+				Description: "Result of the request.",
+				Content:     jsonContent(envelope(schemaOf(d.response, doc.Components.Schemas), doc.Components.Schemas)),
+			}
+			op.Responses["default"] = Response{
+				Description: "The request failed. The HTTP status follows the code of error.",
+				Content:     jsonContent(envelope(&Schema{}, doc.Components.Schemas)),
+			}
+
+			if doc.Paths[p] == nil {
+				doc.Paths[p] = make(map[string]*Operation)
+			}
+			doc.Paths[p][strings.ToLower(method)] = op
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueOperationIds(doc)
+
+	return doc, nil
+}
+
+// uniqueOperationIds suffixes the ids of handlers serving several routes.
+func uniqueOperationIds(doc *Document) {
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]int)
+	for _, p := range paths {
+		methods := make([]string, 0, len(doc.Paths[p]))
+		for m := range doc.Paths[p] {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+
+		for _, m := range methods {
+			op := doc.Paths[p][m]
+			if n := seen[op.OperationId]; n > 0 {
+				seen[op.OperationId]++
+				op.OperationId += "_" + strconv.Itoa(n+1)
+				continue
+			}
+			seen[op.OperationId] = 1
+		}
+	}
+}
+
+// RegisterRouterOpenAPI serves the document of the routes of router. It is
+// generated with the first request, once all plugins registered their routes.
+func RegisterRouterOpenAPI(router *mux.Router) {
+	var once sync.Once
+	var doc *Document
+
+	router.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			d, err := Generate(router)
+			if err != nil {
+				log.Errorf("Failed to generate OpenAPI document: %v", err)
+				return
+			}
+			doc = d
+		})
+
+		if doc == nil {
+			web.JSONResponseError(errors.New("failed to generate document"), w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(doc)
+	}).Methods("GET")
+}
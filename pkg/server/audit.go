@@ -0,0 +1,465 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const (
+	auditMaxBody = 64 * 1024
+	auditRedact  = "<redacted>"
+)
+
+// auditRedactKeys lists the request body keys whose values are never logged.
+var auditRedactKeys = []string{
+	"password",
+	"privatekey",
+	"presharedkey",
+	"secret",
+	"token",
+}
+
+// auditRedactNested lists the keys which are secrets only inside the objects
+// whose key starts with the prefix, like the Key of a MACsec association.
+var auditRedactNested = map[string][]string{
+	"macsec": {"key"},
+}
+
+func auditSecret(parent string, key string) bool {
+	for _, r := range auditRedactKeys {
+		if strings.HasSuffix(key, r) {
+			return true
+		}
+	}
+
+	for prefix, keys := range auditRedactNested {
+		if strings.HasPrefix(parent, prefix) {
+			for _, k := range keys {
+				if key == k {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+type AuditRecord struct {
+	Time       time.Time       `json:"Time"`
+	User       string          `json:"User"`
+	Uid        *uint32         `json:"Uid,omitempty"`
+	Pid        *int32          `json:"Pid,omitempty"`
+	RemoteAddr string          `json:"RemoteAddr,omitempty"`
+	Method     string          `json:"Method"`
+	Route      string          `json:"Route"`
+	Path       string          `json:"Path"`
+	Body       json.RawMessage `json:"Body,omitempty"`
+	Truncated  bool            `json:"Truncated,omitempty"`
+	Status     int             `json:"Status"`
+	Success    bool            `json:"Success"`
+	Error      string          `json:"Error,omitempty"`
+	Duration   string          `json:"Duration"`
+}
+
+// AuditLog appends records as JSON lines to a file which is rotated once it
+// grows beyond MaxSize. Rotated files are kept as File.1 ... File.MaxBackups.
+type AuditLog struct {
+	file       *os.File
+	path       string
+	size       int64
+	maxSize    int64
+	maxBackups int
+	mutex      sync.Mutex
+}
+
+var auditLog *AuditLog
+
+func NewAuditLog(c *conf.Audit) (*AuditLog, error) {
+	a := AuditLog{
+		path:       c.File,
+		maxSize:    int64(c.MaxSize) * 1024 * 1024,
+		maxBackups: c.MaxBackups,
+	}
+
+	if validator.IsEmpty(a.path) {
+		a.path = conf.DefaultAuditFile
+	}
+	if a.maxSize <= 0 {
+		a.maxSize = conf.DefaultAuditMaxSize * 1024 * 1024
+	}
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0750); err != nil {
+		return nil, err
+	}
+
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (a *AuditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	a.file = f
+	a.size = st.Size()
+
+	return nil
+}
+
+func (a *AuditLog) backup(n int) string {
+	return fmt.Sprintf("%s.%d", a.path, n)
+}
+
+func (a *AuditLog) rotate() error {
+	a.file.Close()
+
+	if a.maxBackups > 0 {
+		os.Remove(a.backup(a.maxBackups))
+		for i := a.maxBackups - 1; i > 0; i-- {
+			os.Rename(a.backup(i), a.backup(i+1))
+		}
+		if err := os.Rename(a.path, a.backup(1)); err != nil {
+			return err
+		}
+	} else {
+		os.Remove(a.path)
+	}
+
+	return a.open()
+}
+
+func (a *AuditLog) Write(rec *AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.size+int64(len(b)) > a.maxSize && a.size > 0 {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(b)
+	a.size += int64(n)
+
+	return err
+}
+
+// Query returns the records logged between since and until, oldest first.
+// Zero times and an empty user are not filtered on.
+func (a *AuditLog) Query(since, until time.Time, user string) ([]AuditRecord, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	files := []string{}
+	for i := a.maxBackups; i > 0; i-- {
+		files = append(files, a.backup(i))
+	}
+	files = append(files, a.path)
+
+	records := []AuditRecord{}
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		err = readAuditLines(f, func(line []byte) {
+			rec := AuditRecord{}
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return
+			}
+
+			if !since.IsZero() && rec.Time.Before(since) {
+				return
+			}
+			if !until.IsZero() && rec.Time.After(until) {
+				return
+			}
+			if user != "" && rec.User != user {
+				return
+			}
+
+			records = append(records, rec)
+		})
+		f.Close()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// readAuditLines calls fn with each line of r. Lines longer than the buffer
+// cannot be records written by this version and are skipped.
+func readAuditLines(r io.Reader, fn func(line []byte)) error {
+	br := bufio.NewReaderSize(r, 4*auditMaxBody)
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			for err == bufio.ErrBufferFull {
+				_, err = br.ReadSlice('\n')
+			}
+			log.Warningf("Skipped audit record longer than %d bytes", 4*auditMaxBody)
+		} else if len(bytes.TrimSpace(line)) > 0 {
+			fn(line)
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// redact replaces the secrets of v, found below the key parent.
+func redact(v interface{}, parent string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			key := strings.ToLower(k)
+			if auditSecret(parent, key) {
+				t[k] = auditRedact
+			} else {
+				t[k] = redact(e, key)
+			}
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redact(e, parent)
+		}
+	}
+
+	return v
+}
+
+// redactBody strips secrets from a JSON request body. Bodies which are not
+// JSON cannot be inspected for secrets and are replaced as a whole, as are
+// those longer than auditMaxBody, which tells whether it was truncated.
+func redactBody(body []byte) (json.RawMessage, bool) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, false
+	}
+
+	marker, _ := json.Marshal(auditRedact)
+	if len(body) > auditMaxBody {
+		return marker, true
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return marker, false
+	}
+
+	b, err := json.Marshal(redact(v, ""))
+	if err != nil {
+		return nil, false
+	}
+	if len(b) > auditMaxBody {
+		return marker, true
+	}
+
+	return b, false
+}
+
+// auditBody puts the part of a request body read for the record back in
+// front of the rest, which the handler reads as before.
+type auditBody struct {
+	io.Reader
+	io.Closer
+}
+
+// auditResponseWriter records the status and the start of the body written by
+// the handler so the result of the call can be logged.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if n := auditMaxBody - w.body.Len(); n > 0 {
+		if len(b) < n {
+			n = len(b)
+		}
+		w.body.Write(b[:n])
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// audited tells whether a request modifies the system. Besides every non-GET
+// request this covers the GET routes the readonly role is denied.
+func audited(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return true
+	}
+
+	for _, s := range readOnlyDeny {
+		if rule, err := parseRule(s); err == nil && rule.match(r.Method, r.URL.Path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func AuditMiddleware(a *AuditLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !audited(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			var body []byte
+			if r.Body != nil {
+				body, _ = io.ReadAll(io.LimitReader(r.Body, auditMaxBody+1))
+				r.Body = auditBody{
+					Reader: io.MultiReader(bytes.NewReader(body), r.Body),
+					Closer: r.Body,
+				}
+			}
+
+			rw := &auditResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			rec := AuditRecord{
+				Time:       start,
+				RemoteAddr: r.RemoteAddr,
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Route:      r.URL.Path,
+				Status:     rw.status,
+				Duration:   time.Since(start).String(),
+			}
+
+			rec.Body, rec.Truncated = redactBody(body)
+
+			if route := mux.CurrentRoute(r); route != nil {
+				if t, err := route.GetPathTemplate(); err == nil {
+					rec.Route = t
+				}
+			}
+
+			if id := IdentityFromContext(r.Context()); id != nil {
+				rec.User = id.Name
+				if id.Credentials != nil {
+					rec.Uid = &id.Credentials.Uid
+					rec.Pid = &id.Credentials.Pid
+				}
+			}
+
+			m := web.JSONResponseMessage{}
+			if err := json.Unmarshal(rw.body.Bytes(), &m); err == nil {
+				rec.Success = m.Success
+				rec.Error = m.Errors
+			} else {
+				rec.Success = rw.status >= 200 && rw.status < 300
+			}
+
+			if err := a.Write(&rec); err != nil {
+				log.Errorf("Failed to write audit record for method='%s' path='%s': %v", r.Method, r.URL.Path, err)
+			}
+		})
+	}
+}
+
+func parseAuditTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+func routerAcquireAudit(w http.ResponseWriter, r *http.Request) {
+	if auditLog == nil {
+		web.JSONResponseError(web.NewError(web.ErrNotFound, "audit log not enabled"), w)
+		return
+	}
+
+	since, err := parseAuditTime(r.FormValue("since"))
+	if err != nil {
+		web.JSONResponseError(web.NewError(web.ErrInvalidArgument, "invalid since='%s', expected RFC3339 time", r.FormValue("since")).WithField("since", r.FormValue("since"), "expected RFC3339 time"), w)
+		return
+	}
+
+	until, err := parseAuditTime(r.FormValue("until"))
+	if err != nil {
+		web.JSONResponseError(web.NewError(web.ErrInvalidArgument, "invalid until='%s', expected RFC3339 time", r.FormValue("until")).WithField("until", r.FormValue("until"), "expected RFC3339 time"), w)
+		return
+	}
+
+	records, err := auditLog.Query(since, until, r.FormValue("user"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(records, w)
+}
+
+func RegisterRouterAudit(router *mux.Router) {
+	openapi.Describe(router.HandleFunc("/_audit", routerAcquireAudit).Methods("GET"), "Query the audit log", nil, []AuditRecord{})
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	long := `{"Name":"` + strings.Repeat("a", auditMaxBody) + `"}`
+
+	for _, c := range []struct {
+		body      string
+		expected  string
+		truncated bool
+	}{
+		{"", "", false},
+		{"  \n", "", false},
+		{`{"Link":"eth0","MTU":1400}`, `{"Link":"eth0","MTU":1400}`, false},
+		{`{"User":"tom","Password":"secret"}`, `{"Password":"\u003credacted\u003e","User":"tom"}`, false},
+		{`{"PrivateKey":"x","Peers":[{"PresharedKey":"y","PublicKey":"z"}]}`, `{"Peers":[{"PresharedKey":"\u003credacted\u003e","PublicKey":"z"}],"PrivateKey":"\u003credacted\u003e"}`, false},
+		{`{"MACsecReceiveAssociationSection":[{"Key":"k","KeyId":"1"}],"Key":"v"}`, `{"Key":"v","MACsecReceiveAssociationSection":[{"Key":"\u003credacted\u003e","KeyId":"1"}]}`, false},
+		{`{"MACsecTransmitAssociationSection":{"Key":"k","Activate":"yes"}}`, `{"MACsecTransmitAssociationSection":{"Activate":"yes","Key":"\u003credacted\u003e"}}`, false},
+		{`{"Auth":{"Token":{"Value":"t"}}}`, `{"Auth":{"Token":"\u003credacted\u003e"}}`, false},
+		{"Link=eth0&Password=x", `"\u003credacted\u003e"`, false},
+		{long, `"\u003credacted\u003e"`, true},
+	} {
+		b, truncated := redactBody([]byte(c.body))
+		if string(b) != c.expected || truncated != c.truncated {
+			t.Fatalf("Invalid redacted body of '%.64s': expected '%s' truncated=%t, got '%.64s' truncated=%t", c.body, c.expected, c.truncated, b, truncated)
+		}
+	}
+}
+
+func TestAudited(t *testing.T) {
+	for _, c := range []struct {
+		method  string
+		path    string
+		audited bool
+	}{
+		{http.MethodGet, "/api/v1/system/describe", false},
+		{http.MethodHead, "/api/v1/system/describe", false},
+		{http.MethodGet, "/api/v1/tdnf/list", false},
+		{http.MethodGet, "/api/v1/tdnf/install/curl", true},
+		{http.MethodGet, "/api/v1/tdnf/history/rollback", true},
+		{http.MethodPost, "/api/v1/system/hostname", true},
+		{http.MethodPut, "/api/v1/network/netns/add", true},
+		{http.MethodDelete, "/api/v1/network/netns/remove", true},
+	} {
+		r := httptest.NewRequest(c.method, c.path, nil)
+		if audited(r) != c.audited {
+			t.Fatalf("%s %s: expected audited=%t", c.method, c.path, c.audited)
+		}
+	}
+}
@@ -4,14 +4,16 @@
 package server
 
 import (
+	"context"
+	"crypto/x509"
 	"errors"
-	"fmt"
 	"net/http"
-	"os"
+	"os/user"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
@@ -21,6 +23,42 @@ import (
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
+type contextKey int
+
+const (
+	credentialsContextKey contextKey = iota
+	identityContextKey
+	listenerContextKey
+	vsockCIDContextKey
+	policyContextKey
+)
+
+// Identity describes the authenticated caller of a request.
+type Identity struct {
+	Name        string      `json:"Name"`
+	Groups      []string    `json:"Groups"`
+	Roles       []string    `json:"Roles"`
+	Credentials *unix.Ucred `json:"Credentials,omitempty"`
+}
+
+func withIdentity(r *http.Request, id *Identity) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), identityContextKey, id))
+}
+
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityContextKey).(*Identity)
+	return id
+}
+
+// identityName names the caller of the request, if authenticated.
+func identityName(r *http.Request) string {
+	if id := IdentityFromContext(r.Context()); id != nil {
+		return id.Name
+	}
+
+	return ""
+}
+
 func active(nbf, exp interface{}) bool {
 	if unix, ok := nbf.(float64); ok {
 		t := time.Unix(int64(unix), 0)
@@ -37,93 +75,198 @@ func active(nbf, exp interface{}) bool {
 	return true
 }
 
+// claimStrings accepts a claim holding either a single string or a list of strings.
+func claimStrings(v interface{}) []string {
+	var s []string
+	switch c := v.(type) {
+	case string:
+		s = strings.Fields(c)
+	case []interface{}:
+		for _, e := range c {
+			if str, ok := e.(string); ok {
+				s = append(s, str)
+			}
+		}
+	}
+
+	return s
+}
+
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("X-Session-Token")
 		if validator.IsEmpty(token) {
-			log.Errorf("Could not parse authentication token")
-			web.JSONResponseError(errors.New("invalid token"), w)
-			return
+			token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
 		}
-		tokenJWT, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		if validator.IsEmpty(token) {
+			if isPublicRoute(r) {
+				next.ServeHTTP(w, r)
+				return
 			}
 
-			return []byte(os.Getenv("JWT_SECRET")), nil
-		})
-
-		if err != nil {
-			if ve, ok := err.(*jwt.ValidationError); ok {
-				if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-					log.Errorf("Invalid token='%v'", tokenJWT.Raw)
-					web.JSONResponseError(errors.New("invalid token"), w)
-					return
-				}
-			}
+			log.Errorf("Could not parse authentication token")
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "invalid token"), w)
+			return
 		}
 
-		if !tokenJWT.Valid {
-			log.Errorf("Invalid token='%v'", tokenJWT.Raw)
-			web.JSONResponseError(errors.New("invalid token"), w)
+		claims, err := tokenAuthority.Load().Parse(token)
+		if err != nil {
+			log.Errorf("Failed to verify token: %v", err)
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "%v", err), w)
 			return
 		}
 
-		claims, ok := tokenJWT.Claims.(jwt.MapClaims)
-		if !ok {
-			log.Errorf("Invalid token claims ='%v'", tokenJWT.Raw)
-			web.JSONResponseError(errors.New("invalid token claims"), w)
+		if !active(claims["nbf"], claims["exp"]) {
+			log.Errorf("Expired token='%v'", token)
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "expired token"), w)
 			return
 		}
-		cls := map[string]interface{}{}
-		for k, v := range claims {
-			cls[k] = v
-		}
 
-		if !active(cls["nbf"], cls["exp"]) {
-			log.Errorf("expired token", tokenJWT.Raw)
-			web.JSONResponseError(errors.New("expired token"), w)
-			return
+		id := Identity{
+			Groups: claimStrings(claims["groups"]),
+			Roles:  append(claimStrings(claims["role"]), claimStrings(claims["scope"])...),
 		}
+		id.Name, _ = claims["sub"].(string)
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withIdentity(r, &id))
 	})
 }
 
-func authenticateLocalUser(credentials *unix.Ucred) error {
+func acquireGroupNames(u *user.User) []string {
+	gids, err := u.GroupIds()
+	if err != nil {
+		return nil
+	}
+
+	var groups []string
+	for _, gid := range gids {
+		if g, err := user.LookupGroupId(gid); err == nil {
+			groups = append(groups, g.Name)
+		}
+	}
+
+	return groups
+}
+
+func authenticateLocalUser(credentials *unix.Ucred) (*Identity, error) {
+	id := Identity{
+		Credentials: credentials,
+	}
+
 	if credentials.Uid != 0 {
 		pmUser, err := system.GetUserCredentials("pmd-next-gen-nextgen")
 		if err != nil {
 			log.Infof("Failed to get user 'pmd-next-gen-nextgen' credentials: %+v", err)
-			return err
+			return nil, err
 		}
 
-		u, _ := system.GetUserCredentialsByUid(credentials.Uid)
+		u, err := system.GetUserCredentialsByUid(credentials.Uid)
+		if err != nil {
+			return nil, err
+		}
 
 		groups, _ := u.GroupIds()
 		if !share.StringContains(groups, strconv.Itoa(int(pmUser.Gid))) {
-			return errors.New("user's gid not same as pmd-next-gen-nextgen's gid")
+			return nil, errors.New("user's gid not same as pmd-next-gen-nextgen's gid")
 		}
 
-		log.Debugf("Connection credentials: pid='%d', user='%s' uid='%d', gid='%d' belongs to groups='%v'", credentials.Pid, u.Username, credentials.Gid, credentials.Uid, groups)
+		id.Name = u.Username
+		id.Groups = acquireGroupNames(u)
+
+		log.Debugf("Connection credentials: pid='%d', user='%s' uid='%d', gid='%d' belongs to groups='%v'", credentials.Pid, u.Username, credentials.Uid, credentials.Gid, groups)
 	} else {
-		log.Debugf("Connection credentials: pid='%d', user='root' uid='%d', gid='%d'", credentials.Pid, credentials.Gid, credentials.Uid)
+		id.Name = "root"
+
+		log.Debugf("Connection credentials: pid='%d', user='root' uid='%d', gid='%d'", credentials.Pid, credentials.Uid, credentials.Gid)
 	}
 
-	return nil
+	return &id, nil
 }
 
 func UnixDomainPeerCredential(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var credentialsContextKey = struct{}{}
-
 		credentials := r.Context().Value(credentialsContextKey).(*unix.Ucred)
 
-		if err := authenticateLocalUser(credentials); err != nil {
-			web.JSONResponseError(err, w)
-			log.Infof("Unauthorized connection. Credentials: pid='%d', uid='%d', gid='%d': %v", credentials.Pid, credentials.Gid, credentials.Uid, err)
+		id, err := authenticateLocalUser(credentials)
+		if err != nil {
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "%v", err), w)
+			log.Infof("Unauthorized connection. Credentials: pid='%d', uid='%d', gid='%d': %v", credentials.Pid, credentials.Uid, credentials.Gid, err)
 		} else {
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, withIdentity(r, id))
 		}
 	})
 }
+
+// VSockPeerCID admits VSOCK peers whose context ID is listed in allow. An
+// empty list admits every peer. Peers are granted role, as they have neither
+// groups nor a token the policy could map.
+func VSockPeerCID(allow []uint32, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cid, ok := r.Context().Value(vsockCIDContextKey).(uint32)
+			if !ok {
+				web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "unknown peer"), w)
+				return
+			}
+
+			if len(allow) > 0 && !slices.Contains(allow, cid) {
+				log.Infof("Unauthorized VSOCK connection from cid='%d'", cid)
+				web.JSONResponseError(web.NewError(web.ErrPermissionDenied, "cid='%d' not allowed", cid), w)
+				return
+			}
+
+			id := Identity{
+				Name: "vsock:" + strconv.FormatUint(uint64(cid), 10),
+			}
+			if role != "" {
+				id.Roles = []string{role}
+			}
+
+			next.ServeHTTP(w, withIdentity(r, &id))
+		})
+	}
+}
+
+// certificateIdentity maps the subject of a client certificate to an identity.
+// The common name, or the first SAN if it is empty, names the caller and the
+// organizational units are taken as its groups.
+func certificateIdentity(cert *x509.Certificate) *Identity {
+	id := Identity{
+		Name:   cert.Subject.CommonName,
+		Groups: cert.Subject.OrganizationalUnit,
+	}
+
+	if id.Name == "" {
+		switch {
+		case len(cert.DNSNames) > 0:
+			id.Name = cert.DNSNames[0]
+		case len(cert.EmailAddresses) > 0:
+			id.Name = cert.EmailAddresses[0]
+		case len(cert.URIs) > 0:
+			id.Name = cert.URIs[0].String()
+		}
+	}
+
+	return &id
+}
+
+func ClientCertificateAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			log.Errorf("Missing client certificate from '%s'", r.RemoteAddr)
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "client certificate required"), w)
+			return
+		}
+
+		id := certificateIdentity(r.TLS.PeerCertificates[0])
+		if id.Name == "" {
+			log.Errorf("Client certificate from '%s' carries neither subject nor SAN", r.RemoteAddr)
+			web.JSONResponseError(web.NewError(web.ErrUnauthenticated, "invalid client certificate"), w)
+			return
+		}
+
+		log.Debugf("Client certificate: subject='%s' identity='%s' groups='%v'", r.TLS.PeerCertificates[0].Subject, id.Name, id.Groups)
+
+		next.ServeHTTP(w, withIdentity(r, id))
+	})
+}
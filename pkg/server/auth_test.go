@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestVSockPeerCID(t *testing.T) {
+	for _, c := range []struct {
+		allow  []uint32
+		role   string
+		cid    uint32
+		status int
+		roles  []string
+	}{
+		{nil, "", 3, http.StatusOK, nil},
+		{nil, "readonly", 3, http.StatusOK, []string{"readonly"}},
+		{[]uint32{3}, "admin", 3, http.StatusOK, []string{"admin"}},
+		{[]uint32{3}, "admin", 4, http.StatusForbidden, nil},
+	} {
+		var id *Identity
+		h := VSockPeerCID(c.allow, c.role)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id = IdentityFromContext(r.Context())
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/system/describe", nil)
+		r = r.WithContext(context.WithValue(r.Context(), vsockCIDContextKey, c.cid))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != c.status {
+			t.Fatalf("cid='%d' allow=%v: expected status=%d, got %d", c.cid, c.allow, c.status, w.Code)
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if id == nil || !slices.Equal(id.Roles, c.roles) {
+			t.Fatalf("cid='%d' role='%s': unexpected identity %+v", c.cid, c.role, id)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/share"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleReadOnly = "readonly"
+)
+
+type Rule struct {
+	Methods []string
+	Prefix  string
+}
+
+type Role struct {
+	Name   string
+	Groups []string
+	Allow  []Rule
+	Deny   []Rule
+}
+
+type Policy struct {
+	Roles       map[string]*Role
+	DefaultRole string
+}
+
+type AuthorizationDenied struct {
+	Name   string   `json:"Name"`
+	Roles  []string `json:"Roles"`
+	Method string   `json:"Method"`
+	Path   string   `json:"Path"`
+}
+
+// readOnlyDeny lists the GET routes which modify the system.
+var readOnlyDeny = []string{
+	"GET /api/v1/tdnf/autoremove",
+	"GET /api/v1/tdnf/clean",
+	"GET /api/v1/tdnf/distro-sync",
+	"GET /api/v1/tdnf/downgrade",
+	"GET /api/v1/tdnf/erase",
+	"GET /api/v1/tdnf/install",
+	"GET /api/v1/tdnf/makecache",
+	"GET /api/v1/tdnf/reinstall",
+	"GET /api/v1/tdnf/update",
+	"GET /api/v1/tdnf/mark",
+	"GET /api/v1/tdnf/history/init",
+	"GET /api/v1/tdnf/history/rollback",
+	"GET /api/v1/tdnf/history/undo",
+	"GET /api/v1/tdnf/history/redo",
+}
+
+// adminOnlyRoutes pass their arguments to a command as given, so no role but
+// admin may call them, whatever its rules.
+var adminOnlyRoutes = []Rule{
+	{Methods: []string{"*"}, Prefix: "/api/v1/network/firewall/nft/run"},
+}
+
+// selfServiceRoutes check the caller in their handlers and bypass the policy.
+var selfServiceRoutes = []string{
+	"/api/v1/_auth/token",
+	"/api/v1/_auth/revoke",
+}
+
+func builtinRoles() map[string]conf.Role {
+	return map[string]conf.Role{
+		RoleAdmin: {
+			Allow: []string{"* /"},
+		},
+		RoleReadOnly: {
+			Allow: []string{"GET /api/v1", "GET /metrics"},
+			Deny:  append([]string{"GET /api/v1/_audit"}, readOnlyDeny...),
+		},
+	}
+}
+
+func parseRule(s string) (Rule, error) {
+	f := strings.Fields(s)
+	if len(f) != 2 || !strings.HasPrefix(f[1], "/") {
+		return Rule{}, fmt.Errorf("invalid rule='%s'", s)
+	}
+
+	rule := Rule{
+		Prefix: f[1],
+	}
+	for _, m := range strings.Split(f[0], ",") {
+		rule.Methods = append(rule.Methods, strings.ToUpper(m))
+	}
+
+	return rule, nil
+}
+
+func parseRules(rules []string) ([]Rule, error) {
+	var r []Rule
+	for _, s := range rules {
+		rule, err := parseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, rule)
+	}
+
+	return r, nil
+}
+
+func (rule *Rule) match(method, path string) bool {
+	if !share.StringContains(rule.Methods, "*") && !share.StringContains(rule.Methods, method) {
+		return false
+	}
+
+	if rule.Prefix == "/" || path == rule.Prefix {
+		return true
+	}
+
+	return strings.HasPrefix(path, strings.TrimSuffix(rule.Prefix, "/")+"/")
+}
+
+func NewPolicy(c *conf.Authorization) (*Policy, error) {
+	roles := builtinRoles()
+	for k, v := range c.Roles {
+		roles[strings.ToLower(k)] = v
+	}
+
+	p := Policy{
+		Roles:       make(map[string]*Role),
+		DefaultRole: strings.ToLower(c.DefaultRole),
+	}
+
+	for name, r := range roles {
+		allow, err := parseRules(r.Allow)
+		if err != nil {
+			return nil, fmt.Errorf("role='%s': %v", name, err)
+		}
+
+		deny, err := parseRules(r.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("role='%s': %v", name, err)
+		}
+
+		p.Roles[name] = &Role{
+			Name:   name,
+			Groups: r.Groups,
+			Allow:  allow,
+			Deny:   deny,
+		}
+	}
+
+	if p.DefaultRole != "" {
+		if _, ok := p.Roles[p.DefaultRole]; !ok {
+			return nil, fmt.Errorf("unknown default role='%s'", c.DefaultRole)
+		}
+	}
+
+	if c.VSockRole != "" {
+		if _, ok := p.Roles[strings.ToLower(c.VSockRole)]; !ok {
+			return nil, fmt.Errorf("unknown VSOCK role='%s'", c.VSockRole)
+		}
+	}
+
+	return &p, nil
+}
+
+func (role *Role) allowed(method, path string) bool {
+	for _, rule := range role.Deny {
+		if rule.match(method, path) {
+			return false
+		}
+	}
+
+	for _, rule := range role.Allow {
+		if rule.match(method, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResolveRoles returns the roles granted to the identity, either carried in
+// the token or derived from the unix groups of the peer.
+func (p *Policy) ResolveRoles(id *Identity) []string {
+	set := share.NewSet()
+	for _, r := range id.Roles {
+		set.Add(strings.ToLower(r))
+	}
+
+	for _, role := range p.Roles {
+		for _, g := range role.Groups {
+			if share.StringContains(id.Groups, g) {
+				set.Add(role.Name)
+				break
+			}
+		}
+	}
+
+	if set.Length() == 0 && p.DefaultRole != "" {
+		set.Add(p.DefaultRole)
+	}
+
+	return set.Values()
+}
+
+func (p *Policy) Allowed(roles []string, method, path string) bool {
+	for _, rule := range adminOnlyRoutes {
+		if rule.match(method, path) {
+			return share.StringContains(roles, RoleAdmin)
+		}
+	}
+
+	for _, r := range roles {
+		if role, ok := p.Roles[r]; ok && role.allowed(method, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PolicyFromContext returns the policy the request is authorized against, if
+// any.
+func PolicyFromContext(ctx context.Context) *Policy {
+	p, _ := ctx.Value(policyContextKey).(*Policy)
+	return p
+}
+
+func AuthorizationMiddleware(p *Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = r.WithContext(context.WithValue(r.Context(), policyContextKey, p))
+
+			if share.StringContains(selfServiceRoutes, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id := IdentityFromContext(r.Context())
+			if id == nil {
+				log.Errorf("Unauthorized request method='%s' path='%s': missing identity", r.Method, r.URL.Path)
+				web.JSONResponseStatusError(http.StatusForbidden, nil, errors.New("permission denied"), w)
+				return
+			}
+
+			// root is never restricted
+			if id.Credentials != nil && id.Credentials.Uid == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			roles := p.ResolveRoles(id)
+			if !p.Allowed(roles, r.Method, r.URL.Path) {
+				log.Infof("Permission denied for user='%s' roles='%v' method='%s' path='%s'", id.Name, roles, r.Method, r.URL.Path)
+
+				web.JSONResponseStatusError(http.StatusForbidden,
+					AuthorizationDenied{
+						Name:   id.Name,
+						Roles:  roles,
+						Method: r.Method,
+						Path:   r.URL.Path,
+					},
+					errors.New("permission denied"), w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
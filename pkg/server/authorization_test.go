@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+)
+
+func testPolicy(t *testing.T) *Policy {
+	p, err := NewPolicy(&conf.Authorization{
+		Roles: map[string]conf.Role{
+			"Network": {
+				Groups: []string{"netadmin"},
+				Allow:  []string{"* /api/v1/network", "GET /api/v1"},
+				Deny:   []string{"DELETE,PUT /api/v1/network/netns"},
+			},
+		},
+		DefaultRole: "ReadOnly",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	return p
+}
+
+func TestNewPolicy(t *testing.T) {
+	for _, c := range []struct {
+		auth conf.Authorization
+		ok   bool
+	}{
+		{conf.Authorization{}, true},
+		{conf.Authorization{DefaultRole: "readonly", VSockRole: "Admin"}, true},
+		{conf.Authorization{DefaultRole: "operator"}, false},
+		{conf.Authorization{VSockRole: "operator"}, false},
+		{conf.Authorization{Roles: map[string]conf.Role{"operator": {Allow: []string{"GET"}}}}, false},
+		{conf.Authorization{Roles: map[string]conf.Role{"operator": {Deny: []string{"GET api/v1"}}}}, false},
+		{conf.Authorization{Roles: map[string]conf.Role{"operator": {Allow: []string{"get,post /api/v1/system"}}}, DefaultRole: "Operator"}, true},
+	} {
+		c := c
+		if _, err := NewPolicy(&c.auth); (err == nil) != c.ok {
+			t.Fatalf("Invalid result for %+v: %v", c.auth, err)
+		}
+	}
+}
+
+func TestResolveRoles(t *testing.T) {
+	p := testPolicy(t)
+
+	for _, c := range []struct {
+		id    Identity
+		roles []string
+	}{
+		{Identity{Name: "nobody"}, []string{"readonly"}},
+		{Identity{Name: "token", Roles: []string{"Admin"}}, []string{"admin"}},
+		{Identity{Name: "peer", Groups: []string{"users", "netadmin"}}, []string{"network"}},
+		{Identity{Name: "both", Groups: []string{"netadmin"}, Roles: []string{"admin"}}, []string{"admin", "network"}},
+	} {
+		roles := p.ResolveRoles(&c.id)
+		slices.Sort(roles)
+		if !slices.Equal(roles, c.roles) {
+			t.Fatalf("Invalid roles of %+v: expected %v, got %v", c.id, c.roles, roles)
+		}
+	}
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	p := testPolicy(t)
+
+	for _, c := range []struct {
+		roles   []string
+		method  string
+		path    string
+		allowed bool
+	}{
+		{[]string{"admin"}, http.MethodDelete, "/api/v1/system/hostname", true},
+		{[]string{"admin"}, http.MethodPost, "/api/v1/network/firewall/nft/run", true},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/system/describe", true},
+		{[]string{"readonly"}, http.MethodGet, "/metrics", true},
+		{[]string{"readonly"}, http.MethodPost, "/api/v1/system/hostname", false},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/_audit", false},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/list", true},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/install", false},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/install/curl", false},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/installed", true},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/history/undo", false},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/tdnf/history", true},
+		{[]string{"readonly"}, http.MethodGet, "/api/v1/network/firewall/nft/run", false},
+		{[]string{"network"}, http.MethodPost, "/api/v1/network/link/set", true},
+		{[]string{"network"}, http.MethodPut, "/api/v1/network/netns/add", false},
+		{[]string{"network"}, http.MethodGet, "/api/v1/network/netns", true},
+		{[]string{"network"}, http.MethodPost, "/api/v1/network/firewall/nft/run", false},
+		{[]string{"network"}, http.MethodPost, "/api/v1/system/hostname", false},
+		{[]string{"network", "admin"}, http.MethodPost, "/api/v1/network/firewall/nft/run", true},
+		{[]string{"unknown"}, http.MethodGet, "/api/v1/system/describe", false},
+		{nil, http.MethodGet, "/api/v1/system/describe", false},
+	} {
+		if p.Allowed(c.roles, c.method, c.path) != c.allowed {
+			t.Fatalf("roles=%v %s %s: expected allowed=%t", c.roles, c.method, c.path, c.allowed)
+		}
+	}
+}
@@ -6,12 +6,20 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
@@ -20,6 +28,7 @@ import (
 	"github.com/vmware/pmd-next-gen/pkg/conf"
 	"github.com/vmware/pmd-next-gen/pkg/parser"
 	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/web"
 	"github.com/vmware/pmd-next-gen/plugins/management"
 	"github.com/vmware/pmd-next-gen/plugins/network"
 	"github.com/vmware/pmd-next-gen/plugins/proc"
@@ -27,10 +36,12 @@ import (
 	"github.com/vmware/pmd-next-gen/plugins/tdnf"
 
 	"github.com/linuxkit/virtsock/pkg/vsock"
+	"github.com/vmware/pmd-next-gen/pkg/events"
 	"github.com/vmware/pmd-next-gen/pkg/jobs"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 )
 
-var httpSrv *http.Server
+const shutdownTimeout = 30 * time.Second
 
 func NewRouter() *mux.Router {
 	r := mux.NewRouter()
@@ -43,131 +54,536 @@ func NewRouter() *mux.Router {
 	network.RegisterRouterNetwork(s)
 
 	proc.RegisterRouterProc(s)
+	proc.RegisterMetricsProc()
 
 	tdnf.RegisterRouterTdnf(s)
 
 	jobs.RegisterRouterJobs(s)
 
+	events.RegisterRouterEvents(s)
+
+	RegisterRouterAudit(s)
+	RegisterRouterAuth(s)
+
+	openapi.RegisterRouterOpenAPI(s)
+
+	RegisterRouterMetrics(r)
+
 	return r
 }
 
-func runUnixDomainHttpServer(c *conf.Config, r *mux.Router) error {
-	var credentialsContextKey = struct{}{}
+// Listener serves the shared router on one socket. Its state carries the TLS
+// config and the middlewares authenticating and authorizing the peers; a
+// reload swaps it without closing the socket.
+type Listener struct {
+	Name     string
+	Address  string
+	server   *http.Server
+	listener net.Listener
+	state    atomic.Pointer[listenerState]
+}
 
-	if c.System.UseAuthentication {
-		r.Use(UnixDomainPeerCredential)
+type listenerState struct {
+	tlsConfig   *tls.Config
+	middlewares []mux.MiddlewareFunc
+}
+
+// listenerSpec describes a listener the config asks for. Building it loads the
+// certificates and policies but does not open the socket yet.
+type listenerSpec struct {
+	name        string
+	address     string
+	describe    string
+	listen      func() (net.Listener, error)
+	connContext func(context.Context, net.Conn) context.Context
+	state       *listenerState
+}
+
+func (s *listenerSpec) key() string {
+	return s.name + " " + s.address
+}
+
+func (l *Listener) key() string {
+	return l.Name + " " + l.Address
+}
+
+// ListenerMiddleware runs the middlewares of the listener which accepted the
+// connection of the request.
+func ListenerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l, ok := r.Context().Value(listenerContextKey).(*Listener)
+		if !ok {
+			web.JSONResponseError(web.NewError(web.ErrInternal, "unknown listener"), w)
+			return
+		}
+
+		middlewares := l.state.Load().middlewares
+
+		h := next
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			h = middlewares[i](h)
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// tlsListener wraps the accepted connections in TLS as long as the state of
+// the listener carries a TLS config.
+type tlsListener struct {
+	net.Listener
+	l *Listener
+}
+
+func (t *tlsListener) Accept() (net.Conn, error) {
+	c, err := t.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg := t.l.state.Load().tlsConfig; cfg != nil {
+		return tls.Server(c, cfg), nil
+	}
+
+	return c, nil
+}
+
+func openListener(spec *listenerSpec, r *mux.Router) (*Listener, error) {
+	l, err := spec.listen()
+	if err != nil {
+		return nil, err
+	}
+
+	listener := Listener{
+		Name:     spec.name,
+		Address:  spec.address,
+		listener: l,
 	}
+	listener.state.Store(spec.state)
 
-	httpSrv = &http.Server{
-		Handler: r,
+	listener.server = &http.Server{
+		Handler:      r,
+		TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
 		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
-			file, _ := c.(*net.UnixConn).File()
-			credentials, _ := unix.GetsockoptUcred(int(file.Fd()), unix.SOL_SOCKET, unix.SO_PEERCRED)
-			return context.WithValue(ctx, credentialsContextKey, credentials)
+			ctx = context.WithValue(ctx, listenerContextKey, &listener)
+			if spec.connContext != nil {
+				ctx = spec.connContext(ctx, c)
+			}
+			return ctx
 		},
 	}
 
-	log.Infof("Starting photon-mgmtd... Listening on unix domain socket='%s' in HTTP mode pid=%d", conf.UnixDomainSocketPath, os.Getpid())
+	log.Infof("Starting photon-mgmtd... Listening on %s pid=%d", spec.describe, os.Getpid())
 
-	os.Remove(conf.UnixDomainSocketPath)
-	unixListener, err := net.ListenUnix("unix", &net.UnixAddr{Name: conf.UnixDomainSocketPath, Net: "unix"})
-	if err != nil {
-		log.Fatalf("Unable to listen on unix domain socket='%s': %v", conf.UnixDomainSocketPath, err)
+	return &listener, nil
+}
+
+// middlewares returns the authentication middleware of the listener followed
+// by the audit and authorization middlewares shared by all listeners.
+func middlewares(auth mux.MiddlewareFunc, p *Policy) []mux.MiddlewareFunc {
+	var m []mux.MiddlewareFunc
+	if auth != nil {
+		m = append(m, auth)
 	}
-	defer unixListener.Close()
 
-	if err := system.ChangePermission("photon-mgmt", conf.UnixDomainSocketPath); err != nil {
-		log.Errorf("Failed to change unix domain socket permissions: %v", err)
-		return err
+	if auditLog != nil {
+		m = append(m, AuditMiddleware(auditLog))
 	}
 
-	log.Fatal(httpSrv.Serve(unixListener))
-	return nil
+	if p != nil {
+		m = append(m, AuthorizationMiddleware(p))
+	}
+
+	return m
 }
 
-func runVSockHttpServer(c *conf.Config, r *mux.Router) error {
-	httpSrv = &http.Server{
-		Handler: r,
+func (l *Listener) serve() error {
+	err := l.server.Serve(&tlsListener{Listener: l.listener, l: l})
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
 	}
 
-	log.Infof("Starting photon-mgmtd... Listening on VSOCK")
+	return err
+}
 
-	l, err := vsock.Listen(vsock.CIDAny, 5208)
+func newPolicy(c *conf.Config) (*Policy, error) {
+	if !c.Authorization.UseAuthorization {
+		return nil, nil
+	}
+
+	if !c.System.UseAuthentication {
+		log.Warnf("Authorization requires authentication. Ignoring UseAuthorization=")
+		return nil, nil
+	}
+
+	p, err := NewPolicy(&c.Authorization)
 	if err != nil {
-		return err
+		log.Errorf("Failed to parse authorization policy: %v", err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func peerCredentials(ctx context.Context, c net.Conn) context.Context {
+	conn, ok := c.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return ctx
+	}
+
+	var credentials *unix.Ucred
+	raw.Control(func(fd uintptr) {
+		credentials, err = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		log.Errorf("Failed to acquire peer credentials: %v", err)
+		return ctx
+	}
+
+	return context.WithValue(ctx, credentialsContextKey, credentials)
+}
+
+func peerCID(ctx context.Context, c net.Conn) context.Context {
+	if addr, ok := c.RemoteAddr().(*vsock.Addr); ok {
+		return context.WithValue(ctx, vsockCIDContextKey, addr.CID)
 	}
-	defer l.Close()
 
-	log.Fatal(httpSrv.Serve(l))
-	return nil
+	return ctx
 }
 
-func runWebHttpServer(c *conf.Config, r *mux.Router) error {
+func newUnixDomainListener(c *conf.Config, p *Policy) (*listenerSpec, error) {
+	spec := listenerSpec{
+		name:        "unix",
+		address:     conf.UnixDomainSocketPath,
+		describe:    fmt.Sprintf("unix domain socket='%s' in HTTP mode", conf.UnixDomainSocketPath),
+		connContext: peerCredentials,
+		state:       &listenerState{},
+	}
+
+	spec.listen = func() (net.Listener, error) {
+		os.Remove(conf.UnixDomainSocketPath)
+		unixListener, err := net.ListenUnix("unix", &net.UnixAddr{Name: conf.UnixDomainSocketPath, Net: "unix"})
+		if err != nil {
+			log.Errorf("Unable to listen on unix domain socket='%s': %v", conf.UnixDomainSocketPath, err)
+			return nil, err
+		}
+
+		if err := system.ChangePermission("photon-mgmt", conf.UnixDomainSocketPath); err != nil {
+			log.Errorf("Failed to change unix domain socket permissions: %v", err)
+			unixListener.Close()
+			return nil, err
+		}
+
+		return unixListener, nil
+	}
+
 	if c.System.UseAuthentication {
-		r.Use(AuthMiddleware)
+		spec.state.middlewares = middlewares(UnixDomainPeerCredential, p)
+	} else {
+		spec.state.middlewares = middlewares(nil, nil)
+	}
+
+	return &spec, nil
+}
+
+func newVSockListener(c *conf.Config, p *Policy) (*listenerSpec, error) {
+	spec := listenerSpec{
+		name:        "vsock",
+		address:     strconv.Itoa(conf.VSockPort),
+		describe:    fmt.Sprintf("VSOCK port='%d'", conf.VSockPort),
+		connContext: peerCID,
+		state:       &listenerState{},
+	}
+
+	spec.listen = func() (net.Listener, error) {
+		vsockListener, err := vsock.Listen(vsock.CIDAny, conf.VSockPort)
+		if err != nil {
+			log.Errorf("Unable to listen on VSOCK port='%d': %v", conf.VSockPort, err)
+			return nil, err
+		}
+
+		return vsockListener, nil
+	}
+
+	if len(c.Network.VSockAllowCID) == 0 {
+		log.Warnf("VSockAllowCID= is empty. Accepting VSOCK connections from any cid")
+	}
+
+	if c.System.UseAuthentication {
+		spec.state.middlewares = middlewares(VSockPeerCID(c.Network.VSockAllowCID, strings.ToLower(c.Authorization.VSockRole)), p)
+	} else {
+		spec.state.middlewares = middlewares(VSockPeerCID(c.Network.VSockAllowCID, ""), nil)
+	}
+
+	return &spec, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found")
+	}
+
+	return pool, nil
+}
+
+func newWebListener(c *conf.Config, p *Policy) (*listenerSpec, error) {
+	address := c.Network.Listen
+	if address == "" {
+		address = conf.DefaultIP + ":" + conf.DefaultPort
+	}
+
+	ip, port, err := parser.ParseIpPort(address)
+	if err != nil {
+		log.Errorf("Failed to parse Listen='%s': %v", address, err)
+		return nil, err
+	}
+
+	spec := listenerSpec{
+		name:    "tcp",
+		address: net.JoinHostPort(ip, port),
+		state:   &listenerState{},
 	}
 
-	ip, port, _ := parser.ParseIpPort(c.Network.Listen)
+	spec.listen = func() (net.Listener, error) {
+		tcpListener, err := net.Listen("tcp", spec.address)
+		if err != nil {
+			log.Errorf("Unable to listen on '%s': %v", address, err)
+			return nil, err
+		}
+
+		return tcpListener, nil
+	}
 
+	var auth mux.MiddlewareFunc = AuthMiddleware
 	if system.TLSFilePathExits() {
+		certFile := path.Join(conf.ConfPath, conf.TLSCert)
+		keyFile := path.Join(conf.ConfPath, conf.TLSKey)
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			log.Errorf("Failed to load server certificate='%s': %v", certFile, err)
+			return nil, err
+		}
+
 		cfg := &tls.Config{
 			MinVersion:               tls.VersionTLS12,
 			CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
 			PreferServerCipherSuites: false,
+			Certificates:             []tls.Certificate{cert},
+		}
+
+		if c.Network.TLSClientCA != "" {
+			pool, err := loadCertPool(c.Network.TLSClientCA)
+			if err != nil {
+				log.Errorf("Failed to load client CA bundle='%s': %v", c.Network.TLSClientCA, err)
+				return nil, err
+			}
+
+			cfg.ClientCAs = pool
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			auth = ClientCertificateAuth
 		}
-		httpSrv = &http.Server{
-			Addr:         ip + ":" + port,
-			Handler:      r,
-			TLSConfig:    cfg,
-			TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
+
+		spec.state.tlsConfig = cfg
+
+		if cfg.ClientAuth == tls.RequireAndVerifyClientCert {
+			spec.describe = fmt.Sprintf("%s:%s in HTTPS mode with client certificates", ip, port)
+		} else {
+			spec.describe = fmt.Sprintf("%s:%s in HTTPS mode", ip, port)
+		}
+	} else {
+		if c.Network.TLSClientCA != "" {
+			log.Errorf("TLSClientCA= requires a server certificate in '%s'", path.Join(conf.ConfPath, conf.TLSCert))
+			return nil, errors.New("missing server certificate")
 		}
 
-		log.Infof("Starting photon-mgmtd ... Listening on %s:%s in HTTPS mode pid=%d", ip, port, os.Getpid())
+		spec.describe = fmt.Sprintf("%s:%s in HTTP mode", ip, port)
+	}
 
-		httpSrv.ListenAndServeTLS(path.Join(conf.ConfPath, conf.TLSCert), path.Join(conf.ConfPath, conf.TLSKey))
+	// Verified client certificates identify the caller for audit even when
+	// authentication is disabled.
+	if c.System.UseAuthentication || c.Network.TLSClientCA != "" {
+		spec.state.middlewares = middlewares(auth, p)
 	} else {
-		httpSrv = &http.Server{
-			Addr:    ip + ":" + port,
-			Handler: r,
+		spec.state.middlewares = middlewares(nil, nil)
+	}
+
+	return &spec, nil
+}
+
+func newListenerSpecs(c *conf.Config, p *Policy) ([]*listenerSpec, error) {
+	var specs []*listenerSpec
+
+	add := func(f func(*conf.Config, *Policy) (*listenerSpec, error)) error {
+		s, err := f(c, p)
+		if err != nil {
+			return err
 		}
 
-		log.Infof("Starting photon-mgmtd... Listening on %s:%s in HTTP mode pid=%d", ip, port, os.Getpid())
+		specs = append(specs, s)
+		return nil
+	}
 
-		httpSrv.ListenAndServe()
+	var err error
+	if c.Network.ListenUnixSocket {
+		err = add(newUnixDomainListener)
+	}
+	if err == nil && c.Network.ListenVSock {
+		err = add(newVSockListener)
+	}
+	if err == nil && (c.Network.Listen != "" || len(specs) == 0) {
+		err = add(newWebListener)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return specs, nil
+}
+
+// openListeners opens the sockets of specs. Either all of them are opened or
+// none.
+func openListeners(specs []*listenerSpec, r *mux.Router) ([]*Listener, error) {
+	var listeners []*Listener
+	for _, s := range specs {
+		l, err := openListener(s, r)
+		if err != nil {
+			for _, l := range listeners {
+				l.listener.Close()
+			}
+			return nil, err
+		}
+
+		listeners = append(listeners, l)
+	}
+
+	return listeners, nil
+}
+
+// shutdown stops accepting new connections on the listeners and waits for the
+// requests in flight to complete. Connections still busy after the timeout,
+// such as event streams, are closed.
+func shutdown(listeners []*Listener) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, l := range listeners {
+		wg.Add(1)
+		go func(l *Listener) {
+			defer wg.Done()
+
+			if err := l.server.Shutdown(ctx); err != nil {
+				log.Errorf("Failed to shut down %s listener gracefully: %v", l.Name, err)
+				l.server.Close()
+			}
+		}(l)
+	}
+
+	wg.Wait()
 }
 
 func Run(c *conf.Config) error {
 	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	if c.Audit.UseAudit {
+		a, err := NewAuditLog(&c.Audit)
+		if err != nil {
+			log.Errorf("Failed to open audit log='%s': %v", c.Audit.File, err)
+		} else {
+			auditLog = a
+		}
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	t, err := NewTokenAuthority(&c.Token)
+	if err != nil {
+		log.Errorf("Failed to configure tokens: %v", err)
+		return err
+	}
+	tokenAuthority.Store(t)
 
-	go func() {
+	p, err := newPolicy(c)
+	if err != nil {
+		return err
+	}
+
+	metricsConfig = &c.Metrics
+
+	jobs.New(&c.Jobs)
+	jobs.SetCallerFunc(identityName)
+
+	r := NewRouter()
+	r.Use(ListenerMiddleware)
+
+	specs, err := newListenerSpecs(c, p)
+	if err != nil {
+		return err
+	}
+
+	listeners, err := openListeners(specs, r)
+	if err != nil {
+		return err
+	}
+
+	d := daemon{
+		config:    c,
+		router:    r,
+		listeners: listeners,
+		errs:      make(chan error, 1),
+	}
+	for _, l := range listeners {
+		d.serve(l)
+	}
+
+	changed := make(chan struct{}, 1)
+	if c.System.ReloadOnChange {
+		if err := conf.Watch(changed); err != nil {
+			log.Errorf("Failed to watch config file: %v", err)
+		}
+	}
+
+	// Editors write files in several steps. Reload once the writes settle.
+	settle := time.NewTimer(0)
+	<-settle.C
+
+	for {
 		select {
 		case sig := <-sigs:
-			log.Printf("Signal received='%v'. Shutting down photon-mgmtd ...", sig)
-
-			if err := httpSrv.Shutdown(ctx); err != nil {
-				os.Exit(1)
+			if sig == syscall.SIGHUP {
+				log.Infof("Signal received='%v'. Reloading config ...", sig)
+				d.reload()
+				continue
 			}
 
-			cancel()
-		case <-ctx.Done():
+			log.Infof("Signal received='%v'. Shutting down photon-mgmtd ...", sig)
+		case <-changed:
+			settle.Reset(reloadSettleTime)
+			continue
+		case <-settle.C:
+			log.Infof("Config file changed. Reloading config ...")
+			d.reload()
+			continue
+		case err = <-d.errs:
+			log.Errorf("Failed to serve: %v", err)
 		}
-	}()
 
-	r := NewRouter()
-	if c.Network.ListenUnixSocket {
-		runUnixDomainHttpServer(c, r)
-	} else if c.Network.ListenVSock {
-		runVSockHttpServer(c, r)
-	} else {
-		runWebHttpServer(c, r)
+		break
 	}
 
-	return nil
+	events.CloseSubscriptions()
+	shutdown(d.listeners)
+
+	return err
 }
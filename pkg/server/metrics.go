@@ -0,0 +1,118 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/jobs"
+	"github.com/vmware/pmd-next-gen/pkg/metrics"
+)
+
+var metricsConfig *conf.Metrics
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// MetricsMiddleware accounts every routed request by its route template.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		sw := &statusResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		route := "unknown"
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if t, err := cr.GetPathTemplate(); err == nil {
+				route = t
+			}
+		}
+
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		metrics.ObserveRequest(route, r.Method, status, time.Since(start))
+	})
+}
+
+func collectDaemon(ctx context.Context, m *metrics.Writer) error {
+	metrics.WriteDaemon(m)
+
+	name := metrics.Name("jobs_in_flight")
+	m.Family(name, "Number of asynchronous jobs executing.", metrics.Gauge)
+	m.Sample(name, float64(jobs.InFlight()))
+
+	return nil
+}
+
+func metricsCollectorEnabled(name string) bool {
+	c := metricsConfig
+	switch name {
+	case "cpu":
+		return c.CPU
+	case "memory":
+		return c.Memory
+	case "disk":
+		return c.Disk
+	case "netdev":
+		return c.NetDev
+	case "protocol":
+		return c.Protocol
+	case "temperature":
+		return c.Temperature
+	case "daemon":
+		return c.Daemon
+	}
+
+	return false
+}
+
+func routerAcquireMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+
+	if err := metrics.Collect(r.Context(), w, metricsCollectorEnabled); err != nil {
+		log.Errorf("Failed to collect metrics: %v", err)
+	}
+}
+
+func RegisterRouterMetrics(router *mux.Router) {
+	if metricsConfig == nil || !metricsConfig.UseMetrics {
+		return
+	}
+
+	metrics.RegisterCollector("daemon", collectDaemon)
+
+	router.HandleFunc("/metrics", routerAcquireMetrics).Methods("GET")
+	router.Use(MetricsMiddleware)
+}
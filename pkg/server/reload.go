@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+)
+
+const reloadSettleTime = 500 * time.Millisecond
+
+// daemon holds what a reload replaces. It is only touched by the goroutine
+// running Run.
+type daemon struct {
+	config    *conf.Config
+	router    *mux.Router
+	listeners []*Listener
+	errs      chan error
+}
+
+func (d *daemon) serve(l *Listener) {
+	go func() {
+		if err := l.serve(); err != nil {
+			select {
+			case d.errs <- fmt.Errorf("%s listener: %v", l.Name, err):
+			default:
+			}
+		}
+	}()
+}
+
+// keepRunningSections carries over the sections which are only read at
+// startup, so the config kept matches what actually runs.
+func keepRunningSections(old *conf.Config, c *conf.Config) {
+	if !reflect.DeepEqual(old.Audit, c.Audit) {
+		log.Warnf("Changes to [Audit] take effect after a restart of photon-mgmtd")
+		c.Audit = old.Audit
+	}
+
+	if !reflect.DeepEqual(old.Metrics, c.Metrics) {
+		log.Warnf("Changes to [Metrics] take effect after a restart of photon-mgmtd")
+		c.Metrics = old.Metrics
+	}
+
+	if !reflect.DeepEqual(old.Jobs, c.Jobs) {
+		log.Warnf("Changes to [Jobs] take effect after a restart of photon-mgmtd")
+		c.Jobs = old.Jobs
+	}
+
+	if old.System.ReloadOnChange != c.System.ReloadOnChange {
+		log.Warnf("Changes to ReloadOnChange= take effect after a restart of photon-mgmtd")
+		c.System.ReloadOnChange = old.System.ReloadOnChange
+	}
+}
+
+// reload re-reads the config and swaps the log level, the token settings, the
+// authorization policy, the TLS certificates and the set of listeners. All of
+// them are prepared first: if any fails, the running config is kept.
+func (d *daemon) reload() {
+	c, err := conf.Load()
+	if err != nil {
+		log.Errorf("Failed to reload config, keeping the running one: %v", err)
+		return
+	}
+
+	keepRunningSections(d.config, c)
+
+	level, _ := log.ParseLevel(c.System.LogLevel)
+
+	t := tokenAuthority.Load()
+	if !reflect.DeepEqual(d.config.Token, c.Token) {
+		if t, err = NewTokenAuthority(&c.Token); err != nil {
+			log.Errorf("Failed to configure tokens, keeping the running config: %v", err)
+			return
+		}
+	}
+
+	p, err := newPolicy(c)
+	if err != nil {
+		log.Errorf("Failed to reload config, keeping the running one: %v", err)
+		return
+	}
+
+	specs, err := newListenerSpecs(c, p)
+	if err != nil {
+		log.Errorf("Failed to reload config, keeping the running one: %v", err)
+		return
+	}
+
+	running := make(map[string]*Listener)
+	for _, l := range d.listeners {
+		running[l.key()] = l
+	}
+
+	// Open the sockets of new listeners before touching the running ones.
+	var kept, opened []*Listener
+	states := make(map[*Listener]*listenerState)
+	for _, s := range specs {
+		if l, ok := running[s.key()]; ok {
+			kept = append(kept, l)
+			states[l] = s.state
+			delete(running, s.key())
+			continue
+		}
+
+		l, err := openListener(s, d.router)
+		if err != nil {
+			for _, l := range opened {
+				l.listener.Close()
+			}
+
+			log.Errorf("Failed to reload config, keeping the running one: %v", err)
+			return
+		}
+		opened = append(opened, l)
+	}
+
+	for _, change := range conf.Diff(d.config, c) {
+		log.Infof("Config changed %s", change)
+	}
+
+	log.SetLevel(level)
+	tokenAuthority.Store(t)
+
+	for l, s := range states {
+		l.state.Store(s)
+	}
+
+	for _, l := range opened {
+		d.serve(l)
+	}
+
+	var closed []*Listener
+	for _, l := range running {
+		log.Infof("Stopping %s listener on '%s'", l.Name, l.Address)
+		closed = append(closed, l)
+	}
+	go shutdown(closed)
+
+	d.listeners = append(kept, opened...)
+	d.config = c
+
+	log.Infof("Config reloaded")
+}
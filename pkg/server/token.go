@@ -0,0 +1,569 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/share"
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const (
+	tokenIssuer = "photon-mgmtd"
+
+	// A user or an address which failed loginMaxFailures password logins is
+	// refused until loginLockout passed since the last failure.
+	loginMaxFailures = 5
+	loginLockout     = 5 * time.Minute
+)
+
+// publicRoutes may be called without a token.
+var publicRoutes = []string{
+	"/api/v1/_auth/token",
+}
+
+type TokenRequest struct {
+	User     string `json:"User"`
+	Password string `json:"Password"`
+}
+
+type TokenResponse struct {
+	Token     string    `json:"Token"`
+	ExpiresAt time.Time `json:"ExpiresAt"`
+}
+
+type RevokeRequest struct {
+	Token string `json:"Token"`
+}
+
+// RevocationList holds the tokens revoked before their expiry. Entries map
+// the token id to its expiry and are dropped once the token expires.
+type RevocationList struct {
+	path    string
+	revoked map[string]int64
+	mutex   sync.Mutex
+}
+
+type TokenAuthority struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	lifetime  time.Duration
+	revoked   *RevocationList
+}
+
+var tokenAuthority atomic.Pointer[TokenAuthority]
+
+type loginFailure struct {
+	count int
+	last  time.Time
+}
+
+// loginLimiter counts the failed password logins by user and by address.
+type loginLimiter struct {
+	failures map[string]*loginFailure
+	mutex    sync.Mutex
+}
+
+var logins = loginLimiter{
+	failures: make(map[string]*loginFailure),
+}
+
+func NewRevocationList(path string) (*RevocationList, error) {
+	l := RevocationList{
+		path:    path,
+		revoked: make(map[string]int64),
+	}
+
+	if path == "" {
+		return &l, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &l, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &l.revoked); err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func (l *RevocationList) save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(l.revoked)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0750); err != nil {
+		return err
+	}
+
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, l.path)
+}
+
+func (l *RevocationList) Revoke(id string, exp int64) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now().Unix()
+	for k, v := range l.revoked {
+		if v != 0 && v < now {
+			delete(l.revoked, k)
+		}
+	}
+
+	l.revoked[id] = exp
+
+	return l.save()
+}
+
+func (l *RevocationList) Revoked(id string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, ok := l.revoked[id]
+	return ok
+}
+
+func readKeyFile(path string, parse func([]byte) (interface{}, error)) (interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(b)
+}
+
+func NewTokenAuthority(c *conf.Token) (*TokenAuthority, error) {
+	lifetime, err := time.ParseDuration(c.Lifetime)
+	if err != nil || lifetime <= 0 {
+		return nil, fmt.Errorf("invalid token lifetime='%s'", c.Lifetime)
+	}
+
+	t := TokenAuthority{
+		lifetime: lifetime,
+	}
+
+	var parsePrivate, parsePublic func([]byte) (interface{}, error)
+	switch strings.ToUpper(c.SigningMethod) {
+	case "", "HS256":
+		t.method = jwt.SigningMethodHS256
+		if secret := os.Getenv("JWT_SECRET"); secret != "" {
+			t.signKey = []byte(secret)
+			t.verifyKey = []byte(secret)
+		}
+	case "RS256":
+		t.method = jwt.SigningMethodRS256
+		parsePrivate = func(b []byte) (interface{}, error) { return jwt.ParseRSAPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (interface{}, error) { return jwt.ParseRSAPublicKeyFromPEM(b) }
+	case "EDDSA":
+		t.method = jwt.SigningMethodEdDSA
+		parsePrivate = func(b []byte) (interface{}, error) { return jwt.ParseEdPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (interface{}, error) { return jwt.ParseEdPublicKeyFromPEM(b) }
+	default:
+		return nil, fmt.Errorf("unsupported signing method='%s'", c.SigningMethod)
+	}
+
+	if parsePrivate != nil {
+		if c.KeyFile != "" {
+			k, err := readKeyFile(c.KeyFile, parsePrivate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load key file='%s': %v", c.KeyFile, err)
+			}
+			t.signKey = k
+
+			switch key := k.(type) {
+			case *rsa.PrivateKey:
+				t.verifyKey = &key.PublicKey
+			case ed25519.PrivateKey:
+				t.verifyKey = key.Public()
+			}
+		}
+
+		if c.PublicKeyFile != "" {
+			k, err := readKeyFile(c.PublicKeyFile, parsePublic)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load public key file='%s': %v", c.PublicKeyFile, err)
+			}
+			t.verifyKey = k
+		}
+
+		if t.verifyKey == nil {
+			return nil, fmt.Errorf("signing method='%s' requires KeyFile= or PublicKeyFile=", c.SigningMethod)
+		}
+	}
+
+	t.revoked, err = NewRevocationList(c.RevocationFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load revocation list='%s': %v", c.RevocationFile, err)
+	}
+
+	return &t, nil
+}
+
+// keyFunc hands out the verification key for tokens signed with the configured
+// family of algorithms.
+func (t *TokenAuthority) keyFunc(token *jwt.Token) (interface{}, error) {
+	ok := false
+	switch t.method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok = token.Method.(*jwt.SigningMethodHMAC)
+	case *jwt.SigningMethodRSA:
+		_, ok = token.Method.(*jwt.SigningMethodRSA)
+	case *jwt.SigningMethodEd25519:
+		_, ok = token.Method.(*jwt.SigningMethodEd25519)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	if t.verifyKey == nil {
+		return nil, errors.New("no verification key")
+	}
+
+	return t.verifyKey, nil
+}
+
+// tokenID identifies a token by its "jti" claim or, for tokens minted without
+// one, by the digest of the token.
+func tokenID(raw string, claims jwt.MapClaims) string {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		return jti
+	}
+
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *TokenAuthority) Parse(raw string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(raw, t.keyFunc)
+	if err != nil || token == nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+
+	if t.revoked.Revoked(tokenID(raw, claims)) {
+		return nil, errors.New("revoked token")
+	}
+
+	return claims, nil
+}
+
+func (t *TokenAuthority) Issue(id *Identity) (*TokenResponse, error) {
+	if t.signKey == nil {
+		return nil, errors.New("no signing key configured")
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	exp := now.Add(t.lifetime)
+
+	claims := jwt.MapClaims{
+		"iss": tokenIssuer,
+		"sub": id.Name,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": exp.Unix(),
+		"jti": hex.EncodeToString(jti),
+	}
+	if len(id.Groups) > 0 {
+		claims["groups"] = id.Groups
+	}
+
+	s, err := jwt.NewWithClaims(t.method, claims).SignedString(t.signKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		Token:     s,
+		ExpiresAt: time.Unix(exp.Unix(), 0).UTC(),
+	}, nil
+}
+
+// Revoke revokes the token on behalf of caller, which holds roles.
+func (t *TokenAuthority) Revoke(raw string, caller *Identity, roles []string) error {
+	token, err := jwt.Parse(raw, t.keyFunc)
+	if err != nil || token == nil || !token.Valid {
+		return web.NewError(web.ErrUnauthenticated, "invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return web.NewError(web.ErrUnauthenticated, "invalid token claims")
+	}
+
+	// Callers may revoke their own tokens. root and admins may revoke any.
+	sub, _ := claims["sub"].(string)
+	if caller == nil {
+		return web.NewError(web.ErrUnauthenticated, "missing identity")
+	}
+	if caller.Name != sub && !(caller.Credentials != nil && caller.Credentials.Uid == 0) && !share.StringContains(roles, RoleAdmin) {
+		return web.NewError(web.ErrPermissionDenied, "permission denied")
+	}
+
+	var exp int64
+	if e, ok := claims["exp"].(float64); ok {
+		exp = int64(e)
+	}
+
+	return t.revoked.Revoke(tokenID(raw, claims), exp)
+}
+
+func (l *loginLimiter) blocked(now time.Time, keys ...string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, k := range keys {
+		if f, ok := l.failures[k]; ok && f.count >= loginMaxFailures && now.Sub(f.last) < loginLockout {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *loginLimiter) fail(now time.Time, keys ...string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for k, f := range l.failures {
+		if now.Sub(f.last) >= loginLockout {
+			delete(l.failures, k)
+		}
+	}
+
+	for _, k := range keys {
+		f, ok := l.failures[k]
+		if !ok {
+			f = &loginFailure{}
+			l.failures[k] = f
+		}
+
+		f.count++
+		f.last = now
+	}
+}
+
+func (l *loginLimiter) reset(keys ...string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, k := range keys {
+		delete(l.failures, k)
+	}
+}
+
+// loginAddress names the peer of the request for the login limiter.
+func loginAddress(r *http.Request) string {
+	if c, ok := r.Context().Value(credentialsContextKey).(*unix.Ucred); ok {
+		return "uid:" + strconv.Itoa(int(c.Uid))
+	}
+	if cid, ok := r.Context().Value(vsockCIDContextKey).(uint32); ok {
+		return "vsock:" + strconv.FormatUint(uint64(cid), 10)
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// plainTCP tells whether the request came over a TCP listener without TLS.
+func plainTCP(r *http.Request) bool {
+	l, ok := r.Context().Value(listenerContextKey).(*Listener)
+	return ok && l.Name == "tcp" && r.TLS == nil
+}
+
+func isPublicRoute(r *http.Request) bool {
+	return share.StringContains(publicRoutes, r.URL.Path)
+}
+
+func decodeTokenRequest(r *http.Request) (*TokenRequest, error) {
+	t := TokenRequest{}
+	if r.ContentLength == 0 {
+		return &t, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// authenticateTokenRequest verifies the password of the user when given and
+// falls back to the credentials of the unix domain socket peer otherwise.
+func authenticateTokenRequest(t *TokenRequest, caller *Identity) (*Identity, error) {
+	if t.Password != "" {
+		if validator.IsEmpty(t.User) {
+			return nil, errors.New("missing user")
+		}
+
+		if err := system.VerifyPassword(t.User, t.Password); err != nil {
+			log.Infof("Failed to authenticate user='%s': %v", t.User, err)
+			return nil, errors.New("authentication failed")
+		}
+
+		u, err := user.Lookup(t.User)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Identity{
+			Name:   u.Username,
+			Groups: acquireGroupNames(u),
+		}, nil
+	}
+
+	if caller == nil || caller.Credentials == nil {
+		return nil, errors.New("authentication failed")
+	}
+
+	if t.User != "" && t.User != caller.Name {
+		return nil, errors.New("user does not match peer credentials")
+	}
+
+	return caller, nil
+}
+
+func routerIssueToken(w http.ResponseWriter, r *http.Request) {
+	t, err := decodeTokenRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var keys []string
+	if t.Password != "" {
+		if plainTCP(r) {
+			web.JSONResponseError(web.NewError(web.ErrPermissionDenied, "password login requires TLS"), w)
+			return
+		}
+
+		keys = []string{"user:" + t.User, "address:" + loginAddress(r)}
+		if logins.blocked(time.Now(), keys...) {
+			log.Infof("Refused password login of user='%s' from '%s': too many failed attempts", t.User, loginAddress(r))
+			web.JSONResponseError(web.NewError(web.ErrTooManyRequests, "too many failed logins, retry later"), w)
+			return
+		}
+	}
+
+	id, err := authenticateTokenRequest(t, IdentityFromContext(r.Context()))
+	if err != nil {
+		if len(keys) > 0 {
+			logins.fail(time.Now(), keys...)
+		}
+		web.JSONResponseStatusError(http.StatusUnauthorized, nil, err, w)
+		return
+	}
+	if len(keys) > 0 {
+		logins.reset(keys[0])
+	}
+
+	resp, err := tokenAuthority.Load().Issue(id)
+	if err != nil {
+		log.Errorf("Failed to issue token for user='%s': %v", id.Name, err)
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	log.Infof("Issued token for user='%s' expiring at '%s'", id.Name, resp.ExpiresAt)
+
+	web.JSONResponse(resp, w)
+}
+
+func routerRevokeToken(w http.ResponseWriter, r *http.Request) {
+	t := RevokeRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			web.JSONResponseError(web.InvalidRequest(err), w)
+			return
+		}
+	}
+
+	if validator.IsEmpty(t.Token) {
+		t.Token = r.Header.Get("X-Session-Token")
+	}
+	if validator.IsEmpty(t.Token) {
+		web.JSONResponseError(web.NewError(web.ErrInvalidArgument, "missing token"), w)
+		return
+	}
+
+	caller := IdentityFromContext(r.Context())
+
+	var roles []string
+	if p := PolicyFromContext(r.Context()); p != nil && caller != nil {
+		roles = p.ResolveRoles(caller)
+	} else if caller != nil {
+		roles = caller.Roles
+	}
+
+	if err := tokenAuthority.Load().Revoke(t.Token, caller, roles); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("revoked", w)
+}
+
+func RegisterRouterAuth(router *mux.Router) {
+	n := router.PathPrefix("/_auth").Subrouter().StrictSlash(false)
+
+	openapi.Describe(n.HandleFunc("/token", routerIssueToken).Methods("POST"), "Issue a token", TokenRequest{}, TokenResponse{})
+	openapi.Describe(n.HandleFunc("/revoke", routerRevokeToken).Methods("POST"), "Revoke a token", RevokeRequest{}, nil)
+}
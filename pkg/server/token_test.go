@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func TestLoginLimiter(t *testing.T) {
+	l := loginLimiter{
+		failures: make(map[string]*loginFailure),
+	}
+
+	now := time.Now()
+	for i := 0; i < loginMaxFailures-1; i++ {
+		l.fail(now, "user:alice", "address:192.0.2.1")
+	}
+	if l.blocked(now, "user:alice", "address:192.0.2.1") {
+		t.Fatalf("Blocked before %d failures", loginMaxFailures)
+	}
+
+	l.fail(now, "user:alice", "address:192.0.2.1")
+	if !l.blocked(now, "user:alice") || !l.blocked(now, "address:192.0.2.1") {
+		t.Fatalf("Not blocked after %d failures", loginMaxFailures)
+	}
+	if !l.blocked(now, "user:bob", "address:192.0.2.1") {
+		t.Fatalf("Other user from a blocked address not blocked")
+	}
+	if l.blocked(now, "user:bob", "address:192.0.2.2") {
+		t.Fatalf("Other user and address blocked")
+	}
+
+	if l.blocked(now.Add(loginLockout), "user:alice", "address:192.0.2.1") {
+		t.Fatalf("Still blocked after the lockout")
+	}
+
+	// Expired failures are dropped by the next one.
+	l.fail(now.Add(loginLockout), "user:carol")
+	if _, ok := l.failures["user:alice"]; ok {
+		t.Fatalf("Expired failures kept")
+	}
+
+	l.reset("user:carol")
+	if len(l.failures) != 0 {
+		t.Fatalf("Failures left after reset: %v", l.failures)
+	}
+}
+
+func TestIssueTokenRequiresTLS(t *testing.T) {
+	tcp := &Listener{Name: "tcp"}
+	unixSocket := &Listener{Name: "unix"}
+
+	for _, c := range []struct {
+		listener *Listener
+		tls      bool
+		plain    bool
+	}{
+		{tcp, false, true},
+		{tcp, true, false},
+		{unixSocket, false, false},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/_auth/token", strings.NewReader(`{"User":"alice","Password":"secret"}`))
+		r = r.WithContext(context.WithValue(r.Context(), listenerContextKey, c.listener))
+		if c.tls {
+			r.TLS = &tls.ConnectionState{}
+		}
+
+		if plainTCP(r) != c.plain {
+			t.Fatalf("Listener='%s' tls=%v: expected plain=%v", c.listener.Name, c.tls, c.plain)
+		}
+
+		if c.plain {
+			w := httptest.NewRecorder()
+			routerIssueToken(w, r)
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("Expected password login over plain TCP to be refused, got status=%d", w.Code)
+			}
+		}
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "secret")
+
+	a, err := NewTokenAuthority(&conf.Token{
+		Lifetime:       "15m",
+		RevocationFile: filepath.Join(t.TempDir(), "revoked.json"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create token authority: %v", err)
+	}
+
+	p, err := NewPolicy(&conf.Authorization{
+		Roles: map[string]conf.Role{
+			RoleAdmin: {Groups: []string{"wheel"}, Allow: []string{"* /"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	issue := func() string {
+		resp, err := a.Issue(&Identity{Name: "alice"})
+		if err != nil {
+			t.Fatalf("Failed to issue token: %v", err)
+		}
+		return resp.Token
+	}
+
+	code := func(err error) web.ErrorCode {
+		var e *web.Error
+		if !errors.As(err, &e) {
+			t.Fatalf("Expected a typed error, got: %v", err)
+		}
+		return e.Code
+	}
+
+	bob := &Identity{Name: "bob"}
+	if err := a.Revoke(issue(), bob, p.ResolveRoles(bob)); code(err) != web.ErrPermissionDenied {
+		t.Fatalf("Expected permission denied revoking the token of another user, got: %v", err)
+	}
+
+	if err := a.Revoke("not-a-token", bob, nil); code(err) != web.ErrUnauthenticated {
+		t.Fatalf("Expected unauthenticated revoking an invalid token, got: %v", err)
+	}
+
+	// Admin through the group mapping of the policy.
+	carol := &Identity{Name: "carol", Groups: []string{"wheel"}}
+	token := issue()
+	if err := a.Revoke(token, carol, p.ResolveRoles(carol)); err != nil {
+		t.Fatalf("Failed to revoke as admin by group: %v", err)
+	}
+	if _, err := a.Parse(token); err == nil {
+		t.Fatalf("Revoked token still valid")
+	}
+
+	alice := &Identity{Name: "alice"}
+	if err := a.Revoke(issue(), alice, nil); err != nil {
+		t.Fatalf("Failed to revoke own token: %v", err)
+	}
+}
@@ -35,3 +35,19 @@ func EnableKeepCapability() error {
 func DisableKeepCapability() error {
 	return unix.Prctl(unix.PR_SET_KEEPCAPS, 0, 0, 0, 0)
 }
+
+// RestrictCapability drops every capability of the process but the ones
+// given, without changing its user.
+func RestrictCapability(keep ...capability.Cap) error {
+	caps, err := capability.NewPid2(0)
+	if err != nil {
+		return err
+	}
+
+	allCapabilityTypes := capability.CAPS | capability.BOUNDS | capability.AMBS
+
+	caps.Clear(allCapabilityTypes)
+	caps.Set(capability.BOUNDS|capability.PERMITTED|capability.EFFECTIVE, keep...)
+
+	return caps.Apply(allCapabilityTypes)
+}
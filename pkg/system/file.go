@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/user"
 	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -137,6 +138,51 @@ func ChangePermission(u string, file string) (err error) {
 	return os.Chmod(file, 0660)
 }
 
+// WriteGroupFile replaces file with b, readable by the owner and group only.
+// The daemon has no CAP_CHOWN, so the file is handed to a group it is a
+// member of rather than to another user. It is written next to file and
+// renamed over it, so that a file owned by someone else never keeps its mode.
+func WriteGroupFile(file string, b []byte, group string) error {
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	os.Remove(tmp)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Chown(-1, gid); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to give file='%s' to group %s, the daemon must be a member of it: %w", file, group, err)
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
 func CreateStateDirs(path string, uid int, gid int) error {
 	if err := os.MkdirAll(path, os.FileMode(07777)); err != nil {
 		return err
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package system
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestWriteGroupFile(t *testing.T) {
+	g, err := user.LookupGroupId(strconv.Itoa(os.Getgid()))
+	if err != nil {
+		t.Skipf("Failed to look up the primary group: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "10-test.network")
+	if err := os.WriteFile(file, []byte("old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file='%s': %v", file, err)
+	}
+
+	if err := WriteGroupFile(file, []byte("new\n"), g.Name); err != nil {
+		t.Fatalf("Failed to write file='%s': %v", file, err)
+	}
+
+	st, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat file='%s': %v", file, err)
+	}
+	if st.Mode().Perm() != 0640 {
+		t.Fatalf("Expected mode 0640 of replaced file, got %o", st.Mode().Perm())
+	}
+	if gid := st.Sys().(*syscall.Stat_t).Gid; int(gid) != os.Getgid() {
+		t.Fatalf("Expected gid=%d, got %d", os.Getgid(), gid)
+	}
+	if b, _ := os.ReadFile(file); string(b) != "new\n" {
+		t.Fatalf("Unexpected content: %q", b)
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	if len(entries) != 1 {
+		t.Fatalf("Temporary file left behind: %v", entries)
+	}
+}
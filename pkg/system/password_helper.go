@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package system
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/syndtr/gocapability/capability"
+)
+
+// PasswordHelperEnv makes photon-mgmtd run as the password helper.
+const PasswordHelperEnv = "PHOTON_MGMT_PASSWORD_HELPER"
+
+type passwordRequest struct {
+	User     string `json:"User"`
+	Password string `json:"Password"`
+}
+
+type passwordResponse struct {
+	Error string `json:"Error"`
+}
+
+// helperProcess is the password helper as seen by the daemon. Requests are
+// answered in order, one at a time.
+type helperProcess struct {
+	encoder *json.Encoder
+	decoder *json.Decoder
+	mutex   sync.Mutex
+}
+
+var passwordHelper atomic.Pointer[helperProcess]
+
+// StartPasswordHelper starts a copy of the daemon which keeps the privilege to
+// read the shadow database. It must be called before the daemon switches to
+// its unprivileged user. The helper exits along with the daemon.
+func StartPasswordHelper() error {
+	c := exec.Command("/proc/self/exe")
+	c.Env = append(os.Environ(), PasswordHelperEnv+"=1")
+	c.Stderr = os.Stderr
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Pdeathsig: syscall.SIGKILL,
+	}
+
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+	go c.Wait()
+
+	passwordHelper.Store(&helperProcess{
+		encoder: json.NewEncoder(stdin),
+		decoder: json.NewDecoder(stdout),
+	})
+
+	return nil
+}
+
+func (h *helperProcess) verify(user, password string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if err := h.encoder.Encode(passwordRequest{User: user, Password: password}); err != nil {
+		return errors.New("password helper unavailable")
+	}
+
+	resp := passwordResponse{}
+	if err := h.decoder.Decode(&resp); err != nil {
+		return errors.New("password helper unavailable")
+	}
+
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	return nil
+}
+
+// RunPasswordHelper answers the password checks the daemon writes to stdin
+// until it closes it. Only the capability to read the shadow database is
+// kept.
+func RunPasswordHelper() error {
+	if os.Geteuid() == 0 {
+		if err := RestrictCapability(capability.CAP_DAC_READ_SEARCH); err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		req := passwordRequest{}
+		if err := decoder.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		resp := passwordResponse{}
+		if err := verifyShadowPassword(req.User, req.Password); err != nil {
+			resp.Error = err.Error()
+		}
+
+		if err := encoder.Encode(resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package system
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The helper is started from the test binary.
+	if os.Getenv(PasswordHelperEnv) != "" {
+		if err := RunPasswordHelper(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPasswordHelper(t *testing.T) {
+	if err := StartPasswordHelper(); err != nil {
+		t.Fatalf("Failed to start password helper: %v", err)
+	}
+	defer passwordHelper.Store(nil)
+
+	want := verifyShadowPassword("photon-mgmt-no-such-user", "secret")
+	if want == nil {
+		t.Fatalf("Unknown user matched")
+	}
+
+	for i := 0; i < 2; i++ {
+		err := VerifyPassword("photon-mgmt-no-such-user", "secret")
+		if err == nil || err.Error() != want.Error() {
+			t.Fatalf("Expected helper error '%v', got: %v", want, err)
+		}
+	}
+}
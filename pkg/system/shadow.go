@@ -0,0 +1,252 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package system
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ShadowPath = "/etc/shadow"
+
+	shaCryptRoundsDefault = 5000
+	shaCryptRoundsMin     = 1000
+	shaCryptRoundsMax     = 999999999
+	shaCryptSaltMax       = 16
+
+	cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var (
+	// unsupportedHashes names the other hash identifiers found in shadow
+	// files for a clearer error.
+	unsupportedHashes = map[string]string{
+		"1":  "MD5",
+		"2a": "bcrypt",
+		"2b": "bcrypt",
+		"2y": "bcrypt",
+		"y":  "yescrypt",
+		"gy": "gost-yescrypt",
+		"7":  "scrypt",
+	}
+
+	sha256CryptPermutation = [][3]int{
+		{0, 10, 20}, {21, 1, 11}, {12, 22, 2}, {3, 13, 23}, {24, 4, 14},
+		{15, 25, 5}, {6, 16, 26}, {27, 7, 17}, {18, 28, 8}, {9, 19, 29},
+	}
+
+	sha512CryptPermutation = [][3]int{
+		{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+		{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+		{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+		{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+		{62, 20, 41},
+	}
+)
+
+func cryptEncode(out *strings.Builder, b2, b1, b0 byte, n int) {
+	w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+	for ; n > 0; n-- {
+		out.WriteByte(cryptAlphabet[w&0x3f])
+		w >>= 6
+	}
+}
+
+func repeatDigest(digest []byte, n int) []byte {
+	b := make([]byte, 0, n)
+	for len(b) < n {
+		b = append(b, digest...)
+	}
+
+	return b[:n]
+}
+
+// shaCrypt computes the SHA-crypt digest of the password as used by glibc for
+// the "$5$" and "$6$" hash identifiers.
+func shaCrypt(newHash func() hash.Hash, password, salt []byte, rounds int) []byte {
+	h := newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(password)
+	b := h.Sum(nil)
+
+	h = newHash()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(repeatDigest(b, len(password)))
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write(b)
+		} else {
+			h.Write(password)
+		}
+	}
+	a := h.Sum(nil)
+
+	h = newHash()
+	for i := 0; i < len(password); i++ {
+		h.Write(password)
+	}
+	p := repeatDigest(h.Sum(nil), len(password))
+
+	h = newHash()
+	for i := 0; i < 16+int(a[0]); i++ {
+		h.Write(salt)
+	}
+	s := repeatDigest(h.Sum(nil), len(salt))
+
+	c := a
+	for i := 0; i < rounds; i++ {
+		h = newHash()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(c)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(c)
+		} else {
+			h.Write(p)
+		}
+		c = h.Sum(nil)
+	}
+
+	return c
+}
+
+// ShaCrypt hashes the password with the settings ("$6$[rounds=N$]salt") taken
+// from an existing hash and returns the complete hash string.
+func ShaCrypt(password, setting string) (string, error) {
+	fields := strings.Split(setting, "$")
+	if len(fields) < 3 || fields[0] != "" {
+		return "", errors.New("invalid hash")
+	}
+
+	var newHash func() hash.Hash
+	var permutation [][3]int
+	switch fields[1] {
+	case "5":
+		newHash = sha256.New
+		permutation = sha256CryptPermutation
+	case "6":
+		newHash = sha512.New
+		permutation = sha512CryptPermutation
+	default:
+		if name, ok := unsupportedHashes[fields[1]]; ok {
+			return "", fmt.Errorf("unsupported hash algorithm %s ($%s$), only SHA-256 ($5$) and SHA-512 ($6$) are supported", name, fields[1])
+		}
+		return "", fmt.Errorf("unsupported hash algorithm '$%s$'", fields[1])
+	}
+
+	prefix := "$" + fields[1] + "$"
+	rounds := shaCryptRoundsDefault
+	salt := fields[2]
+	if strings.HasPrefix(salt, "rounds=") {
+		if len(fields) < 4 {
+			return "", errors.New("invalid hash")
+		}
+
+		r, err := strconv.Atoi(strings.TrimPrefix(salt, "rounds="))
+		if err != nil {
+			return "", errors.New("invalid hash rounds")
+		}
+
+		rounds = max(shaCryptRoundsMin, min(r, shaCryptRoundsMax))
+		prefix += "rounds=" + strconv.Itoa(rounds) + "$"
+		salt = fields[3]
+	}
+
+	if len(salt) > shaCryptSaltMax {
+		salt = salt[:shaCryptSaltMax]
+	}
+
+	c := shaCrypt(newHash, []byte(password), []byte(salt), rounds)
+
+	out := strings.Builder{}
+	out.WriteString(prefix + salt + "$")
+	for _, t := range permutation {
+		cryptEncode(&out, c[t[0]], c[t[1]], c[t[2]], 4)
+	}
+	if len(c) == sha512.Size {
+		cryptEncode(&out, 0, 0, c[63], 2)
+	} else {
+		cryptEncode(&out, 0, c[31], c[30], 3)
+	}
+
+	return out.String(), nil
+}
+
+func acquireShadowHash(user string) (string, error) {
+	f, err := os.Open(ShadowPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) > 1 && fields[0] == user {
+			return fields[1], nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errors.New("user not found")
+}
+
+// VerifyPassword checks the password of a local user against the shadow
+// database. Locked accounts and accounts without password never match. Once
+// the daemon dropped its privileges, the password helper reads the database.
+func VerifyPassword(user, password string) error {
+	if h := passwordHelper.Load(); h != nil {
+		return h.verify(user, password)
+	}
+
+	return verifyShadowPassword(user, password)
+}
+
+func verifyShadowPassword(user, password string) error {
+	stored, err := acquireShadowHash(user)
+	if err != nil {
+		return err
+	}
+
+	return verifyHash(password, stored)
+}
+
+func verifyHash(password, stored string) error {
+	if !strings.HasPrefix(stored, "$") {
+		return errors.New("account locked or without password")
+	}
+
+	h, err := ShaCrypt(password, stored)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(h), []byte(stored)) != 1 {
+		return errors.New("password mismatch")
+	}
+
+	return nil
+}
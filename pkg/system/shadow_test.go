@@ -0,0 +1,124 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package system
+
+import (
+	"strings"
+	"testing"
+)
+
+// The test vectors of Ulrich Drepper's "Unix crypt using SHA-256 and SHA-512".
+var shaCryptVectors = []struct {
+	setting  string
+	password string
+	hash     string
+}{
+	{
+		"$5$saltstring",
+		"Hello world!",
+		"$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+	},
+	{
+		"$5$rounds=10000$saltstringsaltstring",
+		"Hello world!",
+		"$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA",
+	},
+	{
+		"$5$rounds=5000$toolongsaltstring",
+		"This is just a test",
+		"$5$rounds=5000$toolongsaltstrin$Un/5jzAHMgOGZ5.mWJpuVolil07guHPvOW8mGRcvxa5",
+	},
+	{
+		"$5$rounds=1400$anotherlongsaltstring",
+		"a very much longer text to encrypt.  This one even stretches over morethan one line.",
+		"$5$rounds=1400$anotherlongsalts$Rx.j8H.h8HjEDGomFU8bDkXm3XIUnzyxf12oP84Bnq1",
+	},
+	{
+		"$5$rounds=77777$short",
+		"we have a short salt string but not a short password",
+		"$5$rounds=77777$short$JiO1O3ZpDAxGJeaDIuqCoEFysAe1mZNJRs3pw0KQRd/",
+	},
+	{
+		"$5$rounds=10$roundstoolow",
+		"the minimum number is still observed",
+		"$5$rounds=1000$roundstoolow$yfvwcWrQ8l/K0DAWyuPMDNHpIVlTQebY9l/gL972bIC",
+	},
+	{
+		"$6$saltstring",
+		"Hello world!",
+		"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+	},
+	{
+		"$6$rounds=10000$saltstringsaltstring",
+		"Hello world!",
+		"$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+	},
+	{
+		"$6$rounds=5000$toolongsaltstring",
+		"This is just a test",
+		"$6$rounds=5000$toolongsaltstrin$lQ8jolhgVRVhY4b5pZKaysCLi0QBxGoNeKQzQ3glMhwllF7oGDZxUhx1yxdYcz/e1JSbq3y6JMxxl8audkUEm0",
+	},
+	{
+		"$6$rounds=1400$anotherlongsaltstring",
+		"a very much longer text to encrypt.  This one even stretches over morethan one line.",
+		"$6$rounds=1400$anotherlongsalts$POfYwTEok97VWcjxIiSOjiykti.o/pQs.wPvMxQ6Fm7I6IoYN3CmLs66x9t0oSwbtEW7o7UmJEiDwGqd8p4ur1",
+	},
+	{
+		"$6$rounds=77777$short",
+		"we have a short salt string but not a short password",
+		"$6$rounds=77777$short$WuQyW2YR.hBNpjjRhpYD/ifIw05xdfeEyQoMxIXbkvr0gge1a1x3yRULJ5CCaUeOxFmtlcGZelFl5CxtgfiAc0",
+	},
+	{
+		"$6$rounds=10$roundstoolow",
+		"the minimum number is still observed",
+		"$6$rounds=1000$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX.",
+	},
+}
+
+func TestShaCrypt(t *testing.T) {
+	for _, v := range shaCryptVectors {
+		h, err := ShaCrypt(v.password, v.setting)
+		if err != nil {
+			t.Fatalf("Failed to hash with setting='%s': %v", v.setting, err)
+		}
+		if h != v.hash {
+			t.Errorf("Hash mismatch for setting='%s': got '%s', expected '%s'", v.setting, h, v.hash)
+		}
+	}
+}
+
+func TestVerifyHash(t *testing.T) {
+	for _, v := range shaCryptVectors {
+		if err := verifyHash(v.password, v.hash); err != nil {
+			t.Errorf("Failed to verify hash='%s': %v", v.hash, err)
+		}
+		if err := verifyHash(v.password+"x", v.hash); err == nil {
+			t.Errorf("Wrong password matched hash='%s'", v.hash)
+		}
+	}
+
+	for _, stored := range []string{"", "!", "*", "!$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl"} {
+		if err := verifyHash("Hello world!", stored); err == nil {
+			t.Errorf("Locked hash='%s' matched", stored)
+		}
+	}
+}
+
+func TestShaCryptUnsupported(t *testing.T) {
+	for _, v := range []struct {
+		stored string
+		name   string
+	}{
+		{"$y$j9T$F5Jx5fExrKuPp53xLKQ..1$X3DX6M94c7o.9agCG9G317fhZg9SqC.5i5rd.RhAtQ7", "yescrypt"},
+		{"$2b$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW", "bcrypt"},
+	} {
+		_, err := ShaCrypt("password", v.stored)
+		if err == nil || !strings.Contains(err.Error(), v.name) {
+			t.Errorf("Expected an unsupported %s error, got: %v", v.name, err)
+		}
+		if err := verifyHash("password", v.stored); err == nil {
+			t.Errorf("Unsupported hash='%s' matched", v.stored)
+		}
+	}
+}
@@ -54,6 +54,27 @@ func SwitchUser(c *syscall.Credential) (err error) {
 	return err
 }
 
+// JoinGroup adds the group to the supplementary groups of the process. It
+// requires CAP_SETGID and is meant to be called before switching user.
+func JoinGroup(grp string) error {
+	g, err := user.LookupGroup(grp)
+	if err != nil {
+		return err
+	}
+
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return err
+	}
+
+	gids, err := syscall.Getgroups()
+	if err != nil {
+		return err
+	}
+
+	return syscall.Setgroups(append(gids, gid))
+}
+
 func GetGroupCredentials(grp string) (*user.Group, error) {
 	return user.LookupGroup(grp)
 }
@@ -4,6 +4,7 @@
 package validator
 
 import (
+	"encoding/hex"
 	"net"
 	"strconv"
 	"strings"
@@ -240,6 +241,71 @@ func IsVxLanVNI(id string) bool {
 	return true
 }
 
+func IsNetDevKind(kind string) bool {
+	switch kind {
+	case "vlan", "bond", "bridge", "macvlan", "macvtap", "ipvlan", "ipvtap", "vxlan", "wireguard", "tun", "tap",
+		"vrf", "veth", "dummy", "geneve", "gre", "gretap", "ipip", "sit", "vti", "macsec":
+		return true
+	}
+
+	return false
+}
+
+func IsVRFTable(table string) bool {
+	t, err := strconv.ParseUint(table, 10, 32)
+	return err == nil && t > 0
+}
+
+func IsGeneveId(id string) bool {
+	return IsVxLanVNI(id)
+}
+
+func IsTunnelKind(kind string) bool {
+	return kind == "gre" || kind == "gretap" || kind == "ipip" || kind == "sit" || kind == "vti"
+}
+
+// IsTunnelKeyKind tells whether a tunnel kind takes Key=, InputKey= and OutputKey=.
+func IsTunnelKeyKind(kind string) bool {
+	return kind == "gre" || kind == "gretap" || kind == "vti"
+}
+
+func IsTunnelAddress(address string) bool {
+	return address == "any" || IsIP(address)
+}
+
+// IsTunnelKey accepts a number or an IPv4 address in dotted notation.
+func IsTunnelKey(key string) bool {
+	if IsUint32(key) {
+		return true
+	}
+
+	ip := net.ParseIP(key)
+	return ip != nil && ip.To4() != nil
+}
+
+func IsTTL(ttl string) bool {
+	return IsUint8(ttl)
+}
+
+func IsMACsecPort(port string) bool {
+	p, err := strconv.ParseUint(port, 10, 16)
+	return err == nil && p > 0
+}
+
+func IsMACsecKeyId(id string) bool {
+	return IsUint8(id)
+}
+
+// IsMACsecKey accepts a 128 or 256 bit key in hexadecimal.
+func IsMACsecKey(key string) bool {
+	if len(key) != 32 && len(key) != 64 {
+		return false
+	}
+
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
+
 func IsWireGuardListenPort(port string) bool {
 	return port == "auto" || IsPort(port)
 }
@@ -512,6 +578,78 @@ func IsSRIOVLinkState(l string) bool {
 	return l == "auto" || IsBool(l)
 }
 
+func IsBridgeVLANId(id string) bool {
+	v, err := strconv.ParseUint(id, 10, 16)
+	return err == nil && v >= 1 && v <= 4094
+}
+
+// IsBridgeVLAN takes a VLAN id or a range of them as "10-20".
+func IsBridgeVLAN(vlan string) bool {
+	first, last, found := strings.Cut(vlan, "-")
+	if !found {
+		return IsBridgeVLANId(vlan)
+	}
+	if !IsBridgeVLANId(first) || !IsBridgeVLANId(last) {
+		return false
+	}
+
+	f, _ := strconv.Atoi(first)
+	l, _ := strconv.Atoi(last)
+	return f <= l
+}
+
+// IsTCHandle takes a qdisc handle or class id as "1:" or "1:10", in hex.
+func IsTCHandle(h string) bool {
+	major, minor, found := strings.Cut(h, ":")
+	if !found {
+		return false
+	}
+	if _, err := strconv.ParseUint(major, 16, 16); err != nil {
+		return false
+	}
+	if minor == "" {
+		return true
+	}
+
+	_, err := strconv.ParseUint(minor, 16, 16)
+	return err == nil
+}
+
+func IsTCParent(p string) bool {
+	return p == "root" || p == "clsact" || p == "ingress" || IsTCHandle(p)
+}
+
+// isNumberWithSuffix checks for a decimal number followed by one of suffixes.
+func isNumberWithSuffix(v string, suffixes ...string) bool {
+	for _, s := range suffixes {
+		if s != "" && strings.HasSuffix(v, s) {
+			v = strings.TrimSuffix(v, s)
+			break
+		}
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	return err == nil && f >= 0
+}
+
+// IsTCRate takes a rate in bits per second with an optional K, M or G suffix.
+func IsTCRate(rate string) bool {
+	return isNumberWithSuffix(rate, "K", "M", "G", "T")
+}
+
+func IsTCBytes(size string) bool {
+	return isNumberWithSuffix(size, "K", "M", "G")
+}
+
+func IsTCTime(t string) bool {
+	return isNumberWithSuffix(t, "usec", "us", "msec", "ms", "sec", "s")
+}
+
+func IsTCPercent(p string) bool {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(p, "%"), 64)
+	return err == nil && f >= 0 && f <= 100
+}
+
 // see https://fedoraproject.org/wiki/Packaging:Naming
 func IsValidPkgName(name string) bool {
 	if IsEmpty(name) {
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrorCode is the machine readable class of a failed request.
+type ErrorCode string
+
+const (
+	ErrInvalidArgument    ErrorCode = "invalid_argument"
+	ErrNotFound           ErrorCode = "not_found"
+	ErrUnauthenticated    ErrorCode = "unauthenticated"
+	ErrPermissionDenied   ErrorCode = "permission_denied"
+	ErrConflict           ErrorCode = "conflict"
+	ErrTooManyRequests    ErrorCode = "too_many_requests"
+	ErrBackendUnavailable ErrorCode = "backend_unavailable"
+	ErrInternal           ErrorCode = "internal"
+)
+
+var errorStatus = map[ErrorCode]int{
+	ErrInvalidArgument:    http.StatusBadRequest,
+	ErrNotFound:           http.StatusNotFound,
+	ErrUnauthenticated:    http.StatusUnauthorized,
+	ErrPermissionDenied:   http.StatusForbidden,
+	ErrConflict:           http.StatusConflict,
+	ErrTooManyRequests:    http.StatusTooManyRequests,
+	ErrBackendUnavailable: http.StatusServiceUnavailable,
+	ErrInternal:           http.StatusInternalServerError,
+}
+
+// FieldError describes why one field of the request was rejected.
+type FieldError struct {
+	Field  string `json:"field"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// Error is a failure the API reports with a code and the matching HTTP status.
+type Error struct {
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func NewError(code ErrorCode, format string, a ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+// InvalidArgument reports a request field holding an invalid value.
+func InvalidArgument(field string, value string) *Error {
+	e := NewError(ErrInvalidArgument, "invalid %s='%s'", field, value)
+	return e.WithField(field, value, "invalid value")
+}
+
+// InvalidRequest reports a request body which could not be decoded. Typed
+// errors of the validation done while decoding are kept.
+func InvalidRequest(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	return NewError(ErrInvalidArgument, "error decoding request: %v", err)
+}
+
+// WithField adds the details of a rejected field.
+func (e *Error) WithField(field string, value string, reason string) *Error {
+	e.Details = append(e.Details, FieldError{
+		Field:  field,
+		Value:  value,
+		Reason: reason,
+	})
+
+	return e
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errorCodeOf returns the code of an HTTP status.
+func errorCodeOf(status int) ErrorCode {
+	for c, s := range errorStatus {
+		if s == status {
+			return c
+		}
+	}
+
+	if status >= http.StatusInternalServerError {
+		return ErrInternal
+	}
+
+	return ErrInvalidArgument
+}
+
+// Status returns the HTTP status of the error code.
+func (e *Error) Status() int {
+	if s, ok := errorStatus[e.Code]; ok {
+		return s
+	}
+
+	return http.StatusInternalServerError
+}
+
+// dbusErrorCodes maps the names of well known D-Bus errors.
+var dbusErrorCodes = map[string]ErrorCode{
+	"org.freedesktop.DBus.Error.ServiceUnknown":                   ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.NameHasNoOwner":                   ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.NoReply":                          ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.Timeout":                          ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.TimedOut":                         ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.Disconnected":                     ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.NoServer":                         ErrBackendUnavailable,
+	"org.freedesktop.DBus.Error.AccessDenied":                     ErrPermissionDenied,
+	"org.freedesktop.DBus.Error.InteractiveAuthorizationRequired": ErrPermissionDenied,
+	"org.freedesktop.DBus.Error.InvalidArgs":                      ErrInvalidArgument,
+	"org.freedesktop.DBus.Error.UnknownObject":                    ErrNotFound,
+	"org.freedesktop.DBus.Error.FileNotFound":                     ErrNotFound,
+	"org.freedesktop.systemd1.NoSuchUnit":                         ErrNotFound,
+	"org.freedesktop.systemd1.LoadFailed":                         ErrNotFound,
+	"org.freedesktop.systemd1.UnitMasked":                         ErrConflict,
+	"org.freedesktop.systemd1.JobTypeNotApplicable":               ErrConflict,
+	"org.freedesktop.network1.NoSuchLink":                         ErrNotFound,
+}
+
+// AsError returns err as *Error. Errors which are not typed are classified
+// by their origin: errno values, missing files, D-Bus errors and failed
+// connections to a backend. Anything else is an internal error.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	code := ErrInternal
+
+	var errno syscall.Errno
+	var dbusErr dbus.Error
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &errno):
+		switch errno {
+		case syscall.ENOENT, syscall.ENODEV, syscall.ESRCH, syscall.ENXIO, syscall.EADDRNOTAVAIL:
+			code = ErrNotFound
+		case syscall.EEXIST, syscall.EBUSY:
+			code = ErrConflict
+		case syscall.EINVAL, syscall.ERANGE, syscall.EAFNOSUPPORT:
+			code = ErrInvalidArgument
+		case syscall.EPERM, syscall.EACCES:
+			code = ErrPermissionDenied
+		case syscall.ECONNREFUSED, syscall.ETIMEDOUT:
+			code = ErrBackendUnavailable
+		}
+	case errors.Is(err, os.ErrNotExist):
+		code = ErrNotFound
+	case errors.Is(err, os.ErrPermission):
+		code = ErrPermissionDenied
+	case errors.As(err, &dbusErr):
+		if c, ok := dbusErrorCodes[dbusErr.Name]; ok {
+			code = c
+		}
+	case errors.As(err, &opErr):
+		code = ErrBackendUnavailable
+	}
+
+	return &Error{
+		Code:    code,
+		Message: err.Error(),
+	}
+}
@@ -4,8 +4,11 @@
 package web
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -45,11 +48,41 @@ const (
 	defaultRequestTimeout = 5 * time.Second
 )
 
-func decodeHttpResponse(resp *http.Response) ([]byte, error) {
-	if resp.StatusCode != 200 {
-		return nil, errors.New(resp.Status)
+var clientTLSConfig *tls.Config
+
+// SetClientTLS configures the client certificate presented to HTTPS servers
+// and the CA bundle used to verify them. Empty paths are ignored.
+func SetClientTLS(certFile, keyFile, caFile string) error {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return errors.Wrap(err, "could not load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read CA bundle")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in CA bundle")
+		}
+		cfg.RootCAs = pool
 	}
 
+	clientTLSConfig = cfg
+	return nil
+}
+
+func decodeHttpResponse(resp *http.Response) ([]byte, error) {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not parse body")
@@ -58,6 +91,22 @@ func decodeHttpResponse(resp *http.Response) ([]byte, error) {
 	return body, nil
 }
 
+// decodeErrorResponse returns the error carried by the body of a failed
+// request, falling back to the HTTP status line.
+func decodeErrorResponse(status string, body []byte) error {
+	m := JSONResponseMessage{}
+	if err := json.Unmarshal(body, &m); err == nil {
+		if m.Error != nil {
+			return m.Error
+		}
+		if m.Errors != "" {
+			return errors.New(m.Errors)
+		}
+	}
+
+	return errors.New(status)
+}
+
 func buildHttpRequest(ctx context.Context, method string, url string, headers map[string]string, data interface{}) (*http.Request, error) {
 	j := new(bytes.Buffer)
 	if err := json.NewEncoder(j).Encode(data); err != nil {
@@ -77,40 +126,48 @@ func buildHttpRequest(ctx context.Context, method string, url string, headers ma
 	return httpRequest, nil
 }
 
-func DispatchSocketWithStatus(method, host string, url string, headers map[string]string, data interface{}) (*Response, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
-	defer cancel()
-
-	var httpClient *http.Client
+func newHttpClient(host string, url string) (*http.Client, string) {
 	if validator.IsEmpty(host) {
-		httpClient = &http.Client{
+		return &http.Client{
 			Transport: &http.Transport{
 				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
 					return net.Dial("unix", conf.UnixDomainSocketPath)
 				},
 			},
-		}
-		url = "http://localhost" + url
-	} else {
-		if validator.IsVSockHost(host) {
-			h := strings.Split(host, ":")
-			cid, _ := strconv.ParseUint(h[0], 10, 32)
-			port, _ := strconv.ParseUint(h[1], 10, 32)
-
-			httpClient = &http.Client{
-				Transport: &http.Transport{
-					DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-						return vsock.Dial(uint32(cid), uint32(port))
-					},
+		}, "http://localhost" + url
+	}
+
+	if validator.IsVSockHost(host) {
+		h := strings.Split(host, ":")
+		cid, _ := strconv.ParseUint(h[0], 10, 32)
+		port, _ := strconv.ParseUint(h[1], 10, 32)
+
+		return &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return vsock.Dial(uint32(cid), uint32(port))
 				},
-			}
-			url = "http://localhost" + url
-		} else {
-			httpClient = http.DefaultClient
-			url = host + url
-		}
+			},
+		}, "http://localhost" + url
+	}
+
+	if clientTLSConfig != nil {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: clientTLSConfig,
+			},
+		}, host + url
 	}
 
+	return http.DefaultClient, host + url
+}
+
+func DispatchSocketWithStatus(method, host string, url string, headers map[string]string, data interface{}) (*Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	httpClient, url := newHttpClient(host, url)
+
 	req, err := buildHttpRequest(ctx, method, url, headers, data)
 	if err != nil {
 		return nil, err
@@ -122,12 +179,9 @@ func DispatchSocketWithStatus(method, host string, url string, headers map[strin
 	}
 	defer resp.Body.Close()
 
-	var body []byte
-	if resp.StatusCode == 200 {
-		body, err = decodeHttpResponse(resp)
-		if err != nil {
-			return nil, err
-		}
+	body, err := decodeHttpResponse(resp)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Response{
@@ -138,6 +192,43 @@ func DispatchSocketWithStatus(method, host string, url string, headers map[strin
 	}, nil
 }
 
+// DispatchStream issues a GET request without timeout and calls f with each
+// line of the response body until the server closes the stream or f fails.
+func DispatchStream(ctx context.Context, host string, url string, headers map[string]string, f func(line string) error) error {
+	httpClient, url := newHttpClient(host, url)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "could not complete HTTP request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		body, _ := decodeHttpResponse(resp)
+		return decodeErrorResponse(resp.Status, body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := f(scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
 func DispatchSocket(method, host string, url string, headers map[string]string, data interface{}) ([]byte, error) {
 	r, err := DispatchSocketWithStatus(method, host, url, headers, data)
 	if err != nil {
@@ -145,7 +236,7 @@ func DispatchSocket(method, host string, url string, headers map[string]string,
 	}
 
 	if r.StatusCode != 200 {
-		return nil, errors.New(r.Status)
+		return nil, decodeErrorResponse(r.Status, r.Body)
 	}
 	return r.Body, err
 }
@@ -193,11 +284,56 @@ func DispatchAndWait(method, host string, url string, token map[string]string, d
 	} else if r.StatusCode == 200 {
 		msg = r.Body
 	} else {
-		return nil, err
+		return nil, decodeErrorResponse(r.Status, r.Body)
 	}
 	return msg, err
 }
 
+// DispatchAndFollow is DispatchAndWait for jobs which report their output.
+// While the job runs, f is called with each event of its log stream ("log",
+// "progress" and "state") and the data it carries.
+func DispatchAndFollow(method, host string, url string, token map[string]string, data interface{}, f func(event, data string)) ([]byte, error) {
+	r, err := DispatchSocketWithStatus(method, host, url, token, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.StatusCode == 200 {
+		return r.Body, nil
+	}
+
+	if r.StatusCode != 202 {
+		return nil, decodeErrorResponse(r.Status, r.Body)
+	}
+
+	location := r.Header.Get("Location")
+	if location == "" {
+		return nil, errors.New("no location in headers")
+	}
+
+	var event string
+	var lines []string
+	err = DispatchStream(context.Background(), host, strings.Replace(location, "/status/", "/", 1)+"/log?follow=true", token, func(line string) error {
+		switch {
+		case line == "":
+			if event != "" {
+				f(event, strings.Join(lines, "\n"))
+			}
+			event, lines = "", nil
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			lines = append(lines, strings.TrimPrefix(line, "data: "))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return DispatchSocket(http.MethodGet, host, strings.Replace(location, "/status/", "/result/", 1), token, nil)
+}
+
 func BuildAuthTokenFromEnv() (map[string]string, error) {
 	token := os.Getenv("PHOTON_MGMT_AUTH_TOKEN")
 	if token == "" {
@@ -12,17 +12,18 @@ type JSONResponseMessage struct {
 	Success bool        `json:"success"`
 	Message interface{} `json:"message"`
 	Errors  string      `json:"errors"`
+	Error   *Error      `json:"error,omitempty"`
 }
 
-func httpResponse(m *JSONResponseMessage, w http.ResponseWriter) error {
+func httpResponse(m *JSONResponseMessage, status int, w http.ResponseWriter) error {
 	j, err := json.Marshal(m)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return err
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(j)
 
 	return nil
@@ -34,16 +35,36 @@ func JSONResponse(response interface{}, w http.ResponseWriter) error {
 		Message: response,
 	}
 
-	return httpResponse(&m, w)
+	return httpResponse(&m, http.StatusOK, w)
 }
 
+// JSONResponseError writes err with the HTTP status of its code. See AsError
+// for how untyped errors are classified.
 func JSONResponseError(err error, w http.ResponseWriter) error {
+	e := AsError(err)
 	m := JSONResponseMessage{
 		Success: false,
+		Errors:  e.Error(),
+		Error:   e,
+	}
+
+	return httpResponse(&m, e.Status(), w)
+}
+
+// JSONResponseStatusError writes err with the given HTTP status. The optional
+// message carries structured details about the failure.
+func JSONResponseStatusError(status int, message interface{}, err error, w http.ResponseWriter) error {
+	m := JSONResponseMessage{
+		Success: false,
+		Message: message,
 		Errors:  err.Error(),
+		Error: &Error{
+			Code:    errorCodeOf(status),
+			Message: err.Error(),
+		},
 	}
 
-	return httpResponse(&m, w)
+	return httpResponse(&m, status, w)
 }
 
 func JSONUnmarshal(msg []byte) (map[string]interface{}, error) {
@@ -9,13 +9,14 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerGroupAdd(w http.ResponseWriter, r *http.Request) {
 	g := Group{}
 	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -27,7 +28,7 @@ func routerGroupAdd(w http.ResponseWriter, r *http.Request) {
 func routerGroupModify(w http.ResponseWriter, r *http.Request) {
 	g := Group{}
 	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -39,7 +40,7 @@ func routerGroupModify(w http.ResponseWriter, r *http.Request) {
 func routerGroupRemove(w http.ResponseWriter, r *http.Request) {
 	g := Group{}
 	if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -61,9 +62,9 @@ func routerGroupView(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterGroup(router *mux.Router) {
 	s := router.PathPrefix("/group").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/add", routerGroupAdd).Methods("POST")
-	s.HandleFunc("/remove", routerGroupRemove).Methods("DELETE")
-	s.HandleFunc("/modify", routerGroupModify).Methods("PUT")
-	s.HandleFunc("/view", routerGroupView).Methods("GET")
-	s.HandleFunc("/view/{groupname}", routerGroupView).Methods("GET")
+	openapi.Describe(s.HandleFunc("/add", routerGroupAdd).Methods("POST"), "Add a group", Group{}, nil)
+	openapi.Describe(s.HandleFunc("/remove", routerGroupRemove).Methods("DELETE"), "Remove a group", Group{}, nil)
+	openapi.Describe(s.HandleFunc("/modify", routerGroupModify).Methods("PUT"), "Modify a group", Group{}, nil)
+	openapi.Describe(s.HandleFunc("/view", routerGroupView).Methods("GET"), "Show groups", nil, nil)
+	openapi.Describe(s.HandleFunc("/view/{groupname}", routerGroupView).Methods("GET"), "Show groups", nil, nil)
 }
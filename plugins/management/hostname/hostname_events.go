@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package hostname
+
+import (
+	"context"
+
+	"github.com/vmware/pmd-next-gen/pkg/bus"
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+// WatchHostname publishes the property changes of systemd-hostnamed until ctx
+// is cancelled.
+func WatchHostname(ctx context.Context) error {
+	return bus.WatchPropertiesChanged(ctx, dbusPath, func(p *bus.PropertiesChanged) {
+		events.Publish(events.TopicHostname, p.Changed)
+	})
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -16,6 +18,7 @@ func routerHostnameDescribe(w http.ResponseWriter, r *http.Request) {
 	desc, err := MethodDescribe(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(desc, w)
@@ -36,6 +39,8 @@ func routerSetHostname(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterHostname(router *mux.Router) {
 	s := router.PathPrefix("/hostname").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/describe", routerHostnameDescribe).Methods("GET")
-	s.HandleFunc("/update", routerSetHostname).Methods("POST")
+	openapi.Describe(s.HandleFunc("/describe", routerHostnameDescribe).Methods("GET"), "Describe the hostname", nil, Describe{})
+	openapi.Describe(s.HandleFunc("/update", routerSetHostname).Methods("POST"), "Set the hostname", Hostname{}, nil)
+
+	events.RegisterSource(events.TopicHostname, WatchHostname)
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -20,7 +21,7 @@ func routerAcquireUserList(w http.ResponseWriter, r *http.Request) {
 func routerAcquireUser(w http.ResponseWriter, r *http.Request) {
 	u, err := decodeUserJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -38,7 +39,7 @@ func routerAcquireSessionList(w http.ResponseWriter, r *http.Request) {
 func routerAcquireSession(w http.ResponseWriter, r *http.Request) {
 	s, err := decodeSessionJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -50,8 +51,8 @@ func routerAcquireSession(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterLogin(router *mux.Router) {
 	s := router.PathPrefix("/login").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/listusers", routerAcquireUserList).Methods("GET")
-	s.HandleFunc("/listsessions", routerAcquireSessionList).Methods("GET")
-	s.HandleFunc("/getsession", routerAcquireSession).Methods("GET")
-	s.HandleFunc("/getuser", routerAcquireUser).Methods("GET")
+	openapi.Describe(s.HandleFunc("/listusers", routerAcquireUserList).Methods("GET"), "List the logged in users", nil, nil)
+	openapi.Describe(s.HandleFunc("/listsessions", routerAcquireSessionList).Methods("GET"), "List the sessions", nil, nil)
+	openapi.Describe(s.HandleFunc("/getsession", routerAcquireSession).Methods("GET"), "Show a session", Session{}, nil)
+	openapi.Describe(s.HandleFunc("/getuser", routerAcquireUser).Methods("GET"), "Show a logged in user", User{}, nil)
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 	"github.com/vmware/pmd-next-gen/plugins/management/group"
 	"github.com/vmware/pmd-next-gen/plugins/management/hostname"
@@ -115,5 +116,5 @@ func RegisterRouterManagement(router *mux.Router) {
 
 	sysctl.RegisterRouterSysctl(n)
 
-	n.HandleFunc("/describe", routerDescribeSystem).Methods("GET")
+	openapi.Describe(n.HandleFunc("/describe", routerDescribeSystem).Methods("GET"), "Describe the system", nil, Describe{})
 }
@@ -147,7 +147,7 @@ func getKeyValueFromProcSys(key string, sysctlMap map[string]string) error {
 // sysctl.conf, sysctl.d or /proc/sys
 func (s *Sysctl) Acquire(w http.ResponseWriter) error {
 	if len(s.Key) == 0 {
-		return fmt.Errorf("Failed to acquire sysctl parameter. Input key missing")
+		return web.NewError(web.ErrInvalidArgument, "Failed to acquire sysctl parameter. Input key missing").WithField("Key", "", "required")
 	}
 
 	sysctlMap := make(map[string]string)
@@ -187,7 +187,7 @@ func (s *Sysctl) GetPattern(w http.ResponseWriter) error {
 
 	re, err := regexp.CompilePOSIX(s.Pattern)
 	if err != nil {
-		return fmt.Errorf("Failed to acquire sysctl parameter, Invalid pattern='%s': %v", s.Pattern, err)
+		return web.NewError(web.ErrInvalidArgument, "Failed to acquire sysctl parameter, Invalid pattern='%s': %v", s.Pattern, err).WithField("Pattern", s.Pattern, err.Error())
 	}
 
 	sysctlMap := make(map[string]string)
@@ -225,12 +225,12 @@ func (s *Sysctl) Update(w http.ResponseWriter) error {
 
 	if validator.IsEmpty(s.Key) {
 		log.Errorf("input Key is missing in json data")
-		return fmt.Errorf("input Key is missing in json data")
+		return web.NewError(web.ErrInvalidArgument, "input Key is missing in json data").WithField("Key", "", "required")
 	}
 
 	if validator.IsEmpty(s.Value) {
 		log.Errorf("input Value is missing in json data")
-		return fmt.Errorf("input Value is missing in json data")
+		return web.NewError(web.ErrInvalidArgument, "input Value is missing in json data").WithField("Value", "", "required")
 	}
 
 	sysctlMap := make(map[string]string)
@@ -243,7 +243,7 @@ func (s *Sysctl) Update(w http.ResponseWriter) error {
 		_, ok := sysctlMap[s.Key]
 		if !ok {
 			log.Errorf("Failed to remove sysctl parameter '%s'. Key not found", s.Key)
-			return fmt.Errorf("Failed to remove sysctl parameter '%s'. Key not found", s.Key)
+			return web.NewError(web.ErrNotFound, "Failed to remove sysctl parameter '%s'. Key not found", s.Key)
 		}
 		delete(sysctlMap, s.Key)
 	} else {
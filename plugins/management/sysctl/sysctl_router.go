@@ -8,13 +8,14 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerAcquireSysctl(w http.ResponseWriter, r *http.Request) {
 	s := Sysctl{}
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -26,7 +27,7 @@ func routerAcquireSysctl(w http.ResponseWriter, r *http.Request) {
 func routerAcquireSysctlPattern(w http.ResponseWriter, r *http.Request) {
 	s := Sysctl{}
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -48,7 +49,7 @@ func routerAcquireSysctlAll(w http.ResponseWriter, r *http.Request) {
 func routerUpdateSysctl(w http.ResponseWriter, r *http.Request) {
 	s := Sysctl{}
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -63,7 +64,7 @@ func routerUpdateSysctl(w http.ResponseWriter, r *http.Request) {
 func routerRemoveSysctl(w http.ResponseWriter, r *http.Request) {
 	s := Sysctl{}
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -79,7 +80,7 @@ func routerRemoveSysctl(w http.ResponseWriter, r *http.Request) {
 func routerSysctlLoad(w http.ResponseWriter, r *http.Request) {
 	s := new(Sysctl)
 	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -95,10 +96,10 @@ func routerSysctlLoad(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterSysctl(router *mux.Router) {
 	s := router.PathPrefix("/sysctl").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/status", routerAcquireSysctl).Methods("GET")
-	s.HandleFunc("/statusall", routerAcquireSysctlAll).Methods("GET")
-	s.HandleFunc("/statuspattern", routerAcquireSysctlPattern).Methods("GET")
-	s.HandleFunc("/update", routerUpdateSysctl).Methods("POST")
-	s.HandleFunc("/remove", routerRemoveSysctl).Methods("DELETE")
-	s.HandleFunc("/load", routerSysctlLoad).Methods("POST")
+	openapi.Describe(s.HandleFunc("/status", routerAcquireSysctl).Methods("GET"), "Show a kernel parameter", Sysctl{}, nil)
+	openapi.Describe(s.HandleFunc("/statusall", routerAcquireSysctlAll).Methods("GET"), "Show all kernel parameters", nil, nil)
+	openapi.Describe(s.HandleFunc("/statuspattern", routerAcquireSysctlPattern).Methods("GET"), "Show the kernel parameters matching a pattern", Sysctl{}, nil)
+	openapi.Describe(s.HandleFunc("/update", routerUpdateSysctl).Methods("POST"), "Set a kernel parameter", Sysctl{}, nil)
+	openapi.Describe(s.HandleFunc("/remove", routerRemoveSysctl).Methods("DELETE"), "Remove a kernel parameter", Sysctl{}, nil)
+	openapi.Describe(s.HandleFunc("/load", routerSysctlLoad).Methods("POST"), "Load kernel parameters from files", Sysctl{}, nil)
 }
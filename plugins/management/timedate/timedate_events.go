@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package timedate
+
+import (
+	"context"
+
+	"github.com/vmware/pmd-next-gen/pkg/bus"
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+// WatchTimeDate publishes the property changes of systemd-timedated until ctx
+// is cancelled.
+func WatchTimeDate(ctx context.Context) error {
+	return bus.WatchPropertiesChanged(ctx, dbusPath, func(p *bus.PropertiesChanged) {
+		events.Publish(events.TopicTimedate, p.Changed)
+	})
+}
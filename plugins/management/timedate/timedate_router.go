@@ -8,6 +8,8 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -20,7 +22,7 @@ func routerAcquireTimeDate(w http.ResponseWriter, r *http.Request) {
 func routerSetTimeDate(w http.ResponseWriter, r *http.Request) {
 	t := TimeDate{}
 	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -32,6 +34,8 @@ func routerSetTimeDate(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterTimeDate(router *mux.Router) {
 	t := router.PathPrefix("/timedate").Subrouter().StrictSlash(false)
 
-	t.HandleFunc("/describe", routerAcquireTimeDate).Methods("GET")
-	t.HandleFunc("/configure", routerSetTimeDate).Methods("POST")
+	openapi.Describe(t.HandleFunc("/describe", routerAcquireTimeDate).Methods("GET"), "Describe time and date settings", nil, Describe{})
+	openapi.Describe(t.HandleFunc("/configure", routerSetTimeDate).Methods("POST"), "Configure time and date", TimeDate{}, nil)
+
+	events.RegisterSource(events.TopicTimedate, WatchTimeDate)
 }
@@ -9,13 +9,14 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerAddUser(w http.ResponseWriter, r *http.Request) {
 	u := User{}
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -27,7 +28,7 @@ func routerAddUser(w http.ResponseWriter, r *http.Request) {
 func routerModifyUser(w http.ResponseWriter, r *http.Request) {
 	u := User{}
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -39,7 +40,7 @@ func routerModifyUser(w http.ResponseWriter, r *http.Request) {
 func routerRemoveUser(w http.ResponseWriter, r *http.Request) {
 	u := User{}
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -58,8 +59,8 @@ func routerViewUsers(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterUser(router *mux.Router) {
 	s := router.PathPrefix("/user").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/add", routerAddUser).Methods("POST")
-	s.HandleFunc("/remove", routerRemoveUser).Methods("DELETE")
-	s.HandleFunc("/modify", routerModifyUser).Methods("PUT")
-	s.HandleFunc("/view", routerViewUsers).Methods("GET")
+	openapi.Describe(s.HandleFunc("/add", routerAddUser).Methods("POST"), "Add a user", User{}, nil)
+	openapi.Describe(s.HandleFunc("/remove", routerRemoveUser).Methods("DELETE"), "Remove a user", User{}, nil)
+	openapi.Describe(s.HandleFunc("/modify", routerModifyUser).Methods("PUT"), "Modify a user", User{}, nil)
+	openapi.Describe(s.HandleFunc("/view", routerViewUsers).Methods("GET"), "List the users", nil, nil)
 }
@@ -0,0 +1,273 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bond
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// BondSlave names a link to enslave to, release from or make the active
+// slave of a bond.
+type BondSlave struct {
+	Link string `json:"Link"`
+}
+
+type SlaveInfo struct {
+	Name              string    `json:"Name"`
+	Index             int       `json:"Index"`
+	State             string    `json:"State"`
+	MIIStatus         string    `json:"MIIStatus"`
+	Speed             string    `json:"Speed"`
+	Duplex            string    `json:"Duplex"`
+	LinkFailureCount  uint32    `json:"LinkFailureCount"`
+	PermHardwareAddr  string    `json:"PermHardwareAddr"`
+	QueueId           uint16    `json:"QueueId"`
+	AggregatorId      uint16    `json:"AggregatorId"`
+	ActorChurnState   string    `json:"ActorChurnState"`
+	PartnerChurnState string    `json:"PartnerChurnState"`
+	Actor             *LACPPort `json:"Actor"`
+	Partner           *LACPPort `json:"Partner"`
+}
+
+type BondInfo struct {
+	Name               string          `json:"Name"`
+	Index              int             `json:"Index"`
+	OperState          string          `json:"OperState"`
+	Mode               string          `json:"Mode"`
+	MIIStatus          string          `json:"MIIStatus"`
+	MIIMonitorMSec     int             `json:"MIIMonitorMSec"`
+	UpDelayMSec        int             `json:"UpDelayMSec"`
+	DownDelayMSec      int             `json:"DownDelayMSec"`
+	TransmitHashPolicy string          `json:"TransmitHashPolicy"`
+	LACPTransmitRate   string          `json:"LACPTransmitRate"`
+	AdSelect           string          `json:"AdSelect"`
+	MinLinks           int             `json:"MinLinks"`
+	PrimarySlave       string          `json:"PrimarySlave"`
+	ActiveSlave        string          `json:"ActiveSlave"`
+	SystemPriority     string          `json:"SystemPriority"`
+	SystemMACAddress   string          `json:"SystemMACAddress"`
+	Aggregator         *AggregatorInfo `json:"Aggregator"`
+	Slaves             []SlaveInfo     `json:"Slaves"`
+}
+
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func acquireBond(name string) (*netlink.Bond, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	b, ok := l.(*netlink.Bond)
+	if !ok {
+		return nil, web.NewError(web.ErrInvalidArgument, "link='%s' is not a bond", name).WithField("Name", name, "not a bond")
+	}
+
+	return b, nil
+}
+
+func acquireSlave(b *netlink.Bond, name string) (netlink.Link, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.Attrs().MasterIndex != b.Index {
+		return nil, web.NewError(web.ErrInvalidArgument, "link='%s' is not a slave of bond='%s'", name, b.Name).WithField("Link", name, "not a slave")
+	}
+
+	return l, nil
+}
+
+// Enslave adds the link to the bond. The kernel refuses links which are up,
+// so the link is brought down first and comes up again with the bond.
+func (s *BondSlave) Enslave(bond string) error {
+	b, err := acquireBond(bond)
+	if err != nil {
+		return err
+	}
+
+	l, err := link.AcquireLinkByName(s.Link)
+	if err != nil {
+		return err
+	}
+
+	if l.Attrs().MasterIndex == b.Index {
+		return nil
+	}
+	if l.Attrs().MasterIndex != 0 {
+		return web.NewError(web.ErrConflict, "link='%s' is already enslaved", s.Link).WithField("Link", s.Link, "already enslaved")
+	}
+
+	if err := netlink.LinkSetDown(l); err != nil {
+		log.Errorf("Failed to set link='%s' down: %v", s.Link, err)
+		return err
+	}
+
+	if err := netlink.LinkSetMaster(l, b); err != nil {
+		log.Errorf("Failed to enslave link='%s' to bond='%s': %v", s.Link, bond, err)
+		if l.Attrs().Flags&net.FlagUp != 0 {
+			if err := netlink.LinkSetUp(l); err != nil {
+				log.Errorf("Failed to set link='%s' up again: %v", s.Link, err)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *BondSlave) Release(bond string) error {
+	b, err := acquireBond(bond)
+	if err != nil {
+		return err
+	}
+
+	l, err := acquireSlave(b, s.Link)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.LinkSetNoMaster(l); err != nil {
+		log.Errorf("Failed to release link='%s' from bond='%s': %v", s.Link, bond, err)
+		return err
+	}
+
+	return nil
+}
+
+// SetActive makes the link the active slave of a bond in a mode with one,
+// active-backup, balance-tlb or balance-alb.
+func (s *BondSlave) SetActive(bond string) error {
+	b, err := acquireBond(bond)
+	if err != nil {
+		return err
+	}
+
+	switch b.Mode {
+	case netlink.BOND_MODE_ACTIVE_BACKUP, netlink.BOND_MODE_BALANCE_TLB, netlink.BOND_MODE_BALANCE_ALB:
+	default:
+		return web.NewError(web.ErrInvalidArgument, "bond='%s' in mode='%s' has no active slave", bond, b.Mode.String())
+	}
+
+	l, err := acquireSlave(b, s.Link)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.LinkSetBondSlaveActive(l, b); err != nil {
+		log.Errorf("Failed to set active slave link='%s' of bond='%s': %v", s.Link, bond, err)
+		return err
+	}
+
+	return nil
+}
+
+// AcquireBond describes a bond and its slaves from its netlink attributes,
+// completed with what only the status file of the driver tells: the MII
+// status, speed and duplex of the slaves and the LACP partner details.
+func AcquireBond(name string) (*BondInfo, error) {
+	b, err := acquireBond(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info := BondInfo{
+		Name:               b.Name,
+		Index:              b.Index,
+		OperState:          b.OperState.String(),
+		Mode:               b.Mode.String(),
+		MIIMonitorMSec:     b.Miimon,
+		UpDelayMSec:        b.UpDelay,
+		DownDelayMSec:      b.DownDelay,
+		TransmitHashPolicy: b.XmitHashPolicy.String(),
+		LACPTransmitRate:   b.LacpRate.String(),
+		AdSelect:           b.AdSelect.String(),
+		MinLinks:           b.MinLinks,
+		Slaves:             []SlaveInfo{},
+	}
+	if b.ActiveSlave > 0 {
+		if l, err := netlink.LinkByIndex(b.ActiveSlave); err == nil {
+			info.ActiveSlave = l.Attrs().Name
+		}
+	}
+	if b.AdInfo != nil {
+		info.Aggregator = &AggregatorInfo{
+			AggregatorId:      strconv.Itoa(b.AdInfo.AggregatorId),
+			NumberOfPorts:     strconv.Itoa(b.AdInfo.NumPorts),
+			ActorKey:          strconv.Itoa(b.AdInfo.ActorKey),
+			PartnerKey:        strconv.Itoa(b.AdInfo.PartnerKey),
+			PartnerMACAddress: b.AdInfo.PartnerMac.String(),
+		}
+	}
+
+	p, err := acquireProcBonding(name)
+	if err != nil {
+		log.Errorf("Failed to read bonding status of bond='%s': %v", name, err)
+		return nil, err
+	}
+	info.MIIStatus = p.miiStatus
+	info.PrimarySlave = p.primarySlave
+	info.SystemPriority = p.systemPriority
+	info.SystemMACAddress = p.systemMACAddress
+	if info.ActiveSlave == "" {
+		info.ActiveSlave = p.activeSlave
+	}
+	if p.aggregator != nil {
+		info.Aggregator = p.aggregator
+	}
+
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, err
+	}
+
+	slaves := map[string]*procSlave{}
+	for _, s := range p.slaves {
+		slaves[s.name] = s
+	}
+
+	for _, l := range links {
+		if l.Attrs().MasterIndex != b.Index {
+			continue
+		}
+
+		s := SlaveInfo{
+			Name:  l.Attrs().Name,
+			Index: l.Attrs().Index,
+		}
+		if bs, ok := l.Attrs().Slave.(*netlink.BondSlave); ok {
+			s.State = bs.State.String()
+			s.MIIStatus = bs.MiiStatus.String()
+			s.LinkFailureCount = bs.LinkFailureCount
+			s.PermHardwareAddr = bs.PermHardwareAddr.String()
+			s.QueueId = bs.QueueId
+			s.AggregatorId = bs.AggregatorId
+		}
+		if ps, ok := slaves[s.Name]; ok {
+			s.MIIStatus = ps.miiStatus
+			s.Speed = ps.speed
+			s.Duplex = ps.duplex
+			s.ActorChurnState = ps.actorChurnState
+			s.PartnerChurnState = ps.partnerChurnState
+			s.Actor = ps.actor
+			s.Partner = ps.partner
+		}
+
+		info.Slaves = append(info.Slaves, s)
+	}
+
+	return &info, nil
+}
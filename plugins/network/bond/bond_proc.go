@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bond
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// procBondingPath holds a status file per bond, written by the driver.
+const procBondingPath = "/proc/net/bonding"
+
+// LACPPort is the LACPDU information of an actor or partner port.
+type LACPPort struct {
+	SystemPriority   string `json:"SystemPriority"`
+	SystemMACAddress string `json:"SystemMACAddress"`
+	Key              string `json:"Key"`
+	PortPriority     string `json:"PortPriority"`
+	PortNumber       string `json:"PortNumber"`
+	PortState        string `json:"PortState"`
+}
+
+type AggregatorInfo struct {
+	AggregatorId      string `json:"AggregatorId"`
+	NumberOfPorts     string `json:"NumberOfPorts"`
+	ActorKey          string `json:"ActorKey"`
+	PartnerKey        string `json:"PartnerKey"`
+	PartnerMACAddress string `json:"PartnerMACAddress"`
+}
+
+type procSlave struct {
+	name              string
+	miiStatus         string
+	speed             string
+	duplex            string
+	actorChurnState   string
+	partnerChurnState string
+	actor             *LACPPort
+	partner           *LACPPort
+}
+
+type procBond struct {
+	miiStatus        string
+	primarySlave     string
+	activeSlave      string
+	systemPriority   string
+	systemMACAddress string
+	aggregator       *AggregatorInfo
+	slaves           []*procSlave
+}
+
+func setLACPPort(p *LACPPort, key string, value string) {
+	switch key {
+	case "system priority":
+		p.SystemPriority = value
+	case "system mac address":
+		p.SystemMACAddress = value
+	case "port key", "oper key":
+		p.Key = value
+	case "port priority":
+		p.PortPriority = value
+	case "port number":
+		p.PortNumber = value
+	case "port state":
+		p.PortState = value
+	}
+}
+
+func setAggregator(a *AggregatorInfo, key string, value string) {
+	switch key {
+	case "Aggregator ID":
+		a.AggregatorId = value
+	case "Number of ports":
+		a.NumberOfPorts = value
+	case "Actor Key":
+		a.ActorKey = value
+	case "Partner Key":
+		a.PartnerKey = value
+	case "Partner Mac Address":
+		a.PartnerMACAddress = value
+	}
+}
+
+// parseProcBonding parses the status file of a bond. The bond comes first,
+// then a block per slave starting at "Slave Interface". Indented lines
+// belong to the block opened by the line before them, as the active
+// aggregator or the LACPDU details of a slave.
+func parseProcBonding(lines []string) *procBond {
+	b := procBond{}
+
+	var slave *procSlave
+	var aggregator *AggregatorInfo
+	var port *LACPPort
+	for _, line := range lines {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		indented := strings.HasPrefix(key, " ") || strings.HasPrefix(key, "\t")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if indented {
+			switch {
+			case port != nil:
+				setLACPPort(port, key, value)
+			case aggregator != nil:
+				setAggregator(aggregator, key, value)
+			}
+			continue
+		}
+		aggregator, port = nil, nil
+
+		if key == "Slave Interface" {
+			slave = &procSlave{name: value}
+			b.slaves = append(b.slaves, slave)
+			continue
+		}
+
+		if slave == nil {
+			switch key {
+			case "MII Status":
+				b.miiStatus = value
+			case "Primary Slave":
+				if value != "None" {
+					b.primarySlave = value
+				}
+			case "Currently Active Slave":
+				if value != "None" {
+					b.activeSlave = value
+				}
+			case "System priority":
+				b.systemPriority = value
+			case "System MAC address":
+				b.systemMACAddress = value
+			case "Active Aggregator Info":
+				b.aggregator = &AggregatorInfo{}
+				aggregator = b.aggregator
+			}
+			continue
+		}
+
+		switch key {
+		case "MII Status":
+			slave.miiStatus = value
+		case "Speed":
+			slave.speed = value
+		case "Duplex":
+			slave.duplex = value
+		case "Actor Churn State":
+			slave.actorChurnState = value
+		case "Partner Churn State":
+			slave.partnerChurnState = value
+		case "details actor lacp pdu":
+			slave.actor = &LACPPort{}
+			port = slave.actor
+		case "details partner lacp pdu":
+			slave.partner = &LACPPort{}
+			port = slave.partner
+		}
+	}
+
+	return &b
+}
+
+func acquireProcBonding(name string) (*procBond, error) {
+	// Read as is, the indentation of the lines tells the blocks apart.
+	b, err := os.ReadFile(filepath.Join(procBondingPath, name))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseProcBonding(strings.Split(string(b), "\n")), nil
+}
@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bond
+
+import (
+	"strings"
+	"testing"
+)
+
+// An 802.3ad bond of two slaves, as written by the driver to
+// /proc/net/bonding.
+const procBonding8023ad = `Ethernet Channel Bonding Driver: v6.1.0
+
+Bonding Mode: IEEE 802.3ad Dynamic link aggregation
+Transmit Hash Policy: layer3+4 (1)
+MII Status: up
+MII Polling Interval (ms): 100
+Up Delay (ms): 0
+Down Delay (ms): 0
+Peer Notification Delay (ms): 0
+
+802.3ad info
+LACP active: on
+LACP rate: fast
+Min links: 0
+Aggregator selection policy (ad_select): stable
+System priority: 65535
+System MAC address: 52:54:00:8a:5f:01
+Active Aggregator Info:
+	Aggregator ID: 1
+	Number of ports: 2
+	Actor Key: 9
+	Partner Key: 14
+	Partner Mac Address: 00:1c:73:aa:bb:cc
+
+Slave Interface: eth1
+MII Status: up
+Speed: 1000 Mbps
+Duplex: full
+Link Failure Count: 0
+Permanent HW addr: 52:54:00:8a:5f:01
+Slave queue ID: 0
+Aggregator ID: 1
+Actor Churn State: none
+Partner Churn State: none
+Actor Churned Count: 0
+Partner Churned Count: 0
+details actor lacp pdu:
+    system priority: 65535
+    system mac address: 52:54:00:8a:5f:01
+    port key: 9
+    port priority: 255
+    port number: 1
+    port state: 63
+details partner lacp pdu:
+    system priority: 32768
+    system mac address: 00:1c:73:aa:bb:cc
+    oper key: 14
+    port priority: 32768
+    port number: 17
+    port state: 61
+
+Slave Interface: eth2
+MII Status: down
+Speed: Unknown
+Duplex: Unknown
+Link Failure Count: 1
+Permanent HW addr: 52:54:00:8a:5f:02
+Slave queue ID: 0
+Aggregator ID: 2
+Actor Churn State: churned
+Partner Churn State: churned
+Actor Churned Count: 1
+Partner Churned Count: 1
+details actor lacp pdu:
+    system priority: 65535
+    system mac address: 52:54:00:8a:5f:01
+    port key: 0
+    port priority: 255
+    port number: 2
+    port state: 69
+details partner lacp pdu:
+    system priority: 65535
+    system mac address: 00:00:00:00:00:00
+    oper key: 1
+    port priority: 255
+    port number: 1
+    port state: 1
+`
+
+const procBondingActiveBackup = `Ethernet Channel Bonding Driver: v6.1.0
+
+Bonding Mode: fault-tolerance (active-backup)
+Primary Slave: None
+Currently Active Slave: eth2
+MII Status: up
+MII Polling Interval (ms): 100
+Up Delay (ms): 0
+Down Delay (ms): 0
+Peer Notification Delay (ms): 0
+
+Slave Interface: eth1
+MII Status: down
+Speed: Unknown
+Duplex: Unknown
+Link Failure Count: 3
+Permanent HW addr: 52:54:00:8a:5f:01
+Slave queue ID: 0
+
+Slave Interface: eth2
+MII Status: up
+Speed: 10000 Mbps
+Duplex: full
+Link Failure Count: 0
+Permanent HW addr: 52:54:00:8a:5f:02
+Slave queue ID: 0
+`
+
+func TestParseProcBonding8023ad(t *testing.T) {
+	b := parseProcBonding(strings.Split(procBonding8023ad, "\n"))
+
+	if b.miiStatus != "up" || b.systemPriority != "65535" || b.systemMACAddress != "52:54:00:8a:5f:01" {
+		t.Fatalf("Invalid bond: %+v", b)
+	}
+	if b.primarySlave != "" || b.activeSlave != "" {
+		t.Fatalf("Unexpected active-backup settings: %+v", b)
+	}
+
+	a := b.aggregator
+	if a == nil {
+		t.Fatalf("Missing active aggregator")
+	}
+	if *a != (AggregatorInfo{AggregatorId: "1", NumberOfPorts: "2", ActorKey: "9", PartnerKey: "14", PartnerMACAddress: "00:1c:73:aa:bb:cc"}) {
+		t.Fatalf("Invalid active aggregator: %+v", *a)
+	}
+
+	if len(b.slaves) != 2 {
+		t.Fatalf("Expected 2 slaves, got %d", len(b.slaves))
+	}
+
+	for _, c := range []struct {
+		name              string
+		miiStatus         string
+		speed             string
+		duplex            string
+		actorChurnState   string
+		partnerChurnState string
+		actor             LACPPort
+		partner           LACPPort
+	}{
+		{
+			"eth1", "up", "1000 Mbps", "full", "none", "none",
+			LACPPort{"65535", "52:54:00:8a:5f:01", "9", "255", "1", "63"},
+			LACPPort{"32768", "00:1c:73:aa:bb:cc", "14", "32768", "17", "61"},
+		},
+		{
+			"eth2", "down", "Unknown", "Unknown", "churned", "churned",
+			LACPPort{"65535", "52:54:00:8a:5f:01", "0", "255", "2", "69"},
+			LACPPort{"65535", "00:00:00:00:00:00", "1", "255", "1", "1"},
+		},
+	} {
+		var s *procSlave
+		for _, e := range b.slaves {
+			if e.name == c.name {
+				s = e
+			}
+		}
+		if s == nil {
+			t.Fatalf("Missing slave='%s'", c.name)
+		}
+
+		if s.miiStatus != c.miiStatus || s.speed != c.speed || s.duplex != c.duplex ||
+			s.actorChurnState != c.actorChurnState || s.partnerChurnState != c.partnerChurnState {
+			t.Fatalf("Invalid slave='%s': %+v", c.name, s)
+		}
+		if s.actor == nil || *s.actor != c.actor {
+			t.Fatalf("Invalid actor of slave='%s': %+v", c.name, s.actor)
+		}
+		if s.partner == nil || *s.partner != c.partner {
+			t.Fatalf("Invalid partner of slave='%s': %+v", c.name, s.partner)
+		}
+	}
+}
+
+func TestParseProcBondingActiveBackup(t *testing.T) {
+	b := parseProcBonding(strings.Split(procBondingActiveBackup, "\n"))
+
+	if b.miiStatus != "up" || b.primarySlave != "" || b.activeSlave != "eth2" || b.aggregator != nil {
+		t.Fatalf("Invalid bond: %+v", b)
+	}
+	if len(b.slaves) != 2 {
+		t.Fatalf("Expected 2 slaves, got %d", len(b.slaves))
+	}
+
+	if s := b.slaves[0]; s.name != "eth1" || s.miiStatus != "down" || s.speed != "Unknown" || s.actor != nil || s.partner != nil {
+		t.Fatalf("Invalid slave='eth1': %+v", s)
+	}
+	if s := b.slaves[1]; s.name != "eth2" || s.miiStatus != "up" || s.speed != "10000 Mbps" || s.duplex != "full" {
+		t.Fatalf("Invalid slave='eth2': %+v", s)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bond
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerAcquireBond(w http.ResponseWriter, r *http.Request) {
+	b, err := AcquireBond(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(b, w)
+}
+
+func routerConfigureSlave(w http.ResponseWriter, r *http.Request) {
+	s := BondSlave{}
+	if err := decodeJSONRequest(r, &s); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = s.Release(mux.Vars(r)["name"])
+	} else {
+		err = s.Enslave(mux.Vars(r)["name"])
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("bond slave configured", w)
+}
+
+func routerSetActiveSlave(w http.ResponseWriter, r *http.Request) {
+	s := BondSlave{}
+	if err := decodeJSONRequest(r, &s); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := s.SetActive(mux.Vars(r)["name"]); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("active slave configured", w)
+}
+
+func RegisterRouterBond(router *mux.Router) {
+	s := router.PathPrefix("/bond").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/{name}", routerAcquireBond).Methods("GET"), "Describe a bond and its slaves", nil, BondInfo{})
+	openapi.Describe(s.HandleFunc("/{name}/slave", routerConfigureSlave).Methods("POST"), "Enslave a link to a bond", BondSlave{}, nil)
+	openapi.Describe(s.HandleFunc("/{name}/slave", routerConfigureSlave).Methods("DELETE"), "Release a link from a bond", BondSlave{}, nil)
+	openapi.Describe(s.HandleFunc("/{name}/active-slave", routerSetActiveSlave).Methods("PUT"), "Change the active slave of a bond", BondSlave{}, nil)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -58,7 +59,7 @@ func routerConfigureEthTool(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterEthTool(n *mux.Router) {
 	e := n.PathPrefix("/ethtool").Subrouter().StrictSlash(false)
 
-	e.HandleFunc("/{link}", routerAcquirEthTool).Methods("GET")
-	e.HandleFunc("/{link}/{property}", routerAcquirActionEthTool).Methods("GET")
-	e.HandleFunc("/{link}/{command}", routerConfigureEthTool).Methods("POST")
+	openapi.Describe(e.HandleFunc("/{link}", routerAcquirEthTool).Methods("GET"), "Show the ethtool settings of a link", nil, nil)
+	openapi.Describe(e.HandleFunc("/{link}/{property}", routerAcquirActionEthTool).Methods("GET"), "Show an ethtool property of a link", nil, nil)
+	openapi.Describe(e.HandleFunc("/{link}/{command}", routerConfigureEthTool).Methods("POST"), "Configure a link with ethtool", Ethtool{}, nil)
 }
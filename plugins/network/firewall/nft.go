@@ -37,6 +37,7 @@ type Chain struct {
 type Nft struct {
 	Table   Table    `json:"Table"`
 	Chain   Chain    `json:"Chain"`
+	Rule    Rule     `json:"Rule"`
 	Command []string `json:"Command"`
 }
 
@@ -180,14 +181,14 @@ func getChainsAndCreateMap(chainMap map[string]*nftables.Chain) error {
 func (n *Nft) ParseTable(tbl *nftables.Table) error {
 	if validator.IsEmpty(n.Table.Name) {
 		log.Errorf("Failed to add nft table, Missing table name")
-		return fmt.Errorf("missing table name")
+		return web.NewError(web.ErrInvalidArgument, "missing table name").WithField("Name", "", "required")
 	}
 	tbl.Name = n.Table.Name
 
 	if !validator.IsEmpty(n.Table.Family) {
 		if !validator.IsNFTFamily(n.Table.Family) {
 			log.Errorf("Failed to add nft table, Invalid family")
-			return fmt.Errorf("Invalid family")
+			return web.InvalidArgument("Family", n.Table.Family)
 		}
 	} else {
 		n.Table.Family = "ipv4"
@@ -246,7 +247,7 @@ func (n *Nft) ShowTable(w http.ResponseWriter) error {
 		key := createTableMapKey(n.Table.Name, convertToUnixFamily(n.Table.Family))
 		v, ok := tableMap[key]
 		if !ok {
-			return fmt.Errorf("Table not found='%s'", n.Table.Name)
+			return web.NewError(web.ErrNotFound, "table='%s' not found", n.Table.Name)
 		}
 		result := make(map[string]*nftables.Table)
 		result[n.Table.Name] = v
@@ -259,19 +260,19 @@ func (n *Nft) ShowTable(w http.ResponseWriter) error {
 func (n *Nft) ParseChain(ch *nftables.Chain) error {
 	if validator.IsEmpty(n.Chain.Name) {
 		log.Errorf("Failed to add nft chain, Missing chain name")
-		return fmt.Errorf("missing chain name")
+		return web.NewError(web.ErrInvalidArgument, "missing chain name").WithField("Name", "", "required")
 	}
 	ch.Name = n.Chain.Name
 
 	if validator.IsEmpty(n.Chain.Table) {
 		log.Errorf("Failed to add nft chain, Missing table name")
-		return fmt.Errorf("missing table name")
+		return web.NewError(web.ErrInvalidArgument, "missing table name").WithField("Table", "", "required")
 	}
 
 	if !validator.IsEmpty(n.Chain.Family) {
 		if !validator.IsNFTFamily(n.Chain.Family) {
 			log.Errorf("Failed to add nft chain, Invalid family")
-			return fmt.Errorf("invalid family: '%s'", n.Chain.Family)
+			return web.InvalidArgument("Family", n.Chain.Family)
 		}
 	} else {
 		n.Chain.Family = "ipv4"
@@ -280,7 +281,7 @@ func (n *Nft) ParseChain(ch *nftables.Chain) error {
 	if !validator.IsEmpty(n.Chain.Hook) {
 		if !validator.IsNFTChainHook(n.Chain.Hook) {
 			log.Errorf("Failed to add nft chain, Invalid hook")
-			return fmt.Errorf("invalid hook: '%s'", n.Chain.Hook)
+			return web.InvalidArgument("Hook", n.Chain.Hook)
 		}
 		ch.Hooknum = convertToUnixHook(n.Chain.Hook)
 	}
@@ -288,7 +289,7 @@ func (n *Nft) ParseChain(ch *nftables.Chain) error {
 	if !validator.IsEmpty(n.Chain.Type) {
 		if !validator.IsNFTChainType(n.Chain.Type) {
 			log.Errorf("Failed to add nft chain, Invalid type")
-			return fmt.Errorf("invalid type: '%s'", n.Chain.Type)
+			return web.InvalidArgument("Type", n.Chain.Type)
 		}
 		ch.Type = nftables.ChainType(n.Chain.Type)
 	}
@@ -297,7 +298,7 @@ func (n *Nft) ParseChain(ch *nftables.Chain) error {
 		v, err := validator.IsInt(n.Chain.Priority)
 		if err != nil {
 			log.Errorf("Failed to add nft chain, Invalid priority")
-			return fmt.Errorf("invalid priority: '%s'", n.Chain.Priority)
+			return web.InvalidArgument("Priority", n.Chain.Priority)
 		}
 		ch.Priority = nftables.ChainPriorityRef(nftables.ChainPriority(v))
 	}
@@ -305,7 +306,7 @@ func (n *Nft) ParseChain(ch *nftables.Chain) error {
 	if !validator.IsEmpty(n.Chain.Policy) {
 		if !validator.IsNFTChainPolicy(n.Chain.Policy) {
 			log.Errorf("Failed to add nft chain, Invalid policy")
-			return fmt.Errorf("invalid policy: '%s'", n.Chain.Policy)
+			return web.InvalidArgument("Policy", n.Chain.Policy)
 		}
 		ch.Policy = convertToUnixPolicy(n.Chain.Policy)
 	}
@@ -330,7 +331,7 @@ func (n *Nft) AddChain(w http.ResponseWriter) error {
 	tbl, ok := tableMap[key]
 	if !ok {
 		log.Errorf("Failed to add chain='%s', table_family not found='%s'", key)
-		return fmt.Errorf("table family not found='%s'", key)
+		return web.NewError(web.ErrNotFound, "table family not found='%s'", key)
 	}
 	ch.Table = tbl
 
@@ -362,7 +363,7 @@ func (n *Nft) RemoveChain(w http.ResponseWriter) error {
 	v, ok := chainMap[key]
 	if !ok {
 		log.Errorf("Failed to delete chain='%s', table_chain_family not found='%s'", key)
-		return fmt.Errorf("table chain family not found='%s'", key)
+		return web.NewError(web.ErrNotFound, "table chain family not found='%s'", key)
 	}
 	ch.Table = v.Table
 
@@ -388,7 +389,7 @@ func (n *Nft) ShowChain(w http.ResponseWriter) error {
 		key := createChainMapKey(n.Chain.Table, n.Chain.Name, convertToUnixFamily(n.Chain.Family))
 		v, ok := chainMap[key]
 		if !ok {
-			return fmt.Errorf("chain not found='%s'", n.Chain.Name)
+			return web.NewError(web.ErrNotFound, "chain not found='%s'", n.Chain.Name)
 		}
 		result := make(map[string]*nftables.Chain)
 		result[n.Chain.Name] = v
@@ -413,10 +414,13 @@ func (n *Nft) SaveNFT(w http.ResponseWriter) error {
 	return web.JSONResponse("saved", w)
 }
 
+// RunNFT passes the command to nft as given. It is deprecated for the typed
+// table, chain and rule calls, and only the admin role may use it.
 func (n *Nft) RunNFT(w http.ResponseWriter) error {
 	args := strings.Join(n.Command, " ")
+	log.Warningf("Running deprecated nft passthrough command='nft %s'", args)
 
-	stdout, err := system.ExecAndCapture("nft", args)
+	stdout, err := system.ExecAndCapture("nft", n.Command...)
 	if err != nil {
 		log.Errorf("Failed to run command='nft %s', command output=%v", args, err)
 		return fmt.Errorf("Failed to acquire command output=%v", err)
@@ -8,13 +8,14 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerAddTable(w http.ResponseWriter, r *http.Request) {
 	t, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -26,7 +27,7 @@ func routerAddTable(w http.ResponseWriter, r *http.Request) {
 func routerRemoveTable(w http.ResponseWriter, r *http.Request) {
 	t, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -38,7 +39,7 @@ func routerRemoveTable(w http.ResponseWriter, r *http.Request) {
 func routerShowTable(w http.ResponseWriter, r *http.Request) {
 	t, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -50,7 +51,7 @@ func routerShowTable(w http.ResponseWriter, r *http.Request) {
 func routerAddChain(w http.ResponseWriter, r *http.Request) {
 	c, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -62,7 +63,7 @@ func routerAddChain(w http.ResponseWriter, r *http.Request) {
 func routerRemoveChain(w http.ResponseWriter, r *http.Request) {
 	c, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -74,7 +75,7 @@ func routerRemoveChain(w http.ResponseWriter, r *http.Request) {
 func routerShowChain(w http.ResponseWriter, r *http.Request) {
 	c, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -83,10 +84,46 @@ func routerShowChain(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func routerAddRule(w http.ResponseWriter, r *http.Request) {
+	n, err := decodeNftJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := n.AddRule(w); err != nil {
+		web.JSONResponseError(err, w)
+	}
+}
+
+func routerRemoveRule(w http.ResponseWriter, r *http.Request) {
+	n, err := decodeNftJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := n.RemoveRule(w); err != nil {
+		web.JSONResponseError(err, w)
+	}
+}
+
+func routerShowRule(w http.ResponseWriter, r *http.Request) {
+	n, err := decodeNftJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := n.ShowRule(w); err != nil {
+		web.JSONResponseError(err, w)
+	}
+}
+
 func routerSaveNFT(w http.ResponseWriter, r *http.Request) {
 	t, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -98,7 +135,7 @@ func routerSaveNFT(w http.ResponseWriter, r *http.Request) {
 func routerRunNFT(w http.ResponseWriter, r *http.Request) {
 	t, err := decodeNftJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -110,12 +147,15 @@ func routerRunNFT(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterNft(router *mux.Router) {
 	n := router.PathPrefix("/firewall/nft/").Subrouter().StrictSlash(false)
 
-	n.HandleFunc("/table/add", routerAddTable).Methods("POST")
-	n.HandleFunc("/table/remove", routerRemoveTable).Methods("DELETE")
-	n.HandleFunc("/table/show", routerShowTable).Methods("GET")
-	n.HandleFunc("/chain/add", routerAddChain).Methods("POST")
-	n.HandleFunc("/chain/remove", routerRemoveChain).Methods("DELETE")
-	n.HandleFunc("/chain/show", routerShowChain).Methods("GET")
-	n.HandleFunc("/save", routerSaveNFT).Methods("PUT")
-	n.HandleFunc("/run", routerRunNFT).Methods("POST")
+	openapi.Describe(n.HandleFunc("/table/add", routerAddTable).Methods("POST"), "Add a table", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/table/remove", routerRemoveTable).Methods("DELETE"), "Remove a table", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/table/show", routerShowTable).Methods("GET"), "Show tables", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/chain/add", routerAddChain).Methods("POST"), "Add a chain", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/chain/remove", routerRemoveChain).Methods("DELETE"), "Remove a chain", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/chain/show", routerShowChain).Methods("GET"), "Show chains", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/rule/add", routerAddRule).Methods("POST"), "Add a rule", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/rule/remove", routerRemoveRule).Methods("DELETE"), "Remove a rule by handle", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/rule/show", routerShowRule).Methods("GET"), "Show rules with handles and counters", Nft{}, []RuleInfo{})
+	openapi.Describe(n.HandleFunc("/save", routerSaveNFT).Methods("PUT"), "Save the ruleset", Nft{}, nil)
+	openapi.Describe(n.HandleFunc("/run", routerRunNFT).Methods("POST"), "Run an nft command. Deprecated and restricted to the admin role, use the table, chain and rule routes", Nft{}, nil)
 }
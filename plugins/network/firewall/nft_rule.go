@@ -0,0 +1,634 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package firewall
+
+import (
+	"bytes"
+	"math/bits"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+// Rule describes a rule by its matches followed by its statements. Matches
+// left empty are not added. Ports take a single port or a range such as
+// "1000-2000" and need Protocol to be tcp, udp or sctp.
+type Rule struct {
+	Table              string   `json:"Table"`
+	Family             string   `json:"Family"`
+	Chain              string   `json:"Chain"`
+	Handle             string   `json:"Handle"`
+	IncomingInterface  string   `json:"IncomingInterface"`
+	OutgoingInterface  string   `json:"OutgoingInterface"`
+	SourceAddress      string   `json:"SourceAddress"`
+	DestinationAddress string   `json:"DestinationAddress"`
+	Protocol           string   `json:"Protocol"`
+	SourcePort         string   `json:"SourcePort"`
+	DestinationPort    string   `json:"DestinationPort"`
+	CtState            []string `json:"CtState"`
+	Mark               string   `json:"Mark"`
+	Counter            bool     `json:"Counter"`
+	Log                bool     `json:"Log"`
+	LogPrefix          string   `json:"LogPrefix"`
+	Verdict            string   `json:"Verdict"`
+	JumpTarget         string   `json:"JumpTarget"`
+}
+
+// RuleInfo is a rule read back from the kernel. Partial is set when the rule
+// holds matches the fields of Rule cannot express, such as one added with nft;
+// the fields then describe only part of it.
+type RuleInfo struct {
+	Rule
+	Packets uint64 `json:"Packets"`
+	Bytes   uint64 `json:"Bytes"`
+	Partial bool   `json:"Partial"`
+}
+
+var (
+	protocolNumbers = map[string]byte{
+		"icmp":   unix.IPPROTO_ICMP,
+		"tcp":    unix.IPPROTO_TCP,
+		"udp":    unix.IPPROTO_UDP,
+		"icmpv6": unix.IPPROTO_ICMPV6,
+		"sctp":   unix.IPPROTO_SCTP,
+	}
+
+	ctStateBits = map[string]uint32{
+		"invalid":     expr.CtStateBitINVALID,
+		"established": expr.CtStateBitESTABLISHED,
+		"related":     expr.CtStateBitRELATED,
+		"new":         expr.CtStateBitNEW,
+		"untracked":   expr.CtStateBitUNTRACKED,
+	}
+)
+
+func ifname(name string) []byte {
+	b := make([]byte, unix.IFNAMSIZ)
+	copy(b, name)
+	return b
+}
+
+func parsePortRange(s string) (uint16, uint16, bool) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		to = from
+	}
+
+	f, err := strconv.ParseUint(from, 10, 16)
+	if err != nil {
+		return 0, 0, false
+	}
+	t, err := strconv.ParseUint(to, 10, 16)
+	if err != nil || t < f {
+		return 0, 0, false
+	}
+
+	return uint16(f), uint16(t), true
+}
+
+// buildAddressMatch matches the network header field at offset v4 or v6 of
+// an address or prefix. Tables of the inet, bridge and netdev families see
+// both IP versions, so the version is matched first.
+func buildAddressMatch(family nftables.TableFamily, field string, s string, v4 uint32, v6 uint32) ([]expr.Any, error) {
+	ip, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		if ip = net.ParseIP(s); ip == nil {
+			return nil, web.InvalidArgument(field, s)
+		}
+		ipNet = &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)}
+	}
+
+	offset := v6
+	nfproto, ethertype := byte(unix.NFPROTO_IPV6), uint16(unix.ETH_P_IPV6)
+	if ip4 := ipNet.IP.To4(); ip4 != nil {
+		ipNet = &net.IPNet{IP: ip4, Mask: ipNet.Mask[len(ipNet.Mask)-4:]}
+		offset = v4
+		nfproto, ethertype = unix.NFPROTO_IPV4, unix.ETH_P_IP
+	}
+
+	exprs := []expr.Any{}
+	switch family {
+	case unix.NFPROTO_IPV4, unix.NFPROTO_IPV6:
+		if family != nftables.TableFamily(nfproto) {
+			return nil, web.NewError(web.ErrInvalidArgument, "%s='%s' does not match the table family", field, s).WithField(field, s, "does not match the table family")
+		}
+	case unix.NFPROTO_INET:
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyNFPROTO, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{nfproto}},
+		)
+	default:
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyPROTOCOL, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.BigEndian.PutUint16(ethertype)},
+		)
+	}
+
+	exprs = append(exprs, &expr.Payload{
+		DestRegister: 1,
+		Base:         expr.PayloadBaseNetworkHeader,
+		Offset:       offset,
+		Len:          uint32(len(ipNet.IP)),
+	})
+	if ones, size := ipNet.Mask.Size(); ones != size {
+		exprs = append(exprs, &expr.Bitwise{
+			SourceRegister: 1,
+			DestRegister:   1,
+			Len:            uint32(len(ipNet.IP)),
+			Mask:           ipNet.Mask,
+			Xor:            make([]byte, len(ipNet.IP)),
+		})
+	}
+
+	return append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ipNet.IP}), nil
+}
+
+func buildPortMatch(field string, s string, offset uint32) ([]expr.Any, error) {
+	from, to, ok := parsePortRange(s)
+	if !ok {
+		return nil, web.InvalidArgument(field, s)
+	}
+
+	exprs := []expr.Any{
+		&expr.Payload{
+			DestRegister: 1,
+			Base:         expr.PayloadBaseTransportHeader,
+			Offset:       offset,
+			Len:          2,
+		},
+	}
+	if from == to {
+		return append(exprs, &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.BigEndian.PutUint16(from)}), nil
+	}
+
+	return append(exprs, &expr.Range{
+		Op:       expr.CmpOpEq,
+		Register: 1,
+		FromData: binaryutil.BigEndian.PutUint16(from),
+		ToData:   binaryutil.BigEndian.PutUint16(to),
+	}), nil
+}
+
+func buildReject(family nftables.TableFamily) *expr.Reject {
+	switch family {
+	case unix.NFPROTO_IPV4:
+		return &expr.Reject{Type: unix.NFT_REJECT_ICMP_UNREACH, Code: 3}
+	case unix.NFPROTO_IPV6:
+		return &expr.Reject{Type: unix.NFT_REJECT_ICMP_UNREACH, Code: 4}
+	}
+
+	return &expr.Reject{Type: unix.NFT_REJECT_ICMPX_UNREACH, Code: unix.NFT_REJECT_ICMPX_PORT_UNREACH}
+}
+
+// buildRuleExprs turns the rule into the expressions the kernel evaluates,
+// in the order nft(8) would generate them.
+func (r *Rule) buildRuleExprs(family nftables.TableFamily) ([]expr.Any, error) {
+	exprs := []expr.Any{}
+
+	if !validator.IsEmpty(r.IncomingInterface) {
+		if len(r.IncomingInterface) >= unix.IFNAMSIZ {
+			return nil, web.InvalidArgument("IncomingInterface", r.IncomingInterface)
+		}
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifname(r.IncomingInterface)},
+		)
+	}
+
+	if !validator.IsEmpty(r.OutgoingInterface) {
+		if len(r.OutgoingInterface) >= unix.IFNAMSIZ {
+			return nil, web.InvalidArgument("OutgoingInterface", r.OutgoingInterface)
+		}
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifname(r.OutgoingInterface)},
+		)
+	}
+
+	if !validator.IsEmpty(r.SourceAddress) {
+		e, err := buildAddressMatch(family, "SourceAddress", r.SourceAddress, 12, 8)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e...)
+	}
+
+	if !validator.IsEmpty(r.DestinationAddress) {
+		e, err := buildAddressMatch(family, "DestinationAddress", r.DestinationAddress, 16, 24)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e...)
+	}
+
+	if !validator.IsEmpty(r.Protocol) {
+		p, ok := protocolNumbers[r.Protocol]
+		if !ok {
+			return nil, web.InvalidArgument("Protocol", r.Protocol)
+		}
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{p}},
+		)
+	}
+
+	if !validator.IsEmpty(r.SourcePort) || !validator.IsEmpty(r.DestinationPort) {
+		if r.Protocol != "tcp" && r.Protocol != "udp" && r.Protocol != "sctp" {
+			return nil, web.NewError(web.ErrInvalidArgument, "ports need protocol tcp, udp or sctp").WithField("Protocol", r.Protocol, "ports need protocol tcp, udp or sctp")
+		}
+	}
+
+	if !validator.IsEmpty(r.SourcePort) {
+		e, err := buildPortMatch("SourcePort", r.SourcePort, 0)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e...)
+	}
+
+	if !validator.IsEmpty(r.DestinationPort) {
+		e, err := buildPortMatch("DestinationPort", r.DestinationPort, 2)
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, e...)
+	}
+
+	if len(r.CtState) > 0 {
+		var mask uint32
+		for _, s := range r.CtState {
+			b, ok := ctStateBits[s]
+			if !ok {
+				return nil, web.InvalidArgument("CtState", s)
+			}
+			mask |= b
+		}
+		exprs = append(exprs,
+			&expr.Ct{Key: expr.CtKeySTATE, Register: 1},
+			&expr.Bitwise{
+				SourceRegister: 1,
+				DestRegister:   1,
+				Len:            4,
+				Mask:           binaryutil.NativeEndian.PutUint32(mask),
+				Xor:            binaryutil.NativeEndian.PutUint32(0),
+			},
+			&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(0)},
+		)
+	}
+
+	if !validator.IsEmpty(r.Mark) {
+		m, err := strconv.ParseUint(r.Mark, 0, 32)
+		if err != nil {
+			return nil, web.InvalidArgument("Mark", r.Mark)
+		}
+		exprs = append(exprs,
+			&expr.Meta{Key: expr.MetaKeyMARK, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.NativeEndian.PutUint32(uint32(m))},
+		)
+	}
+
+	if r.Counter {
+		exprs = append(exprs, &expr.Counter{})
+	}
+
+	if r.Log {
+		l := expr.Log{}
+		if !validator.IsEmpty(r.LogPrefix) {
+			l.Key = 1 << unix.NFTA_LOG_PREFIX
+			l.Data = []byte(r.LogPrefix)
+		}
+		exprs = append(exprs, &l)
+	}
+
+	switch r.Verdict {
+	case "":
+	case "accept":
+		exprs = append(exprs, &expr.Verdict{Kind: expr.VerdictAccept})
+	case "drop":
+		exprs = append(exprs, &expr.Verdict{Kind: expr.VerdictDrop})
+	case "reject":
+		exprs = append(exprs, buildReject(family))
+	case "jump":
+		if validator.IsEmpty(r.JumpTarget) {
+			return nil, web.NewError(web.ErrInvalidArgument, "missing jump target").WithField("JumpTarget", "", "missing jump target")
+		}
+		exprs = append(exprs, &expr.Verdict{Kind: expr.VerdictJump, Chain: r.JumpTarget})
+	default:
+		return nil, web.InvalidArgument("Verdict", r.Verdict)
+	}
+
+	if len(exprs) == 0 {
+		return nil, web.NewError(web.ErrInvalidArgument, "rule has neither a match nor a statement")
+	}
+
+	return exprs, nil
+}
+
+func protocolName(p byte) string {
+	for k, v := range protocolNumbers {
+		if v == p {
+			return k
+		}
+	}
+
+	return strconv.Itoa(int(p))
+}
+
+func decodeAddress(data []byte, mask []byte) string {
+	ip := net.IP(data)
+	if mask == nil {
+		return ip.String()
+	}
+
+	ipNet := net.IPNet{IP: ip, Mask: net.IPMask(mask)}
+	return ipNet.String()
+}
+
+// decodeCmp sets the field matched by a comparison with the value loaded
+// before it. It returns false for a comparison the fields cannot express: any
+// other than equality, except the test of the conntrack state bits.
+func decodeCmp(info *RuleInfo, load expr.Any, mask []byte, e *expr.Cmp) bool {
+	if l, ok := load.(*expr.Ct); ok {
+		if l.Key != expr.CtKeySTATE || len(mask) != 4 || e.Op != expr.CmpOpNeq || !bytes.Equal(e.Data, make([]byte, 4)) {
+			return false
+		}
+
+		m := binaryutil.NativeEndian.Uint32(mask)
+		for m != 0 {
+			b := uint32(1) << bits.TrailingZeros32(m)
+			for k, v := range ctStateBits {
+				if v == b {
+					info.CtState = append(info.CtState, k)
+				}
+			}
+			m &^= b
+		}
+
+		return true
+	}
+
+	if e.Op != expr.CmpOpEq {
+		return false
+	}
+
+	switch l := load.(type) {
+	case *expr.Meta:
+		switch l.Key {
+		case expr.MetaKeyIIFNAME:
+			info.IncomingInterface = string(bytes.TrimRight(e.Data, "\x00"))
+		case expr.MetaKeyOIFNAME:
+			info.OutgoingInterface = string(bytes.TrimRight(e.Data, "\x00"))
+		case expr.MetaKeyL4PROTO:
+			info.Protocol = protocolName(e.Data[0])
+		case expr.MetaKeyMARK:
+			info.Mark = strconv.FormatUint(uint64(binaryutil.NativeEndian.Uint32(e.Data)), 10)
+		case expr.MetaKeyNFPROTO, expr.MetaKeyPROTOCOL:
+			// The family of an address match in an inet or bridge table.
+		default:
+			return false
+		}
+	case *expr.Payload:
+		switch {
+		case l.Base == expr.PayloadBaseNetworkHeader && ((l.Offset == 12 && l.Len == 4) || (l.Offset == 8 && l.Len == 16)):
+			info.SourceAddress = decodeAddress(e.Data, mask)
+		case l.Base == expr.PayloadBaseNetworkHeader && ((l.Offset == 16 && l.Len == 4) || (l.Offset == 24 && l.Len == 16)):
+			info.DestinationAddress = decodeAddress(e.Data, mask)
+		case l.Base == expr.PayloadBaseTransportHeader && l.Offset == 0 && l.Len == 2:
+			info.SourcePort = strconv.Itoa(int(binaryutil.BigEndian.Uint16(e.Data)))
+		case l.Base == expr.PayloadBaseTransportHeader && l.Offset == 2 && l.Len == 2:
+			info.DestinationPort = strconv.Itoa(int(binaryutil.BigEndian.Uint16(e.Data)))
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// decodeRuleExprs reads back the expressions built by buildRuleExprs.
+// Expressions of rules added by other means which have no field here are
+// skipped.
+func decodeRuleExprs(r *nftables.Rule) RuleInfo {
+	info := RuleInfo{
+		Rule: Rule{
+			Table:  r.Table.Name,
+			Family: convertToStringFamily(r.Table.Family),
+			Chain:  r.Chain.Name,
+			Handle: strconv.FormatUint(r.Handle, 10),
+		},
+	}
+
+	var load expr.Any
+	var mask []byte
+	for _, e := range r.Exprs {
+		switch e := e.(type) {
+		case *expr.Meta, *expr.Payload, *expr.Ct:
+			load, mask = e, nil
+		case *expr.Bitwise:
+			mask = e.Mask
+		case *expr.Cmp:
+			if !decodeCmp(&info, load, mask, e) {
+				info.Partial = true
+			}
+			load = nil
+		case *expr.Range:
+			l, ok := load.(*expr.Payload)
+			if ok && e.Op == expr.CmpOpEq && l.Base == expr.PayloadBaseTransportHeader && l.Len == 2 && (l.Offset == 0 || l.Offset == 2) {
+				ports := strconv.Itoa(int(binaryutil.BigEndian.Uint16(e.FromData))) + "-" + strconv.Itoa(int(binaryutil.BigEndian.Uint16(e.ToData)))
+				if l.Offset == 0 {
+					info.SourcePort = ports
+				} else {
+					info.DestinationPort = ports
+				}
+			} else {
+				info.Partial = true
+			}
+			load = nil
+		case *expr.Counter:
+			info.Counter = true
+			info.Packets = e.Packets
+			info.Bytes = e.Bytes
+		case *expr.Log:
+			info.Log = true
+			info.LogPrefix = string(e.Data)
+		case *expr.Reject:
+			info.Verdict = "reject"
+		case *expr.Verdict:
+			switch e.Kind {
+			case expr.VerdictAccept:
+				info.Verdict = "accept"
+			case expr.VerdictDrop:
+				info.Verdict = "drop"
+			case expr.VerdictJump:
+				info.Verdict = "jump"
+				info.JumpTarget = e.Chain
+			default:
+				info.Partial = true
+			}
+		default:
+			info.Partial = true
+		}
+	}
+
+	return info
+}
+
+// acquireRuleChain looks up the chain of the rule. The family defaults to
+// ipv4 as for tables and chains.
+func (r *Rule) acquireRuleChain() (*nftables.Chain, error) {
+	if validator.IsEmpty(r.Table) {
+		return nil, web.NewError(web.ErrInvalidArgument, "missing table name").WithField("Table", "", "missing table name")
+	}
+	if validator.IsEmpty(r.Chain) {
+		return nil, web.NewError(web.ErrInvalidArgument, "missing chain name").WithField("Chain", "", "missing chain name")
+	}
+
+	if !validator.IsEmpty(r.Family) {
+		if !validator.IsNFTFamily(r.Family) {
+			return nil, web.InvalidArgument("Family", r.Family)
+		}
+	} else {
+		r.Family = "ipv4"
+	}
+
+	chainMap := make(map[string]*nftables.Chain)
+	if err := getChainsAndCreateMap(chainMap); err != nil {
+		return nil, err
+	}
+
+	ch, ok := chainMap[createChainMapKey(r.Table, r.Chain, convertToUnixFamily(r.Family))]
+	if !ok {
+		return nil, web.NewError(web.ErrNotFound, "chain='%s' not found in table='%s' family='%s'", r.Chain, r.Table, r.Family)
+	}
+
+	return ch, nil
+}
+
+func (n *Nft) AddRule(w http.ResponseWriter) error {
+	ch, err := n.Rule.acquireRuleChain()
+	if err != nil {
+		log.Errorf("Failed to add nft rule: %v", err)
+		return err
+	}
+
+	exprs, err := n.Rule.buildRuleExprs(ch.Table.Family)
+	if err != nil {
+		log.Errorf("Failed to parse nft rule: %v", err)
+		return err
+	}
+
+	c := newConnection()
+	c.AddRule(&nftables.Rule{
+		Table: ch.Table,
+		Chain: ch,
+		Exprs: exprs,
+	})
+
+	if err := c.Flush(); err != nil {
+		log.Errorf("Unable to flush connection: %v", err)
+		return err
+	}
+
+	return web.JSONResponse("added", w)
+}
+
+func (n *Nft) RemoveRule(w http.ResponseWriter) error {
+	ch, err := n.Rule.acquireRuleChain()
+	if err != nil {
+		log.Errorf("Failed to remove nft rule: %v", err)
+		return err
+	}
+
+	h, err := strconv.ParseUint(n.Rule.Handle, 10, 64)
+	if err != nil || h == 0 {
+		return web.InvalidArgument("Handle", n.Rule.Handle)
+	}
+
+	c := newConnection()
+	rules, err := c.GetRules(ch.Table, ch)
+	if err != nil {
+		log.Errorf("Failed to acquire nft rules of chain='%s': %v", ch.Name, err)
+		return err
+	}
+
+	found := false
+	for _, r := range rules {
+		if r.Handle == h {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return web.NewError(web.ErrNotFound, "rule handle='%d' not found in chain='%s'", h, ch.Name)
+	}
+
+	if err := c.DelRule(&nftables.Rule{Table: ch.Table, Chain: ch, Handle: h}); err != nil {
+		return err
+	}
+
+	if err := c.Flush(); err != nil {
+		log.Errorf("Unable to flush connection: %v", err)
+		return err
+	}
+
+	return web.JSONResponse("removed", w)
+}
+
+// ShowRule lists the rules of the chain, or of all chains when no chain is
+// given, optionally limited to a table and family.
+func (n *Nft) ShowRule(w http.ResponseWriter) error {
+	chains := []*nftables.Chain{}
+	if !validator.IsEmpty(n.Rule.Chain) {
+		ch, err := n.Rule.acquireRuleChain()
+		if err != nil {
+			return err
+		}
+		chains = append(chains, ch)
+	} else {
+		all, err := acquireChains()
+		if err != nil {
+			log.Errorf("Failed to acquire nft chains: %v", err)
+			return err
+		}
+
+		for _, ch := range all {
+			if !validator.IsEmpty(n.Rule.Table) && ch.Table.Name != n.Rule.Table {
+				continue
+			}
+			if !validator.IsEmpty(n.Rule.Family) && convertToStringFamily(ch.Table.Family) != n.Rule.Family {
+				continue
+			}
+			chains = append(chains, ch)
+		}
+	}
+
+	c := newConnection()
+	rules := []RuleInfo{}
+	for _, ch := range chains {
+		rs, err := c.GetRules(ch.Table, ch)
+		if err != nil {
+			log.Errorf("Failed to acquire nft rules of chain='%s': %v", ch.Name, err)
+			return err
+		}
+
+		for _, r := range rs {
+			r.Chain = ch
+			rules = append(rules, decodeRuleExprs(r))
+		}
+	}
+
+	return web.JSONResponse(rules, w)
+}
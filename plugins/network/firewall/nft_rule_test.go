@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package firewall
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+func TestRuleExprs(t *testing.T) {
+	for _, c := range []struct {
+		family nftables.TableFamily
+		rule   Rule
+		ok     bool
+	}{
+		{unix.NFPROTO_IPV4, Rule{SourceAddress: "192.168.1.10", Verdict: "accept"}, true},
+		{unix.NFPROTO_IPV4, Rule{DestinationAddress: "10.0.0.0/8", Protocol: "tcp", DestinationPort: "22", Verdict: "drop"}, true},
+		{unix.NFPROTO_IPV4, Rule{IncomingInterface: "eth0", Protocol: "udp", SourcePort: "1000-2000", Counter: true, Verdict: "reject"}, true},
+		{unix.NFPROTO_IPV4, Rule{CtState: []string{"established", "related"}, Verdict: "accept"}, true},
+		{unix.NFPROTO_IPV4, Rule{Mark: "4096", Log: true, LogPrefix: "marked: ", Verdict: "jump", JumpTarget: "marked"}, true},
+		{unix.NFPROTO_IPV4, Rule{SourceAddress: "fd00::1"}, false},
+		{unix.NFPROTO_IPV6, Rule{SourceAddress: "fd00::/64", DestinationAddress: "fd00::1", Verdict: "accept"}, true},
+		{unix.NFPROTO_IPV6, Rule{OutgoingInterface: "eth1", Protocol: "sctp", DestinationPort: "3868-3869", Verdict: "drop"}, true},
+		{unix.NFPROTO_IPV6, Rule{CtState: []string{"invalid"}, Mark: "1", Verdict: "drop"}, true},
+		{unix.NFPROTO_IPV6, Rule{DestinationAddress: "10.0.0.1"}, false},
+		{unix.NFPROTO_INET, Rule{SourceAddress: "192.168.0.0/16", DestinationAddress: "fd00::1", Verdict: "accept"}, true},
+		{unix.NFPROTO_INET, Rule{Protocol: "tcp", SourcePort: "1024-65535", DestinationPort: "443", CtState: []string{"new"}, Verdict: "reject"}, true},
+		{unix.NFPROTO_INET, Rule{Mark: "16", Counter: true}, true},
+		{unix.NFPROTO_INET, Rule{Protocol: "icmp", DestinationPort: "80"}, false},
+		{unix.NFPROTO_INET, Rule{CtState: []string{"closed"}}, false},
+		{unix.NFPROTO_INET, Rule{Mark: "mark"}, false},
+		{unix.NFPROTO_INET, Rule{Verdict: "jump"}, false},
+		{unix.NFPROTO_INET, Rule{}, false},
+	} {
+		exprs, err := c.rule.buildRuleExprs(c.family)
+		if (err == nil) != c.ok {
+			t.Fatalf("Invalid result for %+v in family=%d: %v", c.rule, c.family, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		info := decodeRuleExprs(&nftables.Rule{
+			Table: &nftables.Table{Name: "filter", Family: c.family},
+			Chain: &nftables.Chain{Name: "input"},
+			Exprs: exprs,
+		})
+
+		expected := c.rule
+		expected.Table, expected.Family, expected.Chain, expected.Handle = "filter", convertToStringFamily(c.family), "input", "0"
+		if info.Partial || !reflect.DeepEqual(info.Rule, expected) {
+			t.Fatalf("Invalid decoded rule: expected %+v, got %+v", expected, info)
+		}
+	}
+}
+
+func TestDecodeRuleExprsPartial(t *testing.T) {
+	for _, c := range []struct {
+		exprs   []expr.Any
+		partial bool
+	}{
+		{[]expr.Any{
+			&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{unix.IPPROTO_TCP}},
+		}, false},
+		{[]expr.Any{
+			&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: []byte{unix.IPPROTO_TCP}},
+		}, true},
+		{[]expr.Any{
+			&expr.Meta{Key: expr.MetaKeySKUID, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{0, 0, 0, 0}},
+		}, true},
+		{[]expr.Any{
+			&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+			&expr.Range{Op: expr.CmpOpNeq, Register: 1, FromData: []byte{0, 1}, ToData: []byte{0, 2}},
+		}, true},
+		{[]expr.Any{
+			&expr.Ct{Key: expr.CtKeySTATE, Register: 1},
+			&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{0, 0, 0, 0}},
+		}, true},
+		{[]expr.Any{&expr.Limit{Rate: 10, Unit: expr.LimitTimeSecond}}, true},
+		{[]expr.Any{&expr.Verdict{Kind: expr.VerdictReturn}}, true},
+	} {
+		info := decodeRuleExprs(&nftables.Rule{
+			Table: &nftables.Table{Name: "filter", Family: unix.NFPROTO_INET},
+			Chain: &nftables.Chain{Name: "input"},
+			Exprs: c.exprs,
+		})
+		if info.Partial != c.partial {
+			t.Fatalf("Invalid partial flag of %+v: expected %t", info, c.partial)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 
 	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
 )
 
 type Address struct {
@@ -48,7 +50,7 @@ func decodeJSONRequest(r *http.Request) (*AddressAction, error) {
 }
 
 func (a *AddressAction) Add() error {
-	link, err := netlink.LinkByName(a.Link)
+	link, err := link.AcquireLinkByName(a.Link)
 	if err != nil {
 		return err
 	}
@@ -66,7 +68,7 @@ func (a *AddressAction) Add() error {
 }
 
 func (a *AddressAction) Remove() error {
-	link, err := netlink.LinkByName(a.Link)
+	link, err := link.AcquireLinkByName(a.Link)
 	if err != nil {
 		return err
 	}
@@ -122,14 +124,19 @@ func buildAddressList(link netlink.Link, addrs []netlink.Addr) AddressInfo {
 }
 
 func AcquireAddresses() ([]AddressInfo, error) {
-	linkList, err := netlink.LinkList()
+	return AcquireAddressesAt(&netlink.Handle{})
+}
+
+// AcquireAddressesAt lists the addresses seen by a netlink handle.
+func AcquireAddressesAt(h *netlink.Handle) ([]AddressInfo, error) {
+	linkList, err := h.LinkList()
 	if err != nil {
 		return nil, err
 	}
 
 	var addrs []AddressInfo
 	for _, link := range linkList {
-		a, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		a, err := h.AddrList(link, netlink.FAMILY_ALL)
 		if err != nil {
 			return nil, err
 		}
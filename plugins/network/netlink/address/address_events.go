@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package address
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+type AddressEvent struct {
+	Action  string  `json:"Action"`
+	Name    string  `json:"Name"`
+	Ifindex int     `json:"Ifindex"`
+	Address Address `json:"Address"`
+}
+
+// WatchAddresses publishes address changes until ctx is cancelled.
+func WatchAddresses(ctx context.Context) error {
+	ch := make(chan netlink.AddrUpdate)
+	done := make(chan struct{})
+	defer close(done)
+
+	if err := netlink.AddrSubscribeWithOptions(ch, done, netlink.AddrSubscribeOptions{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case u, ok := <-ch:
+			if !ok {
+				return errors.New("address subscription closed")
+			}
+
+			e := AddressEvent{
+				Action:  "new",
+				Ifindex: u.LinkIndex,
+				Address: fillOneAddress(&netlink.Addr{
+					IPNet:       &u.LinkAddress,
+					Flags:       u.Flags,
+					Scope:       u.Scope,
+					PreferedLft: u.PreferedLft,
+					ValidLft:    u.ValidLft,
+				}),
+			}
+			if !u.NewAddr {
+				e.Action = "del"
+			}
+			if l, err := netlink.LinkByIndex(u.LinkIndex); err == nil {
+				e.Name = l.Attrs().Name
+			}
+
+			events.Publish(events.TopicAddress, e)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -15,6 +17,7 @@ func routerAcquireAddress(w http.ResponseWriter, r *http.Request) {
 	addrs, err := AcquireAddresses()
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(addrs, w)
@@ -23,5 +26,7 @@ func routerAcquireAddress(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterAddress(router *mux.Router) {
 	s := router.PathPrefix("/netlink").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/address", routerAcquireAddress).Methods("GET")
+	openapi.Describe(s.HandleFunc("/address", routerAcquireAddress).Methods("GET"), "List the addresses", nil, []AddressInfo{})
+
+	events.RegisterSource(events.TopicAddress, WatchAddresses)
 }
@@ -0,0 +1,189 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bridge
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// FDBEntry is a static entry of the forwarding database of the bridge of
+// Link, a bridge port. With Self the entry goes to the table of the device
+// itself instead, as for the bridge or a VXLAN device.
+type FDBEntry struct {
+	Link       string `json:"Link"`
+	MACAddress string `json:"MACAddress"`
+	VLAN       string `json:"VLAN"`
+	Self       bool   `json:"Self"`
+}
+
+type FDBInfo struct {
+	LinkName   string   `json:"LinkName"`
+	LinkIndex  int      `json:"LinkIndex"`
+	Master     string   `json:"Master"`
+	MACAddress string   `json:"MACAddress"`
+	VLAN       int      `json:"VLAN"`
+	State      string   `json:"State"`
+	Flags      []string `json:"Flags"`
+}
+
+var fdbFlags = []struct {
+	name string
+	flag int
+}{
+	{"self", netlink.NTF_SELF},
+	{"master", netlink.NTF_MASTER},
+	{"extern_learn", netlink.NTF_EXT_LEARNED},
+	{"offload", netlink.NTF_OFFLOADED},
+	{"sticky", netlink.NTF_STICKY},
+}
+
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (f *FDBEntry) buildNeigh() (*netlink.Neigh, error) {
+	l, err := link.AcquireLinkByName(f.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := net.ParseMAC(f.MACAddress)
+	if err != nil {
+		return nil, web.InvalidArgument("MACAddress", f.MACAddress)
+	}
+
+	// Static entries of the bridge never age out. Devices other than the
+	// bridge only take permanent entries.
+	neigh := netlink.Neigh{
+		LinkIndex:    l.Attrs().Index,
+		Family:       unix.AF_BRIDGE,
+		State:        netlink.NUD_NOARP | netlink.NUD_REACHABLE,
+		Flags:        netlink.NTF_MASTER,
+		HardwareAddr: mac,
+	}
+	if f.Self {
+		neigh.State = netlink.NUD_NOARP | netlink.NUD_PERMANENT
+		neigh.Flags = netlink.NTF_SELF
+	}
+	if f.VLAN != "" {
+		if !validator.IsBridgeVLANId(f.VLAN) {
+			return nil, web.InvalidArgument("VLAN", f.VLAN)
+		}
+		neigh.Vlan, _ = validator.IsInt(f.VLAN)
+	}
+
+	return &neigh, nil
+}
+
+func (f *FDBEntry) Add() error {
+	neigh, err := f.buildNeigh()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.NeighAdd(neigh); err != nil {
+		log.Errorf("Failed to add fdb entry mac='%s' link='%s': %v", f.MACAddress, f.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func (f *FDBEntry) Remove() error {
+	neigh, err := f.buildNeigh()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.NeighDel(neigh); err != nil {
+		if err == unix.ENOENT {
+			return web.NewError(web.ErrNotFound, "fdb entry mac='%s' not found on link='%s'", f.MACAddress, f.Link)
+		}
+
+		log.Errorf("Failed to delete fdb entry mac='%s' link='%s': %v", f.MACAddress, f.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func fdbState(state int) string {
+	switch {
+	case state&netlink.NUD_PERMANENT != 0:
+		return "permanent"
+	case state&netlink.NUD_NOARP != 0:
+		return "static"
+	}
+
+	return "dynamic"
+}
+
+// AcquireFDB lists the forwarding database entries of the ports of a bridge
+// and the bridge itself. An empty name lists every bridge.
+func AcquireFDB(bridge string) ([]FDBInfo, error) {
+	msg := netlink.Ndmsg{Family: unix.AF_BRIDGE}
+
+	masterIndex := 0
+	if bridge != "" {
+		l, err := link.AcquireLinkByName(bridge)
+		if err != nil {
+			return nil, err
+		}
+		masterIndex = l.Attrs().Index
+	}
+
+	neighs, err := netlink.NeighListExecute(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[int]string{}
+	name := func(index int) string {
+		if n, ok := names[index]; ok {
+			return n
+		}
+		if l, err := netlink.LinkByIndex(index); err == nil {
+			names[index] = l.Attrs().Name
+		}
+		return names[index]
+	}
+
+	fdb := []FDBInfo{}
+	for _, n := range neighs {
+		if masterIndex != 0 && n.MasterIndex != masterIndex && n.LinkIndex != masterIndex {
+			continue
+		}
+
+		info := FDBInfo{
+			LinkName:   name(n.LinkIndex),
+			LinkIndex:  n.LinkIndex,
+			MACAddress: n.HardwareAddr.String(),
+			VLAN:       n.Vlan,
+			State:      fdbState(n.State),
+			Flags:      []string{},
+		}
+		if n.MasterIndex != 0 {
+			info.Master = name(n.MasterIndex)
+		}
+		for _, f := range fdbFlags {
+			if n.Flags&f.flag != 0 {
+				info.Flags = append(info.Flags, f.name)
+			}
+		}
+
+		fdb = append(fdb, info)
+	}
+
+	return fdb, nil
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bridge
+
+import (
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// Port sets the STP parameters of a bridge port. The kernel only takes a
+// State while STP is off on the bridge.
+type Port struct {
+	Link     string `json:"Link"`
+	State    string `json:"State"`
+	Cost     string `json:"Cost"`
+	Priority string `json:"Priority"`
+}
+
+type PortInfo struct {
+	LinkName  string `json:"LinkName"`
+	LinkIndex int    `json:"LinkIndex"`
+	Master    string `json:"Master"`
+	State     string `json:"State"`
+	Cost      uint32 `json:"Cost"`
+	Priority  uint16 `json:"Priority"`
+}
+
+// STP port states, as BR_STATE_* of the kernel.
+var portStates = []string{"disabled", "listening", "learning", "forwarding", "blocking"}
+
+func parsePortState(state string) (uint8, error) {
+	for i, s := range portStates {
+		if strings.EqualFold(s, state) {
+			return uint8(i), nil
+		}
+	}
+
+	return 0, web.InvalidArgument("State", state)
+}
+
+func portStateString(state uint8) string {
+	if int(state) < len(portStates) {
+		return portStates[state]
+	}
+
+	return strconv.Itoa(int(state))
+}
+
+// Set writes the given parameters as IFLA_PROTINFO of the port, which the
+// netlink package has no setter for.
+func (p *Port) Set() error {
+	l, err := link.AcquireLinkByName(p.Link)
+	if err != nil {
+		return err
+	}
+	if l.Attrs().MasterIndex == 0 {
+		return web.NewError(web.ErrInvalidArgument, "link='%s' is not a bridge port", p.Link)
+	}
+
+	info := nl.NewRtAttr(unix.IFLA_PROTINFO|unix.NLA_F_NESTED, nil)
+	if p.State != "" {
+		s, err := parsePortState(p.State)
+		if err != nil {
+			return err
+		}
+		info.AddRtAttr(nl.IFLA_BRPORT_STATE, []byte{s})
+	}
+	if p.Cost != "" {
+		c, err := strconv.ParseUint(p.Cost, 10, 32)
+		if err != nil || c == 0 {
+			return web.InvalidArgument("Cost", p.Cost)
+		}
+		info.AddRtAttr(nl.IFLA_BRPORT_COST, nl.Uint32Attr(uint32(c)))
+	}
+	if p.Priority != "" {
+		pr, err := strconv.ParseUint(p.Priority, 10, 16)
+		if err != nil || pr > 63 {
+			return web.InvalidArgument("Priority", p.Priority)
+		}
+		info.AddRtAttr(nl.IFLA_BRPORT_PRIORITY, nl.Uint16Attr(uint16(pr)))
+	}
+
+	req := nl.NewNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_BRIDGE)
+	msg.Index = int32(l.Attrs().Index)
+	req.AddData(msg)
+	req.AddData(info)
+
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		log.Errorf("Failed to set bridge port link='%s': %v", p.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// AcquirePorts lists the ports of a bridge with their STP parameters. An
+// empty name lists the ports of every bridge.
+func AcquirePorts(bridge string) ([]PortInfo, error) {
+	masterIndex := 0
+	if bridge != "" {
+		l, err := link.AcquireLinkByName(bridge)
+		if err != nil {
+			return nil, err
+		}
+		masterIndex = l.Attrs().Index
+	}
+
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+	req.AddData(nl.NewIfInfomsg(unix.AF_BRIDGE))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []PortInfo{}
+	for _, m := range msgs {
+		ifi := nl.DeserializeIfInfomsg(m)
+		attrs, err := nl.ParseRouteAttr(m[ifi.Len():])
+		if err != nil {
+			return nil, err
+		}
+
+		p := PortInfo{
+			LinkIndex: int(ifi.Index),
+		}
+		master, protinfo := 0, false
+		for _, a := range attrs {
+			switch a.Attr.Type &^ unix.NLA_F_NESTED {
+			case unix.IFLA_IFNAME:
+				p.LinkName = strings.TrimRight(string(a.Value), "\x00")
+			case unix.IFLA_MASTER:
+				master = int(nl.NativeEndian().Uint32(a.Value[0:4]))
+			case unix.IFLA_PROTINFO:
+				infos, err := nl.ParseRouteAttr(a.Value)
+				if err != nil {
+					return nil, err
+				}
+				protinfo = true
+
+				for _, info := range infos {
+					switch info.Attr.Type {
+					case nl.IFLA_BRPORT_STATE:
+						p.State = portStateString(info.Value[0])
+					case nl.IFLA_BRPORT_COST:
+						p.Cost = nl.NativeEndian().Uint32(info.Value[0:4])
+					case nl.IFLA_BRPORT_PRIORITY:
+						p.Priority = nl.NativeEndian().Uint16(info.Value[0:2])
+					}
+				}
+			}
+		}
+
+		// The bridges themselves come without port information.
+		if !protinfo || master == 0 || (masterIndex != 0 && master != masterIndex) {
+			continue
+		}
+		if l, err := netlink.LinkByIndex(master); err == nil {
+			p.Master = l.Attrs().Name
+		}
+
+		ports = append(ports, p)
+	}
+
+	return ports, nil
+}
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bridge
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerConfigureFDB(w http.ResponseWriter, r *http.Request) {
+	f := FDBEntry{}
+	if err := decodeJSONRequest(r, &f); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = f.Remove()
+	} else {
+		err = f.Add()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("fdb entry configured", w)
+}
+
+func routerAcquireFDB(w http.ResponseWriter, r *http.Request) {
+	fdb, err := AcquireFDB(r.URL.Query().Get("bridge"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(fdb, w)
+}
+
+func routerConfigurePortVLAN(w http.ResponseWriter, r *http.Request) {
+	v := PortVLAN{}
+	if err := decodeJSONRequest(r, &v); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = v.Remove()
+	} else {
+		err = v.Add()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("vlan configured", w)
+}
+
+func routerAcquirePortVLANs(w http.ResponseWriter, r *http.Request) {
+	vs, err := AcquirePortVLANs(r.URL.Query().Get("link"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(vs, w)
+}
+
+func routerConfigurePort(w http.ResponseWriter, r *http.Request) {
+	p := Port{}
+	if err := decodeJSONRequest(r, &p); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := p.Set(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("port configured", w)
+}
+
+func routerAcquirePorts(w http.ResponseWriter, r *http.Request) {
+	ps, err := AcquirePorts(r.URL.Query().Get("bridge"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(ps, w)
+}
+
+func RegisterRouterBridge(router *mux.Router) {
+	s := router.PathPrefix("/netlink/bridge").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/fdb", routerConfigureFDB).Methods("POST"), "Add a static forwarding database entry", FDBEntry{}, nil)
+	openapi.Describe(s.HandleFunc("/fdb", routerConfigureFDB).Methods("DELETE"), "Delete a forwarding database entry", FDBEntry{}, nil)
+	openapi.Describe(s.HandleFunc("/fdb", routerAcquireFDB).Methods("GET"), "List the forwarding database of the bridges", nil, []FDBInfo{})
+	openapi.Describe(s.HandleFunc("/vlan", routerConfigurePortVLAN).Methods("POST"), "Add a VLAN to a bridge port", PortVLAN{}, nil)
+	openapi.Describe(s.HandleFunc("/vlan", routerConfigurePortVLAN).Methods("DELETE"), "Delete a VLAN from a bridge port", PortVLAN{}, nil)
+	openapi.Describe(s.HandleFunc("/vlan", routerAcquirePortVLANs).Methods("GET"), "List the VLANs of the bridge ports", nil, []PortVLANInfo{})
+	openapi.Describe(s.HandleFunc("/port", routerConfigurePort).Methods("PUT"), "Set the STP state, cost and priority of a bridge port", Port{}, nil)
+	openapi.Describe(s.HandleFunc("/port", routerAcquirePorts).Methods("GET"), "List the bridge ports with their STP parameters", nil, []PortInfo{})
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package bridge
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// PortVLAN is the membership of a bridge port, or of the bridge itself, in a
+// VLAN or a range of them as "10-20". PVID makes the VLAN the one of the
+// untagged frames received and Untagged sends its frames without a tag.
+type PortVLAN struct {
+	Link     string `json:"Link"`
+	VLAN     string `json:"VLAN"`
+	PVID     bool   `json:"PVID"`
+	Untagged bool   `json:"Untagged"`
+}
+
+type PortVLANInfo struct {
+	LinkName  string `json:"LinkName"`
+	LinkIndex int    `json:"LinkIndex"`
+	VLAN      uint16 `json:"VLAN"`
+	PVID      bool   `json:"PVID"`
+	Untagged  bool   `json:"Untagged"`
+}
+
+func (v *PortVLAN) parse() (netlink.Link, uint16, uint16, error) {
+	l, err := link.AcquireLinkByName(v.Link)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if !validator.IsBridgeVLAN(v.VLAN) {
+		return nil, 0, 0, web.InvalidArgument("VLAN", v.VLAN)
+	}
+
+	first, last, found := strings.Cut(v.VLAN, "-")
+	if !found {
+		last = first
+	}
+	f, _ := strconv.ParseUint(first, 10, 16)
+	e, _ := strconv.ParseUint(last, 10, 16)
+
+	if v.PVID && f != e {
+		return nil, 0, 0, web.NewError(web.ErrInvalidArgument, "PVID takes a single VLAN").WithField("VLAN", v.VLAN, "single VLAN")
+	}
+
+	return l, uint16(f), uint16(e), nil
+}
+
+// self tells whether the VLAN is of the bridge device rather than a port.
+func self(l netlink.Link) bool {
+	return l.Type() == "bridge"
+}
+
+func (v *PortVLAN) Add() error {
+	l, first, last, err := v.parse()
+	if err != nil {
+		return err
+	}
+
+	if first == last {
+		err = netlink.BridgeVlanAdd(l, first, v.PVID, v.Untagged, self(l), !self(l))
+	} else {
+		err = netlink.BridgeVlanAddRange(l, first, last, v.PVID, v.Untagged, self(l), !self(l))
+	}
+	if err != nil {
+		log.Errorf("Failed to add vlan='%s' link='%s': %v", v.VLAN, v.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func (v *PortVLAN) Remove() error {
+	l, first, last, err := v.parse()
+	if err != nil {
+		return err
+	}
+
+	if first == last {
+		err = netlink.BridgeVlanDel(l, first, v.PVID, v.Untagged, self(l), !self(l))
+	} else {
+		err = netlink.BridgeVlanDelRange(l, first, last, v.PVID, v.Untagged, self(l), !self(l))
+	}
+	if err != nil {
+		log.Errorf("Failed to delete vlan='%s' link='%s': %v", v.VLAN, v.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// AcquirePortVLANs lists the VLANs of the bridges and their ports, of one
+// link when given.
+func AcquirePortVLANs(name string) ([]PortVLANInfo, error) {
+	index := 0
+	if name != "" {
+		l, err := link.AcquireLinkByName(name)
+		if err != nil {
+			return nil, err
+		}
+		index = l.Attrs().Index
+	}
+
+	vlans, err := netlink.BridgeVlanList()
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []int{}
+	for i := range vlans {
+		if index == 0 || int(i) == index {
+			indexes = append(indexes, int(i))
+		}
+	}
+	sort.Ints(indexes)
+
+	vs := []PortVLANInfo{}
+	for _, i := range indexes {
+		linkName := ""
+		if l, err := netlink.LinkByIndex(i); err == nil {
+			linkName = l.Attrs().Name
+		}
+
+		for _, v := range vlans[int32(i)] {
+			vs = append(vs, PortVLANInfo{
+				LinkName:  linkName,
+				LinkIndex: i,
+				VLAN:      v.Vid,
+				PVID:      v.PortVID(),
+				Untagged:  v.EngressUntag(),
+			})
+		}
+	}
+
+	return vs, nil
+}
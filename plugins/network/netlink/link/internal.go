@@ -9,7 +9,7 @@ import (
 )
 
 func setMTU(link string, mtu int) error {
-	l, err := netlink.LinkByName(link)
+	l, err := AcquireLinkByName(link)
 	if err != nil {
 		log.Errorf("Failed to find link='%s': %v", link, err)
 		return err
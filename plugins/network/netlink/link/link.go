@@ -4,7 +4,11 @@
 package link
 
 import (
+	"errors"
+
 	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 type Link struct {
@@ -81,8 +85,35 @@ func fillOneLink(link netlink.Link) LinkInfo {
 	return l
 }
 
+// AcquireLinkByName looks up a link and reports a missing one as not found.
+func AcquireLinkByName(name string) (netlink.Link, error) {
+	return AcquireLinkByNameAt(&netlink.Handle{}, name)
+}
+
+// AcquireLinkByNameAt looks up a link through a netlink handle, which may be
+// bound to another network namespace. The zero handle works on the namespace
+// of the daemon.
+func AcquireLinkByNameAt(h *netlink.Handle, name string) (netlink.Link, error) {
+	l, err := h.LinkByName(name)
+	if err != nil {
+		var nf netlink.LinkNotFoundError
+		if errors.As(err, &nf) {
+			return nil, web.NewError(web.ErrNotFound, "link='%s' not found", name)
+		}
+
+		return nil, err
+	}
+
+	return l, nil
+}
+
 func AcquireLinks() ([]LinkInfo, error) {
-	links, err := netlink.LinkList()
+	return AcquireLinksAt(&netlink.Handle{})
+}
+
+// AcquireLinksAt lists the links seen by a netlink handle.
+func AcquireLinksAt(h *netlink.Handle) ([]LinkInfo, error) {
+	links, err := h.LinkList()
 	if err != nil {
 		return nil, err
 	}
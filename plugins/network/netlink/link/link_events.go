@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package link
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+type LinkEvent struct {
+	Action string   `json:"Action"`
+	Link   LinkInfo `json:"Link"`
+}
+
+// WatchLinks publishes link changes until ctx is cancelled.
+func WatchLinks(ctx context.Context) error {
+	ch := make(chan netlink.LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+
+	if err := netlink.LinkSubscribeWithOptions(ch, done, netlink.LinkSubscribeOptions{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case u, ok := <-ch:
+			if !ok {
+				return errors.New("link subscription closed")
+			}
+
+			action := "new"
+			if u.Header.Type == unix.RTM_DELLINK {
+				action = "del"
+			}
+
+			events.Publish(events.TopicLink, LinkEvent{
+				Action: action,
+				Link:   fillOneLink(u.Link),
+			})
+		}
+	}
+}
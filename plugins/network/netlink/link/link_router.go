@@ -8,6 +8,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -15,6 +17,7 @@ func routerAcquireLink(w http.ResponseWriter, r *http.Request) {
 	links, err := AcquireLinks()
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(links, w)
@@ -23,5 +26,7 @@ func routerAcquireLink(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterLink(router *mux.Router) {
 	s := router.PathPrefix("/netlink").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/link", routerAcquireLink).Methods("GET")
+	openapi.Describe(s.HandleFunc("/link", routerAcquireLink).Methods("GET"), "List the links", nil, []LinkInfo{})
+
+	events.RegisterSource(events.TopicLink, WatchLinks)
 }
@@ -0,0 +1,303 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package neighbor
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// Neighbor is an entry of the neighbor table to add, replace or delete.
+// State defaults to PERMANENT.
+type Neighbor struct {
+	Link       string `json:"Link"`
+	IP         string `json:"IP"`
+	MACAddress string `json:"MACAddress"`
+	State      string `json:"State"`
+	Router     bool   `json:"Router"`
+}
+
+type NeighborInfo struct {
+	LinkName   string   `json:"LinkName"`
+	LinkIndex  int      `json:"LinkIndex"`
+	Family     string   `json:"Family"`
+	IP         string   `json:"IP"`
+	MACAddress string   `json:"MACAddress"`
+	State      []string `json:"State"`
+	Flags      []string `json:"Flags"`
+}
+
+var neighborStates = []struct {
+	name  string
+	state int
+}{
+	{"INCOMPLETE", netlink.NUD_INCOMPLETE},
+	{"REACHABLE", netlink.NUD_REACHABLE},
+	{"STALE", netlink.NUD_STALE},
+	{"DELAY", netlink.NUD_DELAY},
+	{"PROBE", netlink.NUD_PROBE},
+	{"FAILED", netlink.NUD_FAILED},
+	{"NOARP", netlink.NUD_NOARP},
+	{"PERMANENT", netlink.NUD_PERMANENT},
+}
+
+var neighborFlags = []struct {
+	name string
+	flag int
+}{
+	{"self", netlink.NTF_SELF},
+	{"master", netlink.NTF_MASTER},
+	{"proxy", netlink.NTF_PROXY},
+	{"extern_learn", netlink.NTF_EXT_LEARNED},
+	{"offload", netlink.NTF_OFFLOADED},
+	{"sticky", netlink.NTF_STICKY},
+	{"router", netlink.NTF_ROUTER},
+}
+
+func decodeJSONRequest(r *http.Request) (*Neighbor, error) {
+	n := Neighbor{}
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+func parseState(state string) (int, error) {
+	if state == "" {
+		return netlink.NUD_PERMANENT, nil
+	}
+
+	for _, s := range neighborStates {
+		if strings.EqualFold(s.name, state) {
+			return s.state, nil
+		}
+	}
+
+	return 0, web.InvalidArgument("State", state)
+}
+
+func parseFamily(family string) (int, error) {
+	switch strings.ToLower(family) {
+	case "":
+		return netlink.FAMILY_ALL, nil
+	case "ipv4", "inet", "4":
+		return netlink.FAMILY_V4, nil
+	case "ipv6", "inet6", "6":
+		return netlink.FAMILY_V6, nil
+	}
+
+	return 0, web.InvalidArgument("family", family)
+}
+
+func (n *Neighbor) buildNeigh(withAddress bool) (*netlink.Neigh, error) {
+	l, err := link.AcquireLinkByName(n.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(n.IP)
+	if ip == nil {
+		return nil, web.InvalidArgument("IP", n.IP)
+	}
+
+	neigh := netlink.Neigh{
+		LinkIndex: l.Attrs().Index,
+		Family:    netlink.FAMILY_V4,
+		IP:        ip,
+	}
+	if ip.To4() == nil {
+		neigh.Family = netlink.FAMILY_V6
+	}
+
+	if !withAddress {
+		return &neigh, nil
+	}
+
+	if neigh.HardwareAddr, err = net.ParseMAC(n.MACAddress); err != nil {
+		return nil, web.InvalidArgument("MACAddress", n.MACAddress)
+	}
+	if neigh.State, err = parseState(n.State); err != nil {
+		return nil, err
+	}
+	if n.Router {
+		if neigh.Family != netlink.FAMILY_V6 {
+			return nil, web.NewError(web.ErrInvalidArgument, "router flag is for IPv6 neighbors").WithField("Router", "true", "IPv6 only")
+		}
+		neigh.Flags = netlink.NTF_ROUTER
+	}
+
+	return &neigh, nil
+}
+
+func (n *Neighbor) Add() error {
+	neigh, err := n.buildNeigh(true)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.NeighAdd(neigh); err != nil {
+		log.Errorf("Failed to add neighbor ip='%s' link='%s': %v", n.IP, n.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// Replace adds the neighbor or overwrites the entry of its address.
+func (n *Neighbor) Replace() error {
+	neigh, err := n.buildNeigh(true)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.NeighSet(neigh); err != nil {
+		log.Errorf("Failed to replace neighbor ip='%s' link='%s': %v", n.IP, n.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func (n *Neighbor) Remove() error {
+	neigh, err := n.buildNeigh(false)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.NeighDel(neigh); err != nil {
+		log.Errorf("Failed to delete neighbor ip='%s' link='%s': %v", n.IP, n.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func listNeighbors(index int, family int) ([]netlink.Neigh, error) {
+	neighs, err := netlink.NeighList(index, family)
+	if err != nil {
+		return nil, err
+	}
+
+	// Leave out the forwarding database of bridges.
+	var ns []netlink.Neigh
+	for _, n := range neighs {
+		if n.Family == netlink.FAMILY_V4 || n.Family == netlink.FAMILY_V6 {
+			ns = append(ns, n)
+		}
+	}
+
+	return ns, nil
+}
+
+// Flush deletes the entries of a link which were learnt, and the permanent
+// ones too when all is set.
+func Flush(name string, family string, all bool) error {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := parseFamily(family)
+	if err != nil {
+		return err
+	}
+
+	neighs, err := listNeighbors(l.Attrs().Index, f)
+	if err != nil {
+		return err
+	}
+
+	for i := range neighs {
+		if !all && neighs[i].State&(netlink.NUD_PERMANENT|netlink.NUD_NOARP) != 0 {
+			continue
+		}
+
+		// The kernel may have dropped the entry since it was listed.
+		if err := netlink.NeighDel(&neighs[i]); err != nil && !errors.Is(err, unix.ENOENT) {
+			log.Errorf("Failed to delete neighbor ip='%s' link='%s': %v", neighs[i].IP, name, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fillOneNeighbor(n *netlink.Neigh) *NeighborInfo {
+	info := NeighborInfo{
+		LinkIndex: n.LinkIndex,
+		Family:    "ipv4",
+		State:     []string{},
+	}
+
+	if l, err := netlink.LinkByIndex(n.LinkIndex); err == nil {
+		info.LinkName = l.Attrs().Name
+	}
+	if n.Family == netlink.FAMILY_V6 {
+		info.Family = "ipv6"
+	}
+	if n.IP != nil {
+		info.IP = n.IP.String()
+	}
+	if n.HardwareAddr != nil {
+		info.MACAddress = n.HardwareAddr.String()
+	}
+
+	for _, s := range neighborStates {
+		if n.State&s.state != 0 {
+			info.State = append(info.State, s.name)
+		}
+	}
+	if len(info.State) == 0 {
+		info.State = append(info.State, "NONE")
+	}
+
+	for _, f := range neighborFlags {
+		if n.Flags&f.flag != 0 {
+			info.Flags = append(info.Flags, f.name)
+		}
+	}
+
+	return &info
+}
+
+// AcquireNeighbors lists the IPv4 and IPv6 neighbors, of one link and family
+// when given.
+func AcquireNeighbors(name string, family string) ([]NeighborInfo, error) {
+	index := 0
+	if name != "" {
+		l, err := link.AcquireLinkByName(name)
+		if err != nil {
+			return nil, err
+		}
+		index = l.Attrs().Index
+	}
+
+	f, err := parseFamily(family)
+	if err != nil {
+		return nil, err
+	}
+
+	neighs, err := listNeighbors(index, f)
+	if err != nil {
+		return nil, err
+	}
+
+	ns := []NeighborInfo{}
+	for i := range neighs {
+		ns = append(ns, *fillOneNeighbor(&neighs[i]))
+	}
+
+	return ns, nil
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package neighbor
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerAddNeighbor(w http.ResponseWriter, r *http.Request) {
+	n, err := decodeJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		err = n.Replace()
+	} else {
+		err = n.Add()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("neighbor configured", w)
+}
+
+func routerDeleteNeighbor(w http.ResponseWriter, r *http.Request) {
+	n, err := decodeJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := n.Remove(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("neighbor removed", w)
+}
+
+func routerFlushNeighbors(w http.ResponseWriter, r *http.Request) {
+	all := validator.BoolToString(r.URL.Query().Get("all")) == "yes"
+	if err := Flush(mux.Vars(r)["link"], r.URL.Query().Get("family"), all); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("neighbors flushed", w)
+}
+
+func routerAcquireNeighbors(w http.ResponseWriter, r *http.Request) {
+	ns, err := AcquireNeighbors(r.URL.Query().Get("link"), r.URL.Query().Get("family"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(ns, w)
+}
+
+func RegisterRouterNeighbor(router *mux.Router) {
+	s := router.PathPrefix("/netlink").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/neighbor", routerAddNeighbor).Methods("POST"), "Add a neighbor", Neighbor{}, nil)
+	openapi.Describe(s.HandleFunc("/neighbor", routerAddNeighbor).Methods("PUT"), "Add or replace a neighbor", Neighbor{}, nil)
+	openapi.Describe(s.HandleFunc("/neighbor", routerDeleteNeighbor).Methods("DELETE"), "Delete a neighbor", Neighbor{}, nil)
+	openapi.Describe(s.HandleFunc("/neighbor/{link}", routerFlushNeighbors).Methods("DELETE"), "Flush the neighbors of a link", nil, nil)
+	openapi.Describe(s.HandleFunc("/neighbor", routerAcquireNeighbors).Methods("GET"), "List the IPv4 and IPv6 neighbors", nil, []NeighborInfo{})
+}
@@ -7,21 +7,62 @@ import (
 	"encoding/json"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"syscall"
 
-	"github.com/vmware/pmd-next-gen/pkg/parser"
 	log "github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/parser"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
 )
 
-type Route struct {
-	Action  string `json:"action"`
+// NextHop is one path of a multipath route. Weight defaults to 1.
+type NextHop struct {
 	Link    string `json:"link"`
 	Gateway string `json:"gateway"`
+	Weight  int    `json:"weight"`
 	OnLink  string `json:"onlink"`
 }
 
+// Route describes a route to add, replace or remove. Table, Scope, Protocol
+// and Type take the iproute2 names or numbers. Destination takes a prefix, an
+// address or "default".
+type Route struct {
+	Action      string    `json:"action"`
+	Link        string    `json:"link"`
+	Gateway     string    `json:"gateway"`
+	OnLink      string    `json:"onlink"`
+	Destination string    `json:"destination"`
+	Source      string    `json:"source"`
+	Metric      int       `json:"metric"`
+	Table       string    `json:"table"`
+	Scope       string    `json:"scope"`
+	Protocol    string    `json:"protocol"`
+	Type        string    `json:"type"`
+	MTU         int       `json:"mtu"`
+	MultiPath   []NextHop `json:"multipath"`
+}
+
+// RouteFilter restricts the routes listed. Table "all" lists every table,
+// an empty one the main table. Family is "ipv4" or "ipv6".
+type RouteFilter struct {
+	Table  string
+	Family string
+	Link   string
+}
+
+type NextHopInfo struct {
+	LinkName  string   `json:"LinkName"`
+	LinkIndex int      `json:"LinkIndex"`
+	Gw        string   `json:"Gw"`
+	Weight    int      `json:"Weight"`
+	Flags     []string `json:"Flags"`
+}
+
 type RouteInfo struct {
 	LinkName   string `json:"LinkName"`
 	LinkIndex  int    `json:"LinkIndex"`
@@ -46,6 +87,12 @@ type RouteInfo struct {
 	Mtu       int      `json:"MTU"`
 	AdvMSS    int      `json:"AdvMSS"`
 	Hoplimit  int      `json:"Hoplimit"`
+
+	ScopeName    string        `json:"ScopeName"`
+	ProtocolName string        `json:"ProtocolName"`
+	TypeName     string        `json:"TypeName"`
+	TableName    string        `json:"TableName"`
+	NextHops     []NextHopInfo `json:"NextHops"`
 }
 
 func decodeJSONRequest(r *http.Request) (*Route, error) {
@@ -59,7 +106,7 @@ func decodeJSONRequest(r *http.Request) (*Route, error) {
 }
 
 func (rt *Route) AddDefaultGateWay() error {
-	link, err := netlink.LinkByName(rt.Link)
+	link, err := link.AcquireLinkByName(rt.Link)
 	if err != nil {
 		log.Errorf("Failed to find link %s: %v", err, rt.Link)
 		return err
@@ -97,7 +144,7 @@ func (rt *Route) AddDefaultGateWay() error {
 }
 
 func (rt *Route) ReplaceDefaultGateWay() error {
-	link, err := netlink.LinkByName(rt.Link)
+	link, err := link.AcquireLinkByName(rt.Link)
 	if err != nil {
 		return err
 	}
@@ -134,7 +181,7 @@ func (rt *Route) ReplaceDefaultGateWay() error {
 }
 
 func (rt *Route) RemoveGateWay() error {
-	link, err := netlink.LinkByName(rt.Link)
+	link, err := link.AcquireLinkByName(rt.Link)
 	if err != nil {
 		log.Errorf("Failed to delete default gateway='%s': %v", link, err)
 		return err
@@ -162,19 +209,33 @@ func (rt *Route) RemoveGateWay() error {
 	return nil
 }
 
-func fillOneRoute(rt *netlink.Route) *RouteInfo {
-	link, err := netlink.LinkByIndex(rt.LinkIndex)
+func linkName(h *netlink.Handle, index int) (string, error) {
+	link, err := h.LinkByIndex(index)
 	if err != nil {
-		log.Debugf("Failed to acquire link ifindex='%d': %v", rt.LinkIndex, err)
-		return nil
+		log.Debugf("Failed to acquire link ifindex='%d': %v", index, err)
+		return "", err
+	}
+
+	return link.Attrs().Name, nil
+}
+
+// fillOneRoute describes a route. Multipath, blackhole and the like have no
+// link.
+func fillOneRoute(h *netlink.Handle, rt *netlink.Route) *RouteInfo {
+	name := ""
+	if rt.LinkIndex != 0 {
+		var err error
+		if name, err = linkName(h, rt.LinkIndex); err != nil {
+			return nil
+		}
 	}
 
 	route := RouteInfo{
-		LinkName:   link.Attrs().Name,
+		LinkName:   name,
 		LinkIndex:  rt.LinkIndex,
 		ILinkIndex: rt.ILinkIndex,
 		Scope:      int(rt.Scope),
-		Protocol:   rt.Protocol,
+		Protocol:   int(rt.Protocol),
 		Priority:   rt.Priority,
 		Table:      rt.Table,
 		Type:       rt.Type,
@@ -182,6 +243,11 @@ func fillOneRoute(rt *netlink.Route) *RouteInfo {
 		Mtu:        rt.MTU,
 		AdvMSS:     rt.AdvMSS,
 		Hoplimit:   rt.Hoplimit,
+
+		ScopeName:    routeName(routeScopes, int(rt.Scope)),
+		ProtocolName: routeName(routeProtocols, int(rt.Protocol)),
+		TypeName:     routeName(routeTypes, rt.Type),
+		TableName:    TableName(rt.Table),
 	}
 
 	if rt.Gw != nil {
@@ -201,17 +267,29 @@ func fillOneRoute(rt *netlink.Route) *RouteInfo {
 		route.Flags = rt.ListFlags()
 	}
 
+	for _, nh := range rt.MultiPath {
+		hop := NextHopInfo{
+			LinkIndex: nh.LinkIndex,
+			Weight:    nh.Hops + 1,
+		}
+		hop.LinkName, _ = linkName(h, nh.LinkIndex)
+		if nh.Gw != nil {
+			hop.Gw = nh.Gw.String()
+		}
+		if nh.Flags != 0 {
+			hop.Flags = (&netlink.Route{Flags: nh.Flags}).ListFlags()
+		}
+
+		route.NextHops = append(route.NextHops, hop)
+	}
+
 	return &route
 }
 
-func buildRouteList(routes []netlink.Route) []RouteInfo {
+func buildRouteList(h *netlink.Handle, routes []netlink.Route) []RouteInfo {
 	var rts []RouteInfo
 	for _, rt := range routes {
-		if rt.LinkIndex == 0 {
-			continue
-		}
-
-		route := fillOneRoute(&rt)
+		route := fillOneRoute(h, &rt)
 		if route != nil {
 			rts = append(rts, *route)
 		}
@@ -221,12 +299,396 @@ func buildRouteList(routes []netlink.Route) []RouteInfo {
 }
 
 func AcquireRoutes() ([]RouteInfo, error) {
-	routes, err := netlink.RouteList(nil, netlink.FAMILY_ALL)
+	return AcquireRoutesFiltered(&RouteFilter{})
+}
+
+func parseFamily(family string) (int, error) {
+	switch strings.ToLower(family) {
+	case "":
+		return netlink.FAMILY_ALL, nil
+	case "ipv4", "inet", "4":
+		return netlink.FAMILY_V4, nil
+	case "ipv6", "inet6", "6":
+		return netlink.FAMILY_V6, nil
+	}
+
+	return 0, web.InvalidArgument("family", family)
+}
+
+// AcquireRoutesFiltered lists the routes of a table, address family or link.
+func AcquireRoutesFiltered(f *RouteFilter) ([]RouteInfo, error) {
+	return AcquireRoutesFilteredAt(&netlink.Handle{}, f)
+}
+
+// AcquireRoutesFilteredAt lists the routes seen by a netlink handle.
+func AcquireRoutesFilteredAt(h *netlink.Handle, f *RouteFilter) ([]RouteInfo, error) {
+	family, err := parseFamily(f.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := netlink.Route{}
+	var mask uint64
+	switch f.Table {
+	case "":
+	case "all":
+		filter.Table = unix.RT_TABLE_UNSPEC
+		mask |= netlink.RT_FILTER_TABLE
+	default:
+		if filter.Table, err = ParseTable(f.Table); err != nil {
+			return nil, err
+		}
+		mask |= netlink.RT_FILTER_TABLE
+	}
+
+	if f.Link != "" {
+		l, err := link.AcquireLinkByNameAt(h, f.Link)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.LinkIndex = l.Attrs().Index
+		mask |= netlink.RT_FILTER_OIF
+	}
+
+	routes, err := h.RouteListFiltered(family, &filter, mask)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildRouteList(h, routes), nil
+}
+
+// parseAddress takes an address with or without prefix length.
+func parseAddress(field string, address string) (net.IP, error) {
+	if ip := net.ParseIP(address); ip != nil {
+		return ip, nil
+	}
+
+	if ip, _, err := net.ParseCIDR(address); err == nil {
+		return ip, nil
+	}
+
+	return nil, web.InvalidArgument(field, address)
+}
+
+func parseOnLink(onlink string) (int, error) {
+	if onlink == "" {
+		return 0, nil
+	}
+
+	b, err := parser.ParseBool(strings.TrimSpace(onlink))
+	if err != nil {
+		return 0, web.InvalidArgument("onlink", onlink)
+	}
+	if b {
+		return syscall.RTNH_F_ONLINK, nil
+	}
+
+	return 0, nil
+}
+
+func isIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil
+}
+
+// parseDestination returns the prefix of destination. A plain address is a
+// host route and "default" the default route of the family of ipv6.
+func parseDestination(destination string, ipv6 bool) (*net.IPNet, error) {
+	switch destination {
+	case "":
+		return nil, nil
+	case "default":
+		if ipv6 {
+			return &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}, nil
+		}
+		return &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}, nil
+	}
+
+	if _, dst, err := net.ParseCIDR(destination); err == nil {
+		return dst, nil
+	}
+
+	ip := net.ParseIP(destination)
+	if ip == nil {
+		return nil, web.InvalidArgument("destination", destination)
+	}
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// buildRoute turns the request into a netlink route. Fields left out stay
+// unset.
+func (rt *Route) buildRoute() (*netlink.Route, error) {
+	route := netlink.Route{
+		Priority: rt.Metric,
+		MTU:      rt.MTU,
+	}
+
+	if rt.Metric < 0 {
+		return nil, web.InvalidArgument("metric", strconv.Itoa(rt.Metric))
+	}
+	if rt.MTU < 0 {
+		return nil, web.InvalidArgument("mtu", strconv.Itoa(rt.MTU))
+	}
+
+	if rt.Link != "" {
+		l, err := link.AcquireLinkByName(rt.Link)
+		if err != nil {
+			return nil, err
+		}
+		route.LinkIndex = l.Attrs().Index
+	}
+
+	var err error
+	if rt.Gateway != "" {
+		if route.Gw, err = parseAddress("gateway", rt.Gateway); err != nil {
+			return nil, err
+		}
+	}
+	if rt.Source != "" {
+		if route.Src, err = parseAddress("source", rt.Source); err != nil {
+			return nil, err
+		}
+	}
+	if route.Flags, err = parseOnLink(rt.OnLink); err != nil {
+		return nil, err
+	}
+
+	ipv6 := isIPv6(route.Gw) || isIPv6(route.Src)
+	for _, nh := range rt.MultiPath {
+		h := netlink.NexthopInfo{}
+		if nh.Link != "" {
+			l, err := link.AcquireLinkByName(nh.Link)
+			if err != nil {
+				return nil, err
+			}
+			h.LinkIndex = l.Attrs().Index
+		}
+		if nh.Gateway != "" {
+			if h.Gw, err = parseAddress("gateway", nh.Gateway); err != nil {
+				return nil, err
+			}
+			ipv6 = ipv6 || isIPv6(h.Gw)
+		}
+		if nh.Weight < 0 || nh.Weight > 256 {
+			return nil, web.InvalidArgument("weight", strconv.Itoa(nh.Weight))
+		}
+		if nh.Weight > 0 {
+			h.Hops = nh.Weight - 1
+		}
+
+		flags, err := parseOnLink(nh.OnLink)
+		if err != nil {
+			return nil, err
+		}
+		h.Flags = flags
+
+		route.MultiPath = append(route.MultiPath, &h)
+	}
+
+	if route.Dst, err = parseDestination(rt.Destination, ipv6); err != nil {
+		return nil, err
+	}
+
+	if rt.Table != "" {
+		if route.Table, err = ParseTable(rt.Table); err != nil {
+			return nil, err
+		}
+	}
+	if rt.Scope != "" {
+		scope, err := parseRouteScope(rt.Scope)
+		if err != nil {
+			return nil, err
+		}
+		route.Scope = netlink.Scope(scope)
+	}
+	if rt.Protocol != "" {
+		protocol, err := parseRouteProtocol(rt.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		route.Protocol = netlink.RouteProtocol(protocol)
+	}
+	if rt.Type != "" {
+		if route.Type, err = parseRouteType(rt.Type); err != nil {
+			return nil, err
+		}
+	}
+
+	return &route, nil
+}
+
+// buildNewRoute is buildRoute with the defaults of "ip route add": routes
+// without gateway are on link, local ones on host, and protocol is boot.
+func (rt *Route) buildNewRoute() (*netlink.Route, error) {
+	route, err := rt.buildRoute()
 	if err != nil {
 		return nil, err
 	}
 
-	return buildRouteList(routes), nil
+	if route.Dst == nil {
+		return nil, web.NewError(web.ErrInvalidArgument, "missing destination").WithField("destination", "", "required")
+	}
+
+	switch route.Type {
+	case 0, unix.RTN_UNICAST:
+		if route.LinkIndex == 0 && len(route.MultiPath) == 0 {
+			return nil, web.NewError(web.ErrInvalidArgument, "missing link or multipath").WithField("link", "", "required")
+		}
+	case unix.RTN_BLACKHOLE, unix.RTN_UNREACHABLE, unix.RTN_PROHIBIT, unix.RTN_THROW:
+		if route.LinkIndex != 0 || route.Gw != nil || len(route.MultiPath) > 0 {
+			return nil, web.NewError(web.ErrInvalidArgument, "route of type='%s' takes no link or gateway", rt.Type).WithField("type", rt.Type, "takes no link or gateway")
+		}
+	}
+
+	if rt.Scope == "" {
+		switch {
+		case route.Type == unix.RTN_LOCAL:
+			route.Scope = netlink.SCOPE_HOST
+		case (route.Type == 0 || route.Type == unix.RTN_UNICAST) && route.Gw == nil && len(route.MultiPath) == 0:
+			route.Scope = netlink.SCOPE_LINK
+		}
+	}
+
+	if rt.Protocol == "" {
+		route.Protocol = unix.RTPROT_BOOT
+	}
+
+	return route, nil
+}
+
+func (rt *Route) AddRoute() error {
+	route, err := rt.buildNewRoute()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteAdd(route); err != nil {
+		log.Errorf("Failed to add route destination='%s': %v", rt.Destination, err)
+		return err
+	}
+
+	return nil
+}
+
+func (rt *Route) ReplaceRoute() error {
+	route, err := rt.buildNewRoute()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.RouteReplace(route); err != nil {
+		log.Errorf("Failed to replace route destination='%s': %v", rt.Destination, err)
+		return err
+	}
+
+	return nil
+}
+
+// ipNetEqual compares prefixes. The kernel leaves out the destination of
+// default routes.
+func ipNetEqual(a *net.IPNet, b *net.IPNet) bool {
+	isDefault := func(n *net.IPNet) bool {
+		if n == nil {
+			return true
+		}
+		ones, _ := n.Mask.Size()
+		return ones == 0
+	}
+
+	if isDefault(a) || isDefault(b) {
+		return isDefault(a) && isDefault(b)
+	}
+
+	return a.String() == b.String()
+}
+
+func nextHopsEqual(a []*netlink.NexthopInfo, b []*netlink.NexthopInfo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if (a[i].LinkIndex != 0 && a[i].LinkIndex != b[i].LinkIndex) || (a[i].Gw != nil && !a[i].Gw.Equal(b[i].Gw)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches tells whether a route of the kernel has the fields set in the
+// request.
+func (rt *Route) matches(want *netlink.Route, got *netlink.Route) bool {
+	switch {
+	case want.Dst != nil && !ipNetEqual(want.Dst, got.Dst),
+		want.LinkIndex != 0 && want.LinkIndex != got.LinkIndex,
+		want.Gw != nil && !want.Gw.Equal(got.Gw),
+		want.Src != nil && !want.Src.Equal(got.Src),
+		want.Priority != 0 && want.Priority != got.Priority,
+		want.MTU != 0 && want.MTU != got.MTU,
+		rt.Scope != "" && want.Scope != got.Scope,
+		rt.Protocol != "" && want.Protocol != got.Protocol,
+		rt.Type != "" && want.Type != got.Type,
+		len(want.MultiPath) > 0 && !nextHopsEqual(want.MultiPath, got.MultiPath):
+		return false
+	}
+
+	return true
+}
+
+// RemoveRoute deletes the one route matching the fields set in the request.
+// It fails rather than guess when several routes match.
+func (rt *Route) RemoveRoute() error {
+	want, err := rt.buildRoute()
+	if err != nil {
+		return err
+	}
+
+	if want.Dst == nil {
+		return web.NewError(web.ErrInvalidArgument, "missing destination").WithField("destination", "", "required")
+	}
+
+	family := netlink.FAMILY_V4
+	if want.Dst.IP.To4() == nil {
+		family = netlink.FAMILY_V6
+	}
+
+	filter := netlink.Route{Table: unix.RT_TABLE_MAIN}
+	if want.Table != 0 {
+		filter.Table = want.Table
+	}
+
+	routes, err := netlink.RouteListFiltered(family, &filter, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return err
+	}
+
+	var found []netlink.Route
+	for _, r := range routes {
+		if rt.matches(want, &r) {
+			found = append(found, r)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return web.NewError(web.ErrNotFound, "no route destination='%s' found", rt.Destination)
+	case 1:
+	default:
+		return web.NewError(web.ErrConflict, "%d routes destination='%s' match, more fields are needed", len(found), rt.Destination)
+	}
+
+	if err := netlink.RouteDel(&found[0]); err != nil {
+		log.Errorf("Failed to delete route destination='%s': %v", rt.Destination, err)
+		return err
+	}
+
+	return nil
 }
 
 func (rt *Route) Configure() error {
@@ -235,7 +697,22 @@ func (rt *Route) Configure() error {
 		return rt.AddDefaultGateWay()
 	case "replace-default-gw":
 		return rt.ReplaceDefaultGateWay()
+	case "", "add-route":
+		return rt.AddRoute()
+	case "replace-route":
+		return rt.ReplaceRoute()
 	}
 
-	return nil
+	return web.InvalidArgument("action", rt.Action)
+}
+
+func (rt *Route) Remove() error {
+	switch rt.Action {
+	case "remove-default-gw":
+		return rt.RemoveGateWay()
+	case "", "remove-route":
+		return rt.RemoveRoute()
+	}
+
+	return web.InvalidArgument("action", rt.Action)
 }
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package route
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+type RouteEvent struct {
+	Action string    `json:"Action"`
+	Route  RouteInfo `json:"Route"`
+}
+
+// WatchRoutes publishes route changes until ctx is cancelled.
+func WatchRoutes(ctx context.Context) error {
+	ch := make(chan netlink.RouteUpdate)
+	done := make(chan struct{})
+	defer close(done)
+
+	if err := netlink.RouteSubscribeWithOptions(ch, done, netlink.RouteSubscribeOptions{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case u, ok := <-ch:
+			if !ok {
+				return errors.New("route subscription closed")
+			}
+
+			action := "new"
+			if u.Type == unix.RTM_DELROUTE {
+				action = "del"
+			}
+
+			// Routes on links which are already gone cannot be described.
+			if rt := fillOneRoute(&netlink.Handle{}, &u.Route); rt != nil {
+				events.Publish(events.TopicRoute, RouteEvent{
+					Action: action,
+					Route:  *rt,
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package route
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+// Symbolic names of the route attributes, as iproute2 prints them.
+var (
+	routeTables = map[string]int{
+		"default": unix.RT_TABLE_DEFAULT,
+		"main":    unix.RT_TABLE_MAIN,
+		"local":   unix.RT_TABLE_LOCAL,
+	}
+
+	routeScopes = map[string]int{
+		"global":  unix.RT_SCOPE_UNIVERSE,
+		"site":    unix.RT_SCOPE_SITE,
+		"link":    unix.RT_SCOPE_LINK,
+		"host":    unix.RT_SCOPE_HOST,
+		"nowhere": unix.RT_SCOPE_NOWHERE,
+	}
+
+	routeProtocols = map[string]int{
+		"unspec":   unix.RTPROT_UNSPEC,
+		"redirect": unix.RTPROT_REDIRECT,
+		"kernel":   unix.RTPROT_KERNEL,
+		"boot":     unix.RTPROT_BOOT,
+		"static":   unix.RTPROT_STATIC,
+		"ra":       unix.RTPROT_RA,
+		"dhcp":     unix.RTPROT_DHCP,
+		"zebra":    unix.RTPROT_ZEBRA,
+		"bird":     unix.RTPROT_BIRD,
+		"babel":    unix.RTPROT_BABEL,
+		"bgp":      unix.RTPROT_BGP,
+		"isis":     unix.RTPROT_ISIS,
+		"ospf":     unix.RTPROT_OSPF,
+		"rip":      unix.RTPROT_RIP,
+		"eigrp":    unix.RTPROT_EIGRP,
+	}
+
+	routeTypes = map[string]int{
+		"unicast":     unix.RTN_UNICAST,
+		"local":       unix.RTN_LOCAL,
+		"broadcast":   unix.RTN_BROADCAST,
+		"anycast":     unix.RTN_ANYCAST,
+		"multicast":   unix.RTN_MULTICAST,
+		"blackhole":   unix.RTN_BLACKHOLE,
+		"unreachable": unix.RTN_UNREACHABLE,
+		"prohibit":    unix.RTN_PROHIBIT,
+		"throw":       unix.RTN_THROW,
+		"nat":         unix.RTN_NAT,
+	}
+)
+
+// parseRouteName accepts a symbolic name of names or a number below max.
+func parseRouteName(names map[string]int, max uint64, field string, value string) (int, error) {
+	if v, ok := names[strings.ToLower(value)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || v >= max {
+		return 0, web.InvalidArgument(field, value)
+	}
+
+	return int(v), nil
+}
+
+// routeName returns the symbolic name of v, or v itself if it has none.
+func routeName(names map[string]int, v int) string {
+	for n, i := range names {
+		if i == v {
+			return n
+		}
+	}
+
+	return strconv.Itoa(v)
+}
+
+// ParseTable accepts a routing table by name or number.
+func ParseTable(table string) (int, error) {
+	return parseRouteName(routeTables, 1<<32, "table", table)
+}
+
+// TableName returns the name of a routing table, or its number.
+func TableName(table int) string {
+	return routeName(routeTables, table)
+}
+
+func parseRouteScope(scope string) (int, error) {
+	if strings.ToLower(scope) == "universe" {
+		return unix.RT_SCOPE_UNIVERSE, nil
+	}
+
+	return parseRouteName(routeScopes, 1<<8, "scope", scope)
+}
+
+func parseRouteProtocol(protocol string) (int, error) {
+	return parseRouteName(routeProtocols, 1<<8, "protocol", protocol)
+}
+
+func parseRouteType(t string) (int, error) {
+	return parseRouteName(routeTypes, 1<<8, "type", t)
+}
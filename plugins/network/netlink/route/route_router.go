@@ -8,37 +8,50 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerAddRoute(w http.ResponseWriter, r *http.Request) {
 	rt, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
 	if err := rt.Configure(); err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
+
+	web.JSONResponse("route configured", w)
 }
 
 func routerDeleteRoute(w http.ResponseWriter, r *http.Request) {
 	rt, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
-	if err = rt.RemoveGateWay(); err != nil {
+	if err = rt.Remove(); err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
+
+	web.JSONResponse("route removed", w)
 }
 
 func routerAcquireRoute(w http.ResponseWriter, r *http.Request) {
-	rts, err := AcquireRoutes()
+	rts, err := AcquireRoutesFiltered(&RouteFilter{
+		Table:  r.URL.Query().Get("table"),
+		Family: r.URL.Query().Get("family"),
+		Link:   r.URL.Query().Get("link"),
+	})
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(rts, w)
@@ -47,7 +60,11 @@ func routerAcquireRoute(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterRoute(router *mux.Router) {
 	s := router.PathPrefix("/netlink").Subrouter().StrictSlash(false)
 
-	s.HandleFunc("/route/{link}", routerAddRoute).Methods("POST")
-	s.HandleFunc("/route/{link}", routerDeleteRoute).Methods("DELETE")
-	s.HandleFunc("/route", routerAcquireRoute).Methods("GET")
+	openapi.Describe(s.HandleFunc("/route/{link}", routerAddRoute).Methods("POST"), "Add a route", Route{}, nil)
+	openapi.Describe(s.HandleFunc("/route/{link}", routerDeleteRoute).Methods("DELETE"), "Delete a route", Route{}, nil)
+	openapi.Describe(s.HandleFunc("/route", routerAddRoute).Methods("POST"), "Add or replace a route", Route{}, nil)
+	openapi.Describe(s.HandleFunc("/route", routerDeleteRoute).Methods("DELETE"), "Delete the route matching exactly", Route{}, nil)
+	openapi.Describe(s.HandleFunc("/route", routerAcquireRoute).Methods("GET"), "List the routes of a table, family or link", nil, []RouteInfo{})
+
+	events.RegisterSource(events.TopicRoute, WatchRoutes)
 }
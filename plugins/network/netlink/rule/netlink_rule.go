@@ -0,0 +1,564 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package rule
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/parser"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/route"
+)
+
+// Rule is a routing policy rule of the kernel. The fields are named and
+// written like the ones of networkd.RoutingPolicyRuleSection: ports and
+// users take a number or a range "start-end", FirewallMark "mark[/mask]".
+type Rule struct {
+	TypeOfService          string `json:"TypeOfService"`
+	From                   string `json:"From"`
+	To                     string `json:"To"`
+	FirewallMark           string `json:"FirewallMark"`
+	Table                  string `json:"Table"`
+	Priority               string `json:"Priority"`
+	IncomingInterface      string `json:"IncomingInterface"`
+	OutgoingInterface      string `json:"OutgoingInterface"`
+	SourcePort             string `json:"SourcePort"`
+	DestinationPort        string `json:"DestinationPort"`
+	IPProtocol             string `json:"IPProtocol"`
+	InvertRule             string `json:"InvertRule"`
+	Family                 string `json:"Family"`
+	User                   string `json:"User"`
+	SuppressPrefixLength   string `json:"SuppressPrefixLength"`
+	SuppressInterfaceGroup string `json:"SuppressInterfaceGroup"`
+	Type                   string `json:"Type"`
+}
+
+var ruleTypes = map[string]uint8{
+	"table":       nl.FR_ACT_TO_TBL,
+	"goto":        nl.FR_ACT_GOTO,
+	"nop":         nl.FR_ACT_NOP,
+	"blackhole":   nl.FR_ACT_BLACKHOLE,
+	"unreachable": nl.FR_ACT_UNREACHABLE,
+	"prohibit":    nl.FR_ACT_PROHIBIT,
+}
+
+var ipProtocols = map[string]int{
+	"icmp":   unix.IPPROTO_ICMP,
+	"tcp":    unix.IPPROTO_TCP,
+	"udp":    unix.IPPROTO_UDP,
+	"sctp":   unix.IPPROTO_SCTP,
+	"icmpv6": unix.IPPROTO_ICMPV6,
+}
+
+func decodeJSONRequest(r *http.Request) (*Rule, error) {
+	rule := Rule{}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func parseFamily(family string) ([]int, error) {
+	switch family {
+	case "", "ipv4":
+		return []int{netlink.FAMILY_V4}, nil
+	case "ipv6":
+		return []int{netlink.FAMILY_V6}, nil
+	case "both":
+		return []int{netlink.FAMILY_V4, netlink.FAMILY_V6}, nil
+	}
+
+	return nil, web.InvalidArgument("Family", family)
+}
+
+func parsePrefix(field string, prefix string) (*net.IPNet, error) {
+	if _, n, err := net.ParseCIDR(prefix); err == nil {
+		return n, nil
+	}
+
+	ip := net.ParseIP(prefix)
+	if ip == nil {
+		return nil, web.InvalidArgument(field, prefix)
+	}
+	if ip.To4() != nil {
+		return &net.IPNet{IP: ip.To4(), Mask: net.CIDRMask(32, 32)}, nil
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// parseRange parses "start" or "start-end" with bits wide bounds.
+func parseRange(field string, value string, bits int) (uint64, uint64, error) {
+	s, e, ok := strings.Cut(value, "-")
+	if !ok {
+		e = s
+	}
+
+	start, err := strconv.ParseUint(s, 10, bits)
+	if err != nil {
+		return 0, 0, web.InvalidArgument(field, value)
+	}
+	end, err := strconv.ParseUint(e, 10, bits)
+	if err != nil || end < start {
+		return 0, 0, web.InvalidArgument(field, value)
+	}
+
+	return start, end, nil
+}
+
+func formatRange(start uint64, end uint64) string {
+	if start == end {
+		return strconv.FormatUint(start, 10)
+	}
+
+	return fmt.Sprintf("%d-%d", start, end)
+}
+
+// buildRule turns the request into a netlink rule of the given family.
+// Fields left out stay unset.
+func (r *Rule) buildRule(family int) (*netlink.Rule, error) {
+	rule := netlink.NewRule()
+	rule.Family = family
+
+	var err error
+	if r.From != "" {
+		if rule.Src, err = parsePrefix("From", r.From); err != nil {
+			return nil, err
+		}
+	}
+	if r.To != "" {
+		if rule.Dst, err = parsePrefix("To", r.To); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range []*net.IPNet{rule.Src, rule.Dst} {
+		if n != nil && (n.IP.To4() != nil) != (family == netlink.FAMILY_V4) {
+			return nil, web.NewError(web.ErrInvalidArgument, "address of rule does not match family").WithField("Family", r.Family, "does not match the addresses")
+		}
+	}
+
+	if r.TypeOfService != "" {
+		tos, err := strconv.ParseUint(r.TypeOfService, 10, 8)
+		if err != nil {
+			return nil, web.InvalidArgument("TypeOfService", r.TypeOfService)
+		}
+		rule.Tos = uint(tos)
+	}
+
+	if r.FirewallMark != "" {
+		m, k, ok := strings.Cut(r.FirewallMark, "/")
+		mark, err := strconv.ParseUint(m, 0, 32)
+		if err != nil {
+			return nil, web.InvalidArgument("FirewallMark", r.FirewallMark)
+		}
+		rule.Mark = uint32(mark)
+
+		if ok {
+			mask, err := strconv.ParseUint(k, 0, 32)
+			if err != nil {
+				return nil, web.InvalidArgument("FirewallMark", r.FirewallMark)
+			}
+			m := uint32(mask)
+			rule.Mask = &m
+		}
+	}
+
+	if r.Table != "" {
+		if rule.Table, err = route.ParseTable(r.Table); err != nil {
+			return nil, web.InvalidArgument("Table", r.Table)
+		}
+	}
+
+	if r.Priority != "" {
+		p, err := strconv.ParseUint(r.Priority, 10, 32)
+		if err != nil {
+			return nil, web.InvalidArgument("Priority", r.Priority)
+		}
+		rule.Priority = int(p)
+	}
+
+	rule.IifName = r.IncomingInterface
+	rule.OifName = r.OutgoingInterface
+
+	if r.SourcePort != "" {
+		s, e, err := parseRange("SourcePort", r.SourcePort, 16)
+		if err != nil {
+			return nil, err
+		}
+		rule.Sport = netlink.NewRulePortRange(uint16(s), uint16(e))
+	}
+	if r.DestinationPort != "" {
+		s, e, err := parseRange("DestinationPort", r.DestinationPort, 16)
+		if err != nil {
+			return nil, err
+		}
+		rule.Dport = netlink.NewRulePortRange(uint16(s), uint16(e))
+	}
+
+	if r.IPProtocol != "" {
+		p, ok := ipProtocols[strings.ToLower(r.IPProtocol)]
+		if !ok {
+			n, err := strconv.ParseUint(r.IPProtocol, 10, 8)
+			if err != nil {
+				return nil, web.InvalidArgument("IPProtocol", r.IPProtocol)
+			}
+			p = int(n)
+		}
+		rule.IPProto = p
+	}
+
+	if r.InvertRule != "" {
+		if rule.Invert, err = parser.ParseBool(r.InvertRule); err != nil {
+			return nil, web.InvalidArgument("InvertRule", r.InvertRule)
+		}
+	}
+
+	if r.User != "" {
+		s, e, err := parseRange("User", r.User, 32)
+		if err != nil {
+			return nil, err
+		}
+		rule.UIDRange = netlink.NewRuleUIDRange(uint32(s), uint32(e))
+	}
+
+	if r.SuppressPrefixLength != "" {
+		l, err := strconv.ParseUint(r.SuppressPrefixLength, 10, 8)
+		if err != nil || l > 128 {
+			return nil, web.InvalidArgument("SuppressPrefixLength", r.SuppressPrefixLength)
+		}
+		rule.SuppressPrefixlen = int(l)
+	}
+	if r.SuppressInterfaceGroup != "" {
+		g, err := strconv.ParseUint(r.SuppressInterfaceGroup, 10, 31)
+		if err != nil {
+			return nil, web.InvalidArgument("SuppressInterfaceGroup", r.SuppressInterfaceGroup)
+		}
+		rule.SuppressIfgroup = int(g)
+	}
+
+	if r.Type != "" {
+		t, ok := ruleTypes[strings.ToLower(r.Type)]
+		if !ok || t == nl.FR_ACT_GOTO {
+			return nil, web.InvalidArgument("Type", r.Type)
+		}
+		rule.Type = t
+	}
+
+	return rule, nil
+}
+
+// buildRules returns the rule for each family requested.
+func (r *Rule) buildRules() ([]*netlink.Rule, error) {
+	families, err := parseFamily(r.Family)
+	if err != nil {
+		return nil, err
+	}
+
+	// The addresses tell the family unless it is given.
+	if r.Family == "" {
+		for _, a := range []string{r.From, r.To} {
+			if n, err := parsePrefix("From", a); err == nil && n.IP.To4() == nil {
+				families = []int{netlink.FAMILY_V6}
+			}
+		}
+	}
+
+	var rules []*netlink.Rule
+	for _, f := range families {
+		rule, err := r.buildRule(f)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Add adds the rule. Like "ip rule add", a rule looks up the main table
+// unless told otherwise. With both families, the rule of ipv4 is deleted
+// again when the one of ipv6 cannot be added.
+func (r *Rule) Add() error {
+	rules, err := r.buildRules()
+	if err != nil {
+		return err
+	}
+
+	for i, rule := range rules {
+		if rule.Table == 0 && (rule.Type == 0 || rule.Type == nl.FR_ACT_TO_TBL) {
+			rule.Table = unix.RT_TABLE_MAIN
+		}
+
+		if err := netlink.RuleAdd(rule); err != nil {
+			log.Errorf("Failed to add rule '%s': %v", rule, err)
+			if i > 0 {
+				if derr := netlink.RuleDel(rules[0]); derr != nil {
+					log.Errorf("Failed to delete rule '%s' again: %v", rules[0], derr)
+					return web.NewError(web.ErrInternal, "rule of family ipv4 added, failed to add the one of family ipv6: %v", err)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func equalPrefix(a, b *net.IPNet) bool {
+	return a != nil && b != nil && a.String() == b.String()
+}
+
+// ruleMatches tells whether the kernel would delete rule when asked for
+// want: only the fields set in want are compared.
+func ruleMatches(rule *netlink.Rule, want *netlink.Rule) bool {
+	switch {
+	case want.Table != 0 && rule.Table != want.Table:
+	case want.Priority >= 0 && rule.Priority != want.Priority:
+	case want.Src != nil && !equalPrefix(rule.Src, want.Src):
+	case want.Dst != nil && !equalPrefix(rule.Dst, want.Dst):
+	case want.Tos != 0 && rule.Tos != want.Tos:
+	case want.Mark != 0 && rule.Mark != want.Mark:
+	case want.Mask != nil && (rule.Mask == nil || *rule.Mask != *want.Mask):
+	case want.IifName != "" && rule.IifName != want.IifName:
+	case want.OifName != "" && rule.OifName != want.OifName:
+	case want.Sport != nil && (rule.Sport == nil || *rule.Sport != *want.Sport):
+	case want.Dport != nil && (rule.Dport == nil || *rule.Dport != *want.Dport):
+	case want.IPProto != 0 && rule.IPProto != want.IPProto:
+	case want.UIDRange != nil && (rule.UIDRange == nil || *rule.UIDRange != *want.UIDRange):
+	case want.SuppressPrefixlen >= 0 && rule.SuppressPrefixlen != want.SuppressPrefixlen:
+	case want.SuppressIfgroup >= 0 && rule.SuppressIfgroup != want.SuppressIfgroup:
+	case want.Invert && !rule.Invert:
+	default:
+		return true
+	}
+
+	return false
+}
+
+func findRule(want *netlink.Rule) (bool, error) {
+	rules, err := netlink.RuleList(want.Family)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range rules {
+		if ruleMatches(&rules[i], want) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Remove deletes the first rule having the fields set in the request. With
+// both families, the rules are looked up first so that neither is deleted
+// unless both exist.
+func (r *Rule) Remove() error {
+	rules, err := r.buildRules()
+	if err != nil {
+		return err
+	}
+
+	if len(rules) > 1 {
+		for _, rule := range rules {
+			found, err := findRule(rule)
+			if err != nil {
+				log.Errorf("Failed to list rules: %v", err)
+				return err
+			}
+			if !found {
+				return web.NewError(web.ErrNotFound, "no rule found")
+			}
+		}
+	}
+
+	for i, rule := range rules {
+		if err := netlink.RuleDel(rule); err != nil {
+			log.Errorf("Failed to delete rule '%s': %v", rule, err)
+			if i > 0 {
+				return web.NewError(web.ErrInternal, "rule of family ipv4 deleted, failed to delete the one of family ipv6: %v", err)
+			}
+			if err == unix.ENOENT {
+				return web.NewError(web.ErrNotFound, "no rule found")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fillOneRule(rule *netlink.Rule) *Rule {
+	r := Rule{
+		Priority: strconv.Itoa(rule.Priority),
+
+		IncomingInterface: rule.IifName,
+		OutgoingInterface: rule.OifName,
+	}
+
+	if rule.Table != 0 {
+		r.Table = route.TableName(rule.Table)
+	}
+
+	switch rule.Family {
+	case netlink.FAMILY_V4:
+		r.Family = "ipv4"
+	case netlink.FAMILY_V6:
+		r.Family = "ipv6"
+	}
+
+	if rule.Src != nil {
+		r.From = rule.Src.String()
+	}
+	if rule.Dst != nil {
+		r.To = rule.Dst.String()
+	}
+	if rule.Tos != 0 {
+		r.TypeOfService = strconv.FormatUint(uint64(rule.Tos), 10)
+	}
+
+	if rule.Mask != nil && *rule.Mask != math.MaxUint32 {
+		r.FirewallMark = fmt.Sprintf("%d/%d", rule.Mark, *rule.Mask)
+	} else if rule.Mark != 0 {
+		r.FirewallMark = strconv.FormatUint(uint64(rule.Mark), 10)
+	}
+
+	if rule.Sport != nil {
+		r.SourcePort = formatRange(uint64(rule.Sport.Start), uint64(rule.Sport.End))
+	}
+	if rule.Dport != nil {
+		r.DestinationPort = formatRange(uint64(rule.Dport.Start), uint64(rule.Dport.End))
+	}
+
+	if rule.IPProto != 0 {
+		r.IPProtocol = strconv.Itoa(rule.IPProto)
+		for n, p := range ipProtocols {
+			if p == rule.IPProto {
+				r.IPProtocol = n
+			}
+		}
+	}
+
+	if rule.Invert {
+		r.InvertRule = "yes"
+	}
+
+	// The kernel reports the full range for rules without user match.
+	if rule.UIDRange != nil && (rule.UIDRange.Start != 0 || rule.UIDRange.End != math.MaxUint32) {
+		r.User = formatRange(uint64(rule.UIDRange.Start), uint64(rule.UIDRange.End))
+	}
+
+	// Unset suppressors are all ones.
+	if rule.SuppressPrefixlen >= 0 && rule.SuppressPrefixlen <= 128 {
+		r.SuppressPrefixLength = strconv.Itoa(rule.SuppressPrefixlen)
+	}
+	if rule.SuppressIfgroup >= 0 && rule.SuppressIfgroup < math.MaxUint32 {
+		r.SuppressInterfaceGroup = strconv.Itoa(rule.SuppressIfgroup)
+	}
+
+	for n, t := range ruleTypes {
+		if t == rule.Type && t != nl.FR_ACT_TO_TBL {
+			r.Type = n
+		}
+	}
+
+	return &r
+}
+
+// AcquireRules lists the rules of family, or of both families when empty.
+func AcquireRules(family string) ([]Rule, error) {
+	f := netlink.FAMILY_ALL
+	if family != "" {
+		families, err := parseFamily(family)
+		if err != nil {
+			return nil, err
+		}
+		if len(families) == 1 {
+			f = families[0]
+		}
+	}
+
+	rules, err := netlink.RuleList(f)
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := ruleActions(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) != len(rules) {
+		// The rules changed between the dumps.
+		actions = nil
+	}
+
+	rs := []Rule{}
+	for i := range rules {
+		if rules[i].Family != netlink.FAMILY_V4 && rules[i].Family != netlink.FAMILY_V6 {
+			continue
+		}
+
+		if actions != nil && actions[i].family == rules[i].Family && actions[i].priority == rules[i].Priority {
+			rules[i].Type = actions[i].action
+		}
+
+		rs = append(rs, *fillOneRule(&rules[i]))
+	}
+
+	return rs, nil
+}
+
+type ruleAction struct {
+	family   int
+	priority int
+	action   uint8
+}
+
+// ruleActions dumps the action of the rules, in the order of
+// netlink.RuleList which does not report it.
+func ruleActions(family int) ([]ruleAction, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETRULE, unix.NLM_F_DUMP|unix.NLM_F_REQUEST)
+	req.AddData(nl.NewIfInfomsg(family))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWRULE)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []ruleAction
+	for _, m := range msgs {
+		msg := nl.DeserializeRtMsg(m)
+		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		if err != nil {
+			return nil, err
+		}
+
+		a := ruleAction{
+			family: int(msg.Family),
+			action: msg.Type,
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type == nl.FRA_PRIORITY {
+				a.priority = int(binary.NativeEndian.Uint32(attr.Value[0:4]))
+			}
+		}
+
+		actions = append(actions, a)
+	}
+
+	return actions, nil
+}
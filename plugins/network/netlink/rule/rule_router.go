@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package rule
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerAddRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := rule.Add(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("rule added", w)
+}
+
+func routerDeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := decodeJSONRequest(r)
+	if err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := rule.Remove(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("rule removed", w)
+}
+
+func routerAcquireRule(w http.ResponseWriter, r *http.Request) {
+	rules, err := AcquireRules(r.URL.Query().Get("family"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(rules, w)
+}
+
+func RegisterRouterRule(router *mux.Router) {
+	s := router.PathPrefix("/netlink").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/rule", routerAddRule).Methods("POST"), "Add a routing policy rule", Rule{}, nil)
+	openapi.Describe(s.HandleFunc("/rule", routerDeleteRule).Methods("DELETE"), "Delete a routing policy rule", Rule{}, nil)
+	openapi.Describe(s.HandleFunc("/rule", routerAcquireRule).Methods("GET"), "List the routing policy rules", nil, []Rule{})
+}
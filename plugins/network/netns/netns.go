@@ -0,0 +1,302 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package netns
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/address"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/route"
+)
+
+// netNsPath is where named namespaces are bind mounted, as with ip netns.
+const netNsPath = "/run/netns"
+
+type NetNs struct {
+	Name string `json:"Name"`
+}
+
+type NetNsInfo struct {
+	Name    string `json:"Name"`
+	NetNsID int    `json:"NetNsID"`
+}
+
+// NetNsLink names a link to move into or out of a namespace.
+type NetNsLink struct {
+	Link string `json:"Link"`
+}
+
+type Describe struct {
+	Links     []link.LinkInfo       `json:"Links"`
+	Addresses []address.AddressInfo `json:"Addresses"`
+	Routes    []route.RouteInfo     `json:"Routes"`
+}
+
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func validName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, '/') {
+		return web.InvalidArgument("Name", name)
+	}
+
+	return nil
+}
+
+// acquireNetNs opens a named namespace. The caller closes the handle.
+func acquireNetNs(name string) (netns.NsHandle, error) {
+	if err := validName(name); err != nil {
+		return netns.None(), err
+	}
+
+	ns, err := netns.GetFromName(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return netns.None(), web.NewError(web.ErrNotFound, "netns='%s' not found", name)
+		}
+
+		log.Errorf("Failed to open netns='%s': %v", name, err)
+		return netns.None(), err
+	}
+
+	return ns, nil
+}
+
+// acquireHandle opens a netlink handle inside a named namespace. The caller
+// closes the handle.
+func acquireHandle(name string) (*netlink.Handle, error) {
+	ns, err := acquireNetNs(name)
+	if err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	h, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		log.Errorf("Failed to open netlink handle in netns='%s': %v", name, err)
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Create adds a named namespace. netns.NewNamed switches the calling thread
+// into the new namespace, so the thread is locked and switched back before it
+// is handed back to the runtime. A thread which cannot be switched back stays
+// locked and is discarded when the goroutine exits.
+func (n *NetNs) Create() error {
+	if err := validName(n.Name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(netNsPath, n.Name)); err == nil {
+		return web.NewError(web.ErrConflict, "netns='%s' already exists", n.Name)
+	}
+
+	runtime.LockOSThread()
+
+	origin, err := netns.Get()
+	if err != nil {
+		runtime.UnlockOSThread()
+		log.Errorf("Failed to acquire current netns: %v", err)
+		return err
+	}
+	defer origin.Close()
+
+	ns, err := netns.NewNamed(n.Name)
+	if serr := netns.Set(origin); serr != nil {
+		// The thread stays locked, so that it exits with the goroutine
+		// instead of running others in the new namespace.
+		log.Errorf("Failed to switch back from netns='%s': %v", n.Name, serr)
+		if err != nil {
+			return serr
+		}
+
+		ns.Close()
+		return web.NewError(web.ErrInternal, "netns='%s' created, but failed to switch back from it: %v", n.Name, serr)
+	}
+	runtime.UnlockOSThread()
+
+	if err != nil {
+		log.Errorf("Failed to create netns='%s': %v", n.Name, err)
+		return err
+	}
+
+	return ns.Close()
+}
+
+// Remove deletes a named namespace. Its virtual links go away with it and
+// physical ones return to the initial namespace.
+func Remove(name string) error {
+	ns, err := acquireNetNs(name)
+	if err != nil {
+		return err
+	}
+	ns.Close()
+
+	if err := netns.DeleteNamed(name); err != nil {
+		log.Errorf("Failed to delete netns='%s': %v", name, err)
+		return err
+	}
+
+	return nil
+}
+
+// AcquireNetNs lists the named namespaces with the id the namespace of the
+// daemon knows them by, -1 when none is assigned.
+func AcquireNetNs() ([]NetNsInfo, error) {
+	entries, err := os.ReadDir(netNsPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	nss := []NetNsInfo{}
+	for _, e := range entries {
+		info := NetNsInfo{
+			Name:    e.Name(),
+			NetNsID: -1,
+		}
+
+		if ns, err := netns.GetFromName(e.Name()); err == nil {
+			if id, err := netlink.GetNetNsIdByFd(int(ns)); err == nil {
+				info.NetNsID = id
+			}
+			ns.Close()
+		}
+
+		nss = append(nss, info)
+	}
+
+	return nss, nil
+}
+
+// MoveLinkIn moves a link of the namespace of the daemon into a named one.
+func (l *NetNsLink) MoveLinkIn(name string) error {
+	ns, err := acquireNetNs(name)
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+
+	lk, err := link.AcquireLinkByName(l.Link)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.LinkSetNsFd(lk, int(ns)); err != nil {
+		log.Errorf("Failed to move link='%s' into netns='%s': %v", l.Link, name, err)
+		return err
+	}
+
+	return nil
+}
+
+// MoveLinkOut moves a link of a named namespace back into the namespace of
+// the daemon.
+func (l *NetNsLink) MoveLinkOut(name string) error {
+	h, err := acquireHandle(name)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+
+	lk, err := link.AcquireLinkByNameAt(h, l.Link)
+	if err != nil {
+		return err
+	}
+
+	host, err := netns.GetFromPid(os.Getpid())
+	if err != nil {
+		log.Errorf("Failed to acquire netns of the daemon: %v", err)
+		return err
+	}
+	defer host.Close()
+
+	if err := h.LinkSetNsFd(lk, int(host)); err != nil {
+		log.Errorf("Failed to move link='%s' out of netns='%s': %v", l.Link, name, err)
+		return err
+	}
+
+	return nil
+}
+
+func AcquireLinks(name string) ([]link.LinkInfo, error) {
+	h, err := acquireHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	links, err := link.AcquireLinksAt(h)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range links {
+		links[i].Namespace = name
+	}
+
+	return links, nil
+}
+
+func AcquireAddresses(name string) ([]address.AddressInfo, error) {
+	h, err := acquireHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return address.AcquireAddressesAt(h)
+}
+
+func AcquireRoutes(name string, f *route.RouteFilter) ([]route.RouteInfo, error) {
+	h, err := acquireHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	return route.AcquireRoutesFilteredAt(h, f)
+}
+
+// AcquireDescribe describes the links, addresses and routes of a namespace.
+func AcquireDescribe(name string) (*Describe, error) {
+	h, err := acquireHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	defer h.Close()
+
+	d := Describe{}
+	if d.Links, err = link.AcquireLinksAt(h); err != nil {
+		return nil, err
+	}
+	for i := range d.Links {
+		d.Links[i].Namespace = name
+	}
+
+	if d.Addresses, err = address.AcquireAddressesAt(h); err != nil {
+		return nil, err
+	}
+
+	if d.Routes, err = route.AcquireRoutesFilteredAt(h, &route.RouteFilter{}); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
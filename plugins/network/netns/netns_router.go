@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package netns
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/address"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/route"
+)
+
+func routerCreateNetNs(w http.ResponseWriter, r *http.Request) {
+	n := NetNs{}
+	if err := decodeJSONRequest(r, &n); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := n.Create(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("netns created", w)
+}
+
+func routerRemoveNetNs(w http.ResponseWriter, r *http.Request) {
+	if err := Remove(mux.Vars(r)["name"]); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("netns removed", w)
+}
+
+func routerAcquireNetNs(w http.ResponseWriter, r *http.Request) {
+	nss, err := AcquireNetNs()
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(nss, w)
+}
+
+func routerMoveLink(w http.ResponseWriter, r *http.Request) {
+	l := NetNsLink{}
+	if err := decodeJSONRequest(r, &l); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = l.MoveLinkOut(mux.Vars(r)["name"])
+	} else {
+		err = l.MoveLinkIn(mux.Vars(r)["name"])
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("link moved", w)
+}
+
+func routerAcquireLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := AcquireLinks(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(links, w)
+}
+
+func routerAcquireAddresses(w http.ResponseWriter, r *http.Request) {
+	addrs, err := AcquireAddresses(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(addrs, w)
+}
+
+func routerAcquireRoutes(w http.ResponseWriter, r *http.Request) {
+	rts, err := AcquireRoutes(mux.Vars(r)["name"], &route.RouteFilter{
+		Table:  r.URL.Query().Get("table"),
+		Family: r.URL.Query().Get("family"),
+		Link:   r.URL.Query().Get("link"),
+	})
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(rts, w)
+}
+
+func routerAcquireDescribe(w http.ResponseWriter, r *http.Request) {
+	d, err := AcquireDescribe(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(d, w)
+}
+
+func RegisterRouterNetNs(router *mux.Router) {
+	s := router.PathPrefix("/netns").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("", routerCreateNetNs).Methods("POST"), "Create a named network namespace", NetNs{}, nil)
+	openapi.Describe(s.HandleFunc("", routerAcquireNetNs).Methods("GET"), "List the named network namespaces", nil, []NetNsInfo{})
+	openapi.Describe(s.HandleFunc("/{name}", routerRemoveNetNs).Methods("DELETE"), "Delete a named network namespace", nil, nil)
+	openapi.Describe(s.HandleFunc("/{name}/link", routerMoveLink).Methods("POST"), "Move a link into a network namespace", NetNsLink{}, nil)
+	openapi.Describe(s.HandleFunc("/{name}/link", routerMoveLink).Methods("DELETE"), "Move a link out of a network namespace", NetNsLink{}, nil)
+	openapi.Describe(s.HandleFunc("/{name}/link", routerAcquireLinks).Methods("GET"), "List the links of a network namespace", nil, []link.LinkInfo{})
+	openapi.Describe(s.HandleFunc("/{name}/address", routerAcquireAddresses).Methods("GET"), "List the addresses of a network namespace", nil, []address.AddressInfo{})
+	openapi.Describe(s.HandleFunc("/{name}/route", routerAcquireRoutes).Methods("GET"), "List the routes of a network namespace", nil, []route.RouteInfo{})
+	openapi.Describe(s.HandleFunc("/{name}/describe", routerAcquireDescribe).Methods("GET"), "Describe a network namespace", nil, Describe{})
+}
@@ -10,15 +10,23 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/bond"
 	"github.com/vmware/pmd-next-gen/plugins/network/ethtool"
 	"github.com/vmware/pmd-next-gen/plugins/network/firewall"
 	"github.com/vmware/pmd-next-gen/plugins/network/netlink/address"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/bridge"
 	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/neighbor"
 	"github.com/vmware/pmd-next-gen/plugins/network/netlink/route"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/rule"
+	"github.com/vmware/pmd-next-gen/plugins/network/netns"
 	"github.com/vmware/pmd-next-gen/plugins/network/networkd"
 	"github.com/vmware/pmd-next-gen/plugins/network/resolved"
+	"github.com/vmware/pmd-next-gen/plugins/network/tc"
 	"github.com/vmware/pmd-next-gen/plugins/network/timesyncd"
+	"github.com/vmware/pmd-next-gen/plugins/network/wireguard"
 )
 
 type Describe struct {
@@ -94,6 +102,13 @@ func RegisterRouterNetwork(router *mux.Router) {
 	link.RegisterRouterLink(n)
 	address.RegisterRouterAddress(n)
 	route.RegisterRouterRoute(n)
+	rule.RegisterRouterRule(n)
+	neighbor.RegisterRouterNeighbor(n)
+	bridge.RegisterRouterBridge(n)
+	bond.RegisterRouterBond(n)
+	netns.RegisterRouterNetNs(n)
+	tc.RegisterRouterTC(n)
+	wireguard.RegisterRouterWireGuard(n)
 
 	// ethtool
 	ethtool.RegisterRouterEthTool(n)
@@ -107,5 +122,5 @@ func RegisterRouterNetwork(router *mux.Router) {
 	// firewall
 	firewall.RegisterRouterNft(n)
 
-	n.HandleFunc("/describe", routerDescribeNetwork).Methods("GET")
+	openapi.Describe(n.HandleFunc("/describe", routerDescribeNetwork).Methods("GET"), "Describe the network", nil, Describe{})
 }
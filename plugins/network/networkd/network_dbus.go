@@ -6,11 +6,11 @@ package networkd
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/godbus/dbus/v5"
 
 	"github.com/vmware/pmd-next-gen/pkg/bus"
+	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 const (
@@ -28,7 +28,7 @@ type SDConnection struct {
 func NewSDConnection() (*SDConnection, error) {
 	conn, err := bus.SystemBusPrivateConn()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to system bus: %v", err)
+		return nil, web.NewError(web.ErrBackendUnavailable, "failed to connect to system bus: %v", err)
 	}
 
 	return &SDConnection{
@@ -4,7 +4,6 @@
 package networkd
 
 import (
-	"errors"
 	"os"
 	"path"
 	"strconv"
@@ -15,6 +14,7 @@ import (
 
 	"github.com/vmware/pmd-next-gen/pkg/configfile"
 	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func ParseLinkString(ifindex int, key string) (string, error) {
@@ -162,6 +162,7 @@ func ParseNetworkRouteDomains() ([]string, error) {
 func CreateMatchSection(m *configfile.Meta, link string) error {
 	if _, err := m.Cfg.GetSection("Match"); err != nil {
 		m.NewSection("Match")
+		m.Section.Comment = managedComment
 		m.SetKeyToNewSectionString("Name", link)
 	}
 
@@ -253,8 +254,10 @@ func RemoveNetDev(link string, kind string) error {
 	configfile.RemoveFilesGlob("/lib/systemd/network", "*.network", "Match", "Name", link)
 
 	// Remove [Network] section
-	configfile.RemoveFilesSectionGlob("/etc/systemd/network", "*.network", "Network", netDevKindToNetworkKind(kind), link)
-	configfile.RemoveFilesSectionGlob("/lib/systemd/network", "*.network", "Network", netDevKindToNetworkKind(kind), link)
+	if k := netDevKindToNetworkKind(kind); k != "" {
+		configfile.RemoveFilesSectionGlob("/etc/systemd/network", "*.network", "Network", k, link)
+		configfile.RemoveFilesSectionGlob("/lib/systemd/network", "*.network", "Network", k, link)
+	}
 
 	l, err := netlink.LinkByName(link)
 	if err != nil {
@@ -300,7 +303,7 @@ func CreateNetDevNetworkFile(link string, kind string) error {
 
 func RemoveNetDevNetworkFile(link string, kind string) error {
 	if !system.PathExists(buildNetDevNetworkFilePath(link, kind)) {
-		return errors.New("file does not exist")
+		return web.NewError(web.ErrNotFound, "file does not exist")
 	}
 	return os.Remove(buildNetDevNetworkFilePath(link, kind))
 }
@@ -323,6 +326,7 @@ func CreateOrParseLinkFile(link string) (*configfile.Meta, error) {
 		}
 
 		m.NewSection("Match")
+		m.Section.Comment = managedComment
 		l, err := netlink.LinkByName(link)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,500 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package networkd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/configfile"
+	"github.com/vmware/pmd-next-gen/pkg/share"
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+const networkdConfigPath = "/etc/systemd/network"
+
+// managedComment heads the files photon-mgmtd creates. Apply leaves the
+// files without it alone unless pruning is requested.
+const managedComment = "# Managed by photon-mgmtd"
+
+const (
+	FileCreate = "create"
+	FileUpdate = "update"
+	FileDelete = "delete"
+)
+
+// DesiredState is the complete configuration of systemd-networkd. Every
+// .network, .netdev and .link file under /etc/systemd/network which is not
+// part of it is removed, if photon-mgmtd created it or Prune is set.
+type DesiredState struct {
+	Networks []Network `json:"Networks"`
+	NetDevs  []NetDev  `json:"NetDevs"`
+	Links    []Link    `json:"Links"`
+	DryRun   bool      `json:"DryRun"`
+	Prune    bool      `json:"Prune"`
+}
+
+// FileChange is the change of one file in unified diff format.
+type FileChange struct {
+	Path   string `json:"Path"`
+	Action string `json:"Action"`
+	Diff   string `json:"Diff"`
+}
+
+type ApplyResult struct {
+	DryRun  bool         `json:"DryRun"`
+	Changes []FileChange `json:"Changes"`
+}
+
+func markManaged(m *configfile.Meta) {
+	// The parser keeps comments with the section following them.
+	if s := m.Cfg.Sections(); len(s) > 1 {
+		s[1].Comment = managedComment
+	}
+}
+
+func isManaged(file string) bool {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return false
+	}
+
+	return bytes.HasPrefix(b, []byte(managedComment))
+}
+
+func isValidLinkName(name string) bool {
+	return name != "" && len(name) < 16 && !strings.ContainsAny(name, "/ \t\n")
+}
+
+func (n *Network) renderNetworkFile() (*configfile.Meta, error) {
+	if !isValidLinkName(n.Link) {
+		return nil, web.InvalidArgument("Link", n.Link)
+	}
+
+	m, err := configfile.New(path.Join(networkdConfigPath, "10-"+n.Link+".network"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CreateMatchSection(m, n.Link); err != nil {
+		return nil, err
+	}
+
+	if err := n.buildSections(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (n *NetDev) renderNetDevFile() (*configfile.Meta, error) {
+	m, err := configfile.New("")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.BuildNetDevSection(m); err != nil {
+		return nil, err
+	}
+	if !isValidLinkName(n.Name) {
+		return nil, web.InvalidArgument("Name", n.Name)
+	}
+	if !validator.IsNetDevKind(n.Kind) {
+		return nil, web.InvalidArgument("Kind", n.Kind)
+	}
+	if err := n.validateLinks(); err != nil {
+		return nil, err
+	}
+	if err := n.BuildKindSection(m); err != nil {
+		return nil, err
+	}
+
+	markManaged(m)
+	m.Path = buildNetDevFilePath(n.Name, n.Kind)
+
+	return m, nil
+}
+
+func renderMatchOnlyNetworkFile(link string, kind string) (*configfile.Meta, error) {
+	m, err := configfile.New(buildNetDevNetworkFilePath(link, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CreateMatchSection(m, link); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (l *Link) renderLinkFile() (*configfile.Meta, error) {
+	if !isValidLinkName(l.Link) {
+		return nil, web.InvalidArgument("Link", l.Link)
+	}
+
+	m, err := configfile.New(path.Join(networkdConfigPath, "10-"+l.Link+".link"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Match the link by its address like CreateOrParseLinkFile does, or by
+	// its name until it exists.
+	m.NewSection("Match")
+	m.Section.Comment = managedComment
+	if nl, err := netlink.LinkByName(l.Link); err == nil && len(nl.Attrs().HardwareAddr) > 0 {
+		m.SetKeyToNewSectionString("MACAddress", nl.Attrs().HardwareAddr.String())
+	} else {
+		m.SetKeyToNewSectionString("OriginalName", l.Link)
+	}
+
+	if err := l.BuildLinkSection(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// render returns the content of the files of the desired state by path, and
+// the link each .network and .link file is written for.
+func (s *DesiredState) render() (map[string][]byte, map[string]string, error) {
+	var metas []*configfile.Meta
+
+	networks := make(map[string]*configfile.Meta)
+	for i := range s.Networks {
+		m, err := s.Networks[i].renderNetworkFile()
+		if err != nil {
+			log.Errorf("Failed to render .network file of link='%s': %v", s.Networks[i].Link, err)
+			return nil, nil, err
+		}
+
+		if _, ok := networks[s.Networks[i].Link]; ok {
+			return nil, nil, web.NewError(web.ErrInvalidArgument, "duplicate network of link='%s'", s.Networks[i].Link).WithField("Link", s.Networks[i].Link, "duplicate")
+		}
+		networks[s.Networks[i].Link] = m
+		metas = append(metas, m)
+	}
+
+	for i := range s.NetDevs {
+		n := &s.NetDevs[i]
+		m, err := n.renderNetDevFile()
+		if err != nil {
+			log.Errorf("Failed to render .netdev file of netdev='%s': %v", n.Name, err)
+			return nil, nil, err
+		}
+		metas = append(metas, m)
+
+		// Like ConfigureNetDev, give the netdev a .network file unless the
+		// desired state has one.
+		if _, ok := networks[n.Name]; !ok {
+			m, err := renderMatchOnlyNetworkFile(n.Name, n.Kind)
+			if err != nil {
+				return nil, nil, err
+			}
+			networks[n.Name] = m
+			metas = append(metas, m)
+		}
+	}
+
+	// The links a netdev is stacked on or enslaves refer to it by kind.
+	for _, n := range s.NetDevs {
+		for _, l := range n.Links {
+			m, ok := networks[l]
+			if !ok {
+				var err error
+				if m, err = renderMatchOnlyNetworkFile(l, ""); err != nil {
+					return nil, nil, err
+				}
+				networks[l] = m
+				metas = append(metas, m)
+			}
+
+			if err := m.NewKeyToSectionString("Network", netDevKindToNetworkKind(n.Kind), n.Name); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	links := make(map[string]string)
+	for l, m := range networks {
+		links[m.Path] = l
+	}
+
+	for i := range s.Links {
+		m, err := s.Links[i].renderLinkFile()
+		if err != nil {
+			log.Errorf("Failed to render .link file of link='%s': %v", s.Links[i].Link, err)
+			return nil, nil, err
+		}
+		metas = append(metas, m)
+		links[m.Path] = s.Links[i].Link
+	}
+
+	files := make(map[string][]byte)
+	for _, m := range metas {
+		if _, ok := files[m.Path]; ok {
+			return nil, nil, web.NewError(web.ErrInvalidArgument, "more than one definition of file='%s'", m.Path)
+		}
+
+		b, err := m.Bytes()
+		if err != nil {
+			return nil, nil, err
+		}
+		files[m.Path] = b
+	}
+
+	return files, links, nil
+}
+
+// checkPrecedence refuses a .network or .link file which systemd-networkd
+// would not use, as another file read before it selects the same link. The
+// files the desired state writes or removes are left out.
+func checkPrecedence(files map[string][]byte, links map[string]string, changes []FileChange) error {
+	removed := make(map[string]bool)
+	for _, c := range changes {
+		if c.Action == FileDelete {
+			removed[c.Path] = true
+		}
+	}
+
+	paths := make([]string, 0, len(links))
+	for p := range links {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		name := links[p]
+
+		// A link to be created is matched by its name only.
+		var l netlink.Link = &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name}}
+		used := ""
+		if nl, err := netlink.LinkByName(name); err == nil {
+			l = nl
+			if strings.HasSuffix(p, ".network") {
+				used, _ = ParseLinkNetworkFile(nl.Attrs().Index)
+			}
+		}
+
+		for _, f := range unitFiles("*" + path.Ext(p)) {
+			if path.Base(f) == path.Base(p) {
+				break
+			}
+			if _, ok := files[f]; ok || removed[f] {
+				continue
+			}
+
+			m, err := configfile.Load(f)
+			if err != nil {
+				continue
+			}
+
+			if f == used || matchesLink(m, l) {
+				return web.NewError(web.ErrConflict, "file='%s' takes precedence over '%s' for link='%s'", f, p, name).
+					WithField("Link", name, "configured by "+f)
+			}
+		}
+	}
+
+	return nil
+}
+
+// secretKeys lists the keys holding secrets by the section they appear in.
+var secretKeys = map[string][]string{
+	"WireGuard":                 {"PrivateKey"},
+	"WireGuardPeer":             {"PresharedKey"},
+	"MACsecTransmitAssociation": {"Key"},
+	"MACsecReceiveAssociation":  {"Key"},
+}
+
+// redactSecrets masks the values of the secret keys of a file, as the read
+// back of a .netdev does.
+func redactSecrets(content string) string {
+	lines := strings.Split(content, "\n")
+
+	section := ""
+	for i, l := range lines {
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			section = strings.TrimSpace(t[1 : len(t)-1])
+			continue
+		}
+
+		k, v, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+
+		for _, key := range secretKeys[section] {
+			if strings.TrimSpace(k) == key {
+				lines[i] = k + "=" + strings.Repeat(" ", len(v)-len(strings.TrimLeft(v, " "))) + redacted
+				break
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// diffFiles compares the desired files with the ones on disk. Secrets are
+// masked in the diffs, so a change of a secret alone shows as an update
+// without one.
+func diffFiles(files map[string][]byte, prune bool) ([]FileChange, error) {
+	changes := []FileChange{}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		current, err := os.ReadFile(p)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+
+			changes = append(changes, FileChange{
+				Path:   p,
+				Action: FileCreate,
+				Diff:   share.UnifiedDiff("/dev/null", p, "", redactSecrets(string(files[p]))),
+			})
+			continue
+		}
+
+		if !bytes.Equal(current, files[p]) {
+			changes = append(changes, FileChange{
+				Path:   p,
+				Action: FileUpdate,
+				Diff:   share.UnifiedDiff(p, p, redactSecrets(string(current)), redactSecrets(string(files[p]))),
+			})
+		}
+	}
+
+	var existing []string
+	for _, pattern := range []string{"*.network", "*.netdev", "*.link"} {
+		f, err := filepath.Glob(path.Join(networkdConfigPath, pattern))
+		if err != nil {
+			return nil, err
+		}
+		existing = append(existing, f...)
+	}
+	sort.Strings(existing)
+
+	for _, p := range existing {
+		if _, ok := files[p]; ok {
+			continue
+		}
+		if !prune && !isManaged(p) {
+			continue
+		}
+
+		current, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, FileChange{
+			Path:   p,
+			Action: FileDelete,
+			Diff:   share.UnifiedDiff(p, "/dev/null", redactSecrets(string(current)), ""),
+		})
+	}
+
+	return changes, nil
+}
+
+// removeNetDevFile removes a .netdev file and the device, which
+// systemd-networkd keeps after a reload.
+func removeNetDevFile(file string) error {
+	name := ""
+	if m, err := configfile.Load(file); err == nil {
+		name = m.GetKeySectionString("NetDev", "Name")
+	}
+
+	if err := os.Remove(file); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	l, err := netlink.LinkByName(name)
+	if err != nil {
+		return nil
+	}
+
+	return netlink.LinkDel(l)
+}
+
+// Apply writes the desired state and reloads systemd-networkd once. In dry
+// run mode only the changes are returned.
+func (s *DesiredState) Apply(ctx context.Context, w http.ResponseWriter) error {
+	files, links, err := s.render()
+	if err != nil {
+		return err
+	}
+
+	changes, err := diffFiles(files, s.Prune)
+	if err != nil {
+		log.Errorf("Failed to compare network configuration: %v", err)
+		return err
+	}
+
+	if err := checkPrecedence(files, links, changes); err != nil {
+		return err
+	}
+
+	result := ApplyResult{
+		DryRun:  s.DryRun,
+		Changes: changes,
+	}
+	if s.DryRun || len(changes) == 0 {
+		return web.JSONResponse(result, w)
+	}
+
+	for _, c := range changes {
+		switch c.Action {
+		case FileDelete:
+			if strings.HasSuffix(c.Path, ".netdev") {
+				err = removeNetDevFile(c.Path)
+			} else {
+				err = os.Remove(c.Path)
+			}
+		default:
+			err = system.WriteGroupFile(c.Path, files[c.Path], "systemd-network")
+		}
+		if err != nil {
+			log.Errorf("Failed to %s config file='%s': %v", c.Action, c.Path, err)
+			return err
+		}
+	}
+
+	c, err := NewSDConnection()
+	if err != nil {
+		log.Errorf("Failed to establish connection with the system bus: %v", err)
+		return err
+	}
+	defer c.Close()
+
+	if err := c.DBusNetworkReload(ctx); err != nil {
+		return err
+	}
+
+	return web.JSONResponse(result, w)
+}
@@ -0,0 +1,483 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package networkd
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/configfile"
+	"github.com/vmware/pmd-next-gen/pkg/parser"
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// networkdSearchPaths lists the directories systemd-networkd reads, in order
+// of precedence.
+var networkdSearchPaths = []string{
+	"/etc/systemd/network",
+	"/run/systemd/network",
+	"/usr/local/lib/systemd/network",
+	"/usr/lib/systemd/network",
+	"/lib/systemd/network",
+}
+
+// ConfigSources tells where the settings were read from. Files holds the main
+// file followed by its drop-ins. Sources maps each setting, named
+// "Section.Key" or "Section[index].Key" for repeated sections, to its file.
+type ConfigSources struct {
+	Files   []string          `json:"Files"`
+	Sources map[string]string `json:"Sources"`
+}
+
+type NetworkConfig struct {
+	Network
+	ConfigSources
+}
+
+type NetDevConfig struct {
+	NetDev
+	ConfigSources
+}
+
+type LinkConfig struct {
+	Link
+	ConfigSources
+}
+
+// Sections of the files and the fields of the types holding them. An empty
+// field stands for the type itself.
+var (
+	networkSections = map[string]string{
+		"Match":             "MatchSection",
+		"Link":              "LinkSection",
+		"Network":           "NetworkSection",
+		"DHCPv4":            "DHCPv4Section",
+		"DHCPServer":        "DHCPv4ServerSection",
+		"DHCPv6":            "DHCPv6Section",
+		"Address":           "AddressSections",
+		"Route":             "RouteSections",
+		"RoutingPolicyRule": "RoutingPolicyRuleSections",
+		"IPv6SendRA":        "IPv6SendRASection",
+		"IPv6Prefix":        "IPv6PrefixSections",
+		"IPv6RoutePrefix":   "IPv6RoutePrefixSections",
+		"SR-IOV":            "SRIOVSections",
+		"BridgeVLAN":        "BridgeVLANSections",
+
+		"TrafficControlQueueingDiscipline": "TrafficControlQueueingDisciplineSections",
+		"TokenBucketFilter":                "TokenBucketFilterSections",
+		"HierarchyTokenBucket":             "HierarchyTokenBucketSections",
+		"HierarchyTokenBucketClass":        "HierarchyTokenBucketClassSections",
+	}
+
+	netDevSections = map[string]string{
+		"Match":         "MatchSection",
+		"NetDev":        "",
+		"VLAN":          "VLanSection",
+		"MACVLAN":       "MacVLanSection",
+		"MACVTAP":       "MacVLanSection",
+		"IPVLAN":        "IpVLanSection",
+		"IPVTAP":        "IpVLanSection",
+		"VXLAN":         "VxLanSection",
+		"Bond":          "BondSection",
+		"Bridge":        "BridgeSection",
+		"WireGuard":     "WireGuardSection",
+		"WireGuardPeer": "WireGuardPeerSections",
+		"Tun":           "TunOrTapSection",
+		"Tap":           "TunOrTapSection",
+		"VRF":           "VRFSection",
+		"Peer":          "PeerSection",
+		"GENEVE":        "GeneveSection",
+		"Tunnel":        "TunnelSection",
+		"MACsec":        "MACsecSection",
+
+		"MACsecTransmitAssociation": "MACsecTransmitAssociationSection",
+		"MACsecReceiveAssociation":  "MACsecReceiveAssociationSection",
+	}
+
+	linkSections = map[string]string{
+		"Match": "MatchSection",
+		"Link":  "",
+	}
+)
+
+// redacted replaces secrets read back from the files. They stay readable
+// only through the files named by the *File keys.
+const redacted = "<redacted>"
+
+// redactNetDev masks the keys of a netdev which the read-only role must not
+// see.
+func redactNetDev(n *NetDev) {
+	mask := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+
+	mask(&n.WireGuardSection.PrivateKey)
+	mask(&n.WireGuardPeerSection.PresharedKey)
+	for i := range n.WireGuardPeerSections {
+		mask(&n.WireGuardPeerSections[i].PresharedKey)
+	}
+	mask(&n.MACsecTransmitAssociationSection.Key)
+	mask(&n.MACsecReceiveAssociationSection.Key)
+}
+
+// unitFiles returns the files of the search paths matching pattern in the
+// order systemd-networkd reads them: sorted by name across all directories,
+// a file in a directory of higher precedence masking one of the same name.
+// Files masked by an empty file or /dev/null are left out.
+func unitFiles(pattern string) []string {
+	files := make(map[string]string)
+	for _, d := range networkdSearchPaths {
+		matches, err := filepath.Glob(path.Join(d, pattern))
+		if err != nil {
+			continue
+		}
+
+		for _, f := range matches {
+			if _, ok := files[path.Base(f)]; !ok {
+				files[path.Base(f)] = f
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var found []string
+	for _, n := range names {
+		if fi, err := os.Stat(files[n]); err == nil && fi.Size() > 0 {
+			found = append(found, files[n])
+		}
+	}
+
+	return found
+}
+
+// findUnitFile returns the first file systemd-networkd reads for which match
+// returns true.
+func findUnitFile(pattern string, match func(m *configfile.Meta) bool) string {
+	for _, f := range unitFiles(pattern) {
+		m, err := configfile.Load(f)
+		if err != nil {
+			log.Debugf("Failed to parse file='%s': %v", f, err)
+			continue
+		}
+
+		if match(m) {
+			return f
+		}
+	}
+
+	return ""
+}
+
+// dropInFiles returns the drop-ins of file sorted by name.
+func dropInFiles(file string) []string {
+	return unitFiles(path.Join(path.Base(file)+".d", "*.conf"))
+}
+
+// matchValues returns the words assigned to key in the [Match] sections of a
+// file. As in systemd an empty assignment resets the list, and a leading "!"
+// inverts it.
+func matchValues(m *configfile.Meta, key string) (values []string, invert bool) {
+	for _, s := range m.Cfg.Sections() {
+		if s.Name() != "Match" || !s.HasKey(key) {
+			continue
+		}
+
+		for _, v := range s.Key(key).ValueWithShadows() {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				values, invert = nil, false
+				continue
+			}
+			if strings.HasPrefix(v, "!") {
+				invert = true
+				v = v[1:]
+			}
+
+			values = append(values, strings.Fields(v)...)
+		}
+	}
+
+	return values, invert
+}
+
+// linkDriver returns the name of the kernel driver of a link, empty for
+// virtual devices.
+func linkDriver(name string) string {
+	d, err := os.Readlink(path.Join("/sys/class/net", name, "device", "driver"))
+	if err != nil {
+		return ""
+	}
+
+	return path.Base(d)
+}
+
+// matchesLink tells whether the [Match] section of a .network or .link file
+// selects a link. The name, address and driver keys are evaluated; a file
+// matching on any other key is not taken to apply, nor is one without any
+// key, which systemd ignores.
+func matchesLink(m *configfile.Meta, l netlink.Link) bool {
+	keys := 0
+	for _, s := range m.Cfg.Sections() {
+		if s.Name() != "Match" {
+			continue
+		}
+
+		for _, k := range s.KeyStrings() {
+			var ok bool
+
+			values, invert := matchValues(m, k)
+			switch k {
+			case "Name", "OriginalName":
+				ok = matchesGlob(values, l.Attrs().Name)
+			case "Driver":
+				ok = matchesGlob(values, linkDriver(l.Attrs().Name))
+			case "MACAddress":
+				for _, v := range values {
+					if a, err := net.ParseMAC(v); err == nil && bytes.Equal(a, l.Attrs().HardwareAddr) {
+						ok = true
+						break
+					}
+				}
+			default:
+				return false
+			}
+
+			if len(values) == 0 {
+				continue
+			}
+			if ok == invert {
+				return false
+			}
+			keys++
+		}
+	}
+
+	return keys > 0
+}
+
+func matchesGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setValue assigns a setting to a field. Lists accumulate over the
+// assignments and are reset by an empty one, as in systemd.
+func setValue(f reflect.Value, values []string) error {
+	for _, v := range values {
+		switch f.Kind() {
+		case reflect.String:
+			f.SetString(v)
+		case reflect.Slice:
+			if v == "" {
+				f.Set(reflect.Zero(f.Type()))
+				continue
+			}
+			for _, s := range strings.Fields(v) {
+				f.Set(reflect.Append(f, reflect.ValueOf(s)))
+			}
+		case reflect.Bool:
+			b, err := parser.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			f.SetBool(b)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return err
+			}
+			f.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return err
+			}
+			f.SetUint(n)
+		}
+	}
+
+	return nil
+}
+
+// fieldByKey returns the field of the struct v named by key, matching the
+// JSON names the files are written from.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == key || (name == "" && f.Name == key) {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// decodeConfigFiles reads files in order into v, a pointer to the type whose
+// fields hold sections.
+func decodeConfigFiles(files []string, sections map[string]string, v interface{}) (*ConfigSources, error) {
+	c := ConfigSources{
+		Files:   files,
+		Sources: make(map[string]string),
+	}
+
+	root := reflect.ValueOf(v).Elem()
+	for _, file := range files {
+		m, err := configfile.Load(file)
+		if err != nil {
+			log.Errorf("Failed to parse file='%s': %v", file, err)
+			return nil, err
+		}
+
+		for _, s := range m.Cfg.Sections() {
+			if s.Name() == ini.DefaultSection {
+				continue
+			}
+
+			field, ok := sections[s.Name()]
+			if !ok {
+				log.Debugf("Ignoring section='%s' of file='%s'", s.Name(), file)
+				continue
+			}
+
+			prefix := s.Name()
+			target := root
+			if field != "" {
+				target = root.FieldByName(field)
+			}
+			if target.Kind() == reflect.Slice {
+				target.Set(reflect.Append(target, reflect.New(target.Type().Elem()).Elem()))
+				prefix += "[" + strconv.Itoa(target.Len()-1) + "]"
+				target = target.Index(target.Len() - 1)
+			}
+
+			for _, k := range s.Keys() {
+				f, ok := fieldByKey(target, k.Name())
+				if !ok {
+					log.Debugf("Ignoring key='%s' in section='%s' of file='%s'", k.Name(), s.Name(), file)
+					continue
+				}
+
+				if err := setValue(f, k.ValueWithShadows()); err != nil {
+					return nil, web.NewError(web.ErrInvalidArgument, "invalid %s='%s' in file='%s'", k.Name(), k.Value(), file)
+				}
+
+				c.Sources[prefix+"."+k.Name()] = file
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+func networkFilePath(name string) (string, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if n, err := ParseLinkNetworkFile(l.Attrs().Index); err == nil {
+		return n, nil
+	}
+
+	f := path.Join("/etc/systemd/network", "10-"+name+".network")
+	if system.PathExists(f) {
+		return f, nil
+	}
+
+	return "", web.NewError(web.ErrNotFound, "no .network file for link='%s'", name)
+}
+
+// AcquireNetworkConfig reads the .network file of a link and its drop-ins.
+func AcquireNetworkConfig(name string) (*NetworkConfig, error) {
+	f, err := networkFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	n := NetworkConfig{}
+	s, err := decodeConfigFiles(append([]string{f}, dropInFiles(f)...), networkSections, &n.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Link = name
+	n.ConfigSources = *s
+
+	return &n, nil
+}
+
+// AcquireNetDevConfig reads the .netdev file creating the device name and its
+// drop-ins. Secret keys are redacted.
+func AcquireNetDevConfig(name string) (*NetDevConfig, error) {
+	f := findUnitFile("*.netdev", func(m *configfile.Meta) bool {
+		return m.GetKeySectionString("NetDev", "Name") == name
+	})
+	if f == "" {
+		return nil, web.NewError(web.ErrNotFound, "no .netdev file for netdev='%s'", name)
+	}
+
+	n := NetDevConfig{}
+	s, err := decodeConfigFiles(append([]string{f}, dropInFiles(f)...), netDevSections, &n.NetDev)
+	if err != nil {
+		return nil, err
+	}
+
+	n.ConfigSources = *s
+	redactNetDev(&n.NetDev)
+
+	return &n, nil
+}
+
+// AcquireLinkConfig reads the .link file applying to a link, the first one
+// whose [Match] section selects it, and its drop-ins.
+func AcquireLinkConfig(name string) (*LinkConfig, error) {
+	nl, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f := findUnitFile("*.link", func(m *configfile.Meta) bool {
+		return matchesLink(m, nl)
+	})
+	if f == "" {
+		return nil, web.NewError(web.ErrNotFound, "no .link file for link='%s'", name)
+	}
+
+	l := LinkConfig{}
+	s, err := decodeConfigFiles(append([]string{f}, dropInFiles(f)...), linkSections, &l.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	l.Link.Link = name
+	l.ConfigSources = *s
+
+	return &l, nil
+}
@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package networkd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/conf"
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+// ConfirmTimeoutHeader requests a change to be rolled back unless it is
+// confirmed within the given number of seconds.
+const ConfirmTimeoutHeader = "X-Confirm-Timeout"
+
+const rollbackReloadTimeout = 30 * time.Second
+
+// confirmStateFile keeps the change awaiting confirmation across restarts of
+// the daemon.
+var confirmStateFile = filepath.Join(conf.StatePath, "network-confirm.json")
+
+// PendingChange is a change awaiting confirmation.
+type PendingChange struct {
+	Method   string    `json:"Method"`
+	Path     string    `json:"Path"`
+	Started  time.Time `json:"Started"`
+	Deadline time.Time `json:"Deadline"`
+	Files    []string  `json:"Files"`
+}
+
+type ConfirmState struct {
+	Pending *PendingChange `json:"Pending"`
+}
+
+// snapshot holds the content of the files under /etc/systemd/network by path.
+type snapshot map[string][]byte
+
+type confirmation struct {
+	change   PendingChange
+	snapshot snapshot
+	timer    *time.Timer
+}
+
+// savedConfirmation is the change awaiting confirmation as written to
+// confirmStateFile.
+type savedConfirmation struct {
+	Change   PendingChange `json:"Change"`
+	Snapshot snapshot      `json:"Snapshot"`
+}
+
+var (
+	pending *confirmation
+	// confirmMutex serializes the changes while one may await confirmation.
+	confirmMutex sync.Mutex
+)
+
+func takeSnapshot() (snapshot, error) {
+	s := make(snapshot)
+	err := filepath.WalkDir(networkdConfigPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		s[p] = b
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// changedFiles returns the files created, modified or removed since the
+// snapshot was taken.
+func (s snapshot) changedFiles() ([]string, error) {
+	current, err := takeSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for p, b := range current {
+		if old, ok := s[p]; !ok || !bytes.Equal(old, b) {
+			files = append(files, p)
+		}
+	}
+	for p := range s {
+		if _, ok := current[p]; !ok {
+			files = append(files, p)
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// restore puts back the files of the snapshot and removes the ones created
+// since, together with their netdevs.
+func (s snapshot) restore() error {
+	current, err := takeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	for p := range current {
+		if _, ok := s[p]; ok {
+			continue
+		}
+
+		if strings.HasSuffix(p, ".netdev") {
+			err = removeNetDevFile(p)
+		} else {
+			err = os.Remove(p)
+		}
+		if err != nil {
+			log.Errorf("Failed to remove config file='%s': %v", p, err)
+			return err
+		}
+	}
+
+	for p, b := range s {
+		if c, ok := current[p]; ok && bytes.Equal(c, b) {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		if err := system.WriteGroupFile(p, b, "systemd-network"); err != nil {
+			log.Errorf("Failed to restore config file='%s': %v", p, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save writes the change and its snapshot to confirmStateFile. The snapshot
+// may hold keys, so the file is only readable by the daemon.
+func (c *confirmation) save() error {
+	data, err := json.Marshal(savedConfirmation{Change: c.change, Snapshot: c.snapshot})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(confirmStateFile), ".network-confirm-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	return os.Rename(tmp.Name(), confirmStateFile)
+}
+
+func removeConfirmState() {
+	if err := os.Remove(confirmStateFile); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Failed to remove confirm state file='%s': %v", confirmStateFile, err)
+	}
+}
+
+// loadConfirmState picks up the change left awaiting confirmation by a
+// previous run of the daemon. It is rolled back if its deadline passed in
+// the meantime, otherwise it awaits confirmation for the time left.
+func loadConfirmState() {
+	data, err := os.ReadFile(confirmStateFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("Failed to read confirm state file='%s': %v", confirmStateFile, err)
+		}
+		return
+	}
+
+	var saved savedConfirmation
+	if err := json.Unmarshal(data, &saved); err != nil {
+		log.Errorf("Failed to decode confirm state file='%s': %v", confirmStateFile, err)
+		return
+	}
+
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	c := &confirmation{
+		change:   saved.Change,
+		snapshot: saved.Snapshot,
+	}
+
+	left := time.Until(c.change.Deadline)
+	if left <= 0 {
+		log.Warningf("Change method='%s' path='%s' was not confirmed before %s, rolling back", c.change.Method, c.change.Path, c.change.Deadline.Format(time.RFC3339))
+
+		// Keep the state file when the rollback fails so that it is tried
+		// again on the next start.
+		if err := c.snapshot.restore(); err != nil {
+			log.Errorf("Failed to roll back change method='%s' path='%s': %v", c.change.Method, c.change.Path, err)
+			return
+		}
+		removeConfirmState()
+
+		ctx, cancel := context.WithTimeout(context.Background(), rollbackReloadTimeout)
+		defer cancel()
+
+		if err := reloadNetworkd(ctx); err != nil {
+			log.Errorf("Failed to reload systemd-networkd: %v", err)
+		}
+		return
+	}
+
+	c.timer = time.AfterFunc(left, func() { expire(c) })
+	pending = c
+}
+
+func reloadNetworkd(ctx context.Context) error {
+	c, err := NewSDConnection()
+	if err != nil {
+		log.Errorf("Failed to establish connection with the system bus: %v", err)
+		return err
+	}
+	defer c.Close()
+
+	return c.DBusNetworkReload(ctx)
+}
+
+// rollback restores the snapshot of the pending change and reloads
+// systemd-networkd. It must be called with confirmMutex held.
+func rollback(c *confirmation) error {
+	c.timer.Stop()
+	pending = nil
+	removeConfirmState()
+
+	if err := c.snapshot.restore(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackReloadTimeout)
+	defer cancel()
+
+	return reloadNetworkd(ctx)
+}
+
+func expire(c *confirmation) {
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	if pending != c {
+		return
+	}
+
+	log.Warningf("Change method='%s' path='%s' was not confirmed before %s, rolling back", c.change.Method, c.change.Path, c.change.Deadline.Format(time.RFC3339))
+	if err := rollback(c); err != nil {
+		log.Errorf("Failed to roll back change method='%s' path='%s': %v", c.change.Method, c.change.Path, err)
+	}
+}
+
+// confirmResponseWriter holds back the response of a change until it is
+// known whether it awaits confirmation.
+type confirmResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *confirmResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *confirmResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+func isConfirmRoute(r *http.Request) bool {
+	if route := mux.CurrentRoute(r); route != nil {
+		if t, err := route.GetPathTemplate(); err == nil {
+			return strings.HasSuffix(t, "/confirm")
+		}
+	}
+
+	return false
+}
+
+// confirmMiddleware applies the changes requesting confirmation in
+// commit-confirm mode: the files under /etc/systemd/network are saved before
+// the change and restored unless it is confirmed in time. Other changes are
+// refused while one awaits confirmation, as the rollback would revert them.
+func confirmMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || isConfirmRoute(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var timeout time.Duration
+		if v := r.Header.Get(ConfirmTimeoutHeader); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil || n == 0 {
+				web.JSONResponseError(web.InvalidArgument(ConfirmTimeoutHeader, v), w)
+				return
+			}
+			timeout = time.Duration(n) * time.Second
+		}
+
+		confirmMutex.Lock()
+		defer confirmMutex.Unlock()
+
+		if pending != nil {
+			web.JSONResponseError(web.NewError(web.ErrConflict, "change method='%s' path='%s' awaits confirmation until %s",
+				pending.change.Method, pending.change.Path, pending.change.Deadline.Format(time.RFC3339)), w)
+			return
+		}
+
+		if timeout == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		s, err := takeSnapshot()
+		if err != nil {
+			log.Errorf("Failed to save network configuration: %v", err)
+			web.JSONResponseError(err, w)
+			return
+		}
+
+		cw := &confirmResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+
+		files, err := s.changedFiles()
+		if err != nil {
+			log.Errorf("Failed to compare network configuration: %v", err)
+		}
+
+		switch {
+		case len(files) == 0:
+		case cw.status >= http.StatusMultipleChoices:
+			// Do not leave a failed change half applied.
+			if err := s.restore(); err != nil {
+				log.Errorf("Failed to restore network configuration: %v", err)
+			} else if err := reloadNetworkd(r.Context()); err != nil {
+				log.Errorf("Failed to reload systemd-networkd: %v", err)
+			}
+		default:
+			c := &confirmation{
+				change: PendingChange{
+					Method:   r.Method,
+					Path:     r.URL.Path,
+					Started:  time.Now(),
+					Deadline: time.Now().Add(timeout),
+					Files:    files,
+				},
+				snapshot: s,
+			}
+			if err := c.save(); err != nil {
+				// A change which would not be rolled back after a restart
+				// is not applied at all.
+				log.Errorf("Failed to save confirm state file='%s': %v", confirmStateFile, err)
+				if err := s.restore(); err != nil {
+					log.Errorf("Failed to restore network configuration: %v", err)
+				} else if err := reloadNetworkd(r.Context()); err != nil {
+					log.Errorf("Failed to reload systemd-networkd: %v", err)
+				}
+				web.JSONResponseError(err, w)
+				return
+			}
+
+			c.timer = time.AfterFunc(timeout, func() { expire(c) })
+			pending = c
+
+			w.Header().Set("X-Confirm-Deadline", c.change.Deadline.Format(time.RFC3339))
+		}
+
+		w.WriteHeader(cw.status)
+		w.Write(cw.body.Bytes())
+	})
+}
+
+func AcquireConfirmState() *ConfirmState {
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	s := ConfirmState{}
+	if pending != nil {
+		c := pending.change
+		s.Pending = &c
+	}
+
+	return &s
+}
+
+// ConfirmChange keeps the change awaiting confirmation.
+func ConfirmChange() error {
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	if pending == nil {
+		return web.NewError(web.ErrNotFound, "no change awaits confirmation")
+	}
+
+	pending.timer.Stop()
+	removeConfirmState()
+	log.Infof("Confirmed change method='%s' path='%s'", pending.change.Method, pending.change.Path)
+	pending = nil
+
+	return nil
+}
+
+// RollbackChange reverts the change awaiting confirmation right away.
+func RollbackChange() error {
+	confirmMutex.Lock()
+	defer confirmMutex.Unlock()
+
+	if pending == nil {
+		return web.NewError(web.ErrNotFound, "no change awaits confirmation")
+	}
+
+	log.Infof("Rolling back change method='%s' path='%s'", pending.change.Method, pending.change.Path)
+	return rollback(pending)
+}
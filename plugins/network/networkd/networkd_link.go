@@ -6,7 +6,6 @@ package networkd
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
 
@@ -112,7 +111,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.MACAddressPolicy) {
 		if !validator.IsLinkMACAddressPolicy(l.MACAddressPolicy) {
 			log.Errorf("Failed to create .link. Invalid MACAddressPolicy='%s'", l.MACAddressPolicy)
-			return fmt.Errorf("invalid MACAddressPolicy='%s'", l.MACAddressPolicy)
+			return web.InvalidArgument("MACAddressPolicy", l.MACAddressPolicy)
 		}
 
 		m.SetKeySectionString("Link", "MACAddressPolicy", l.MACAddressPolicy)
@@ -120,7 +119,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.MACAddress) {
 		if validator.IsNotMAC(l.MACAddress) {
 			log.Errorf("Failed to create .link. Invalid MACAddress='%s'", l.MACAddress)
-			return fmt.Errorf("invalid MACAddress='%s'", l.MACAddress)
+			return web.InvalidArgument("MACAddress", l.MACAddress)
 		}
 
 		m.SetKeySectionString("Link", "MACAddress", l.MACAddress)
@@ -130,7 +129,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 		for _, name := range l.NamePolicy {
 			if !validator.IsLinkNamePolicy(name) {
 				log.Errorf("Failed to create .link. Invalid NamePolicy='%s'", name)
-				return fmt.Errorf("invalid NamePolicy='%s'", name)
+				return web.InvalidArgument("NamePolicy", name)
 			}
 		}
 		m.SetKeySectionString("Link", "NamePolicy", strings.Join(l.NamePolicy, " "))
@@ -138,7 +137,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.Name) {
 		if !validator.IsLinkName(l.Name) {
 			log.Errorf("Failed to create .link. Invalid Name='%s'", l.Name)
-			return fmt.Errorf("invalid Name='%s'", l.Name)
+			return web.InvalidArgument("Name", l.Name)
 		}
 
 		m.SetKeySectionString("Link", "Name", l.Name)
@@ -148,7 +147,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 		for _, altname := range l.AlternativeNamesPolicy {
 			if !validator.IsLinkAlternativeNamesPolicy(altname) {
 				log.Errorf("Failed to create .link. Invalid AlternativeNamesPolicy='%s'", altname)
-				return fmt.Errorf("invalid AlternativeNamesPolicy='%s'", altname)
+				return web.InvalidArgument("AlternativeNamesPolicy", altname)
 			}
 		}
 		m.SetKeySectionString("Link", "AlternativeNamesPolicy", strings.Join(l.AlternativeNamesPolicy, " "))
@@ -170,7 +169,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.MTUBytes) {
 		if !validator.IsLinkMtu(l.MTUBytes) {
 			log.Errorf("Failed to create .link. Invalid MTUBytes='%s'", l.MTUBytes)
-			return fmt.Errorf("invalid MTUBytes='%s'", l.MTUBytes)
+			return web.InvalidArgument("MTUBytes", l.MTUBytes)
 		}
 
 		m.SetKeySectionString("Link", "MTUBytes", l.MTUBytes)
@@ -179,7 +178,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.BitsPerSecond) {
 		if !validator.IsLinkBitsPerSecond(l.BitsPerSecond) {
 			log.Errorf("Failed to create .link. Invalid BitsPerSecond='%s'", l.BitsPerSecond)
-			return fmt.Errorf("invalid BitsPerSecond='%s'", l.BitsPerSecond)
+			return web.InvalidArgument("BitsPerSecond", l.BitsPerSecond)
 		}
 
 		m.SetKeySectionString("Link", "BitsPerSecond", l.BitsPerSecond)
@@ -188,7 +187,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.Duplex) {
 		if !validator.IsLinkDuplex(l.Duplex) {
 			log.Errorf("Failed to create .link. Invalid Duplex='%s'", l.Duplex)
-			return fmt.Errorf("invalid Duplex='%s'", l.Duplex)
+			return web.InvalidArgument("Duplex", l.Duplex)
 		}
 
 		m.SetKeySectionString("Link", "Duplex", l.Duplex)
@@ -197,7 +196,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.AutoNegotiation) {
 		if !validator.IsBool(l.AutoNegotiation) {
 			log.Errorf("Failed to create .link. Invalid AutoNegotiation='%s'", l.AutoNegotiation)
-			return fmt.Errorf("invalid AutoNegotiation='%s'", l.AutoNegotiation)
+			return web.InvalidArgument("AutoNegotiation", l.AutoNegotiation)
 		}
 
 		m.SetKeySectionString("Link", "AutoNegotiation", l.AutoNegotiation)
@@ -207,7 +206,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 		for _, lan := range l.WakeOnLan {
 			if !validator.IsLinkWakeOnLan(lan) {
 				log.Errorf("Failed to create .link. Invalid WakeOnLan='%s'", lan)
-				return fmt.Errorf("invalid WakeOnLan='%s'", lan)
+				return web.InvalidArgument("WakeOnLan", lan)
 			}
 		}
 		m.SetKeySectionString("Link", "WakeOnLan", strings.Join(l.WakeOnLan, " "))
@@ -216,7 +215,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.WakeOnLanPassword) {
 		if validator.IsNotMAC(l.WakeOnLanPassword) {
 			log.Errorf("Failed to create .link. Invalid WakeOnLanPassword='%s'", l.WakeOnLanPassword)
-			return fmt.Errorf("invalid WakeOnLanPassword='%s'", l.WakeOnLanPassword)
+			return web.InvalidArgument("WakeOnLanPassword", l.WakeOnLanPassword)
 		}
 
 		m.SetKeySectionString("Link", "WakeOnLanPassword", l.WakeOnLanPassword)
@@ -225,7 +224,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.Port) {
 		if !validator.IsLinkPort(l.Port) {
 			log.Errorf("Failed to create .link. Invalid Port='%s'", l.Port)
-			return fmt.Errorf("invalid Port='%s'", l.Port)
+			return web.InvalidArgument("Port", l.Port)
 		}
 
 		m.SetKeySectionString("Link", "Port", l.Port)
@@ -235,7 +234,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 		for _, adv := range l.Advertise {
 			if !validator.IsLinkAdvertise(adv) {
 				log.Errorf("Failed to create .link. Invalid Advertise='%s'", adv)
-				return fmt.Errorf("invalid Advertise='%s'", adv)
+				return web.InvalidArgument("Advertise", adv)
 			}
 		}
 		m.SetKeySectionString("Link", "Advertise", strings.Join(l.Advertise, " "))
@@ -244,7 +243,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.ReceiveChecksumOffload) {
 		if !validator.IsBool(l.ReceiveChecksumOffload) {
 			log.Errorf("Failed to create .link. Invalid ReceiveChecksumOffload='%s'", l.ReceiveChecksumOffload)
-			return fmt.Errorf("invalid ReceiveChecksumOffload='%s'", l.ReceiveChecksumOffload)
+			return web.InvalidArgument("ReceiveChecksumOffload", l.ReceiveChecksumOffload)
 		}
 
 		m.SetKeySectionString("Link", "ReceiveChecksumOffload", l.ReceiveChecksumOffload)
@@ -252,7 +251,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TransmitChecksumOffload) {
 		if !validator.IsBool(l.TransmitChecksumOffload) {
 			log.Errorf("Failed to create .link. Invalid TransmitChecksumOffload='%s'", l.TransmitChecksumOffload)
-			return fmt.Errorf("invalid TransmitChecksumOffload='%s'", l.TransmitChecksumOffload)
+			return web.InvalidArgument("TransmitChecksumOffload", l.TransmitChecksumOffload)
 		}
 
 		m.SetKeySectionString("Link", "TransmitChecksumOffload", l.TransmitChecksumOffload)
@@ -261,7 +260,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TCPSegmentationOffload) {
 		if !validator.IsBool(l.TCPSegmentationOffload) {
 			log.Errorf("Failed to create .link. Invalid TCPSegmentationOffload='%s'", l.TCPSegmentationOffload)
-			return fmt.Errorf("invalid TCPSegmentationOffload='%s'", l.TCPSegmentationOffload)
+			return web.InvalidArgument("TCPSegmentationOffload", l.TCPSegmentationOffload)
 		}
 
 		m.SetKeySectionString("Link", "TCPSegmentationOffload", l.TCPSegmentationOffload)
@@ -269,7 +268,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TCP6SegmentationOffload) {
 		if !validator.IsBool(l.TCP6SegmentationOffload) {
 			log.Errorf("Failed to create .link. Invalid TCP6SegmentationOffload='%s'", l.TCP6SegmentationOffload)
-			return fmt.Errorf("invalid TCP6SegmentationOffload='%s'", l.TCP6SegmentationOffload)
+			return web.InvalidArgument("TCP6SegmentationOffload", l.TCP6SegmentationOffload)
 		}
 
 		m.SetKeySectionString("Link", "TCP6SegmentationOffload", l.TCP6SegmentationOffload)
@@ -278,7 +277,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.GenericSegmentationOffload) {
 		if !validator.IsBool(l.GenericSegmentationOffload) {
 			log.Errorf("Failed to create .link. Invalid GenericSegmentationOffload='%s'", l.GenericSegmentationOffload)
-			return fmt.Errorf("invalid GenericSegmentationOffload='%s'", l.GenericSegmentationOffload)
+			return web.InvalidArgument("GenericSegmentationOffload", l.GenericSegmentationOffload)
 		}
 
 		m.SetKeySectionString("Link", "GenericSegmentationOffload", l.GenericSegmentationOffload)
@@ -286,7 +285,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.GenericReceiveOffload) {
 		if !validator.IsBool(l.GenericReceiveOffload) {
 			log.Errorf("Failed to create .link. Invalid GenericReceiveOffload='%s'", l.GenericReceiveOffload)
-			return fmt.Errorf("invalid GenericReceiveOffload='%s'", l.GenericReceiveOffload)
+			return web.InvalidArgument("GenericReceiveOffload", l.GenericReceiveOffload)
 		}
 
 		m.SetKeySectionString("Link", "GenericReceiveOffload", l.GenericReceiveOffload)
@@ -294,7 +293,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.GenericReceiveOffloadHardware) {
 		if !validator.IsBool(l.GenericReceiveOffloadHardware) {
 			log.Errorf("Failed to create .link. Invalid GenericReceiveOffloadHardware='%s'", l.GenericReceiveOffloadHardware)
-			return fmt.Errorf("invalid GenericReceiveOffloadHardware='%s'", l.GenericReceiveOffloadHardware)
+			return web.InvalidArgument("GenericReceiveOffloadHardware", l.GenericReceiveOffloadHardware)
 		}
 
 		m.SetKeySectionString("Link", "GenericReceiveOffloadHardware", l.GenericReceiveOffloadHardware)
@@ -302,7 +301,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.LargeReceiveOffload) {
 		if !validator.IsBool(l.LargeReceiveOffload) {
 			log.Errorf("Failed to create .link. Invalid LargeReceiveOffload='%s'", l.LargeReceiveOffload)
-			return fmt.Errorf("invalid LargeReceiveOffload='%s'", l.LargeReceiveOffload)
+			return web.InvalidArgument("LargeReceiveOffload", l.LargeReceiveOffload)
 		}
 
 		m.SetKeySectionString("Link", "LargeReceiveOffload", l.LargeReceiveOffload)
@@ -311,7 +310,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.ReceiveVLANCTAGHardwareAcceleration) {
 		if !validator.IsBool(l.ReceiveVLANCTAGHardwareAcceleration) {
 			log.Errorf("Failed to create .link. Invalid ReceiveVLANCTAGHardwareAcceleration='%s'", l.ReceiveVLANCTAGHardwareAcceleration)
-			return fmt.Errorf("invalid ReceiveVLANCTAGHardwareAcceleration='%s'", l.ReceiveVLANCTAGHardwareAcceleration)
+			return web.InvalidArgument("ReceiveVLANCTAGHardwareAcceleration", l.ReceiveVLANCTAGHardwareAcceleration)
 		}
 
 		m.SetKeySectionString("Link", "ReceiveVLANCTAGHardwareAcceleration", l.ReceiveVLANCTAGHardwareAcceleration)
@@ -319,7 +318,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TransmitVLANCTAGHardwareAcceleration) {
 		if !validator.IsBool(l.TransmitVLANCTAGHardwareAcceleration) {
 			log.Errorf("Failed to create .link. Invalid TransmitVLANCTAGHardwareAcceleration='%s'", l.TransmitVLANCTAGHardwareAcceleration)
-			return fmt.Errorf("invalid TransmitVLANCTAGHardwareAcceleration='%s'", l.TransmitVLANCTAGHardwareAcceleration)
+			return web.InvalidArgument("TransmitVLANCTAGHardwareAcceleration", l.TransmitVLANCTAGHardwareAcceleration)
 		}
 
 		m.SetKeySectionString("Link", "TransmitVLANCTAGHardwareAcceleration", l.TransmitVLANCTAGHardwareAcceleration)
@@ -327,7 +326,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.ReceiveVLANCTAGFilter) {
 		if !validator.IsBool(l.ReceiveVLANCTAGFilter) {
 			log.Errorf("Failed to create .link. Invalid ReceiveVLANCTAGFilter='%s'", l.ReceiveVLANCTAGFilter)
-			return fmt.Errorf("invalid ReceiveVLANCTAGFilter='%s'", l.ReceiveVLANCTAGFilter)
+			return web.InvalidArgument("ReceiveVLANCTAGFilter", l.ReceiveVLANCTAGFilter)
 		}
 
 		m.SetKeySectionString("Link", "ReceiveVLANCTAGFilter", l.ReceiveVLANCTAGFilter)
@@ -335,7 +334,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TransmitVLANSTAGHardwareAcceleration) {
 		if !validator.IsBool(l.TransmitVLANSTAGHardwareAcceleration) {
 			log.Errorf("Failed to create .link. Invalid TransmitVLANSTAGHardwareAcceleration='%s'", l.TransmitVLANSTAGHardwareAcceleration)
-			return fmt.Errorf("invalid TransmitVLANSTAGHardwareAcceleration='%s'", l.TransmitVLANSTAGHardwareAcceleration)
+			return web.InvalidArgument("TransmitVLANSTAGHardwareAcceleration", l.TransmitVLANSTAGHardwareAcceleration)
 		}
 
 		m.SetKeySectionString("Link", "TransmitVLANSTAGHardwareAcceleration", l.TransmitVLANSTAGHardwareAcceleration)
@@ -344,7 +343,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.NTupleFilter) {
 		if !validator.IsBool(l.NTupleFilter) {
 			log.Errorf("Failed to create .link. Invalid NTupleFilter='%s'", l.NTupleFilter)
-			return fmt.Errorf("invalid NTupleFilter='%s'", l.NTupleFilter)
+			return web.InvalidArgument("NTupleFilter", l.NTupleFilter)
 		}
 
 		m.SetKeySectionString("Link", "NTupleFilter", l.NTupleFilter)
@@ -353,7 +352,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.RxChannels) {
 		if !validator.IsUintOrMax(l.RxChannels) {
 			log.Errorf("Failed to create .link. Invalid RxChannels='%s'", l.RxChannels)
-			return fmt.Errorf("invalid RxChannels='%s'", l.RxChannels)
+			return web.InvalidArgument("RxChannels", l.RxChannels)
 		}
 
 		m.SetKeySectionString("Link", "RxChannels", l.RxChannels)
@@ -361,7 +360,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TxChannels) {
 		if !validator.IsUintOrMax(l.TxChannels) {
 			log.Errorf("Failed to create .link. Invalid TxChannels='%s'", l.TxChannels)
-			return fmt.Errorf("invalid TxChannels='%s'", l.TxChannels)
+			return web.InvalidArgument("TxChannels", l.TxChannels)
 		}
 
 		m.SetKeySectionString("Link", "TxChannels", l.TxChannels)
@@ -369,7 +368,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.OtherChannels) {
 		if !validator.IsUintOrMax(l.OtherChannels) {
 			log.Errorf("Failed to create .link. Invalid OtherChannels='%s'", l.OtherChannels)
-			return fmt.Errorf("invalid OtherChannels='%s'", l.OtherChannels)
+			return web.InvalidArgument("OtherChannels", l.OtherChannels)
 		}
 
 		m.SetKeySectionString("Link", "OtherChannels", l.OtherChannels)
@@ -377,7 +376,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.CombinedChannels) {
 		if !validator.IsUintOrMax(l.CombinedChannels) {
 			log.Errorf("Failed to create .link. Invalid CombinedChannels='%s'", l.CombinedChannels)
-			return fmt.Errorf("invalid CombinedChannels='%s'", l.CombinedChannels)
+			return web.InvalidArgument("CombinedChannels", l.CombinedChannels)
 		}
 
 		m.SetKeySectionString("Link", "CombinedChannels", l.CombinedChannels)
@@ -386,7 +385,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.RxBufferSize) {
 		if !validator.IsUintOrMax(l.RxBufferSize) {
 			log.Errorf("Failed to create .link. Invalid RxBufferSize='%s'", l.RxBufferSize)
-			return fmt.Errorf("invalid RxBufferSize='%s'", l.RxBufferSize)
+			return web.InvalidArgument("RxBufferSize", l.RxBufferSize)
 		}
 
 		m.SetKeySectionString("Link", "RxBufferSize", l.RxBufferSize)
@@ -394,7 +393,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.RxMiniBufferSize) {
 		if !validator.IsUintOrMax(l.RxMiniBufferSize) {
 			log.Errorf("Failed to create .link. Invalid RxMiniBufferSize='", l.RxMiniBufferSize)
-			return fmt.Errorf("invalid RxMiniBufferSize='%s'", l.RxMiniBufferSize)
+			return web.InvalidArgument("RxMiniBufferSize", l.RxMiniBufferSize)
 		}
 
 		m.SetKeySectionString("Link", "RxMiniBufferSize", l.RxMiniBufferSize)
@@ -402,7 +401,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.RxJumboBufferSize) {
 		if !validator.IsUintOrMax(l.RxJumboBufferSize) {
 			log.Errorf("Failed to create .link. Invalid RxJumboBufferSize='%s': %v", l.RxJumboBufferSize)
-			return fmt.Errorf("invalid RxJumboBufferSize='%s'", l.RxJumboBufferSize)
+			return web.InvalidArgument("RxJumboBufferSize", l.RxJumboBufferSize)
 		}
 
 		m.SetKeySectionString("Link", "RxJumboBufferSize", l.RxJumboBufferSize)
@@ -410,7 +409,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TxBufferSize) {
 		if !validator.IsUintOrMax(l.TxBufferSize) {
 			log.Errorf("Failed to create .link. Invalid TxBufferSize='%s'", l.TxBufferSize)
-			return fmt.Errorf("invalid TxBufferSize='%s'", l.TxBufferSize)
+			return web.InvalidArgument("TxBufferSize", l.TxBufferSize)
 		}
 
 		m.SetKeySectionString("Link", "TxBufferSize", l.TxBufferSize)
@@ -419,7 +418,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.RxFlowControl) {
 		if !validator.IsBool(l.RxFlowControl) {
 			log.Errorf("Failed to create .link. Invalid RxFlowControl='%s'", l.RxFlowControl)
-			return fmt.Errorf("invalid RxFlowControl='%s'", l.RxFlowControl)
+			return web.InvalidArgument("RxFlowControl", l.RxFlowControl)
 		}
 
 		m.SetKeySectionString("Link", "RxFlowControl", l.RxFlowControl)
@@ -427,7 +426,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.TxFlowControl) {
 		if !validator.IsBool(l.TxFlowControl) {
 			log.Errorf("Failed to create .link. Invalid TxFlowControl='%s'", l.TxFlowControl)
-			return fmt.Errorf("invalid TxFlowControl='%s'", l.TxFlowControl)
+			return web.InvalidArgument("TxFlowControl", l.TxFlowControl)
 		}
 
 		m.SetKeySectionString("Link", "TxFlowControl", l.TxFlowControl)
@@ -435,7 +434,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.AutoNegotiationFlowControl) {
 		if !validator.IsBool(l.AutoNegotiationFlowControl) {
 			log.Errorf("Failed to create .link. Invalid AutoNegotiationFlowControl='%s'", l.AutoNegotiationFlowControl)
-			return fmt.Errorf("invalid AutoNegotiationFlowControl='%s'", l.AutoNegotiationFlowControl)
+			return web.InvalidArgument("AutoNegotiationFlowControl", l.AutoNegotiationFlowControl)
 		}
 
 		m.SetKeySectionString("Link", "AutoNegotiationFlowControl", l.AutoNegotiationFlowControl)
@@ -451,7 +450,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.UseAdaptiveRxCoalesce) {
 		if !validator.IsBool(l.UseAdaptiveRxCoalesce) {
 			log.Errorf("Failed to create .link. Invalid UseAdaptiveRxCoalesce='%s'", l.UseAdaptiveRxCoalesce)
-			return fmt.Errorf("invalid UseAdaptiveRxCoalesce='%s'", l.UseAdaptiveRxCoalesce)
+			return web.InvalidArgument("UseAdaptiveRxCoalesce", l.UseAdaptiveRxCoalesce)
 		}
 
 		m.SetKeySectionString("Link", "UseAdaptiveRxCoalesce", l.UseAdaptiveRxCoalesce)
@@ -459,7 +458,7 @@ func (l *Link) BuildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(l.UseAdaptiveTxCoalesce) {
 		if !validator.IsBool(l.UseAdaptiveTxCoalesce) {
 			log.Errorf("Failed to create .link. Invalid UseAdaptiveTxCoalesce='%s'", l.UseAdaptiveTxCoalesce)
-			return fmt.Errorf("invalid UseAdaptiveTxCoalesce='%s'", l.UseAdaptiveTxCoalesce)
+			return web.InvalidArgument("UseAdaptiveTxCoalesce", l.UseAdaptiveTxCoalesce)
 		}
 
 		m.SetKeySectionString("Link", "UseAdaptiveTxCoalesce", l.UseAdaptiveTxCoalesce)
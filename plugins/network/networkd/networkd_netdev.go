@@ -6,7 +6,6 @@ package networkd
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -113,6 +112,56 @@ type TunOrTap struct {
 	KeepCarrier string `json:"KeepCarrier"`
 }
 
+type VRF struct {
+	Table string `json:"Table"`
+}
+
+type Peer struct {
+	Name       string `json:"Name"`
+	MACAddress string `json:"MACAddress"`
+}
+
+type Geneve struct {
+	Id              string `json:"Id"`
+	Remote          string `json:"Remote"`
+	TTL             string `json:"TTL"`
+	DestinationPort string `json:"DestinationPort"`
+}
+
+type Tunnel struct {
+	Local       string `json:"Local"`
+	Remote      string `json:"Remote"`
+	TTL         string `json:"TTL"`
+	Key         string `json:"Key"`
+	InputKey    string `json:"InputKey"`
+	OutputKey   string `json:"OutputKey"`
+	Independent string `json:"Independent"`
+}
+
+type MACsec struct {
+	Port    string `json:"Port"`
+	Encrypt string `json:"Encrypt"`
+}
+
+type MACsecTransmitAssociation struct {
+	PacketNumber   string `json:"PacketNumber"`
+	KeyId          string `json:"KeyId"`
+	Key            string `json:"Key"`
+	KeyFile        string `json:"KeyFile"`
+	Activate       string `json:"Activate"`
+	UseForEncoding string `json:"UseForEncoding"`
+}
+
+type MACsecReceiveAssociation struct {
+	Port         string `json:"Port"`
+	MACAddress   string `json:"MACAddress"`
+	PacketNumber string `json:"PacketNumber"`
+	KeyId        string `json:"KeyId"`
+	Key          string `json:"Key"`
+	KeyFile      string `json:"KeyFile"`
+	Activate     string `json:"Activate"`
+}
+
 type NetDev struct {
 	Links []string `json:"Link"` // Master device
 
@@ -134,6 +183,17 @@ type NetDev struct {
 	WireGuardSection     WireGuard     `json:"WireGuardSection"`
 	WireGuardPeerSection WireGuardPeer `json:"WireGuardPeerSection"`
 	TunOrTapSection      TunOrTap      `json:"TunOrTapSection"`
+	VRFSection           VRF           `json:"VRFSection"`
+	PeerSection          Peer          `json:"PeerSection"`
+	GeneveSection        Geneve        `json:"GeneveSection"`
+	TunnelSection        Tunnel        `json:"TunnelSection"`
+	MACsecSection        MACsec        `json:"MACsecSection"`
+
+	MACsecTransmitAssociationSection MACsecTransmitAssociation `json:"MACsecTransmitAssociationSection"`
+	MACsecReceiveAssociationSection  MACsecReceiveAssociation  `json:"MACsecReceiveAssociationSection"`
+
+	// Further peers, each in a [WireGuardPeer] section of its own.
+	WireGuardPeerSections []WireGuardPeer `json:"WireGuardPeerSections"`
 }
 
 func netDevKindToNetworkKind(s string) string {
@@ -161,6 +221,12 @@ func netDevKindToNetworkKind(s string) string {
 		kind = "Tun"
 	case "tap":
 		kind = "Tap"
+	case "vrf":
+		kind = "VRF"
+	case "gre", "gretap", "ipip", "sit", "vti":
+		kind = "Tunnel"
+	case "macsec":
+		kind = "MACsec"
 	}
 
 	return kind
@@ -184,21 +250,21 @@ func (n *NetDev) BuildNetDevSection(m *configfile.Meta) error {
 
 	if validator.IsEmpty(n.Name) {
 		log.Errorf("Failed to create VLan. Missing NetDev name")
-		return errors.New("missing netdev name")
+		return web.NewError(web.ErrInvalidArgument, "missing netdev name").WithField("Name", "", "required")
 
 	}
 	m.SetKeyToNewSectionString("Name", n.Name)
 
 	if validator.IsEmpty(n.Kind) {
 		log.Errorf("Failed to create VLan. Missing NetDev kind")
-		return errors.New("missing netdev kind")
+		return web.NewError(web.ErrInvalidArgument, "missing netdev kind").WithField("Kind", "", "required")
 	}
 	m.SetKeyToNewSectionString("Kind", n.Kind)
 
 	if !validator.IsEmpty(n.MACAddress) {
 		if validator.IsNotMAC(n.MACAddress) {
 			log.Errorf("Failed to create VLan='%s'. Invalid MACAddress='%s': %v", n.Name, n.MTUBytes)
-			return fmt.Errorf("invalid MACAddress='%s'", n.MACAddress)
+			return web.InvalidArgument("MACAddress", n.MACAddress)
 		}
 
 		m.SetKeyToNewSectionString("MACAddress", n.MACAddress)
@@ -207,7 +273,7 @@ func (n *NetDev) BuildNetDevSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.MTUBytes) {
 		if !validator.IsUint32(n.MTUBytes) {
 			log.Errorf("Failed to create VLan='%s'. Invalid MTUBytes='%s': %v", n.Name, n.MTUBytes)
-			return fmt.Errorf("invalid MTUBytes='%s'", n.MTUBytes)
+			return web.InvalidArgument("MTUBytes", n.MTUBytes)
 		}
 
 		m.SetKeyToNewSectionString("MTUBytes", n.MTUBytes)
@@ -221,7 +287,7 @@ func (n *NetDev) buildVlanSection(m *configfile.Meta) error {
 
 	if n.VLanSection.Id == 0 {
 		log.Errorf("Failed to create VLan='%s'. Missing Id,", n.Name)
-		return errors.New("missing vlan id")
+		return web.NewError(web.ErrInvalidArgument, "missing vlan id").WithField("Id", "", "required")
 	}
 
 	m.SetKeySectionUint("VLAN", "Id", n.VLanSection.Id)
@@ -235,7 +301,7 @@ func (n *NetDev) buildBondSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.BondSection.Mode) {
 		if !validator.IsBondMode(n.BondSection.Mode) {
 			log.Errorf("Failed to create Bond='%s'. Invalid Mode='%s'", n.Name, n.BondSection.Mode)
-			return fmt.Errorf("invalid mode='%s'", n.BondSection.Mode)
+			return web.InvalidArgument("mode", n.BondSection.Mode)
 		}
 		m.SetKeyToNewSectionString("Mode", n.BondSection.Mode)
 	}
@@ -243,7 +309,8 @@ func (n *NetDev) buildBondSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.BondSection.TransmitHashPolicy) {
 		if !validator.IsBondTransmitHashPolicy(n.BondSection.Mode, n.BondSection.TransmitHashPolicy) {
 			log.Errorf("Failed to create Bond='%s'. Invalid TransmitHashPolicy='%s' with mode='%s'", n.Name, n.BondSection.TransmitHashPolicy, n.BondSection.Mode)
-			return fmt.Errorf("invalid transmithashpolicy='%s' with mode='%s'", n.BondSection.TransmitHashPolicy, n.BondSection.Mode)
+			return web.NewError(web.ErrInvalidArgument, "invalid transmithashpolicy='%s' with mode='%s'", n.BondSection.TransmitHashPolicy, n.BondSection.Mode).
+				WithField("transmithashpolicy", n.BondSection.TransmitHashPolicy, "not supported with mode='"+n.BondSection.Mode+"'")
 		}
 		m.SetKeyToNewSectionString("TransmitHashPolicy", n.BondSection.TransmitHashPolicy)
 	}
@@ -251,7 +318,7 @@ func (n *NetDev) buildBondSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.BondSection.LACPTransmitRate) {
 		if !validator.IsBondLACPTransmitRate(n.BondSection.LACPTransmitRate) {
 			log.Errorf("Failed to create Bond='%s'. Invalid LACPTransmitRate='%s'", n.Name, n.BondSection.LACPTransmitRate)
-			return fmt.Errorf("invalid lacptransmitRate='%s'", n.BondSection.LACPTransmitRate)
+			return web.InvalidArgument("lacptransmitRate", n.BondSection.LACPTransmitRate)
 		}
 		m.SetKeyToNewSectionString("LACPTransmitRate", n.BondSection.LACPTransmitRate)
 	}
@@ -279,11 +346,11 @@ func (n *NetDev) buildMacVLanSection(m *configfile.Meta) error {
 	// Mode Validate
 	if validator.IsEmpty(n.MacVLanSection.Mode) {
 		log.Errorf("Failed to create MacVLan='%s'. Missing Mode,", n.Name)
-		return errors.New("missing macvlan mode")
+		return web.NewError(web.ErrInvalidArgument, "missing macvlan mode").WithField("Mode", "", "required")
 	}
 	if !validator.IsMacVLanMode(n.MacVLanSection.Mode) {
 		log.Errorf("Failed to create MacVLan='%s'. Invalid Mode='%s'", n.Name, n.MacVLanSection.Mode)
-		return fmt.Errorf("invalid mode='%s'", n.MacVLanSection.Mode)
+		return web.InvalidArgument("mode", n.MacVLanSection.Mode)
 	}
 	m.SetKeyToNewSectionString("Mode", n.MacVLanSection.Mode)
 
@@ -296,25 +363,25 @@ func (n *NetDev) buildMacVTapSection(m *configfile.Meta) error {
 	// Mode Validate
 	if validator.IsEmpty(n.MacVLanSection.Mode) {
 		log.Errorf("Failed to create MacVTap='%s'. Missing Mode,", n.Name)
-		return errors.New("missing macvlan mode")
+		return web.NewError(web.ErrInvalidArgument, "missing macvlan mode").WithField("Mode", "", "required")
 	}
 	if !validator.IsMacVLanMode(n.MacVLanSection.Mode) {
 		log.Errorf("Failed to create MacVtap='%s'. Invalid Mode='%s'", n.Name, n.MacVLanSection.Mode)
-		return fmt.Errorf("invalid mode='%s'", n.MacVLanSection.Mode)
+		return web.InvalidArgument("mode", n.MacVLanSection.Mode)
 	}
 	m.SetKeyToNewSectionString("Mode", n.MacVLanSection.Mode)
 
 	return nil
 }
 
-func (n *NetDev) buildIpVLanSection(m *configfile.Meta) error {
-	m.NewSection("IPVLAN")
+func (n *NetDev) buildIpVLanSection(kind string, m *configfile.Meta) error {
+	m.NewSection(netDevKindToNetworkKind(kind))
 
 	// Mode Validate
 	if !validator.IsEmpty(n.IpVLanSection.Mode) {
 		if !validator.IsIpVLanMode(n.IpVLanSection.Mode) {
 			log.Errorf("Failed to create IpVLan='%s'. Invalid Mode='%s'", n.Name, n.IpVLanSection.Mode)
-			return fmt.Errorf("invalid mode='%s'", n.IpVLanSection.Mode)
+			return web.InvalidArgument("mode", n.IpVLanSection.Mode)
 		}
 		m.SetKeyToNewSectionString("Mode", n.IpVLanSection.Mode)
 	}
@@ -322,7 +389,7 @@ func (n *NetDev) buildIpVLanSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.IpVLanSection.Flags) {
 		if !validator.IsIpVLanFlags(n.IpVLanSection.Flags) {
 			log.Errorf("Failed to create IpVLan='%s'. Invalid Flags='%s'", n.Name, n.IpVLanSection.Flags)
-			return fmt.Errorf("invalid flags='%s'", n.IpVLanSection.Flags)
+			return web.InvalidArgument("flags", n.IpVLanSection.Flags)
 		}
 		m.SetKeyToNewSectionString("Flags", n.IpVLanSection.Flags)
 	}
@@ -336,19 +403,19 @@ func (n *NetDev) buildVxLanSection(m *configfile.Meta) error {
 	// Mandatory Argument Check VNI
 	if validator.IsEmpty(n.VxLanSection.VNI) {
 		log.Errorf("Failed to create vxlan='%s'. Missing VNI", n.Name)
-		return errors.New("missing vxlan vni")
+		return web.NewError(web.ErrInvalidArgument, "missing vxlan vni").WithField("VNI", "", "required")
 
 	}
 	if !validator.IsVxLanVNI(n.VxLanSection.VNI) {
 		log.Errorf("Failed to create VxLan='%s'. Invalid VNI='%s'", n.Name, n.VxLanSection.VNI)
-		return fmt.Errorf("invalid vni='%s'", n.VxLanSection.VNI)
+		return web.InvalidArgument("vni", n.VxLanSection.VNI)
 	}
 	m.SetKeyToNewSectionString("VNI", n.VxLanSection.VNI)
 
 	if !validator.IsEmpty(n.VxLanSection.Remote) {
 		if !validator.IsIP(n.VxLanSection.Remote) {
 			log.Errorf("Failed to create VxLan='%s'. Invalid Remote='%s'", n.Name, n.VxLanSection.Remote)
-			return fmt.Errorf("invalid remote='%s'", n.VxLanSection.Remote)
+			return web.InvalidArgument("remote", n.VxLanSection.Remote)
 		}
 		m.SetKeyToNewSectionString("Remote", n.VxLanSection.Remote)
 	}
@@ -356,7 +423,7 @@ func (n *NetDev) buildVxLanSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.VxLanSection.Local) {
 		if !validator.IsIP(n.VxLanSection.Local) {
 			log.Errorf("Failed to create VxLan='%s'. Invalid Local='%s'", n.Name, n.VxLanSection.Local)
-			return fmt.Errorf("invalid local='%s'", n.VxLanSection.Local)
+			return web.InvalidArgument("local", n.VxLanSection.Local)
 		}
 		m.SetKeyToNewSectionString("Local", n.VxLanSection.Local)
 	}
@@ -364,7 +431,7 @@ func (n *NetDev) buildVxLanSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.VxLanSection.Group) {
 		if !validator.IsIP(n.VxLanSection.Group) {
 			log.Errorf("Failed to create VxLan='%s'. Invalid Group='%s'", n.Name, n.VxLanSection.Group)
-			return fmt.Errorf("invalid Group='%s'", n.VxLanSection.Group)
+			return web.InvalidArgument("Group", n.VxLanSection.Group)
 		}
 		m.SetKeyToNewSectionString("Group", n.VxLanSection.Group)
 	}
@@ -386,7 +453,7 @@ func (n *NetDev) buildWireGuardSection(m *configfile.Meta) error {
 	// Mandatory Argument Check
 	if validator.IsEmpty(n.WireGuardSection.PrivateKey) && validator.IsEmpty(n.WireGuardSection.PrivateKeyFile) {
 		log.Errorf("Failed to create WireGuard='%s'. Missing PrivateKey and PrivateKeyFile,", n.Name)
-		return errors.New("missing wireguard privatekey and privatekeyfile")
+		return web.NewError(web.ErrInvalidArgument, "missing wireguard privatekey and privatekeyfile").WithField("PrivateKey", "", "required")
 	}
 
 	// PrivateKey Validate
@@ -401,7 +468,7 @@ func (n *NetDev) buildWireGuardSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.WireGuardSection.ListenPort) {
 		if !validator.IsWireGuardListenPort(n.WireGuardSection.ListenPort) {
 			log.Errorf("Failed to create WireGuard='%s'. Invalid ListenPort='%s'", n.Name, n.WireGuardSection.ListenPort)
-			return fmt.Errorf("invalid listenport='%s'", n.WireGuardSection.ListenPort)
+			return web.InvalidArgument("listenport", n.WireGuardSection.ListenPort)
 		}
 		m.SetKeyToNewSectionString("ListenPort", n.WireGuardSection.ListenPort)
 	}
@@ -409,45 +476,62 @@ func (n *NetDev) buildWireGuardSection(m *configfile.Meta) error {
 	return nil
 }
 
+// buildWireGuardPeerSection writes a [WireGuardPeer] section per peer, the
+// one of WireGuardPeerSection first.
 func (n *NetDev) buildWireGuardPeerSection(m *configfile.Meta) error {
+	peers := n.WireGuardPeerSections
+	if len(peers) == 0 || !validator.IsEmpty(n.WireGuardPeerSection.PublicKey) {
+		peers = append([]WireGuardPeer{n.WireGuardPeerSection}, peers...)
+	}
+
+	for _, p := range peers {
+		if err := n.buildWireGuardPeer(m, &p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (n *NetDev) buildWireGuardPeer(m *configfile.Meta, p *WireGuardPeer) error {
 	m.NewSection("WireGuardPeer")
 
 	// PublicKey Validate
-	if validator.IsEmpty(n.WireGuardPeerSection.PublicKey) {
+	if validator.IsEmpty(p.PublicKey) {
 		log.Errorf("Failed to create WireGuardPeer='%s'. Missing PublicKey,", n.Name)
-		return errors.New("missing wireguardpeer publickey")
+		return web.NewError(web.ErrInvalidArgument, "missing wireguardpeer publickey").WithField("PublicKey", "", "required")
 	}
-	m.SetKeyToNewSectionString("PublicKey", n.WireGuardPeerSection.PublicKey)
+	m.SetKeyToNewSectionString("PublicKey", p.PublicKey)
 
 	// Endpoint Validate
-	if validator.IsEmpty(n.WireGuardPeerSection.Endpoint) {
+	if validator.IsEmpty(p.Endpoint) {
 		log.Errorf("Failed to create WireGuardPeer='%s'. Missing Endpoint,", n.Name)
-		return errors.New("missing wireguardpeer endpoint")
+		return web.NewError(web.ErrInvalidArgument, "missing wireguardpeer endpoint").WithField("Endpoint", "", "required")
 	}
 
-	if !validator.IsWireGuardPeerEndpoint(n.WireGuardPeerSection.Endpoint) {
-		log.Errorf("Failed to create WireGuard='%s'. Invalid Endpoint='%s'", n.Name, n.WireGuardPeerSection.Endpoint)
-		return fmt.Errorf("invalid endpoint='%s'", n.WireGuardPeerSection.Endpoint)
+	if !validator.IsWireGuardPeerEndpoint(p.Endpoint) {
+		log.Errorf("Failed to create WireGuard='%s'. Invalid Endpoint='%s'", n.Name, p.Endpoint)
+		return web.InvalidArgument("endpoint", p.Endpoint)
 	}
-	m.SetKeyToNewSectionString("Endpoint", n.WireGuardPeerSection.Endpoint)
+	m.SetKeyToNewSectionString("Endpoint", p.Endpoint)
 
 	// PresharedKey Validate
-	if !validator.IsEmpty(n.WireGuardPeerSection.PresharedKey) {
-		m.SetKeyToNewSectionString("PresharedKey", n.WireGuardPeerSection.PresharedKey)
+	if !validator.IsEmpty(p.PresharedKey) {
+		m.SetKeyToNewSectionString("PresharedKey", p.PresharedKey)
 	}
 	// PresharedKeyFile Validate
-	if !validator.IsEmpty(n.WireGuardPeerSection.PresharedKeyFile) {
-		m.SetKeyToNewSectionString("PresharedKeyFile", n.WireGuardPeerSection.PresharedKeyFile)
+	if !validator.IsEmpty(p.PresharedKeyFile) {
+		m.SetKeyToNewSectionString("PresharedKeyFile", p.PresharedKeyFile)
 	}
 	// AllowedIPs Validate
-	if !validator.IsArrayEmpty(n.WireGuardPeerSection.AllowedIPs) {
-		for _, ip := range n.WireGuardPeerSection.AllowedIPs {
+	if !validator.IsArrayEmpty(p.AllowedIPs) {
+		for _, ip := range p.AllowedIPs {
 			if !validator.IsIP(ip) {
-				log.Errorf("Failed to create WireGuardPeer='%s'. Invalid AllowedIPs='%s'", n.Name, n.WireGuardPeerSection.AllowedIPs)
-				return fmt.Errorf("invalid allowedips='%s'", n.WireGuardPeerSection.AllowedIPs)
+				log.Errorf("Failed to create WireGuardPeer='%s'. Invalid AllowedIPs='%s'", n.Name, p.AllowedIPs)
+				return web.InvalidArgument("allowedips", ip)
 			}
 		}
-		m.SetKeyToNewSectionString("AllowedIPs", strings.Join(n.WireGuardPeerSection.AllowedIPs, " "))
+		m.SetKeyToNewSectionString("AllowedIPs", strings.Join(p.AllowedIPs, " "))
 	}
 
 	return nil
@@ -459,7 +543,7 @@ func (n *NetDev) buildTunOrTapSection(kind string, m *configfile.Meta) error {
 	if !validator.IsEmpty(n.TunOrTapSection.MultiQueue) {
 		if !validator.IsBool(n.TunOrTapSection.MultiQueue) {
 			log.Errorf("Failed to create %s='%s'. Invalid MultiQueue='%s'", kind, n.Name, n.TunOrTapSection.MultiQueue)
-			return fmt.Errorf("invalid multiqueue='%s'", n.TunOrTapSection.MultiQueue)
+			return web.InvalidArgument("multiqueue", n.TunOrTapSection.MultiQueue)
 		}
 		m.SetKeyToNewSectionString("MultiQueue", validator.BoolToString(n.TunOrTapSection.MultiQueue))
 	}
@@ -467,7 +551,7 @@ func (n *NetDev) buildTunOrTapSection(kind string, m *configfile.Meta) error {
 	if !validator.IsEmpty(n.TunOrTapSection.PacketInfo) {
 		if !validator.IsBool(n.TunOrTapSection.PacketInfo) {
 			log.Errorf("Failed to create %s='%s'. Invalid PacketInfo='%s'", kind, n.Name, n.TunOrTapSection.PacketInfo)
-			return fmt.Errorf("invalid packetinfo='%s'", n.TunOrTapSection.PacketInfo)
+			return web.InvalidArgument("packetinfo", n.TunOrTapSection.PacketInfo)
 		}
 		m.SetKeyToNewSectionString("PacketInfo", validator.BoolToString(n.TunOrTapSection.PacketInfo))
 	}
@@ -475,7 +559,7 @@ func (n *NetDev) buildTunOrTapSection(kind string, m *configfile.Meta) error {
 	if !validator.IsEmpty(n.TunOrTapSection.VNetHeader) {
 		if !validator.IsBool(n.TunOrTapSection.VNetHeader) {
 			log.Errorf("Failed to create %s='%s'. Invalid VNetHeader='%s'", kind, n.Name, n.TunOrTapSection.VNetHeader)
-			return fmt.Errorf("invalid vnetheader='%s'", n.TunOrTapSection.VNetHeader)
+			return web.InvalidArgument("vnetheader", n.TunOrTapSection.VNetHeader)
 		}
 		m.SetKeyToNewSectionString("VNetHeader", validator.BoolToString(n.TunOrTapSection.VNetHeader))
 	}
@@ -491,7 +575,7 @@ func (n *NetDev) buildTunOrTapSection(kind string, m *configfile.Meta) error {
 	if !validator.IsEmpty(n.TunOrTapSection.KeepCarrier) {
 		if !validator.IsBool(n.TunOrTapSection.KeepCarrier) {
 			log.Errorf("Failed to create %s='%s'. Invalid KeepCarrier='%s'", kind, n.Name, n.TunOrTapSection.KeepCarrier)
-			return fmt.Errorf("invalid keepcarrier='%s'", n.TunOrTapSection.KeepCarrier)
+			return web.InvalidArgument("keepcarrier", n.TunOrTapSection.KeepCarrier)
 		}
 		m.SetKeyToNewSectionString("KeepCarrier", validator.BoolToString(n.TunOrTapSection.KeepCarrier))
 	}
@@ -499,7 +583,263 @@ func (n *NetDev) buildTunOrTapSection(kind string, m *configfile.Meta) error {
 	return nil
 }
 
+func (n *NetDev) buildVRFSection(m *configfile.Meta) error {
+	m.NewSection("VRF")
+
+	if validator.IsEmpty(n.VRFSection.Table) {
+		log.Errorf("Failed to create VRF='%s'. Missing Table", n.Name)
+		return web.NewError(web.ErrInvalidArgument, "missing vrf table").WithField("Table", "", "required")
+	}
+	if !validator.IsVRFTable(n.VRFSection.Table) {
+		log.Errorf("Failed to create VRF='%s'. Invalid Table='%s'", n.Name, n.VRFSection.Table)
+		return web.InvalidArgument("table", n.VRFSection.Table)
+	}
+	m.SetKeyToNewSectionString("Table", n.VRFSection.Table)
+
+	return nil
+}
+
+func (n *NetDev) buildPeerSection(m *configfile.Meta) error {
+	m.NewSection("Peer")
+
+	if validator.IsEmpty(n.PeerSection.Name) {
+		log.Errorf("Failed to create Veth='%s'. Missing peer Name", n.Name)
+		return web.NewError(web.ErrInvalidArgument, "missing veth peer name").WithField("Name", "", "required")
+	}
+	m.SetKeyToNewSectionString("Name", n.PeerSection.Name)
+
+	if !validator.IsEmpty(n.PeerSection.MACAddress) {
+		if validator.IsNotMAC(n.PeerSection.MACAddress) {
+			log.Errorf("Failed to create Veth='%s'. Invalid peer MACAddress='%s'", n.Name, n.PeerSection.MACAddress)
+			return web.InvalidArgument("macaddress", n.PeerSection.MACAddress)
+		}
+		m.SetKeyToNewSectionString("MACAddress", n.PeerSection.MACAddress)
+	}
+
+	return nil
+}
+
+func (n *NetDev) buildGeneveSection(m *configfile.Meta) error {
+	m.NewSection("GENEVE")
+
+	if validator.IsEmpty(n.GeneveSection.Id) {
+		log.Errorf("Failed to create Geneve='%s'. Missing Id", n.Name)
+		return web.NewError(web.ErrInvalidArgument, "missing geneve id").WithField("Id", "", "required")
+	}
+	if !validator.IsGeneveId(n.GeneveSection.Id) {
+		log.Errorf("Failed to create Geneve='%s'. Invalid Id='%s'", n.Name, n.GeneveSection.Id)
+		return web.InvalidArgument("id", n.GeneveSection.Id)
+	}
+	m.SetKeyToNewSectionString("Id", n.GeneveSection.Id)
+
+	if !validator.IsEmpty(n.GeneveSection.Remote) {
+		if !validator.IsIP(n.GeneveSection.Remote) {
+			log.Errorf("Failed to create Geneve='%s'. Invalid Remote='%s'", n.Name, n.GeneveSection.Remote)
+			return web.InvalidArgument("remote", n.GeneveSection.Remote)
+		}
+		m.SetKeyToNewSectionString("Remote", n.GeneveSection.Remote)
+	}
+
+	if !validator.IsEmpty(n.GeneveSection.TTL) {
+		if !validator.IsTTL(n.GeneveSection.TTL) {
+			log.Errorf("Failed to create Geneve='%s'. Invalid TTL='%s'", n.Name, n.GeneveSection.TTL)
+			return web.InvalidArgument("ttl", n.GeneveSection.TTL)
+		}
+		m.SetKeyToNewSectionString("TTL", n.GeneveSection.TTL)
+	}
+
+	if !validator.IsEmpty(n.GeneveSection.DestinationPort) {
+		if !validator.IsPort(n.GeneveSection.DestinationPort) {
+			log.Errorf("Failed to create Geneve='%s'. Invalid DestinationPort='%s'", n.Name, n.GeneveSection.DestinationPort)
+			return web.InvalidArgument("destinationport", n.GeneveSection.DestinationPort)
+		}
+		m.SetKeyToNewSectionString("DestinationPort", n.GeneveSection.DestinationPort)
+	}
+
+	return nil
+}
+
+func (n *NetDev) buildTunnelSection(m *configfile.Meta) error {
+	m.NewSection("Tunnel")
+
+	if !validator.IsEmpty(n.TunnelSection.Local) {
+		if !validator.IsTunnelAddress(n.TunnelSection.Local) {
+			log.Errorf("Failed to create %s='%s'. Invalid Local='%s'", n.Kind, n.Name, n.TunnelSection.Local)
+			return web.InvalidArgument("local", n.TunnelSection.Local)
+		}
+		m.SetKeyToNewSectionString("Local", n.TunnelSection.Local)
+	}
+
+	if !validator.IsEmpty(n.TunnelSection.Remote) {
+		if !validator.IsTunnelAddress(n.TunnelSection.Remote) {
+			log.Errorf("Failed to create %s='%s'. Invalid Remote='%s'", n.Kind, n.Name, n.TunnelSection.Remote)
+			return web.InvalidArgument("remote", n.TunnelSection.Remote)
+		}
+		m.SetKeyToNewSectionString("Remote", n.TunnelSection.Remote)
+	}
+
+	if !validator.IsEmpty(n.TunnelSection.TTL) {
+		if !validator.IsTTL(n.TunnelSection.TTL) {
+			log.Errorf("Failed to create %s='%s'. Invalid TTL='%s'", n.Kind, n.Name, n.TunnelSection.TTL)
+			return web.InvalidArgument("ttl", n.TunnelSection.TTL)
+		}
+		m.SetKeyToNewSectionString("TTL", n.TunnelSection.TTL)
+	}
+
+	keys := []struct {
+		name  string
+		value string
+	}{
+		{"Key", n.TunnelSection.Key},
+		{"InputKey", n.TunnelSection.InputKey},
+		{"OutputKey", n.TunnelSection.OutputKey},
+	}
+	for _, k := range keys {
+		if validator.IsEmpty(k.value) {
+			continue
+		}
+
+		if !validator.IsTunnelKeyKind(n.Kind) {
+			log.Errorf("Failed to create %s='%s'. %s is not supported", n.Kind, n.Name, k.name)
+			return web.NewError(web.ErrInvalidArgument, "%s is not supported with kind='%s'", k.name, n.Kind).WithField(strings.ToLower(k.name), k.value, "not supported with kind='"+n.Kind+"'")
+		}
+		if !validator.IsTunnelKey(k.value) {
+			log.Errorf("Failed to create %s='%s'. Invalid %s='%s'", n.Kind, n.Name, k.name, k.value)
+			return web.InvalidArgument(strings.ToLower(k.name), k.value)
+		}
+		m.SetKeyToNewSectionString(k.name, k.value)
+	}
+
+	if !validator.IsEmpty(n.TunnelSection.Independent) {
+		if !validator.IsBool(n.TunnelSection.Independent) {
+			log.Errorf("Failed to create %s='%s'. Invalid Independent='%s'", n.Kind, n.Name, n.TunnelSection.Independent)
+			return web.InvalidArgument("independent", n.TunnelSection.Independent)
+		}
+		m.SetKeyToNewSectionString("Independent", validator.BoolToString(n.TunnelSection.Independent))
+	}
+
+	return nil
+}
+
+// buildMACsecKey validates the key of a transmit or receive association.
+func (n *NetDev) buildMACsecKey(m *configfile.Meta, keyId, key, keyFile, packetNumber, activate string) error {
+	if validator.IsEmpty(keyId) {
+		log.Errorf("Failed to create MACsec='%s'. Missing KeyId", n.Name)
+		return web.NewError(web.ErrInvalidArgument, "missing macsec keyid").WithField("KeyId", "", "required")
+	}
+	if !validator.IsMACsecKeyId(keyId) {
+		log.Errorf("Failed to create MACsec='%s'. Invalid KeyId='%s'", n.Name, keyId)
+		return web.InvalidArgument("keyid", keyId)
+	}
+	m.SetKeyToNewSectionString("KeyId", keyId)
+
+	if validator.IsEmpty(key) && validator.IsEmpty(keyFile) {
+		log.Errorf("Failed to create MACsec='%s'. Missing Key and KeyFile", n.Name)
+		return web.NewError(web.ErrInvalidArgument, "missing macsec key and keyfile").WithField("Key", "", "required")
+	}
+	if !validator.IsEmpty(key) {
+		if !validator.IsMACsecKey(key) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid Key", n.Name)
+			return web.NewError(web.ErrInvalidArgument, "invalid macsec key").WithField("key", "", "expected 32 or 64 hexadecimal digits")
+		}
+		m.SetKeyToNewSectionString("Key", key)
+	}
+	if !validator.IsEmpty(keyFile) {
+		m.SetKeyToNewSectionString("KeyFile", keyFile)
+	}
+
+	if !validator.IsEmpty(packetNumber) {
+		if !validator.IsUint32(packetNumber) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid PacketNumber='%s'", n.Name, packetNumber)
+			return web.InvalidArgument("packetnumber", packetNumber)
+		}
+		m.SetKeyToNewSectionString("PacketNumber", packetNumber)
+	}
+
+	if !validator.IsEmpty(activate) {
+		if !validator.IsBool(activate) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid Activate='%s'", n.Name, activate)
+			return web.InvalidArgument("activate", activate)
+		}
+		m.SetKeyToNewSectionString("Activate", validator.BoolToString(activate))
+	}
+
+	return nil
+}
+
+func (n *NetDev) buildMACsecSection(m *configfile.Meta) error {
+	m.NewSection("MACsec")
+
+	if !validator.IsEmpty(n.MACsecSection.Port) {
+		if !validator.IsMACsecPort(n.MACsecSection.Port) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid Port='%s'", n.Name, n.MACsecSection.Port)
+			return web.InvalidArgument("port", n.MACsecSection.Port)
+		}
+		m.SetKeyToNewSectionString("Port", n.MACsecSection.Port)
+	}
+
+	if !validator.IsEmpty(n.MACsecSection.Encrypt) {
+		if !validator.IsBool(n.MACsecSection.Encrypt) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid Encrypt='%s'", n.Name, n.MACsecSection.Encrypt)
+			return web.InvalidArgument("encrypt", n.MACsecSection.Encrypt)
+		}
+		m.SetKeyToNewSectionString("Encrypt", validator.BoolToString(n.MACsecSection.Encrypt))
+	}
+
+	if t := n.MACsecTransmitAssociationSection; t != (MACsecTransmitAssociation{}) {
+		m.NewSection("MACsecTransmitAssociation")
+		if err := n.buildMACsecKey(m, t.KeyId, t.Key, t.KeyFile, t.PacketNumber, t.Activate); err != nil {
+			return err
+		}
+
+		if !validator.IsEmpty(t.UseForEncoding) {
+			if !validator.IsBool(t.UseForEncoding) {
+				log.Errorf("Failed to create MACsec='%s'. Invalid UseForEncoding='%s'", n.Name, t.UseForEncoding)
+				return web.InvalidArgument("useforencoding", t.UseForEncoding)
+			}
+			m.SetKeyToNewSectionString("UseForEncoding", validator.BoolToString(t.UseForEncoding))
+		}
+	}
+
+	if r := n.MACsecReceiveAssociationSection; r != (MACsecReceiveAssociation{}) {
+		m.NewSection("MACsecReceiveAssociation")
+
+		if validator.IsEmpty(r.MACAddress) || validator.IsNotMAC(r.MACAddress) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid receive MACAddress='%s'", n.Name, r.MACAddress)
+			return web.InvalidArgument("macaddress", r.MACAddress)
+		}
+		m.SetKeyToNewSectionString("MACAddress", r.MACAddress)
+
+		if validator.IsEmpty(r.Port) || !validator.IsMACsecPort(r.Port) {
+			log.Errorf("Failed to create MACsec='%s'. Invalid receive Port='%s'", n.Name, r.Port)
+			return web.InvalidArgument("port", r.Port)
+		}
+		m.SetKeyToNewSectionString("Port", r.Port)
+
+		if err := n.buildMACsecKey(m, r.KeyId, r.Key, r.KeyFile, r.PacketNumber, r.Activate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateLinks refuses links for the kinds which stand on their own, as veth,
+// dummy and geneve.
+func (n *NetDev) validateLinks() error {
+	if netDevKindToNetworkKind(n.Kind) == "" && len(n.Links) > 0 {
+		return web.NewError(web.ErrInvalidArgument, "netdev kind='%s' does not take links", n.Kind).
+			WithField("Link", strings.Join(n.Links, " "), "not supported with kind='"+n.Kind+"'")
+	}
+
+	return nil
+}
+
 func (n *NetDev) BuildKindInLinkNetworkFile() error {
+	if err := n.validateLinks(); err != nil {
+		return err
+	}
+
 	for _, l := range n.Links {
 		m, err := CreateOrParseNetworkFile(l)
 		if err != nil {
@@ -548,9 +888,9 @@ func (n *NetDev) BuildKindSection(m *configfile.Meta) error {
 			log.Errorf("Failed to create MacVTap ='%s': %v", n.Name, err)
 			return err
 		}
-	case "ipvlan":
-		if err := n.buildIpVLanSection(m); err != nil {
-			log.Errorf("Failed to create IpVLan ='%s': %v", n.Name, err)
+	case "ipvlan", "ipvtap":
+		if err := n.buildIpVLanSection(n.Kind, m); err != nil {
+			log.Errorf("Failed to create %s ='%s': %v", n.Kind, n.Name, err)
 			return err
 		}
 	case "vxlan":
@@ -572,6 +912,34 @@ func (n *NetDev) BuildKindSection(m *configfile.Meta) error {
 			log.Errorf("Failed to create %s ='%s': %v", n.Kind, n.Name, err)
 			return err
 		}
+	case "vrf":
+		if err := n.buildVRFSection(m); err != nil {
+			log.Errorf("Failed to create VRF ='%s': %v", n.Name, err)
+			return err
+		}
+	case "veth":
+		if err := n.buildPeerSection(m); err != nil {
+			log.Errorf("Failed to create Veth ='%s': %v", n.Name, err)
+			return err
+		}
+	case "geneve":
+		if err := n.buildGeneveSection(m); err != nil {
+			log.Errorf("Failed to create Geneve ='%s': %v", n.Name, err)
+			return err
+		}
+	case "gre", "gretap", "ipip", "sit", "vti":
+		if err := n.buildTunnelSection(m); err != nil {
+			log.Errorf("Failed to create %s ='%s': %v", n.Kind, n.Name, err)
+			return err
+		}
+	case "macsec":
+		if err := n.buildMACsecSection(m); err != nil {
+			log.Errorf("Failed to create MACsec ='%s': %v", n.Name, err)
+			return err
+		}
+	case "dummy":
+	default:
+		return web.InvalidArgument("Kind", n.Kind)
 	}
 
 	return nil
@@ -584,6 +952,7 @@ func (n *NetDev) ConfigureNetDev(ctx context.Context, w http.ResponseWriter) err
 		return err
 	}
 
+	created := len(m.Cfg.SectionStrings()) == 1
 	if err = n.BuildNetDevSection(m); err != nil {
 		return err
 	}
@@ -594,6 +963,10 @@ func (n *NetDev) ConfigureNetDev(ctx context.Context, w http.ResponseWriter) err
 		return err
 	}
 
+	if created {
+		markManaged(m)
+	}
+
 	if err := m.Save(); err != nil {
 		log.Errorf("Failed to update config file='%s': %v", m.Path, err)
 		return err
@@ -6,7 +6,6 @@ package networkd
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"path"
 	"strings"
@@ -175,6 +174,53 @@ type SRIOVSection struct {
 	MACAddress              string `json:"MACAddress"`
 }
 
+type BridgeVLANSection struct {
+	VLAN           string `json:"VLAN"`
+	EgressUntagged string `json:"EgressUntagged"`
+	PVID           string `json:"PVID"`
+}
+
+type TrafficControlQueueingDisciplineSection struct {
+	Parent                        string `json:"Parent"`
+	NetworkEmulatorDelaySec       string `json:"NetworkEmulatorDelaySec"`
+	NetworkEmulatorDelayJitterSec string `json:"NetworkEmulatorDelayJitterSec"`
+	NetworkEmulatorPacketLimit    string `json:"NetworkEmulatorPacketLimit"`
+	NetworkEmulatorLossRate       string `json:"NetworkEmulatorLossRate"`
+	NetworkEmulatorDuplicateRate  string `json:"NetworkEmulatorDuplicateRate"`
+}
+
+type TokenBucketFilterSection struct {
+	Parent     string `json:"Parent"`
+	Handle     string `json:"Handle"`
+	LatencySec string `json:"LatencySec"`
+	LimitBytes string `json:"LimitBytes"`
+	BurstBytes string `json:"BurstBytes"`
+	Rate       string `json:"Rate"`
+	MPUBytes   string `json:"MPUBytes"`
+	PeakRate   string `json:"PeakRate"`
+	MTUBytes   string `json:"MTUBytes"`
+}
+
+type HierarchyTokenBucketSection struct {
+	Parent        string `json:"Parent"`
+	Handle        string `json:"Handle"`
+	DefaultClass  string `json:"DefaultClass"`
+	RateToQuantum string `json:"RateToQuantum"`
+}
+
+type HierarchyTokenBucketClassSection struct {
+	Parent          string `json:"Parent"`
+	ClassId         string `json:"ClassId"`
+	Priority        string `json:"Priority"`
+	QuantumBytes    string `json:"QuantumBytes"`
+	MTUBytes        string `json:"MTUBytes"`
+	OverheadBytes   string `json:"OverheadBytes"`
+	Rate            string `json:"Rate"`
+	CeilRate        string `json:"CeilRate"`
+	BufferBytes     string `json:"BufferBytes"`
+	CeilBufferBytes string `json:"CeilBufferBytes"`
+}
+
 type Network struct {
 	Link                      string                     `json:"Link"`
 	LinkSection               LinkSection                `json:"LinkSection"`
@@ -190,6 +236,12 @@ type Network struct {
 	IPv6PrefixSections        []IPv6PrefixSection        `json:"IPv6PrefixSections"`
 	IPv6RoutePrefixSections   []IPv6RoutePrefixSection   `json:"IPv6RoutePrefixSections"`
 	SRIOVSections             []SRIOVSection             `json:"SRIOVSections"`
+	BridgeVLANSections        []BridgeVLANSection        `json:"BridgeVLANSections"`
+
+	TrafficControlQueueingDisciplineSections []TrafficControlQueueingDisciplineSection `json:"TrafficControlQueueingDisciplineSections"`
+	TokenBucketFilterSections                []TokenBucketFilterSection                `json:"TokenBucketFilterSections"`
+	HierarchyTokenBucketSections             []HierarchyTokenBucketSection             `json:"HierarchyTokenBucketSections"`
+	HierarchyTokenBucketClassSections        []HierarchyTokenBucketClassSection        `json:"HierarchyTokenBucketClassSections"`
 }
 
 type LinkDescribe struct {
@@ -327,14 +379,14 @@ func (n *Network) buildNetworkSection(m *configfile.Meta) error {
 			m.SetKeySectionString("Network", "DHCP", n.NetworkSection.DHCP)
 		} else {
 			log.Errorf("Failed to parse DHCP='%s'", n.NetworkSection.DHCP)
-			return fmt.Errorf("invalid DHCP='%s'", n.NetworkSection.DHCP)
+			return web.InvalidArgument("DHCP", n.NetworkSection.DHCP)
 		}
 	}
 
 	if !validator.IsEmpty(n.NetworkSection.DHCPServer) {
 		if !validator.IsBool(n.NetworkSection.DHCPServer) {
 			log.Errorf("Failed to parse DHCPServer='%s'", n.NetworkSection.DHCPServer)
-			return fmt.Errorf("invalid DHCPServer='%s'", n.NetworkSection.DHCPServer)
+			return web.InvalidArgument("DHCPServer", n.NetworkSection.DHCPServer)
 		}
 		m.SetKeySectionString("Network", "DHCPServer", n.NetworkSection.DHCPServer)
 	}
@@ -344,7 +396,7 @@ func (n *Network) buildNetworkSection(m *configfile.Meta) error {
 			m.SetKeySectionString("Network", "LinkLocalAddressing", n.NetworkSection.LinkLocalAddressing)
 		} else {
 			log.Errorf("Failed to parse LinkLocalAddressing='%s'", n.NetworkSection.LinkLocalAddressing)
-			return fmt.Errorf("invalid LinkLocalAddressing='%s'", n.NetworkSection.LinkLocalAddressing)
+			return web.InvalidArgument("LinkLocalAddressing", n.NetworkSection.LinkLocalAddressing)
 		}
 	}
 
@@ -357,7 +409,7 @@ func (n *Network) buildNetworkSection(m *configfile.Meta) error {
 			m.SetKeySectionString("Network", "Address", n.NetworkSection.Address)
 		} else {
 			log.Errorf("Failed to parse Address='%s'", n.NetworkSection.Address)
-			return fmt.Errorf("invalid Address='%s'", n.NetworkSection.Address)
+			return web.InvalidArgument("Address", n.NetworkSection.Address)
 		}
 	}
 
@@ -366,7 +418,7 @@ func (n *Network) buildNetworkSection(m *configfile.Meta) error {
 			m.SetKeySectionString("Network", "Gateway", n.NetworkSection.Gateway)
 		} else {
 			log.Errorf("Failed to parse Gateway='%s'", n.NetworkSection.Gateway)
-			return fmt.Errorf("invalid Gateway='%s'", n.NetworkSection.Gateway)
+			return web.InvalidArgument("Gateway", n.NetworkSection.Gateway)
 		}
 	}
 
@@ -374,7 +426,7 @@ func (n *Network) buildNetworkSection(m *configfile.Meta) error {
 		for _, dns := range n.NetworkSection.DNS {
 			if !validator.IsIP(dns) {
 				log.Errorf("Failed to parse DNS='%s'", dns)
-				return fmt.Errorf("invalid DNS='%s'", dns)
+				return web.InvalidArgument("DNS", dns)
 			}
 		}
 		s := m.GetKeySectionString("Network", "DNS")
@@ -476,14 +528,14 @@ func (n *Network) buildLinkSection(m *configfile.Meta) error {
 			m.SetKeySectionString("Link", "MTUBytes", n.LinkSection.MTUBytes)
 		} else {
 			log.Errorf("Invalid MTU='%s'", n.LinkSection.MTUBytes)
-			return fmt.Errorf("invalid MTU='%s'", n.LinkSection.MTUBytes)
+			return web.InvalidArgument("MTU", n.LinkSection.MTUBytes)
 		}
 	}
 
 	if !validator.IsEmpty(n.LinkSection.MACAddress) {
 		if validator.IsNotMAC(n.LinkSection.MACAddress) {
 			log.Errorf("Failed to parse Mac='%s'", n.LinkSection.MACAddress)
-			return fmt.Errorf("invalid Address='%s'", n.LinkSection.MACAddress)
+			return web.InvalidArgument("Address", n.LinkSection.MACAddress)
 
 		} else {
 			m.SetKeySectionString("Link", "MACAddress", n.LinkSection.MACAddress)
@@ -513,7 +565,7 @@ func (n *Network) buildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.LinkSection.Group) {
 		if !validator.IsLinkGroup(n.LinkSection.Group) {
 			log.Errorf("Failed to parse Group='%s'", n.LinkSection.Group)
-			return fmt.Errorf("invalid group='%s'", n.LinkSection.Group)
+			return web.InvalidArgument("group", n.LinkSection.Group)
 
 		}
 		m.SetKeySectionString("Link", "Group", n.LinkSection.Group)
@@ -526,7 +578,7 @@ func (n *Network) buildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.LinkSection.RequiredFamilyForOnline) {
 		if !validator.IsAddressFamily(n.LinkSection.RequiredFamilyForOnline) {
 			log.Errorf("Failed to parse RequiredFamilyForOnline='%s'", n.LinkSection.RequiredFamilyForOnline)
-			return fmt.Errorf("invalid online family='%s'", n.LinkSection.RequiredFamilyForOnline)
+			return web.InvalidArgument("online family", n.LinkSection.RequiredFamilyForOnline)
 
 		}
 		m.SetKeySectionString("Link", "RequiredFamilyForOnline", n.LinkSection.RequiredFamilyForOnline)
@@ -535,7 +587,7 @@ func (n *Network) buildLinkSection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.LinkSection.ActivationPolicy) {
 		if !validator.IsLinkActivationPolicy(n.LinkSection.ActivationPolicy) {
 			log.Errorf("Failed to parse ActivationPolicy='%s'", n.LinkSection.ActivationPolicy)
-			return fmt.Errorf("invalid activation policy='%s'", n.LinkSection.ActivationPolicy)
+			return web.InvalidArgument("activation policy", n.LinkSection.ActivationPolicy)
 
 		}
 		m.SetKeySectionString("Link", "ActivationPolicy", n.LinkSection.ActivationPolicy)
@@ -570,7 +622,7 @@ func (n *Network) buildDHCPv4Section(m *configfile.Meta) error {
 		for _, o := range n.DHCPv4Section.RequestOptions {
 			if !validator.IsUint8(o) {
 				log.Errorf("Failed to create DHCPv4Section. Invalid RequestOptions='%s'", o)
-				return fmt.Errorf("invalid options='%s'", o)
+				return web.InvalidArgument("options", o)
 			}
 		}
 		m.SetKeySectionString("DHCPv4", "RequestOptions", strings.Join(n.DHCPv4Section.RequestOptions, " "))
@@ -640,7 +692,7 @@ func (n *Network) buildDHCPv6Section(m *configfile.Meta) error {
 		for _, o := range n.DHCPv6Section.RequestOptions {
 			if !validator.IsUint8(o) {
 				log.Errorf("Failed to create DHCPv6Section. Invalid RequestOptions='%s'", o)
-				return fmt.Errorf("invalid options='%s'", o)
+				return web.InvalidArgument("options", o)
 			}
 		}
 		m.SetKeySectionString("DHCPv6", "RequestOptions", strings.Join(n.DHCPv6Section.RequestOptions, " "))
@@ -718,7 +770,7 @@ func (n *Network) buildDHCPv4ServerSection(m *configfile.Meta) error {
 		for _, d := range n.DHCPv4ServerSection.DNS {
 			if !validator.IsIP(d) {
 				log.Errorf("Failed to create DHCPServer. Invalid DNS='%s'", d)
-				return fmt.Errorf("invalid dns='%s'", d)
+				return web.InvalidArgument("dns", d)
 			}
 		}
 		m.SetKeySectionString("DHCPServer", "DNS", strings.Join(n.DHCPv4ServerSection.DNS, " "))
@@ -750,7 +802,7 @@ func (n *Network) buildAddressSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("Address", a.Address)
 			} else {
 				log.Errorf("Failed to parse Address='%s'", a.Address)
-				return fmt.Errorf("invalid Address='%s'", a.Address)
+				return web.InvalidArgument("Address", a.Address)
 			}
 		}
 
@@ -759,7 +811,7 @@ func (n *Network) buildAddressSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("Peer", a.Peer)
 			} else {
 				log.Errorf("Failed to parse Peer='%s'", a.Peer)
-				return fmt.Errorf("invalid Peer='%s'", a.Peer)
+				return web.InvalidArgument("Peer", a.Peer)
 			}
 		}
 
@@ -786,14 +838,14 @@ func (n *Network) buildRouteSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("Gateway", rt.Gateway)
 			} else {
 				log.Errorf("Failed to parse Gateway='%s'", rt.Gateway)
-				return fmt.Errorf("invalid Gateway='%s'", rt.Gateway)
+				return web.InvalidArgument("Gateway", rt.Gateway)
 			}
 		}
 
 		if !validator.IsEmpty(rt.GatewayOnlink) {
 			if !validator.IsBool(rt.GatewayOnlink) {
 				log.Errorf("Failed to parse GatewayOnlink='%s'", rt.GatewayOnlink)
-				return fmt.Errorf("invalid GatewayOnlink='%s'", rt.GatewayOnlink)
+				return web.InvalidArgument("GatewayOnlink", rt.GatewayOnlink)
 			}
 			m.SetKeyToNewSectionString("GatewayOnlink", rt.GatewayOnlink)
 		}
@@ -803,7 +855,7 @@ func (n *Network) buildRouteSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("Destination", rt.Destination)
 			} else {
 				log.Errorf("Failed to parse Destination='%s'", rt.Destination)
-				return fmt.Errorf("invalid Destination='%s'", rt.Destination)
+				return web.InvalidArgument("Destination", rt.Destination)
 			}
 		}
 
@@ -812,7 +864,7 @@ func (n *Network) buildRouteSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("Source", rt.Source)
 			} else {
 				log.Errorf("Failed to parse Source='%s'", rt.Source)
-				return fmt.Errorf("invalid Source='%s'", rt.Source)
+				return web.InvalidArgument("Source", rt.Source)
 			}
 		}
 
@@ -821,7 +873,7 @@ func (n *Network) buildRouteSection(m *configfile.Meta) error {
 				m.SetKeyToNewSectionString("PreferredSource", rt.PreferredSource)
 			} else {
 				log.Errorf("Failed to parse PreferredSource='%s'", rt.PreferredSource)
-				return fmt.Errorf("invalid PreferredSource='%s'", rt.PreferredSource)
+				return web.InvalidArgument("PreferredSource", rt.PreferredSource)
 			}
 		}
 
@@ -846,7 +898,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.TypeOfService) {
 			if !validator.IsRoutingTypeOfService(rtpr.TypeOfService) {
 				log.Errorf("Failed to parse TypeOfService='%s'", rtpr.TypeOfService)
-				return fmt.Errorf("invalid TypeOfService='%s'", rtpr.TypeOfService)
+				return web.InvalidArgument("TypeOfService", rtpr.TypeOfService)
 			}
 			m.SetKeyToNewSectionString("TypeOfService", rtpr.TypeOfService)
 		}
@@ -854,7 +906,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.From) {
 			if !validator.IsIP(rtpr.From) {
 				log.Errorf("Failed to parse From='%s'", rtpr.From)
-				return fmt.Errorf("invalid From='%s'", rtpr.From)
+				return web.InvalidArgument("From", rtpr.From)
 			}
 			m.SetKeyToNewSectionString("From", rtpr.From)
 		}
@@ -862,7 +914,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.To) {
 			if !validator.IsIP(rtpr.To) {
 				log.Errorf("Failed to parse To='%s'", rtpr.To)
-				return fmt.Errorf("invalid To='%s'", rtpr.To)
+				return web.InvalidArgument("To", rtpr.To)
 			}
 			m.SetKeyToNewSectionString("To", rtpr.To)
 		}
@@ -870,7 +922,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.FirewallMark) {
 			if !validator.IsRoutingFirewallMark(rtpr.FirewallMark) {
 				log.Errorf("Failed to parse FirewallMark='%s'", rtpr.FirewallMark)
-				return fmt.Errorf("invalid FirewallMark='%s'", rtpr.FirewallMark)
+				return web.InvalidArgument("FirewallMark", rtpr.FirewallMark)
 			}
 			m.SetKeyToNewSectionString("FirewallMark", rtpr.FirewallMark)
 		}
@@ -878,7 +930,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.Table) {
 			if !validator.IsUint32(rtpr.Table) {
 				log.Errorf("Failed to parse Table='%s'", rtpr.Table)
-				return fmt.Errorf("invalid Table='%s'", rtpr.Table)
+				return web.InvalidArgument("Table", rtpr.Table)
 			}
 			m.SetKeyToNewSectionString("Table", rtpr.Table)
 		}
@@ -886,7 +938,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.Priority) {
 			if !validator.IsUint32(rtpr.Priority) {
 				log.Errorf("Failed to parse Priority='%s'", rtpr.Priority)
-				return fmt.Errorf("invalid Priority='%s'", rtpr.Priority)
+				return web.InvalidArgument("Priority", rtpr.Priority)
 			}
 			m.SetKeyToNewSectionString("Priority", rtpr.Priority)
 		}
@@ -902,7 +954,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.SourcePort) {
 			if !validator.IsRoutingPort(rtpr.SourcePort) {
 				log.Errorf("Failed to parse SourcePort='%s'", rtpr.SourcePort)
-				return fmt.Errorf("invalid SourcePort='%s'", rtpr.SourcePort)
+				return web.InvalidArgument("SourcePort", rtpr.SourcePort)
 			}
 			m.SetKeyToNewSectionString("SourcePort", rtpr.SourcePort)
 		}
@@ -910,7 +962,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.DestinationPort) {
 			if !validator.IsRoutingPort(rtpr.DestinationPort) {
 				log.Errorf("Failed to parse DestinationPort='%s'", rtpr.DestinationPort)
-				return fmt.Errorf("invalid DestinationPort='%s'", rtpr.DestinationPort)
+				return web.InvalidArgument("DestinationPort", rtpr.DestinationPort)
 			}
 			m.SetKeyToNewSectionString("DestinationPort", rtpr.DestinationPort)
 		}
@@ -918,7 +970,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.IPProtocol) {
 			if !validator.IsRoutingIPProtocol(rtpr.IPProtocol) {
 				log.Errorf("Failed to parse IPProtocol='%s'", rtpr.IPProtocol)
-				return fmt.Errorf("invalid IPProtocol='%s'", rtpr.IPProtocol)
+				return web.InvalidArgument("IPProtocol", rtpr.IPProtocol)
 			}
 			m.SetKeyToNewSectionString("IPProtocol", rtpr.IPProtocol)
 		}
@@ -926,7 +978,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.InvertRule) {
 			if !validator.IsBool(rtpr.InvertRule) {
 				log.Errorf("Failed to parse InvertRule='%s'", rtpr.InvertRule)
-				return fmt.Errorf("invalid InvertRule='%s'", rtpr.InvertRule)
+				return web.InvalidArgument("InvertRule", rtpr.InvertRule)
 			}
 			m.SetKeyToNewSectionString("InvertRule", rtpr.InvertRule)
 		}
@@ -934,7 +986,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.Family) {
 			if !validator.IsAddressFamily(rtpr.Family) {
 				log.Errorf("Failed to parse Family='%s'", rtpr.Family)
-				return fmt.Errorf("invalid Family='%s'", rtpr.Family)
+				return web.InvalidArgument("Family", rtpr.Family)
 			}
 			m.SetKeyToNewSectionString("Family", rtpr.Family)
 		}
@@ -942,7 +994,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.User) {
 			if !validator.IsRoutingUser(rtpr.User) {
 				log.Errorf("Failed to parse User='%s'", rtpr.User)
-				return fmt.Errorf("invalid User='%s'", rtpr.User)
+				return web.InvalidArgument("User", rtpr.User)
 			}
 			m.SetKeyToNewSectionString("User", rtpr.User)
 		}
@@ -950,7 +1002,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.SuppressPrefixLength) {
 			if !validator.IsRoutingSuppressPrefixLength(rtpr.SuppressPrefixLength) {
 				log.Errorf("Failed to parse SuppressPrefixLength='%s'", rtpr.SuppressPrefixLength)
-				return fmt.Errorf("invalid SuppressPrefixLength='%s'", rtpr.SuppressPrefixLength)
+				return web.InvalidArgument("SuppressPrefixLength", rtpr.SuppressPrefixLength)
 			}
 			m.SetKeyToNewSectionString("SuppressPrefixLength", rtpr.SuppressPrefixLength)
 		}
@@ -958,7 +1010,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.SuppressInterfaceGroup) {
 			if !validator.IsUint32(rtpr.SuppressInterfaceGroup) {
 				log.Errorf("Failed to parse SuppressInterfaceGroup='%s'", rtpr.SuppressInterfaceGroup)
-				return fmt.Errorf("invalid SuppressInterfaceGroup='%s'", rtpr.SuppressInterfaceGroup)
+				return web.InvalidArgument("SuppressInterfaceGroup", rtpr.SuppressInterfaceGroup)
 			}
 			m.SetKeyToNewSectionString("SuppressInterfaceGroup", rtpr.SuppressInterfaceGroup)
 		}
@@ -966,7 +1018,7 @@ func (n *Network) buildRoutingPolicyRuleSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(rtpr.Type) {
 			if !validator.IsRoutingType(rtpr.Type) {
 				log.Errorf("Failed to parse Type='%s'", rtpr.Type)
-				return fmt.Errorf("invalid Type='%s'", rtpr.Type)
+				return web.InvalidArgument("Type", rtpr.Type)
 			}
 			m.SetKeyToNewSectionString("Type", rtpr.Type)
 		}
@@ -980,7 +1032,7 @@ func (n *Network) buildIPv6SendRASection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.IPv6SendRASection.RouterPreference) {
 		if !validator.IsRouterPreference(n.IPv6SendRASection.RouterPreference) {
 			log.Errorf("Failed to parse RouterPreference='%s'", n.IPv6SendRASection.RouterPreference)
-			return fmt.Errorf("invalid RouterPreference='%s'", n.IPv6SendRASection.RouterPreference)
+			return web.InvalidArgument("RouterPreference", n.IPv6SendRASection.RouterPreference)
 		}
 		m.SetKeySectionString("IPv6SendRA", "RouterPreference", n.IPv6SendRASection.RouterPreference)
 	}
@@ -993,7 +1045,7 @@ func (n *Network) buildIPv6SendRASection(m *configfile.Meta) error {
 		for _, d := range n.IPv6SendRASection.DNS {
 			if !validator.IsIP(d) {
 				log.Errorf("Failed to configure IPv6SendRA. Invalid DNS='%s'", d)
-				return fmt.Errorf("invalid dns='%s'", d)
+				return web.InvalidArgument("dns", d)
 			}
 		}
 		m.SetKeySectionString("IPv6SendRA", "DNS", strings.Join(n.IPv6SendRASection.DNS, " "))
@@ -1010,7 +1062,7 @@ func (n *Network) buildIPv6SendRASection(m *configfile.Meta) error {
 	if !validator.IsEmpty(n.IPv6SendRASection.DNSLifetimeSec) {
 		if !validator.IsUint32(n.IPv6SendRASection.DNSLifetimeSec) {
 			log.Errorf("Failed to parse DNSLifetimeSec='%s'", n.IPv6SendRASection.DNSLifetimeSec)
-			return fmt.Errorf("invalid DNSLifetimeSec='%s'", n.IPv6SendRASection.DNSLifetimeSec)
+			return web.InvalidArgument("DNSLifetimeSec", n.IPv6SendRASection.DNSLifetimeSec)
 		}
 		m.SetKeySectionString("IPv6SendRA", "DNSLifetimeSec", n.IPv6SendRASection.DNSLifetimeSec)
 	}
@@ -1027,7 +1079,7 @@ func (n *Network) buildIPv6PrefixSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(p.Prefix) {
 			if !validator.IsIP(p.Prefix) {
 				log.Errorf("Failed to parse Prefix='%s'", p.Prefix)
-				return fmt.Errorf("invalid Prefix='%s'", p.Prefix)
+				return web.InvalidArgument("Prefix", p.Prefix)
 			}
 			m.SetKeyToNewSectionString("Prefix", p.Prefix)
 		}
@@ -1035,7 +1087,7 @@ func (n *Network) buildIPv6PrefixSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(p.PreferredLifetimeSec) {
 			if !validator.IsUint32(p.PreferredLifetimeSec) {
 				log.Errorf("Failed to parse PreferredLifetimeSec='%s'", p.PreferredLifetimeSec)
-				return fmt.Errorf("invalid PreferredLifetimeSec='%s'", p.PreferredLifetimeSec)
+				return web.InvalidArgument("PreferredLifetimeSec", p.PreferredLifetimeSec)
 			}
 			m.SetKeyToNewSectionString("PreferredLifetimeSec", p.PreferredLifetimeSec)
 		}
@@ -1043,7 +1095,7 @@ func (n *Network) buildIPv6PrefixSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(p.ValidLifetimeSec) {
 			if !validator.IsUint32(p.ValidLifetimeSec) {
 				log.Errorf("Failed to parse ValidLifetimeSec='%s'", p.ValidLifetimeSec)
-				return fmt.Errorf("invalid ValidLifetimeSec='%s'", p.ValidLifetimeSec)
+				return web.InvalidArgument("ValidLifetimeSec", p.ValidLifetimeSec)
 			}
 			m.SetKeyToNewSectionString("ValidLifetimeSec", p.ValidLifetimeSec)
 		}
@@ -1065,7 +1117,7 @@ func (n *Network) buildIPv6RoutePrefixSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(r.Route) {
 			if !validator.IsIP(r.Route) {
 				log.Errorf("Failed to parse Route='%s'", r.Route)
-				return fmt.Errorf("invalid Route='%s'", r.Route)
+				return web.InvalidArgument("Route", r.Route)
 			}
 			m.SetKeyToNewSectionString("Route", r.Route)
 		}
@@ -1073,7 +1125,7 @@ func (n *Network) buildIPv6RoutePrefixSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(r.LifetimeSec) {
 			if !validator.IsUint32(r.LifetimeSec) {
 				log.Errorf("Failed to parse LifetimeSec='%s'", r.LifetimeSec)
-				return fmt.Errorf("invalid LifetimeSec='%s'", r.LifetimeSec)
+				return web.InvalidArgument("LifetimeSec", r.LifetimeSec)
 			}
 			m.SetKeyToNewSectionString("LifetimeSec", r.LifetimeSec)
 		}
@@ -1092,18 +1144,18 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(s.VirtualFunction) {
 			if !validator.IsSRIOVVirtualFunction(s.VirtualFunction) {
 				log.Errorf("Failed to parse VirtualFunction='%s'", s.VirtualFunction)
-				return fmt.Errorf("invalid virtualfunction='%s'", s.VirtualFunction)
+				return web.InvalidArgument("virtualfunction", s.VirtualFunction)
 			}
 			m.SetKeyToNewSectionString("VirtualFunction", s.VirtualFunction)
 		} else {
 			log.Errorf("Failed to configure SR-IOV. Missing VirtualFunction")
-			return fmt.Errorf("missing mandatory argument VirtualFunction")
+			return web.NewError(web.ErrInvalidArgument, "missing mandatory argument VirtualFunction").WithField("VirtualFunction", "", "required")
 		}
 
 		if !validator.IsEmpty(s.VLANId) {
 			if !validator.IsSRIOVVLANId(s.VLANId) {
 				log.Errorf("Failed to parse VLANId='%s'", s.VLANId)
-				return fmt.Errorf("invalid vlanid='%s'", s.VLANId)
+				return web.InvalidArgument("vlanid", s.VLANId)
 			}
 			m.SetKeyToNewSectionString("VLANId", s.VLANId)
 		}
@@ -1111,7 +1163,7 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(s.QualityOfService) {
 			if !validator.IsSRIOVQualityOfService(s.QualityOfService) {
 				log.Errorf("Failed to parse QualityOfService='%s'", s.QualityOfService)
-				return fmt.Errorf("invalid qualityofservice='%s'", s.QualityOfService)
+				return web.InvalidArgument("qualityofservice", s.QualityOfService)
 			}
 			m.SetKeyToNewSectionString("QualityOfService", s.QualityOfService)
 		}
@@ -1119,7 +1171,7 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(s.VLANProtocol) {
 			if !validator.IsSRIOVVLANProtocol(s.VLANProtocol) {
 				log.Errorf("Failed to parse VLANProtocol='%s'", s.VLANProtocol)
-				return fmt.Errorf("invalid vlanprotocol='%s'", s.VLANProtocol)
+				return web.InvalidArgument("vlanprotocol", s.VLANProtocol)
 			}
 			m.SetKeyToNewSectionString("VLANProtocol", s.VLANProtocol)
 		}
@@ -1139,7 +1191,7 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(s.LinkState) {
 			if !validator.IsSRIOVLinkState(s.LinkState) {
 				log.Errorf("Failed to parse LinkState='%s'", s.LinkState)
-				return fmt.Errorf("invalid linkstate='%s'", s.LinkState)
+				return web.InvalidArgument("linkstate", s.LinkState)
 
 			}
 			m.SetKeyToNewSectionString("LinkState", s.LinkState)
@@ -1148,7 +1200,7 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 		if !validator.IsEmpty(s.MACAddress) {
 			if validator.IsNotMAC(s.MACAddress) {
 				log.Errorf("Failed to parse MACAddress='%s'", s.MACAddress)
-				return fmt.Errorf("invalid macaddress='%s'", s.MACAddress)
+				return web.InvalidArgument("macaddress", s.MACAddress)
 			}
 			m.SetKeyToNewSectionString("MACAddress", s.MACAddress)
 		}
@@ -1157,6 +1209,178 @@ func (n *Network) buildSRIOVSection(m *configfile.Meta) error {
 	return nil
 }
 
+func (n *Network) buildBridgeVLANSection(m *configfile.Meta) error {
+	for _, v := range n.BridgeVLANSections {
+		if err := m.NewSection("BridgeVLAN"); err != nil {
+			return err
+		}
+
+		if !validator.IsEmpty(v.VLAN) {
+			if !validator.IsBridgeVLAN(v.VLAN) {
+				log.Errorf("Failed to parse VLAN='%s'", v.VLAN)
+				return web.InvalidArgument("VLAN", v.VLAN)
+			}
+			m.SetKeyToNewSectionString("VLAN", v.VLAN)
+		}
+
+		if !validator.IsEmpty(v.EgressUntagged) {
+			if !validator.IsBridgeVLAN(v.EgressUntagged) {
+				log.Errorf("Failed to parse EgressUntagged='%s'", v.EgressUntagged)
+				return web.InvalidArgument("EgressUntagged", v.EgressUntagged)
+			}
+			m.SetKeyToNewSectionString("EgressUntagged", v.EgressUntagged)
+		}
+
+		if !validator.IsEmpty(v.PVID) {
+			if !validator.IsBridgeVLANId(v.PVID) {
+				log.Errorf("Failed to parse PVID='%s'", v.PVID)
+				return web.InvalidArgument("PVID", v.PVID)
+			}
+			m.SetKeyToNewSectionString("PVID", v.PVID)
+		}
+	}
+
+	return nil
+}
+
+// setTCKey writes a key of the traffic control section just created.
+func setTCKey(m *configfile.Meta, key string, value string, valid func(string) bool) error {
+	if validator.IsEmpty(value) {
+		return nil
+	}
+
+	if !valid(value) {
+		log.Errorf("Failed to parse %s='%s'", key, value)
+		return web.InvalidArgument(key, value)
+	}
+	m.SetKeyToNewSectionString(key, value)
+
+	return nil
+}
+
+func (n *Network) buildTrafficControlQueueingDisciplineSection(m *configfile.Meta) error {
+	for _, q := range n.TrafficControlQueueingDisciplineSections {
+		if err := m.NewSection("TrafficControlQueueingDiscipline"); err != nil {
+			return err
+		}
+
+		for _, k := range []struct {
+			key   string
+			value string
+			valid func(string) bool
+		}{
+			{"Parent", q.Parent, validator.IsTCParent},
+			{"NetworkEmulatorDelaySec", q.NetworkEmulatorDelaySec, validator.IsTCTime},
+			{"NetworkEmulatorDelayJitterSec", q.NetworkEmulatorDelayJitterSec, validator.IsTCTime},
+			{"NetworkEmulatorPacketLimit", q.NetworkEmulatorPacketLimit, validator.IsUint32},
+			{"NetworkEmulatorLossRate", q.NetworkEmulatorLossRate, validator.IsTCPercent},
+			{"NetworkEmulatorDuplicateRate", q.NetworkEmulatorDuplicateRate, validator.IsTCPercent},
+		} {
+			if err := setTCKey(m, k.key, k.value, k.valid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *Network) buildTokenBucketFilterSection(m *configfile.Meta) error {
+	for _, t := range n.TokenBucketFilterSections {
+		if err := m.NewSection("TokenBucketFilter"); err != nil {
+			return err
+		}
+
+		if validator.IsEmpty(t.Rate) || validator.IsEmpty(t.BurstBytes) {
+			log.Errorf("Failed to configure TokenBucketFilter. Missing Rate or BurstBytes")
+			return web.NewError(web.ErrInvalidArgument, "missing mandatory argument Rate or BurstBytes").WithField("Rate", t.Rate, "required")
+		}
+
+		for _, k := range []struct {
+			key   string
+			value string
+			valid func(string) bool
+		}{
+			{"Parent", t.Parent, validator.IsTCParent},
+			{"Handle", t.Handle, validator.IsTCHandle},
+			{"LatencySec", t.LatencySec, validator.IsTCTime},
+			{"LimitBytes", t.LimitBytes, validator.IsTCBytes},
+			{"BurstBytes", t.BurstBytes, validator.IsTCBytes},
+			{"Rate", t.Rate, validator.IsTCRate},
+			{"MPUBytes", t.MPUBytes, validator.IsTCBytes},
+			{"PeakRate", t.PeakRate, validator.IsTCRate},
+			{"MTUBytes", t.MTUBytes, validator.IsTCBytes},
+		} {
+			if err := setTCKey(m, k.key, k.value, k.valid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *Network) buildHierarchyTokenBucketSection(m *configfile.Meta) error {
+	for _, h := range n.HierarchyTokenBucketSections {
+		if err := m.NewSection("HierarchyTokenBucket"); err != nil {
+			return err
+		}
+
+		for _, k := range []struct {
+			key   string
+			value string
+			valid func(string) bool
+		}{
+			{"Parent", h.Parent, validator.IsTCParent},
+			{"Handle", h.Handle, validator.IsTCHandle},
+			{"DefaultClass", h.DefaultClass, validator.IsTCHandle},
+			{"RateToQuantum", h.RateToQuantum, validator.IsUint32},
+		} {
+			if err := setTCKey(m, k.key, k.value, k.valid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *Network) buildHierarchyTokenBucketClassSection(m *configfile.Meta) error {
+	for _, c := range n.HierarchyTokenBucketClassSections {
+		if err := m.NewSection("HierarchyTokenBucketClass"); err != nil {
+			return err
+		}
+
+		if validator.IsEmpty(c.ClassId) {
+			log.Errorf("Failed to configure HierarchyTokenBucketClass. Missing ClassId")
+			return web.NewError(web.ErrInvalidArgument, "missing mandatory argument ClassId").WithField("ClassId", "", "required")
+		}
+
+		for _, k := range []struct {
+			key   string
+			value string
+			valid func(string) bool
+		}{
+			{"Parent", c.Parent, validator.IsTCParent},
+			{"ClassId", c.ClassId, validator.IsTCHandle},
+			{"Priority", c.Priority, validator.IsUint32},
+			{"QuantumBytes", c.QuantumBytes, validator.IsTCBytes},
+			{"MTUBytes", c.MTUBytes, validator.IsTCBytes},
+			{"OverheadBytes", c.OverheadBytes, validator.IsTCBytes},
+			{"Rate", c.Rate, validator.IsTCRate},
+			{"CeilRate", c.CeilRate, validator.IsTCRate},
+			{"BufferBytes", c.BufferBytes, validator.IsTCBytes},
+			{"CeilBufferBytes", c.CeilBufferBytes, validator.IsTCBytes},
+		} {
+			if err := setTCKey(m, k.key, k.value, k.valid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 func (n *Network) removeAddressSection(m *configfile.Meta) error {
 	for _, a := range n.AddressSections {
 		if !validator.IsEmpty(a.Address) {
@@ -1357,13 +1581,48 @@ func (n *Network) removeIPv6RoutePrefixSection(m *configfile.Meta) error {
 	return nil
 }
 
-func (n *Network) ConfigureNetwork(ctx context.Context, w http.ResponseWriter) error {
-	m, err := CreateOrParseNetworkFile(n.Link)
-	if err != nil {
-		log.Errorf("Failed to parse network file for link='%s': %v", n.Link, err)
-		return err
+func (n *Network) removeBridgeVLANSection(m *configfile.Meta) error {
+	for _, v := range n.BridgeVLANSections {
+		if err := m.RemoveSection("BridgeVLAN", "VLAN", v.VLAN); err != nil {
+			return err
+		}
 	}
 
+	return nil
+}
+
+// removeTrafficControlSections removes the qdisc sections by Parent and the
+// class sections by ClassId.
+func (n *Network) removeTrafficControlSections(m *configfile.Meta) error {
+	for _, q := range n.TrafficControlQueueingDisciplineSections {
+		if err := m.RemoveSection("TrafficControlQueueingDiscipline", "Parent", q.Parent); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range n.TokenBucketFilterSections {
+		if err := m.RemoveSection("TokenBucketFilter", "Parent", t.Parent); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range n.HierarchyTokenBucketSections {
+		if err := m.RemoveSection("HierarchyTokenBucket", "Parent", h.Parent); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range n.HierarchyTokenBucketClassSections {
+		if err := m.RemoveSection("HierarchyTokenBucketClass", "ClassId", c.ClassId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildSections writes the sections of n to m.
+func (n *Network) buildSections(m *configfile.Meta) error {
 	if err := n.buildNetworkSection(m); err != nil {
 		return err
 	}
@@ -1376,9 +1635,6 @@ func (n *Network) ConfigureNetwork(ctx context.Context, w http.ResponseWriter) e
 	if err := n.buildDHCPv4ServerSection(m); err != nil {
 		return err
 	}
-	if err := n.buildDHCPv4Section(m); err != nil {
-		return err
-	}
 	if err := n.buildDHCPv6Section(m); err != nil {
 		return err
 	}
@@ -1403,6 +1659,35 @@ func (n *Network) ConfigureNetwork(ctx context.Context, w http.ResponseWriter) e
 	if err := n.buildSRIOVSection(m); err != nil {
 		return err
 	}
+	if err := n.buildBridgeVLANSection(m); err != nil {
+		return err
+	}
+	if err := n.buildTrafficControlQueueingDisciplineSection(m); err != nil {
+		return err
+	}
+	if err := n.buildTokenBucketFilterSection(m); err != nil {
+		return err
+	}
+	if err := n.buildHierarchyTokenBucketSection(m); err != nil {
+		return err
+	}
+	if err := n.buildHierarchyTokenBucketClassSection(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (n *Network) ConfigureNetwork(ctx context.Context, w http.ResponseWriter) error {
+	m, err := CreateOrParseNetworkFile(n.Link)
+	if err != nil {
+		log.Errorf("Failed to parse network file for link='%s': %v", n.Link, err)
+		return err
+	}
+
+	if err := n.buildSections(m); err != nil {
+		return err
+	}
 
 	if err := m.Save(); err != nil {
 		log.Errorf("Failed to update config file='%s': %v", m.Path, err)
@@ -1470,6 +1755,16 @@ func (n *Network) RemoveNetwork(ctx context.Context, w http.ResponseWriter) erro
 		return err
 	}
 
+	if err := n.removeBridgeVLANSection(m); err != nil {
+		log.Errorf("Failed to remove BridgeVLAN section: %v", err)
+		return err
+	}
+
+	if err := n.removeTrafficControlSections(m); err != nil {
+		log.Errorf("Failed to remove traffic control section: %v", err)
+		return err
+	}
+
 	if err := m.Save(); err != nil {
 		log.Errorf("Failed to update config file='%s': %v", m.Path, err)
 		return err
@@ -4,17 +4,19 @@
 package networkd
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
 func routerConfigureNetwork(w http.ResponseWriter, r *http.Request) {
 	n, err := decodeNetworkJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -26,7 +28,7 @@ func routerConfigureNetwork(w http.ResponseWriter, r *http.Request) {
 func routerRemoveNetwork(w http.ResponseWriter, r *http.Request) {
 	n, err := decodeNetworkJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -39,6 +41,7 @@ func routerAcquireLinks(w http.ResponseWriter, r *http.Request) {
 	l, err := AcquireLinks(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(l, w)
@@ -48,6 +51,7 @@ func routerAcquireNetworkState(w http.ResponseWriter, r *http.Request) {
 	n, err := AcquireNetworkState(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(n, w)
@@ -56,7 +60,7 @@ func routerAcquireNetworkState(w http.ResponseWriter, r *http.Request) {
 func routerConfigureNetDev(w http.ResponseWriter, r *http.Request) {
 	n, err := decodeNetDevJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -68,7 +72,7 @@ func routerConfigureNetDev(w http.ResponseWriter, r *http.Request) {
 func routerRemoveNetDev(w http.ResponseWriter, r *http.Request) {
 	n, err := decodeNetDevJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -80,7 +84,7 @@ func routerRemoveNetDev(w http.ResponseWriter, r *http.Request) {
 func routerConfigureLink(w http.ResponseWriter, r *http.Request) {
 	n, err := decodeLinkJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -89,16 +93,92 @@ func routerConfigureLink(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func routerAcquireNetworkConfig(w http.ResponseWriter, r *http.Request) {
+	n, err := AcquireNetworkConfig(mux.Vars(r)["link"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(n, w)
+}
+
+func routerAcquireNetDevConfig(w http.ResponseWriter, r *http.Request) {
+	n, err := AcquireNetDevConfig(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(n, w)
+}
+
+func routerAcquireLinkConfig(w http.ResponseWriter, r *http.Request) {
+	l, err := AcquireLinkConfig(mux.Vars(r)["name"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(l, w)
+}
+
+func routerApplyNetwork(w http.ResponseWriter, r *http.Request) {
+	s := DesiredState{}
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	if err := s.Apply(r.Context(), w); err != nil {
+		web.JSONResponseError(err, w)
+	}
+}
+
+func routerAcquireConfirmState(w http.ResponseWriter, r *http.Request) {
+	web.JSONResponse(AcquireConfirmState(), w)
+}
+
+func routerConfirmChange(w http.ResponseWriter, r *http.Request) {
+	if err := ConfirmChange(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("confirmed", w)
+}
+
+func routerRollbackChange(w http.ResponseWriter, r *http.Request) {
+	if err := RollbackChange(); err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("rolled back", w)
+}
+
 func RegisterRouterNetworkd(router *mux.Router) {
 	n := router.PathPrefix("/networkd").Subrouter().StrictSlash(false)
+	loadConfirmState()
+	n.Use(confirmMiddleware)
+
+	openapi.Describe(n.HandleFunc("/network/describenetwork", routerAcquireNetworkState).Methods("GET"), "Describe the network state", nil, NetworkDescribe{})
+	openapi.Describe(n.HandleFunc("/network/describelinks", routerAcquireLinks).Methods("GET"), "Describe the links", nil, LinksDescribe{})
+	openapi.Describe(n.HandleFunc("/network/configure", routerConfigureNetwork).Methods("POST"), "Configure a .network file", Network{}, nil)
+	openapi.Describe(n.HandleFunc("/network/remove", routerRemoveNetwork).Methods("DELETE"), "Remove settings from a .network file", Network{}, nil)
+
+	openapi.Describe(n.HandleFunc("/netdev/configure", routerConfigureNetDev).Methods("POST"), "Create a virtual network device", NetDev{}, nil)
+	openapi.Describe(n.HandleFunc("/netdev/remove", routerRemoveNetDev).Methods("DELETE"), "Remove a virtual network device", NetDev{}, nil)
+
+	openapi.Describe(n.HandleFunc("/link/configure", routerConfigureLink).Methods("POST"), "Configure a .link file", Link{}, nil)
 
-	n.HandleFunc("/network/describenetwork", routerAcquireNetworkState).Methods("GET")
-	n.HandleFunc("/network/describelinks", routerAcquireLinks).Methods("GET")
-	n.HandleFunc("/network/configure", routerConfigureNetwork).Methods("POST")
-	n.HandleFunc("/network/remove", routerRemoveNetwork).Methods("DELETE")
+	openapi.Describe(n.HandleFunc("/apply", routerApplyNetwork).Methods("POST"), "Apply the desired .network, .netdev and .link files", DesiredState{}, ApplyResult{})
 
-	n.HandleFunc("/netdev/configure", routerConfigureNetDev).Methods("POST")
-	n.HandleFunc("/netdev/remove", routerRemoveNetDev).Methods("DELETE")
+	openapi.Describe(n.HandleFunc("/confirm", routerAcquireConfirmState).Methods("GET"), "Show the change awaiting confirmation", nil, ConfirmState{})
+	openapi.Describe(n.HandleFunc("/confirm", routerConfirmChange).Methods("POST"), "Confirm the change awaiting confirmation", nil, nil)
+	openapi.Describe(n.HandleFunc("/confirm", routerRollbackChange).Methods("DELETE"), "Roll back the change awaiting confirmation", nil, nil)
 
-	n.HandleFunc("/link/configure", routerConfigureLink).Methods("POST")
+	openapi.Describe(n.HandleFunc("/network/{link}", routerAcquireNetworkConfig).Methods("GET"), "Read the .network file of a link and its drop-ins", nil, NetworkConfig{})
+	openapi.Describe(n.HandleFunc("/netdev/{name}", routerAcquireNetDevConfig).Methods("GET"), "Read the .netdev file of a virtual network device and its drop-ins", nil, NetDevConfig{})
+	openapi.Describe(n.HandleFunc("/link/{name}", routerAcquireLinkConfig).Methods("GET"), "Read the .link file of a link and its drop-ins", nil, LinkConfig{})
 }
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -33,6 +34,7 @@ func routerAcquireDns(w http.ResponseWriter, r *http.Request) {
 	dns, err := AcquireDns(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(dns, w)
@@ -42,6 +44,7 @@ func routerAcquireDomains(w http.ResponseWriter, r *http.Request) {
 	domains, err := AcquireDomains(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(domains, w)
@@ -51,6 +54,7 @@ func routerDescribeDns(w http.ResponseWriter, r *http.Request) {
 	d, err := DescribeDns(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(d, w)
@@ -59,7 +63,7 @@ func routerDescribeDns(w http.ResponseWriter, r *http.Request) {
 func routerAddDns(w http.ResponseWriter, r *http.Request) {
 	d, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -71,7 +75,7 @@ func routerAddDns(w http.ResponseWriter, r *http.Request) {
 func routerRemoveDns(w http.ResponseWriter, r *http.Request) {
 	d, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -83,13 +87,13 @@ func routerRemoveDns(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterResolved(router *mux.Router) {
 	n := router.PathPrefix("/resolved").Subrouter().StrictSlash(false)
 
-	n.HandleFunc("/describe", routerDescribeDns).Methods("GET")
-	n.HandleFunc("/dns", routerAcquireDns).Methods("GET")
-	n.HandleFunc("/domains", routerAcquireDomains).Methods("GET")
-	n.HandleFunc("/{link}/dns", routerAcquireLinkDns).Methods("GET")
-	n.HandleFunc("/{link}/domains", routerAcquireLinkDomains).Methods("GET")
-	n.HandleFunc("/{link}/currentdns", routerAcquireLinkCurrentDns).Methods("GET")
+	openapi.Describe(n.HandleFunc("/describe", routerDescribeDns).Methods("GET"), "Describe the DNS settings", nil, Describe{})
+	openapi.Describe(n.HandleFunc("/dns", routerAcquireDns).Methods("GET"), "List the DNS servers", nil, []Dns{})
+	openapi.Describe(n.HandleFunc("/domains", routerAcquireDomains).Methods("GET"), "List the search domains", nil, []Domains{})
+	openapi.Describe(n.HandleFunc("/{link}/dns", routerAcquireLinkDns).Methods("GET"), "List the DNS servers of a link", nil, nil)
+	openapi.Describe(n.HandleFunc("/{link}/domains", routerAcquireLinkDomains).Methods("GET"), "List the search domains of a link", nil, nil)
+	openapi.Describe(n.HandleFunc("/{link}/currentdns", routerAcquireLinkCurrentDns).Methods("GET"), "Show the current DNS server of a link", nil, nil)
 
-	n.HandleFunc("/add", routerAddDns).Methods("POST")
-	n.HandleFunc("/remove", routerRemoveDns).Methods("DELETE")
+	openapi.Describe(n.HandleFunc("/add", routerAddDns).Methods("POST"), "Add DNS servers and domains", GlobalDns{}, nil)
+	openapi.Describe(n.HandleFunc("/remove", routerRemoveDns).Methods("DELETE"), "Remove DNS servers and domains", GlobalDns{}, nil)
 }
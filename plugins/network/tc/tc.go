@@ -0,0 +1,493 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// Qdisc is a queueing discipline of a link. Parent defaults to root. Only the
+// fields of the Kind apply: Limit is in packets for fq_codel and netem and in
+// bytes for tbf, Rate is the shaping rate of tbf and netem.
+type Qdisc struct {
+	Link   string `json:"Link"`
+	Kind   string `json:"Kind"`
+	Parent string `json:"Parent"`
+	Handle string `json:"Handle"`
+
+	Limit    string `json:"Limit"`
+	Target   string `json:"Target"`
+	Interval string `json:"Interval"`
+	Flows    string `json:"Flows"`
+	Quantum  string `json:"Quantum"`
+	ECN      string `json:"ECN"`
+
+	Rate     string `json:"Rate"`
+	Burst    string `json:"Burst"`
+	Latency  string `json:"Latency"`
+	PeakRate string `json:"PeakRate"`
+	MTU      string `json:"MTU"`
+
+	DefaultClass string `json:"DefaultClass"`
+
+	Delay     string `json:"Delay"`
+	Jitter    string `json:"Jitter"`
+	Loss      string `json:"Loss"`
+	Duplicate string `json:"Duplicate"`
+	Reorder   string `json:"Reorder"`
+	Corrupt   string `json:"Corrupt"`
+}
+
+type Statistics struct {
+	Bytes      uint64 `json:"Bytes"`
+	Packets    uint32 `json:"Packets"`
+	Drops      uint32 `json:"Drops"`
+	Overlimits uint32 `json:"Overlimits"`
+	Requeues   uint32 `json:"Requeues"`
+	Backlog    uint32 `json:"Backlog"`
+	Qlen       uint32 `json:"Qlen"`
+	Bps        uint32 `json:"Bps"`
+	Pps        uint32 `json:"Pps"`
+}
+
+type QdiscInfo struct {
+	LinkName   string            `json:"LinkName"`
+	LinkIndex  int               `json:"LinkIndex"`
+	Kind       string            `json:"Kind"`
+	Handle     string            `json:"Handle"`
+	Parent     string            `json:"Parent"`
+	Options    map[string]string `json:"Options"`
+	Statistics *Statistics       `json:"Statistics"`
+}
+
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func (q *Qdisc) buildFqCodel(attrs netlink.QdiscAttrs) (netlink.Qdisc, error) {
+	fq := netlink.NewFqCodel(attrs)
+
+	var err error
+	if q.Limit != "" {
+		if fq.Limit, err = parseUint32("Limit", q.Limit); err != nil {
+			return nil, err
+		}
+	}
+	if q.Target != "" {
+		if fq.Target, err = parseTime("Target", q.Target); err != nil {
+			return nil, err
+		}
+	}
+	if q.Interval != "" {
+		if fq.Interval, err = parseTime("Interval", q.Interval); err != nil {
+			return nil, err
+		}
+	}
+	if q.Flows != "" {
+		if fq.Flows, err = parseUint32("Flows", q.Flows); err != nil {
+			return nil, err
+		}
+	}
+	if q.Quantum != "" {
+		if fq.Quantum, err = parseSize("Quantum", q.Quantum); err != nil {
+			return nil, err
+		}
+	}
+	if q.ECN != "" {
+		if !validator.IsBool(q.ECN) {
+			return nil, web.InvalidArgument("ECN", q.ECN)
+		}
+		fq.ECN = 0
+		if validator.BoolToString(q.ECN) == "yes" {
+			fq.ECN = 1
+		}
+	}
+
+	return fq, nil
+}
+
+// buildTbf converts the rate and burst as tc does: the kernel takes the
+// bucket as the time to send a burst at the rate and the limit in bytes,
+// which is derived from Latency (50ms by default) unless given.
+func (q *Qdisc) buildTbf(attrs netlink.QdiscAttrs) (netlink.Qdisc, error) {
+	if q.Rate == "" || q.Burst == "" {
+		return nil, web.NewError(web.ErrInvalidArgument, "tbf needs Rate and Burst").WithField("Rate", q.Rate, "required")
+	}
+
+	rate, err := parseRate("Rate", q.Rate)
+	if err != nil {
+		return nil, err
+	}
+	burst, err := parseSize("Burst", q.Burst)
+	if err != nil {
+		return nil, err
+	}
+
+	tbf := netlink.Tbf{
+		QdiscAttrs: attrs,
+		Rate:       rate / 8,
+	}
+	tbf.Buffer = netlink.Xmittime(tbf.Rate, burst)
+
+	if q.Limit != "" {
+		if tbf.Limit, err = parseSize("Limit", q.Limit); err != nil {
+			return nil, err
+		}
+	} else {
+		latency := uint32(50 * 1000)
+		if q.Latency != "" {
+			if latency, err = parseTime("Latency", q.Latency); err != nil {
+				return nil, err
+			}
+		}
+		tbf.Limit = uint32(float64(tbf.Rate)*float64(latency)/1e6) + burst
+	}
+
+	if q.PeakRate != "" {
+		peak, err := parseRate("PeakRate", q.PeakRate)
+		if err != nil {
+			return nil, err
+		}
+		if q.MTU == "" {
+			return nil, web.NewError(web.ErrInvalidArgument, "PeakRate needs MTU").WithField("MTU", "", "required")
+		}
+
+		tbf.Peakrate = peak / 8
+		if tbf.Minburst, err = parseSize("MTU", q.MTU); err != nil {
+			return nil, err
+		}
+	}
+
+	return &tbf, nil
+}
+
+func (q *Qdisc) buildHtb(attrs netlink.QdiscAttrs) (netlink.Qdisc, error) {
+	htb := netlink.NewHtb(attrs)
+
+	if q.DefaultClass != "" {
+		d, err := strconv.ParseUint(q.DefaultClass, 16, 32)
+		if err != nil {
+			return nil, web.InvalidArgument("DefaultClass", q.DefaultClass)
+		}
+		htb.Defcls = uint32(d)
+	}
+
+	return htb, nil
+}
+
+func (q *Qdisc) buildNetem(attrs netlink.QdiscAttrs) (netlink.Qdisc, error) {
+	n := netlink.NetemQdiscAttrs{}
+
+	var err error
+	if q.Delay != "" {
+		if n.Latency, err = parseTime("Delay", q.Delay); err != nil {
+			return nil, err
+		}
+	}
+	if q.Jitter != "" {
+		if n.Jitter, err = parseTime("Jitter", q.Jitter); err != nil {
+			return nil, err
+		}
+	}
+	if q.Limit != "" {
+		if n.Limit, err = parseUint32("Limit", q.Limit); err != nil {
+			return nil, err
+		}
+	}
+	if q.Loss != "" {
+		if n.Loss, err = parsePercent("Loss", q.Loss); err != nil {
+			return nil, err
+		}
+	}
+	if q.Duplicate != "" {
+		if n.Duplicate, err = parsePercent("Duplicate", q.Duplicate); err != nil {
+			return nil, err
+		}
+	}
+	if q.Reorder != "" {
+		if n.Latency == 0 {
+			return nil, web.NewError(web.ErrInvalidArgument, "Reorder needs Delay").WithField("Delay", "", "required")
+		}
+		if n.ReorderProb, err = parsePercent("Reorder", q.Reorder); err != nil {
+			return nil, err
+		}
+	}
+	if q.Corrupt != "" {
+		if n.CorruptProb, err = parsePercent("Corrupt", q.Corrupt); err != nil {
+			return nil, err
+		}
+	}
+	if q.Rate != "" {
+		rate, err := parseRate("Rate", q.Rate)
+		if err != nil {
+			return nil, err
+		}
+		n.Rate64 = rate / 8
+	}
+
+	return netlink.NewNetem(attrs, n), nil
+}
+
+func (q *Qdisc) buildQdisc() (netlink.Qdisc, error) {
+	l, err := link.AcquireLinkByName(q.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := netlink.QdiscAttrs{
+		LinkIndex: l.Attrs().Index,
+	}
+
+	kind := strings.ToLower(q.Kind)
+	switch kind {
+	case "ingress":
+		attrs.Parent = netlink.HANDLE_INGRESS
+		attrs.Handle = netlink.MakeHandle(0xffff, 0)
+		return &netlink.Ingress{QdiscAttrs: attrs}, nil
+	case "clsact":
+		attrs.Parent = netlink.HANDLE_CLSACT
+		attrs.Handle = netlink.MakeHandle(0xffff, 0)
+		return &netlink.Clsact{QdiscAttrs: attrs}, nil
+	}
+
+	if attrs.Parent, err = parseParent(q.Parent); err != nil {
+		return nil, err
+	}
+	if q.Handle != "" {
+		if attrs.Handle, err = parseHandle("Handle", q.Handle); err != nil {
+			return nil, err
+		}
+	}
+
+	switch kind {
+	case "fq_codel":
+		return q.buildFqCodel(attrs)
+	case "tbf":
+		return q.buildTbf(attrs)
+	case "htb":
+		// Classes are attached to the handle, so one is always set.
+		if attrs.Handle == 0 {
+			attrs.Handle = netlink.MakeHandle(1, 0)
+		}
+		return q.buildHtb(attrs)
+	case "netem":
+		return q.buildNetem(attrs)
+	}
+
+	return nil, web.InvalidArgument("Kind", q.Kind)
+}
+
+func (q *Qdisc) Add() error {
+	qdisc, err := q.buildQdisc()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.QdiscAdd(qdisc); err != nil {
+		log.Errorf("Failed to add qdisc kind='%s' link='%s': %v", q.Kind, q.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// Replace adds the qdisc or overwrites the one at its parent.
+func (q *Qdisc) Replace() error {
+	qdisc, err := q.buildQdisc()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.QdiscReplace(qdisc); err != nil {
+		log.Errorf("Failed to replace qdisc kind='%s' link='%s': %v", q.Kind, q.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// Remove deletes the qdisc at Parent, of Handle when given. The kernel wants
+// the kind of the qdisc, so it is looked up first.
+func (q *Qdisc) Remove() error {
+	l, err := link.AcquireLinkByName(q.Link)
+	if err != nil {
+		return err
+	}
+
+	parent, err := parseParent(q.Parent)
+	if err != nil {
+		return err
+	}
+	kind := strings.ToLower(q.Kind)
+	if kind == "ingress" || kind == "clsact" {
+		parent = netlink.HANDLE_INGRESS
+	}
+
+	var handle uint32
+	if q.Handle != "" {
+		if handle, err = parseHandle("Handle", q.Handle); err != nil {
+			return err
+		}
+	}
+
+	qdiscs, err := netlink.QdiscList(l)
+	if err != nil {
+		return err
+	}
+
+	for _, qdisc := range qdiscs {
+		a := qdisc.Attrs()
+		if a.Parent != parent || (handle != 0 && a.Handle != handle) || (kind != "" && qdisc.Type() != kind) {
+			continue
+		}
+
+		if err := netlink.QdiscDel(qdisc); err != nil {
+			log.Errorf("Failed to delete qdisc kind='%s' link='%s': %v", qdisc.Type(), q.Link, err)
+			return err
+		}
+
+		return nil
+	}
+
+	return web.NewError(web.ErrNotFound, "qdisc parent='%s' not found on link='%s'", netlink.HandleStr(parent), q.Link)
+}
+
+func fillStatistics(s *netlink.ClassStatistics) *Statistics {
+	if s == nil {
+		return nil
+	}
+
+	st := Statistics{}
+	if s.Basic != nil {
+		st.Bytes = s.Basic.Bytes
+		st.Packets = s.Basic.Packets
+	}
+	if s.Queue != nil {
+		st.Drops = s.Queue.Drops
+		st.Overlimits = s.Queue.Overlimits
+		st.Requeues = s.Queue.Requeues
+		st.Backlog = s.Queue.Backlog
+		st.Qlen = s.Queue.Qlen
+	}
+	if s.RateEst != nil {
+		st.Bps = s.RateEst.Bps
+		st.Pps = s.RateEst.Pps
+	}
+
+	return &st
+}
+
+func qdiscOptions(qdisc netlink.Qdisc) map[string]string {
+	o := map[string]string{}
+
+	switch q := qdisc.(type) {
+	case *netlink.FqCodel:
+		o["Limit"] = strconv.FormatUint(uint64(q.Limit), 10)
+		o["Target"] = formatTime(q.Target)
+		o["Interval"] = formatTime(q.Interval)
+		o["Flows"] = strconv.FormatUint(uint64(q.Flows), 10)
+		o["Quantum"] = strconv.FormatUint(uint64(q.Quantum), 10)
+		o["ECN"] = strconv.FormatBool(q.ECN != 0)
+	case *netlink.Tbf:
+		o["Rate"] = formatRate(q.Rate * 8)
+		o["Burst"] = strconv.FormatUint(uint64(netlink.Xmitsize(q.Rate, q.Buffer)), 10)
+		o["Limit"] = strconv.FormatUint(uint64(q.Limit), 10)
+		if q.Peakrate != 0 {
+			o["PeakRate"] = formatRate(q.Peakrate * 8)
+			o["MTU"] = strconv.FormatUint(uint64(q.Minburst), 10)
+		}
+	case *netlink.Htb:
+		o["DefaultClass"] = strconv.FormatUint(uint64(q.Defcls), 16)
+		o["RateToQuantum"] = strconv.FormatUint(uint64(q.Rate2Quantum), 10)
+	case *netlink.Netem:
+		tick := netlink.TickInUsec()
+		o["Limit"] = strconv.FormatUint(uint64(q.Limit), 10)
+		if q.Latency != 0 {
+			o["Delay"] = formatTime(uint32(float64(q.Latency) / tick))
+		}
+		if q.Jitter != 0 {
+			o["Jitter"] = formatTime(uint32(float64(q.Jitter) / tick))
+		}
+		if q.Loss != 0 {
+			o["Loss"] = formatPercent(q.Loss)
+		}
+		if q.Duplicate != 0 {
+			o["Duplicate"] = formatPercent(q.Duplicate)
+		}
+		if q.ReorderProb != 0 {
+			o["Reorder"] = formatPercent(q.ReorderProb)
+		}
+		if q.CorruptProb != 0 {
+			o["Corrupt"] = formatPercent(q.CorruptProb)
+		}
+		if q.Rate64 != 0 {
+			o["Rate"] = formatRate(q.Rate64 * 8)
+		}
+	}
+
+	return o
+}
+
+func fillOneQdisc(qdisc netlink.Qdisc) QdiscInfo {
+	a := qdisc.Attrs()
+
+	info := QdiscInfo{
+		LinkIndex: a.LinkIndex,
+		Kind:      qdisc.Type(),
+		Handle:    netlink.HandleStr(a.Handle),
+		Parent:    netlink.HandleStr(a.Parent),
+		Options:   qdiscOptions(qdisc),
+	}
+	if g, ok := qdisc.(*netlink.GenericQdisc); ok {
+		info.Kind = g.QdiscType
+	}
+	if a.Statistics != nil {
+		info.Statistics = fillStatistics((*netlink.ClassStatistics)(a.Statistics))
+	}
+	if l, err := netlink.LinkByIndex(a.LinkIndex); err == nil {
+		info.LinkName = l.Attrs().Name
+	}
+
+	return info
+}
+
+// acquireLink looks up the link a listing is restricted to, if any.
+func acquireLink(name string) (netlink.Link, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	return link.AcquireLinkByName(name)
+}
+
+// AcquireQdiscs lists the qdiscs with their statistics, of one link when
+// given.
+func AcquireQdiscs(name string) ([]QdiscInfo, error) {
+	l, err := acquireLink(name)
+	if err != nil {
+		return nil, err
+	}
+
+	qdiscs, err := netlink.QdiscList(l)
+	if err != nil {
+		return nil, err
+	}
+
+	qs := []QdiscInfo{}
+	for _, q := range qdiscs {
+		qs = append(qs, fillOneQdisc(q))
+	}
+
+	return qs, nil
+}
@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tc
+
+import (
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// Class is a class of an htb qdisc. Parent is the qdisc handle or a parent
+// class, Ceil defaults to Rate.
+type Class struct {
+	Link     string `json:"Link"`
+	Parent   string `json:"Parent"`
+	ClassId  string `json:"ClassId"`
+	Rate     string `json:"Rate"`
+	Ceil     string `json:"Ceil"`
+	Burst    string `json:"Burst"`
+	CBurst   string `json:"CBurst"`
+	Priority string `json:"Priority"`
+	Quantum  string `json:"Quantum"`
+}
+
+type ClassInfo struct {
+	LinkName   string            `json:"LinkName"`
+	LinkIndex  int               `json:"LinkIndex"`
+	Kind       string            `json:"Kind"`
+	ClassId    string            `json:"ClassId"`
+	Parent     string            `json:"Parent"`
+	Options    map[string]string `json:"Options"`
+	Statistics *Statistics       `json:"Statistics"`
+}
+
+func (c *Class) buildClass() (netlink.Class, error) {
+	l, err := link.AcquireLinkByName(c.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Parent == "" {
+		return nil, web.InvalidArgument("Parent", c.Parent)
+	}
+	attrs := netlink.ClassAttrs{
+		LinkIndex: l.Attrs().Index,
+	}
+	if attrs.Parent, err = parseHandle("Parent", c.Parent); err != nil {
+		return nil, err
+	}
+	if attrs.Handle, err = parseHandle("ClassId", c.ClassId); err != nil {
+		return nil, err
+	}
+	if c.Rate == "" {
+		return nil, web.InvalidArgument("Rate", c.Rate)
+	}
+
+	h := netlink.HtbClassAttrs{}
+	if h.Rate, err = parseRate("Rate", c.Rate); err != nil {
+		return nil, err
+	}
+	if c.Ceil != "" {
+		if h.Ceil, err = parseRate("Ceil", c.Ceil); err != nil {
+			return nil, err
+		}
+	}
+	if c.Burst != "" {
+		if h.Buffer, err = parseSize("Burst", c.Burst); err != nil {
+			return nil, err
+		}
+	}
+	if c.CBurst != "" {
+		if h.Cbuffer, err = parseSize("CBurst", c.CBurst); err != nil {
+			return nil, err
+		}
+	}
+	if c.Priority != "" {
+		if h.Prio, err = parseUint32("Priority", c.Priority); err != nil {
+			return nil, err
+		}
+	}
+	if c.Quantum != "" {
+		if h.Quantum, err = parseSize("Quantum", c.Quantum); err != nil {
+			return nil, err
+		}
+	}
+
+	return netlink.NewHtbClass(attrs, h), nil
+}
+
+func (c *Class) Add() error {
+	class, err := c.buildClass()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.ClassAdd(class); err != nil {
+		log.Errorf("Failed to add class='%s' link='%s': %v", c.ClassId, c.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Class) Replace() error {
+	class, err := c.buildClass()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.ClassReplace(class); err != nil {
+		log.Errorf("Failed to replace class='%s' link='%s': %v", c.ClassId, c.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *Class) Remove() error {
+	l, err := link.AcquireLinkByName(c.Link)
+	if err != nil {
+		return err
+	}
+
+	id, err := parseHandle("ClassId", c.ClassId)
+	if err != nil {
+		return err
+	}
+
+	classes, err := netlink.ClassList(l, netlink.HANDLE_NONE)
+	if err != nil {
+		return err
+	}
+
+	for _, class := range classes {
+		if class.Attrs().Handle != id {
+			continue
+		}
+
+		if err := netlink.ClassDel(class); err != nil {
+			log.Errorf("Failed to delete class='%s' link='%s': %v", c.ClassId, c.Link, err)
+			return err
+		}
+
+		return nil
+	}
+
+	return web.NewError(web.ErrNotFound, "class='%s' not found on link='%s'", c.ClassId, c.Link)
+}
+
+func fillOneClass(class netlink.Class) ClassInfo {
+	a := class.Attrs()
+
+	info := ClassInfo{
+		LinkIndex:  a.LinkIndex,
+		Kind:       class.Type(),
+		ClassId:    netlink.HandleStr(a.Handle),
+		Parent:     netlink.HandleStr(a.Parent),
+		Options:    map[string]string{},
+		Statistics: fillStatistics(a.Statistics),
+	}
+
+	switch c := class.(type) {
+	case *netlink.HtbClass:
+		info.Options["Rate"] = formatRate(c.Rate * 8)
+		info.Options["Ceil"] = formatRate(c.Ceil * 8)
+		info.Options["Burst"] = strconv.FormatUint(uint64(netlink.Xmitsize(c.Rate, c.Buffer)), 10)
+		info.Options["CBurst"] = strconv.FormatUint(uint64(netlink.Xmitsize(c.Ceil, c.Cbuffer)), 10)
+		info.Options["Priority"] = strconv.FormatUint(uint64(c.Prio), 10)
+		info.Options["Quantum"] = strconv.FormatUint(uint64(c.Quantum), 10)
+	case *netlink.GenericClass:
+		info.Kind = c.ClassType
+	}
+
+	if l, err := netlink.LinkByIndex(a.LinkIndex); err == nil {
+		info.LinkName = l.Attrs().Name
+	}
+
+	return info
+}
+
+// AcquireClasses lists the classes of a link with their statistics.
+func AcquireClasses(name string) ([]ClassInfo, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	classes, err := netlink.ClassList(l, netlink.HANDLE_NONE)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := []ClassInfo{}
+	for _, c := range classes {
+		cs = append(cs, fillOneClass(c))
+	}
+
+	return cs, nil
+}
@@ -0,0 +1,453 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tc
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/validator"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+// Filter is a u32 or matchall filter. Parent is a qdisc handle or, for the
+// ingress and clsact qdiscs, "ingress" or "egress". A filter either sends
+// the packets to ClassId or applies Action, "pass" or "drop". The u32 filter
+// matches the IPv4 header fields given, none of them matches every packet.
+type Filter struct {
+	Link            string `json:"Link"`
+	Parent          string `json:"Parent"`
+	Kind            string `json:"Kind"`
+	Priority        string `json:"Priority"`
+	Protocol        string `json:"Protocol"`
+	ClassId         string `json:"ClassId"`
+	Action          string `json:"Action"`
+	SourceIP        string `json:"SourceIP"`
+	DestinationIP   string `json:"DestinationIP"`
+	IPProtocol      string `json:"IPProtocol"`
+	SourcePort      string `json:"SourcePort"`
+	DestinationPort string `json:"DestinationPort"`
+}
+
+type FilterInfo struct {
+	LinkName  string            `json:"LinkName"`
+	LinkIndex int               `json:"LinkIndex"`
+	Kind      string            `json:"Kind"`
+	Parent    string            `json:"Parent"`
+	Handle    string            `json:"Handle"`
+	Priority  uint16            `json:"Priority"`
+	Protocol  string            `json:"Protocol"`
+	ClassId   string            `json:"ClassId"`
+	Action    string            `json:"Action"`
+	Match     map[string]string `json:"Match"`
+}
+
+// Offsets and masks of the IPv4 header fields matched by u32.
+const (
+	u32OffProtocol = 8
+	u32OffSource   = 12
+	u32OffDest     = 16
+	u32OffPorts    = 20
+
+	u32MaskProtocol   = 0x00ff0000
+	u32MaskSourcePort = 0xffff0000
+	u32MaskDestPort   = 0x0000ffff
+)
+
+var ipProtocols = map[string]uint32{
+	"icmp": 1,
+	"tcp":  6,
+	"udp":  17,
+	"sctp": 132,
+}
+
+var actions = map[string]netlink.TcAct{
+	"pass": netlink.TC_ACT_OK,
+	"drop": netlink.TC_ACT_SHOT,
+}
+
+func parseU32Address(field string, s string, off int32) (netlink.TcU32Key, error) {
+	ip, n, err := net.ParseCIDR(s)
+	if err != nil {
+		if ip = net.ParseIP(s); ip == nil {
+			return netlink.TcU32Key{}, web.InvalidArgument(field, s)
+		}
+		n = &net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}
+	}
+	if n.IP.To4() == nil {
+		return netlink.TcU32Key{}, web.InvalidArgument(field, s)
+	}
+
+	return netlink.TcU32Key{
+		Val:  binary.BigEndian.Uint32(n.IP.To4()),
+		Mask: binary.BigEndian.Uint32(net.IP(n.Mask).To4()),
+		Off:  off,
+	}, nil
+}
+
+// u32Keys builds the keys of the selector from the IPv4 match fields.
+func (f *Filter) u32Keys() ([]netlink.TcU32Key, error) {
+	keys := []netlink.TcU32Key{}
+
+	if f.SourceIP != "" {
+		k, err := parseU32Address("SourceIP", f.SourceIP, u32OffSource)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if f.DestinationIP != "" {
+		k, err := parseU32Address("DestinationIP", f.DestinationIP, u32OffDest)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if f.IPProtocol != "" {
+		p, ok := ipProtocols[strings.ToLower(f.IPProtocol)]
+		if !ok {
+			v, err := strconv.ParseUint(f.IPProtocol, 10, 8)
+			if err != nil {
+				return nil, web.InvalidArgument("IPProtocol", f.IPProtocol)
+			}
+			p = uint32(v)
+		}
+		keys = append(keys, netlink.TcU32Key{Val: p << 16, Mask: u32MaskProtocol, Off: u32OffProtocol})
+	}
+
+	var ports netlink.TcU32Key
+	if f.SourcePort != "" {
+		if !validator.IsPort(f.SourcePort) {
+			return nil, web.InvalidArgument("SourcePort", f.SourcePort)
+		}
+		p, _ := strconv.ParseUint(f.SourcePort, 10, 16)
+		ports.Val |= uint32(p) << 16
+		ports.Mask |= u32MaskSourcePort
+	}
+	if f.DestinationPort != "" {
+		if !validator.IsPort(f.DestinationPort) {
+			return nil, web.InvalidArgument("DestinationPort", f.DestinationPort)
+		}
+		p, _ := strconv.ParseUint(f.DestinationPort, 10, 16)
+		ports.Val |= uint32(p)
+		ports.Mask |= u32MaskDestPort
+	}
+	if ports.Mask != 0 {
+		// Ports follow a header without options.
+		ports.Off = u32OffPorts
+		keys = append(keys, ports)
+	}
+
+	return keys, nil
+}
+
+func (f *Filter) buildFilter() (netlink.Filter, error) {
+	l, err := link.AcquireLinkByName(f.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := netlink.FilterAttrs{
+		LinkIndex: l.Attrs().Index,
+	}
+	if attrs.Parent, err = parseFilterParent(f.Parent); err != nil {
+		return nil, err
+	}
+	if f.Priority != "" {
+		p, err := strconv.ParseUint(f.Priority, 10, 16)
+		if err != nil || p == 0 {
+			return nil, web.InvalidArgument("Priority", f.Priority)
+		}
+		attrs.Priority = uint16(p)
+	}
+
+	keys, err := f.u32Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	// The u32 keys match at IPv4 header offsets, so they need Protocol ip.
+	proto := f.Protocol
+	if proto == "" {
+		proto = "all"
+		if len(keys) > 0 {
+			proto = "ip"
+		}
+	}
+	if attrs.Protocol, err = parseProtocol(proto); err != nil {
+		return nil, err
+	}
+	if len(keys) > 0 && attrs.Protocol != unix.ETH_P_IP {
+		return nil, web.InvalidArgument("Protocol", f.Protocol)
+	}
+
+	var classId uint32
+	if f.ClassId != "" {
+		if classId, err = parseHandle("ClassId", f.ClassId); err != nil {
+			return nil, err
+		}
+	}
+
+	var acts []netlink.Action
+	if f.Action != "" {
+		a, ok := actions[strings.ToLower(f.Action)]
+		if !ok {
+			return nil, web.InvalidArgument("Action", f.Action)
+		}
+		acts = append(acts, &netlink.GenericAction{ActionAttrs: netlink.ActionAttrs{Action: a}})
+	}
+	if classId == 0 && len(acts) == 0 {
+		return nil, web.NewError(web.ErrInvalidArgument, "filter needs ClassId or Action").WithField("ClassId", "", "required")
+	}
+
+	switch strings.ToLower(f.Kind) {
+	case "", "u32":
+		u := &netlink.U32{
+			FilterAttrs: attrs,
+			ClassId:     classId,
+			Actions:     acts,
+		}
+		if len(keys) > 0 {
+			u.Sel = &netlink.TcU32Sel{
+				Flags: netlink.TC_U32_TERMINAL,
+				Keys:  keys,
+			}
+		}
+
+		return u, nil
+	case "matchall":
+		if len(keys) > 0 {
+			return nil, web.NewError(web.ErrInvalidArgument, "matchall takes no match fields")
+		}
+
+		return &netlink.MatchAll{
+			FilterAttrs: attrs,
+			ClassId:     classId,
+			Actions:     acts,
+		}, nil
+	}
+
+	return nil, web.InvalidArgument("Kind", f.Kind)
+}
+
+func (f *Filter) Add() error {
+	filter, err := f.buildFilter()
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.FilterAdd(filter); err != nil {
+		log.Errorf("Failed to add filter kind='%s' link='%s': %v", filter.Type(), f.Link, err)
+		return err
+	}
+
+	return nil
+}
+
+// Remove deletes the filters of Priority at Parent.
+func (f *Filter) Remove() error {
+	l, err := link.AcquireLinkByName(f.Link)
+	if err != nil {
+		return err
+	}
+
+	parent, err := parseFilterParent(f.Parent)
+	if err != nil {
+		return err
+	}
+	prio, err := strconv.ParseUint(f.Priority, 10, 16)
+	if err != nil || prio == 0 {
+		return web.InvalidArgument("Priority", f.Priority)
+	}
+
+	filters, err := netlink.FilterList(l, parent)
+	if err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		a := filter.Attrs()
+		if a.Priority != uint16(prio) {
+			continue
+		}
+
+		g := &netlink.GenericFilter{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: a.LinkIndex,
+				Parent:    parent,
+				Priority:  a.Priority,
+				Protocol:  a.Protocol,
+			},
+			FilterType: filter.Type(),
+		}
+		if err := netlink.FilterDel(g); err != nil {
+			log.Errorf("Failed to delete filter priority='%s' link='%s': %v", f.Priority, f.Link, err)
+			return err
+		}
+
+		return nil
+	}
+
+	return web.NewError(web.ErrNotFound, "filter priority='%s' not found on link='%s'", f.Priority, f.Link)
+}
+
+func actionString(acts []netlink.Action) string {
+	for _, a := range acts {
+		g, ok := a.(*netlink.GenericAction)
+		if !ok {
+			return a.Type()
+		}
+		for name, v := range actions {
+			if v == g.Action {
+				return name
+			}
+		}
+		return g.Action.String()
+	}
+
+	return ""
+}
+
+// u32Match turns the keys written by u32Keys back into fields. Other keys
+// are shown by offset.
+func u32Match(sel *netlink.TcU32Sel) map[string]string {
+	m := map[string]string{}
+	if sel == nil {
+		return m
+	}
+
+	for _, k := range sel.Keys {
+		switch {
+		case k.Mask == 0:
+		case k.Off == u32OffSource || k.Off == u32OffDest:
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, k.Val)
+			mask := make(net.IPMask, 4)
+			binary.BigEndian.PutUint32(mask, k.Mask)
+			ones, _ := mask.Size()
+
+			a := (&net.IPNet{IP: ip, Mask: mask}).String()
+			if ones == 32 {
+				a = ip.String()
+			}
+			if k.Off == u32OffSource {
+				m["SourceIP"] = a
+			} else {
+				m["DestinationIP"] = a
+			}
+		case k.Off == u32OffProtocol && k.Mask == u32MaskProtocol:
+			p := strconv.FormatUint(uint64(k.Val>>16), 10)
+			for name, v := range ipProtocols {
+				if v == k.Val>>16 {
+					p = name
+				}
+			}
+			m["IPProtocol"] = p
+		case k.Off == u32OffPorts:
+			if k.Mask&u32MaskSourcePort != 0 {
+				m["SourcePort"] = strconv.FormatUint(uint64(k.Val>>16), 10)
+			}
+			if k.Mask&u32MaskDestPort != 0 {
+				m["DestinationPort"] = strconv.FormatUint(uint64(k.Val&u32MaskDestPort), 10)
+			}
+		default:
+			m["Offset"+strconv.Itoa(int(k.Off))] = strconv.FormatUint(uint64(k.Val), 16) + "/" + strconv.FormatUint(uint64(k.Mask), 16)
+		}
+	}
+
+	return m
+}
+
+func fillOneFilter(filter netlink.Filter) FilterInfo {
+	a := filter.Attrs()
+
+	info := FilterInfo{
+		LinkIndex: a.LinkIndex,
+		Kind:      filter.Type(),
+		Parent:    handleString(a.Parent),
+		Handle:    netlink.HandleStr(a.Handle),
+		Priority:  a.Priority,
+		Protocol:  protocolString(a.Protocol),
+		Match:     map[string]string{},
+	}
+
+	switch f := filter.(type) {
+	case *netlink.U32:
+		if f.ClassId != 0 {
+			info.ClassId = netlink.HandleStr(f.ClassId)
+		}
+		info.Action = actionString(f.Actions)
+		info.Match = u32Match(f.Sel)
+	case *netlink.MatchAll:
+		if f.ClassId != 0 {
+			info.ClassId = netlink.HandleStr(f.ClassId)
+		}
+		info.Action = actionString(f.Actions)
+	case *netlink.GenericFilter:
+		info.Kind = f.FilterType
+	}
+
+	if l, err := netlink.LinkByIndex(a.LinkIndex); err == nil {
+		info.LinkName = l.Attrs().Name
+	}
+
+	return info
+}
+
+// AcquireFilters lists the filters of a link at parent or, when parent is
+// empty, at every qdisc of the link.
+func AcquireFilters(name string, parent string) ([]FilterInfo, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := []uint32{}
+	if parent != "" {
+		p, err := parseFilterParent(parent)
+		if err != nil {
+			return nil, err
+		}
+		parents = append(parents, p)
+	} else {
+		qdiscs, err := netlink.QdiscList(l)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, q := range qdiscs {
+			switch q.Type() {
+			case "ingress":
+				parents = append(parents, netlink.HANDLE_MIN_INGRESS)
+			case "clsact":
+				parents = append(parents, netlink.HANDLE_MIN_INGRESS, netlink.HANDLE_MIN_EGRESS)
+			default:
+				if q.Attrs().Handle != 0 {
+					parents = append(parents, q.Attrs().Handle)
+				}
+			}
+		}
+	}
+
+	fs := []FilterInfo{}
+	for _, p := range parents {
+		filters, err := netlink.FilterList(l, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range filters {
+			fs = append(fs, fillOneFilter(f))
+		}
+	}
+
+	return fs, nil
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tc
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerConfigureQdisc(w http.ResponseWriter, r *http.Request) {
+	q := Qdisc{}
+	if err := decodeJSONRequest(r, &q); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = q.Add()
+	case http.MethodPut:
+		err = q.Replace()
+	case http.MethodDelete:
+		err = q.Remove()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("qdisc configured", w)
+}
+
+func routerAcquireQdiscs(w http.ResponseWriter, r *http.Request) {
+	qs, err := AcquireQdiscs(r.URL.Query().Get("link"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(qs, w)
+}
+
+func routerConfigureClass(w http.ResponseWriter, r *http.Request) {
+	c := Class{}
+	if err := decodeJSONRequest(r, &c); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	switch r.Method {
+	case http.MethodPost:
+		err = c.Add()
+	case http.MethodPut:
+		err = c.Replace()
+	case http.MethodDelete:
+		err = c.Remove()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("class configured", w)
+}
+
+func routerAcquireClasses(w http.ResponseWriter, r *http.Request) {
+	cs, err := AcquireClasses(r.URL.Query().Get("link"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(cs, w)
+}
+
+func routerConfigureFilter(w http.ResponseWriter, r *http.Request) {
+	f := Filter{}
+	if err := decodeJSONRequest(r, &f); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	var err error
+	if r.Method == http.MethodDelete {
+		err = f.Remove()
+	} else {
+		err = f.Add()
+	}
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse("filter configured", w)
+}
+
+func routerAcquireFilters(w http.ResponseWriter, r *http.Request) {
+	fs, err := AcquireFilters(r.URL.Query().Get("link"), r.URL.Query().Get("parent"))
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(fs, w)
+}
+
+func RegisterRouterTC(router *mux.Router) {
+	s := router.PathPrefix("/tc").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/qdisc", routerConfigureQdisc).Methods("POST"), "Add a qdisc to a link", Qdisc{}, nil)
+	openapi.Describe(s.HandleFunc("/qdisc", routerConfigureQdisc).Methods("PUT"), "Add or replace a qdisc of a link", Qdisc{}, nil)
+	openapi.Describe(s.HandleFunc("/qdisc", routerConfigureQdisc).Methods("DELETE"), "Delete a qdisc of a link", Qdisc{}, nil)
+	openapi.Describe(s.HandleFunc("/qdisc", routerAcquireQdiscs).Methods("GET"), "List qdiscs with statistics", nil, []QdiscInfo{})
+	openapi.Describe(s.HandleFunc("/class", routerConfigureClass).Methods("POST"), "Add an htb class", Class{}, nil)
+	openapi.Describe(s.HandleFunc("/class", routerConfigureClass).Methods("PUT"), "Add or replace an htb class", Class{}, nil)
+	openapi.Describe(s.HandleFunc("/class", routerConfigureClass).Methods("DELETE"), "Delete a class", Class{}, nil)
+	openapi.Describe(s.HandleFunc("/class", routerAcquireClasses).Methods("GET"), "List the classes of a link with statistics", nil, []ClassInfo{})
+	openapi.Describe(s.HandleFunc("/filter", routerConfigureFilter).Methods("POST"), "Add a u32 or matchall filter", Filter{}, nil)
+	openapi.Describe(s.HandleFunc("/filter", routerConfigureFilter).Methods("DELETE"), "Delete the filters of a priority", Filter{}, nil)
+	openapi.Describe(s.HandleFunc("/filter", routerAcquireFilters).Methods("GET"), "List the filters of a link", nil, []FilterInfo{})
+}
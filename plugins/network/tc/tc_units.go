@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+// splitSuffix splits a value like "10mbit" into its number and suffix.
+func splitSuffix(s string) (float64, string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || v < 0 {
+		return 0, "", fmt.Errorf("invalid number '%s'", s)
+	}
+
+	return v, s[i:], nil
+}
+
+// parseRate takes a rate in bits per second as tc or networkd write it:
+// "10mbit", "10M", "1gbit" or, in bytes per second, "125kbps".
+func parseRate(field string, s string) (uint64, error) {
+	v, suffix, err := splitSuffix(s)
+	if err != nil {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	bytes := false
+	if strings.HasSuffix(suffix, "bps") {
+		bytes = true
+		suffix = strings.TrimSuffix(suffix, "bps")
+	} else {
+		suffix = strings.TrimSuffix(suffix, "bit")
+	}
+
+	switch suffix {
+	case "":
+	case "k":
+		v *= 1000
+	case "m":
+		v *= 1000 * 1000
+	case "g":
+		v *= 1000 * 1000 * 1000
+	case "t":
+		v *= 1000 * 1000 * 1000 * 1000
+	default:
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	if bytes {
+		v *= 8
+	}
+
+	return uint64(v), nil
+}
+
+// parseSize takes a size in bytes: "1500", "32k", "32kb" or "1m".
+func parseSize(field string, s string) (uint32, error) {
+	v, suffix, err := splitSuffix(s)
+	if err != nil {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	switch strings.TrimSuffix(suffix, "b") {
+	case "":
+	case "k":
+		v *= 1024
+	case "m":
+		v *= 1024 * 1024
+	case "g":
+		v *= 1024 * 1024 * 1024
+	default:
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	if v > float64(^uint32(0)) {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	return uint32(v), nil
+}
+
+// parseTime takes a time as "100us", "5ms" or "1s" and returns microseconds.
+// A bare number is in microseconds, as with tc.
+func parseTime(field string, s string) (uint32, error) {
+	v, suffix, err := splitSuffix(s)
+	if err != nil {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	switch suffix {
+	case "", "us", "usec":
+	case "ms", "msec":
+		v *= 1000
+	case "s", "sec":
+		v *= 1000 * 1000
+	default:
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	if v > float64(^uint32(0)) {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	return uint32(v), nil
+}
+
+// parsePercent takes a percentage with or without the % sign.
+func parsePercent(field string, s string) (float32, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 32)
+	if err != nil || v < 0 || v > 100 {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	return float32(v), nil
+}
+
+func parseUint32(field string, s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	return uint32(v), nil
+}
+
+// parseHandle takes a handle or class id as "1:", "1:10" or "1", in hex.
+func parseHandle(field string, s string) (uint32, error) {
+	major, minor, found := strings.Cut(s, ":")
+
+	ma, err := strconv.ParseUint(major, 16, 16)
+	if err != nil {
+		return 0, web.InvalidArgument(field, s)
+	}
+
+	var mi uint64
+	if found && minor != "" {
+		if mi, err = strconv.ParseUint(minor, 16, 16); err != nil {
+			return 0, web.InvalidArgument(field, s)
+		}
+	}
+
+	return netlink.MakeHandle(uint16(ma), uint16(mi)), nil
+}
+
+// parseParent takes the parent of a qdisc or class: "root" or a handle.
+func parseParent(s string) (uint32, error) {
+	switch strings.ToLower(s) {
+	case "", "root":
+		return netlink.HANDLE_ROOT, nil
+	case "ingress", "clsact":
+		return netlink.HANDLE_INGRESS, nil
+	}
+
+	return parseHandle("Parent", s)
+}
+
+// parseFilterParent takes the parent of a filter. "ingress" and "egress"
+// are the hooks of the ingress and clsact qdiscs.
+func parseFilterParent(s string) (uint32, error) {
+	switch strings.ToLower(s) {
+	case "", "root":
+		return netlink.HANDLE_ROOT, nil
+	case "ingress":
+		return netlink.HANDLE_MIN_INGRESS, nil
+	case "egress":
+		return netlink.HANDLE_MIN_EGRESS, nil
+	}
+
+	return parseHandle("Parent", s)
+}
+
+func handleString(h uint32) string {
+	switch h {
+	case netlink.HANDLE_MIN_INGRESS:
+		return "ingress"
+	case netlink.HANDLE_MIN_EGRESS:
+		return "egress"
+	}
+
+	return netlink.HandleStr(h)
+}
+
+var protocols = map[string]uint16{
+	"all":  unix.ETH_P_ALL,
+	"ip":   unix.ETH_P_IP,
+	"ipv6": unix.ETH_P_IPV6,
+	"arp":  unix.ETH_P_ARP,
+}
+
+func parseProtocol(s string) (uint16, error) {
+	if p, ok := protocols[strings.ToLower(s)]; ok {
+		return p, nil
+	}
+
+	return 0, web.InvalidArgument("Protocol", s)
+}
+
+func protocolString(p uint16) string {
+	for name, v := range protocols {
+		if v == p {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("0x%04x", p)
+}
+
+func formatRate(bits uint64) string {
+	switch {
+	case bits >= 1000*1000*1000 && bits%(1000*1000*1000) == 0:
+		return fmt.Sprintf("%dGbit", bits/(1000*1000*1000))
+	case bits >= 1000*1000 && bits%(1000*1000) == 0:
+		return fmt.Sprintf("%dMbit", bits/(1000*1000))
+	case bits >= 1000 && bits%1000 == 0:
+		return fmt.Sprintf("%dKbit", bits/1000)
+	}
+
+	return fmt.Sprintf("%dbit", bits)
+}
+
+func formatTime(us uint32) string {
+	switch {
+	case us >= 1000*1000 && us%(1000*1000) == 0:
+		return fmt.Sprintf("%ds", us/(1000*1000))
+	case us >= 1000 && us%1000 == 0:
+		return fmt.Sprintf("%dms", us/1000)
+	}
+
+	return fmt.Sprintf("%dus", us)
+}
+
+func formatPercent(v uint32) string {
+	p := math.Round(float64(v)/float64(^uint32(0))*100*100) / 100
+	return strconv.FormatFloat(p, 'f', -1, 64) + "%"
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -34,7 +35,7 @@ func routerDescribeNTPServers(w http.ResponseWriter, r *http.Request) {
 func routerAddNTP(w http.ResponseWriter, r *http.Request) {
 	d, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -46,7 +47,7 @@ func routerAddNTP(w http.ResponseWriter, r *http.Request) {
 func routerRemoveNTP(w http.ResponseWriter, r *http.Request) {
 	d, err := decodeJSONRequest(r)
 	if err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -58,9 +59,9 @@ func routerRemoveNTP(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterTimeSyncd(router *mux.Router) {
 	n := router.PathPrefix("/timesyncd").Subrouter().StrictSlash(false)
 
-	n.HandleFunc("/describe", routerDescribeNTPServers).Methods("GET")
-	n.HandleFunc("/{ntpserver}", routerAcquireNTPServers).Methods("GET")
+	openapi.Describe(n.HandleFunc("/describe", routerDescribeNTPServers).Methods("GET"), "Describe the NTP servers", nil, Describe{})
+	openapi.Describe(n.HandleFunc("/{ntpserver}", routerAcquireNTPServers).Methods("GET"), "Show NTP servers", nil, nil)
 
-	n.HandleFunc("/add", routerAddNTP).Methods("POST")
-	n.HandleFunc("/remove", routerRemoveNTP).Methods("DELETE")
+	openapi.Describe(n.HandleFunc("/add", routerAddNTP).Methods("POST"), "Add NTP servers", NTP{}, nil)
+	openapi.Describe(n.HandleFunc("/remove", routerRemoveNTP).Methods("DELETE"), "Remove NTP servers", NTP{}, nil)
 }
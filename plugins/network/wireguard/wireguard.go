@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package wireguard
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	"github.com/vmware/pmd-next-gen/pkg/web"
+	"github.com/vmware/pmd-next-gen/plugins/network/netlink/link"
+)
+
+type PeerInfo struct {
+	PublicKey              string     `json:"PublicKey"`
+	PresharedKey           bool       `json:"PresharedKey"`
+	Endpoint               string     `json:"Endpoint"`
+	AllowedIPs             []string   `json:"AllowedIPs"`
+	PersistentKeepaliveSec uint16     `json:"PersistentKeepaliveSec"`
+	LatestHandshake        *time.Time `json:"LatestHandshake,omitempty"`
+	ReceiveBytes           uint64     `json:"ReceiveBytes"`
+	TransmitBytes          uint64     `json:"TransmitBytes"`
+}
+
+type DeviceInfo struct {
+	Name         string     `json:"Name"`
+	Index        int        `json:"Index"`
+	PublicKey    string     `json:"PublicKey"`
+	ListenPort   uint16     `json:"ListenPort"`
+	FirewallMark uint32     `json:"FirewallMark"`
+	Peers        []PeerInfo `json:"Peers"`
+}
+
+func decodeJSONRequest(r *http.Request, v interface{}) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// short tells whether an attribute holds less than the n bytes of its type.
+// Such an attribute is skipped instead of read past.
+func short(a syscall.NetlinkRouteAttr, n int) bool {
+	return len(a.Value) < n
+}
+
+func parseEndpoint(b []byte) string {
+	if len(b) < 4 {
+		return ""
+	}
+
+	port := strconv.Itoa(int(binary.BigEndian.Uint16(b[2:4])))
+	switch nl.NativeEndian().Uint16(b[0:2]) {
+	case unix.AF_INET:
+		if len(b) >= 8 {
+			return net.JoinHostPort(net.IP(b[4:8]).String(), port)
+		}
+	case unix.AF_INET6:
+		if len(b) >= 24 {
+			return net.JoinHostPort(net.IP(b[8:24]).String(), port)
+		}
+	}
+
+	return ""
+}
+
+func parseAllowedIPs(b []byte) ([]string, error) {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []string{}
+	for _, a := range attrs {
+		ipAttrs, err := nl.ParseRouteAttr(a.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		var ip net.IP
+		mask := 0
+		for _, i := range ipAttrs {
+			switch i.Attr.Type &^ unix.NLA_F_NESTED {
+			case unix.WGALLOWEDIP_A_IPADDR:
+				ip = net.IP(i.Value)
+			case unix.WGALLOWEDIP_A_CIDR_MASK:
+				if !short(i, 1) {
+					mask = int(i.Value[0])
+				}
+			}
+		}
+		if len(ip) != net.IPv4len && len(ip) != net.IPv6len {
+			continue
+		}
+
+		ipNet := net.IPNet{IP: ip, Mask: net.CIDRMask(mask, len(ip)*8)}
+		ips = append(ips, ipNet.String())
+	}
+
+	return ips, nil
+}
+
+func parsePeer(b []byte) (*PeerInfo, error) {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return nil, err
+	}
+
+	p := PeerInfo{
+		AllowedIPs: []string{},
+	}
+	for _, a := range attrs {
+		switch a.Attr.Type &^ unix.NLA_F_NESTED {
+		case unix.WGPEER_A_PUBLIC_KEY:
+			p.PublicKey = base64.StdEncoding.EncodeToString(a.Value)
+		case unix.WGPEER_A_PRESHARED_KEY:
+			// Only tell whether there is one, the key is a secret.
+			for _, c := range a.Value {
+				if c != 0 {
+					p.PresharedKey = true
+					break
+				}
+			}
+		case unix.WGPEER_A_ENDPOINT:
+			p.Endpoint = parseEndpoint(a.Value)
+		case unix.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL:
+			if short(a, 2) {
+				continue
+			}
+			p.PersistentKeepaliveSec = nl.NativeEndian().Uint16(a.Value[0:2])
+		case unix.WGPEER_A_LAST_HANDSHAKE_TIME:
+			if short(a, 16) {
+				continue
+			}
+			sec := int64(nl.NativeEndian().Uint64(a.Value[0:8]))
+			nsec := int64(nl.NativeEndian().Uint64(a.Value[8:16]))
+			if sec != 0 || nsec != 0 {
+				t := time.Unix(sec, nsec)
+				p.LatestHandshake = &t
+			}
+		case unix.WGPEER_A_RX_BYTES:
+			if short(a, 8) {
+				continue
+			}
+			p.ReceiveBytes = nl.NativeEndian().Uint64(a.Value[0:8])
+		case unix.WGPEER_A_TX_BYTES:
+			if short(a, 8) {
+				continue
+			}
+			p.TransmitBytes = nl.NativeEndian().Uint64(a.Value[0:8])
+		case unix.WGPEER_A_ALLOWEDIPS:
+			if p.AllowedIPs, err = parseAllowedIPs(a.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &p, nil
+}
+
+// parseDevice adds a message of the dump to the device. A device with many
+// peers or allowed IPs takes several messages, where a peer may go on in
+// the next one under the same public key.
+func parseDevice(d *DeviceInfo, b []byte) error {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range attrs {
+		switch a.Attr.Type &^ unix.NLA_F_NESTED {
+		case unix.WGDEVICE_A_IFINDEX:
+			if short(a, 4) {
+				continue
+			}
+			d.Index = int(nl.NativeEndian().Uint32(a.Value[0:4]))
+		case unix.WGDEVICE_A_IFNAME:
+			d.Name = strings.TrimRight(string(a.Value), "\x00")
+		case unix.WGDEVICE_A_PUBLIC_KEY:
+			d.PublicKey = base64.StdEncoding.EncodeToString(a.Value)
+		case unix.WGDEVICE_A_LISTEN_PORT:
+			if short(a, 2) {
+				continue
+			}
+			d.ListenPort = nl.NativeEndian().Uint16(a.Value[0:2])
+		case unix.WGDEVICE_A_FWMARK:
+			if short(a, 4) {
+				continue
+			}
+			d.FirewallMark = nl.NativeEndian().Uint32(a.Value[0:4])
+		case unix.WGDEVICE_A_PEERS:
+			peers, err := nl.ParseRouteAttr(a.Value)
+			if err != nil {
+				return err
+			}
+
+			for _, pa := range peers {
+				p, err := parsePeer(pa.Value)
+				if err != nil {
+					return err
+				}
+
+				if n := len(d.Peers); n > 0 && d.Peers[n-1].PublicKey == p.PublicKey {
+					d.Peers[n-1].AllowedIPs = append(d.Peers[n-1].AllowedIPs, p.AllowedIPs...)
+					continue
+				}
+				d.Peers = append(d.Peers, *p)
+			}
+		}
+	}
+
+	return nil
+}
+
+// AcquireDevice reads the live state of a WireGuard device from the kernel
+// over generic netlink. The private key is left out. wgctrl is not among the
+// vendored modules, so the WG_CMD_GET_DEVICE dump is decoded with the netlink
+// package the other plugins use, the same way wgctrl decodes it.
+func AcquireDevice(name string) (*DeviceInfo, error) {
+	l, err := link.AcquireLinkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if l.Type() != "wireguard" {
+		return nil, web.NewError(web.ErrInvalidArgument, "link='%s' is not a wireguard device", name).WithField("Name", name, "not a wireguard device")
+	}
+
+	f, err := netlink.GenlFamilyGet(unix.WG_GENL_NAME)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) {
+			return nil, web.NewError(web.ErrBackendUnavailable, "wireguard generic netlink family not available")
+		}
+		return nil, err
+	}
+
+	req := nl.NewNetlinkRequest(int(f.ID), unix.NLM_F_DUMP)
+	req.AddData(&nl.Genlmsg{
+		Command: unix.WG_CMD_GET_DEVICE,
+		Version: unix.WG_GENL_VERSION,
+	})
+	req.AddData(nl.NewRtAttr(unix.WGDEVICE_A_IFNAME, nl.ZeroTerminated(name)))
+
+	msgs, err := req.Execute(unix.NETLINK_GENERIC, 0)
+	if err != nil {
+		log.Errorf("Failed to acquire wireguard device='%s': %v", name, err)
+		return nil, err
+	}
+
+	d := DeviceInfo{
+		Peers: []PeerInfo{},
+	}
+	for _, m := range msgs {
+		if len(m) < nl.SizeofGenlmsg {
+			continue
+		}
+		if err := parseDevice(&d, m[nl.SizeofGenlmsg:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &d, nil
+}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package wireguard
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"os/user"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/system"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+// Key asks for a new key pair of the WireGuard device Name.
+type Key struct {
+	Name string `json:"Name"`
+}
+
+// KeyInfo tells where the private key went, to be given as PrivateKeyFile
+// of the .netdev, and the public key to hand to the peers. The private key
+// itself never leaves the host.
+type KeyInfo struct {
+	Name           string `json:"Name"`
+	PrivateKeyFile string `json:"PrivateKeyFile"`
+	PublicKey      string `json:"PublicKey"`
+}
+
+func keyFilePath(name string) string {
+	return path.Join("/etc/systemd/network", "10-"+name+"-wireguard.key")
+}
+
+// generatePrivateKey returns a Curve25519 private key clamped as wg genkey
+// does.
+func generatePrivateKey() (*ecdh.PrivateKey, error) {
+	k := make([]byte, 32)
+	if _, err := rand.Read(k); err != nil {
+		return nil, err
+	}
+
+	k[0] &= 248
+	k[31] = (k[31] & 127) | 64
+
+	return ecdh.X25519().NewPrivateKey(k)
+}
+
+// keyFileGroup is the group systemd-networkd reads the keys as.
+var keyFileGroup = "systemd-network"
+
+// writeKeyFile writes the key readable by the daemon and keyFileGroup only.
+// The daemon runs without CAP_CHOWN, so the file keeps its user as owner and
+// is handed to the group, of which the daemon is a member. Without the group
+// the key could not be used and no file is written.
+func writeKeyFile(file string, key string) error {
+	g, err := user.LookupGroup(keyFileGroup)
+	if err != nil {
+		return web.NewError(web.ErrBackendUnavailable, "group %s not found, systemd-networkd could not read the key: %v", keyFileGroup, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	// Hand the empty file to the group before the key is written.
+	if err := f.Chown(-1, gid); err != nil {
+		f.Close()
+		os.Remove(file)
+		return web.NewError(web.ErrPermissionDenied, "failed to give the key to group %s, the daemon must be a member of it: %v", keyFileGroup, err)
+	}
+	if err := f.Chmod(0640); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(file)
+		return err
+	}
+
+	return nil
+}
+
+// Generate creates the key pair. An existing key is kept, as replacing it
+// would break the tunnels of the device with its peers.
+func (k *Key) Generate() (*KeyInfo, error) {
+	if k.Name == "" || strings.ContainsAny(k.Name, "/") || k.Name == "." || k.Name == ".." {
+		return nil, web.InvalidArgument("Name", k.Name)
+	}
+
+	file := keyFilePath(k.Name)
+	if system.PathExists(file) {
+		return nil, web.NewError(web.ErrConflict, "private key of wireguard='%s' exists", k.Name).WithField("Name", k.Name, "key exists")
+	}
+
+	priv, err := generatePrivateKey()
+	if err != nil {
+		log.Errorf("Failed to generate wireguard key name='%s': %v", k.Name, err)
+		return nil, err
+	}
+
+	if err := writeKeyFile(file, base64.StdEncoding.EncodeToString(priv.Bytes())); err != nil {
+		log.Errorf("Failed to write wireguard key file='%s': %v", file, err)
+		return nil, err
+	}
+
+	return &KeyInfo{
+		Name:           k.Name,
+		PrivateKeyFile: file,
+		PublicKey:      base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()),
+	}, nil
+}
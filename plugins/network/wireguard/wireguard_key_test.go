@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package wireguard
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+const (
+	keyTestFileEnv  = "WIREGUARD_KEY_TEST_FILE"
+	keyTestGroupEnv = "WIREGUARD_KEY_TEST_GROUP"
+
+	nobody = 65534
+)
+
+func TestMain(m *testing.M) {
+	// writeKeyFile runs in a copy of the test binary started as another user.
+	if file := os.Getenv(keyTestFileEnv); file != "" {
+		keyFileGroup = os.Getenv(keyTestGroupEnv)
+		if err := writeKeyFile(file, "key"); err != nil {
+			os.Stderr.WriteString(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// writeKeyFileAs runs writeKeyFile from exe as nobody with the supplementary
+// groups given and returns its error output.
+func writeKeyFileAs(t *testing.T, exe string, file string, group string, groups []uint32) (string, error) {
+	c := exec.Command(exe)
+	c.Env = append(os.Environ(), keyTestFileEnv+"="+file, keyTestGroupEnv+"="+group)
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Credential: &syscall.Credential{Uid: nobody, Gid: nobody, Groups: groups},
+	}
+
+	out, err := c.CombinedOutput()
+	return string(out), err
+}
+
+// copyTestBinary copies the test binary to dir, where nobody may run it.
+func copyTestBinary(t *testing.T, dir string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to find test binary: %v", err)
+	}
+
+	b, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatalf("Failed to read test binary: %v", err)
+	}
+
+	file := filepath.Join(dir, "wireguard.test")
+	if err := os.WriteFile(file, b, 0755); err != nil {
+		t.Fatalf("Failed to copy test binary: %v", err)
+	}
+
+	return file
+}
+
+func TestWriteKeyFileUnprivileged(t *testing.T) {
+	dir, err := os.MkdirTemp("", "wireguard-key")
+	if err != nil {
+		t.Fatalf("Failed to create test dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "10-wg0-wireguard.key")
+
+	group := "users"
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		t.Skipf("Group %s not found: %v", group, err)
+	}
+	gid, _ := strconv.Atoi(g.Gid)
+
+	if os.Geteuid() == 0 {
+		if err := os.Chmod(dir, 0777); err != nil {
+			t.Fatalf("Failed to open up test dir: %v", err)
+		}
+
+		exe := copyTestBinary(t, dir)
+
+		out, err := writeKeyFileAs(t, exe, file, group, nil)
+		if err == nil || !strings.Contains(out, "must be a member") {
+			t.Fatalf("Expected writing the key outside group %s to fail, got: %v %s", group, err, out)
+		}
+		if _, err := os.Stat(file); !os.IsNotExist(err) {
+			t.Fatalf("Key file left after failure")
+		}
+
+		if out, err := writeKeyFileAs(t, exe, file, group, []uint32{uint32(gid)}); err != nil {
+			t.Fatalf("Failed to write key as nobody in group %s: %v %s", group, err, out)
+		}
+	} else {
+		// Already unprivileged: hand the key to the primary group.
+		g, err := user.LookupGroupId(strconv.Itoa(os.Getgid()))
+		if err != nil {
+			t.Skipf("Primary group not found: %v", err)
+		}
+		group, gid = g.Name, os.Getgid()
+
+		keyFileGroup = group
+		defer func() { keyFileGroup = "systemd-network" }()
+
+		if err := writeKeyFile(file, "key"); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+	}
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Failed to stat key file: %v", err)
+	}
+	st := fi.Sys().(*syscall.Stat_t)
+	if int(st.Gid) != gid || fi.Mode().Perm() != 0640 {
+		t.Fatalf("Invalid key file group=%d mode=%v, expected group=%d mode=0640", st.Gid, fi.Mode().Perm(), gid)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil || string(b) != "key\n" {
+		t.Fatalf("Invalid key file content='%s': %v", b, err)
+	}
+}
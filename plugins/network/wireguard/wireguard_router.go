@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package wireguard
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
+	"github.com/vmware/pmd-next-gen/pkg/web"
+)
+
+func routerGenerateKey(w http.ResponseWriter, r *http.Request) {
+	k := Key{}
+	if err := decodeJSONRequest(r, &k); err != nil {
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
+	}
+
+	info, err := k.Generate()
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(info, w)
+}
+
+func routerAcquireDevice(w http.ResponseWriter, r *http.Request) {
+	d, err := AcquireDevice(mux.Vars(r)["dev"])
+	if err != nil {
+		web.JSONResponseError(err, w)
+		return
+	}
+
+	web.JSONResponse(d, w)
+}
+
+func RegisterRouterWireGuard(router *mux.Router) {
+	s := router.PathPrefix("/wireguard").Subrouter().StrictSlash(false)
+
+	openapi.Describe(s.HandleFunc("/key", routerGenerateKey).Methods("POST"), "Generate the key pair of a WireGuard device", Key{}, KeyInfo{})
+	openapi.Describe(s.HandleFunc("/{dev}", routerAcquireDevice).Methods("GET"), "Describe a WireGuard device and its peers", nil, DeviceInfo{})
+}
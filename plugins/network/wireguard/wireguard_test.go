@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package wireguard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func key(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func uint16Attr(t int, v uint16) *nl.RtAttr {
+	b := make([]byte, 2)
+	nl.NativeEndian().PutUint16(b, v)
+	return nl.NewRtAttr(t, b)
+}
+
+func uint32Attr(t int, v uint32) *nl.RtAttr {
+	b := make([]byte, 4)
+	nl.NativeEndian().PutUint32(b, v)
+	return nl.NewRtAttr(t, b)
+}
+
+func uint64Attr(t int, v uint64) *nl.RtAttr {
+	b := make([]byte, 8)
+	nl.NativeEndian().PutUint64(b, v)
+	return nl.NewRtAttr(t, b)
+}
+
+func sockaddr(ip net.IP, port uint16) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		b := make([]byte, 16)
+		nl.NativeEndian().PutUint16(b[0:2], unix.AF_INET)
+		binary.BigEndian.PutUint16(b[2:4], port)
+		copy(b[4:8], ip4)
+		return b
+	}
+
+	b := make([]byte, 28)
+	nl.NativeEndian().PutUint16(b[0:2], unix.AF_INET6)
+	binary.BigEndian.PutUint16(b[2:4], port)
+	copy(b[8:24], ip)
+	return b
+}
+
+func allowedIPs(prefixes ...string) *nl.RtAttr {
+	a := nl.NewRtAttr(unix.WGPEER_A_ALLOWEDIPS|unix.NLA_F_NESTED, nil)
+	for _, p := range prefixes {
+		ip, ipNet, _ := net.ParseCIDR(p)
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		ones, _ := ipNet.Mask.Size()
+
+		e := a.AddRtAttr(unix.NLA_F_NESTED, nil)
+		e.AddRtAttr(unix.WGALLOWEDIP_A_FAMILY, nl.Uint16Attr(uint16(len(ip))))
+		e.AddRtAttr(unix.WGALLOWEDIP_A_IPADDR, ip)
+		e.AddRtAttr(unix.WGALLOWEDIP_A_CIDR_MASK, []byte{byte(ones)})
+	}
+
+	return a
+}
+
+func peers(p ...*nl.RtAttr) *nl.RtAttr {
+	a := nl.NewRtAttr(unix.WGDEVICE_A_PEERS|unix.NLA_F_NESTED, nil)
+	for _, e := range p {
+		a.AddChild(e)
+	}
+
+	return a
+}
+
+func peer(attrs ...*nl.RtAttr) *nl.RtAttr {
+	a := nl.NewRtAttr(unix.NLA_F_NESTED, nil)
+	for _, e := range attrs {
+		a.AddChild(e)
+	}
+
+	return a
+}
+
+func message(attrs ...*nl.RtAttr) []byte {
+	var b []byte
+	for _, a := range attrs {
+		b = append(b, a.Serialize()...)
+	}
+
+	return b
+}
+
+func TestParseDeviceAcrossMessages(t *testing.T) {
+	handshake := make([]byte, 16)
+	nl.NativeEndian().PutUint64(handshake[0:8], 1700000000)
+
+	msgs := [][]byte{
+		message(
+			uint32Attr(unix.WGDEVICE_A_IFINDEX, 7),
+			nl.NewRtAttr(unix.WGDEVICE_A_IFNAME, nl.ZeroTerminated("wg0")),
+			nl.NewRtAttr(unix.WGDEVICE_A_PRIVATE_KEY, key(9)),
+			nl.NewRtAttr(unix.WGDEVICE_A_PUBLIC_KEY, key(1)),
+			uint16Attr(unix.WGDEVICE_A_LISTEN_PORT, 51820),
+			uint32Attr(unix.WGDEVICE_A_FWMARK, 42),
+			peers(peer(
+				nl.NewRtAttr(unix.WGPEER_A_PUBLIC_KEY, key(2)),
+				nl.NewRtAttr(unix.WGPEER_A_PRESHARED_KEY, key(3)),
+				nl.NewRtAttr(unix.WGPEER_A_ENDPOINT, sockaddr(net.ParseIP("192.168.1.10"), 51820)),
+				uint16Attr(unix.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL, 25),
+				nl.NewRtAttr(unix.WGPEER_A_LAST_HANDSHAKE_TIME, handshake),
+				uint64Attr(unix.WGPEER_A_RX_BYTES, 100),
+				uint64Attr(unix.WGPEER_A_TX_BYTES, 200),
+				allowedIPs("10.0.0.2/32"),
+			)),
+		),
+		// The kernel goes on with the allowed IPs of the same peer in the
+		// next message, under its public key only.
+		message(
+			nl.NewRtAttr(unix.WGDEVICE_A_IFNAME, nl.ZeroTerminated("wg0")),
+			peers(
+				peer(
+					nl.NewRtAttr(unix.WGPEER_A_PUBLIC_KEY, key(2)),
+					allowedIPs("10.0.1.0/24"),
+				),
+				peer(
+					nl.NewRtAttr(unix.WGPEER_A_PUBLIC_KEY, key(4)),
+					nl.NewRtAttr(unix.WGPEER_A_PRESHARED_KEY, make([]byte, 32)),
+					nl.NewRtAttr(unix.WGPEER_A_ENDPOINT, sockaddr(net.ParseIP("fd00::1"), 51821)),
+					allowedIPs("fd00:1::/64"),
+				),
+			),
+		),
+	}
+
+	d := DeviceInfo{
+		Peers: []PeerInfo{},
+	}
+	for _, m := range msgs {
+		if err := parseDevice(&d, m); err != nil {
+			t.Fatalf("Failed to parse message: %v", err)
+		}
+	}
+
+	if d.Name != "wg0" || d.Index != 7 || d.ListenPort != 51820 || d.FirewallMark != 42 {
+		t.Fatalf("Invalid device: %+v", d)
+	}
+	if d.PublicKey != base64.StdEncoding.EncodeToString(key(1)) {
+		t.Fatalf("Invalid public key='%s'", d.PublicKey)
+	}
+	if len(d.Peers) != 2 {
+		t.Fatalf("Expected 2 peers, got %d: %+v", len(d.Peers), d.Peers)
+	}
+
+	a := d.Peers[0]
+	if a.PublicKey != base64.StdEncoding.EncodeToString(key(2)) || !a.PresharedKey || a.Endpoint != "192.168.1.10:51820" ||
+		a.PersistentKeepaliveSec != 25 || a.ReceiveBytes != 100 || a.TransmitBytes != 200 ||
+		a.LatestHandshake == nil || a.LatestHandshake.Unix() != 1700000000 {
+		t.Fatalf("Invalid first peer: %+v", a)
+	}
+	if len(a.AllowedIPs) != 2 || a.AllowedIPs[0] != "10.0.0.2/32" || a.AllowedIPs[1] != "10.0.1.0/24" {
+		t.Fatalf("Allowed IPs of the first peer not merged: %v", a.AllowedIPs)
+	}
+
+	b := d.Peers[1]
+	if b.PresharedKey || b.Endpoint != "[fd00::1]:51821" || b.LatestHandshake != nil {
+		t.Fatalf("Invalid second peer: %+v", b)
+	}
+	if len(b.AllowedIPs) != 1 || b.AllowedIPs[0] != "fd00:1::/64" {
+		t.Fatalf("Invalid allowed IPs of the second peer: %v", b.AllowedIPs)
+	}
+}
+
+func TestParseDeviceShortAttributes(t *testing.T) {
+	m := message(
+		nl.NewRtAttr(unix.WGDEVICE_A_IFINDEX, []byte{1}),
+		nl.NewRtAttr(unix.WGDEVICE_A_LISTEN_PORT, []byte{1}),
+		nl.NewRtAttr(unix.WGDEVICE_A_FWMARK, []byte{1, 2}),
+		peers(peer(
+			nl.NewRtAttr(unix.WGPEER_A_PUBLIC_KEY, key(2)),
+			nl.NewRtAttr(unix.WGPEER_A_ENDPOINT, []byte{1, 2}),
+			nl.NewRtAttr(unix.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL, []byte{1}),
+			nl.NewRtAttr(unix.WGPEER_A_LAST_HANDSHAKE_TIME, []byte{1, 2, 3, 4}),
+			nl.NewRtAttr(unix.WGPEER_A_RX_BYTES, []byte{1}),
+			nl.NewRtAttr(unix.WGPEER_A_TX_BYTES, []byte{1}),
+		)),
+	)
+
+	d := DeviceInfo{
+		Peers: []PeerInfo{},
+	}
+	if err := parseDevice(&d, m); err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+
+	if d.Index != 0 || d.ListenPort != 0 || d.FirewallMark != 0 || len(d.Peers) != 1 {
+		t.Fatalf("Short attributes not skipped: %+v", d)
+	}
+	if p := d.Peers[0]; p.Endpoint != "" || p.PersistentKeepaliveSec != 0 || p.LatestHandshake != nil || p.ReceiveBytes != 0 {
+		t.Fatalf("Short peer attributes not skipped: %+v", p)
+	}
+}
@@ -0,0 +1,239 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package proc
+
+import (
+	"context"
+	"sort"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/load"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+
+	"github.com/vmware/pmd-next-gen/pkg/metrics"
+)
+
+func collectCPU(ctx context.Context, m *metrics.Writer) error {
+	times, err := cpu.TimesWithContext(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	name := metrics.Name("cpu_seconds_total")
+	m.Family(name, "Seconds the CPUs spent in each mode.", metrics.Counter)
+	for _, t := range times {
+		for _, s := range []struct {
+			mode  string
+			value float64
+		}{
+			{"user", t.User},
+			{"nice", t.Nice},
+			{"system", t.System},
+			{"idle", t.Idle},
+			{"iowait", t.Iowait},
+			{"irq", t.Irq},
+			{"softirq", t.Softirq},
+			{"steal", t.Steal},
+		} {
+			m.Sample(name, s.value, "cpu", t.CPU, "mode", s.mode)
+		}
+	}
+
+	avg, err := load.AvgWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range []struct {
+		name  string
+		value float64
+	}{
+		{"load1", avg.Load1},
+		{"load5", avg.Load5},
+		{"load15", avg.Load15},
+	} {
+		name := metrics.Name(l.name)
+		m.Family(name, "Load average.", metrics.Gauge)
+		m.Sample(name, l.value)
+	}
+
+	return nil
+}
+
+func collectMemory(ctx context.Context, m *metrics.Writer) error {
+	v, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	s, err := mem.SwapMemoryWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, g := range []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{"memory_total_bytes", "Total memory in bytes.", v.Total},
+		{"memory_available_bytes", "Memory available for new processes in bytes.", v.Available},
+		{"memory_used_bytes", "Used memory in bytes.", v.Used},
+		{"memory_free_bytes", "Free memory in bytes.", v.Free},
+		{"memory_buffers_bytes", "Memory used by kernel buffers in bytes.", v.Buffers},
+		{"memory_cached_bytes", "Memory used by the page cache in bytes.", v.Cached},
+		{"memory_swap_total_bytes", "Total swap space in bytes.", s.Total},
+		{"memory_swap_free_bytes", "Free swap space in bytes.", s.Free},
+	} {
+		name := metrics.Name(g.name)
+		m.Family(name, g.help, metrics.Gauge)
+		m.Sample(name, float64(g.value))
+	}
+
+	return nil
+}
+
+func collectDisk(ctx context.Context, m *metrics.Writer) error {
+	io, err := disk.IOCountersWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	devices := make([]string, 0, len(io))
+	for d := range io {
+		devices = append(devices, d)
+	}
+	sort.Strings(devices)
+
+	for _, c := range []struct {
+		name  string
+		help  string
+		value func(disk.IOCountersStat) float64
+	}{
+		{"disk_reads_completed_total", "Number of reads completed.", func(s disk.IOCountersStat) float64 { return float64(s.ReadCount) }},
+		{"disk_writes_completed_total", "Number of writes completed.", func(s disk.IOCountersStat) float64 { return float64(s.WriteCount) }},
+		{"disk_read_bytes_total", "Number of bytes read.", func(s disk.IOCountersStat) float64 { return float64(s.ReadBytes) }},
+		{"disk_written_bytes_total", "Number of bytes written.", func(s disk.IOCountersStat) float64 { return float64(s.WriteBytes) }},
+		{"disk_io_time_seconds_total", "Seconds spent doing I/O.", func(s disk.IOCountersStat) float64 { return float64(s.IoTime) / 1000 }},
+	} {
+		name := metrics.Name(c.name)
+		m.Family(name, c.help, metrics.Counter)
+		for _, d := range devices {
+			m.Sample(name, c.value(io[d]), "device", d)
+		}
+	}
+
+	partitions, err := disk.PartitionsWithContext(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	var usage []*disk.UsageStat
+	for _, p := range partitions {
+		if u, err := disk.UsageWithContext(ctx, p.Mountpoint); err == nil {
+			u.Fstype = p.Fstype
+			usage = append(usage, u)
+		}
+	}
+
+	for _, g := range []struct {
+		name  string
+		help  string
+		value func(*disk.UsageStat) float64
+	}{
+		{"filesystem_size_bytes", "Filesystem size in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Total) }},
+		{"filesystem_free_bytes", "Filesystem free space in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Free) }},
+		{"filesystem_used_bytes", "Filesystem used space in bytes.", func(u *disk.UsageStat) float64 { return float64(u.Used) }},
+	} {
+		name := metrics.Name(g.name)
+		m.Family(name, g.help, metrics.Gauge)
+		for _, u := range usage {
+			m.Sample(name, g.value(u), "mountpoint", u.Path, "fstype", u.Fstype)
+		}
+	}
+
+	return nil
+}
+
+func collectNetDev(ctx context.Context, m *metrics.Writer) error {
+	counters, err := net.IOCountersWithContext(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []struct {
+		name  string
+		help  string
+		value func(net.IOCountersStat) uint64
+	}{
+		{"network_receive_bytes_total", "Number of bytes received.", func(s net.IOCountersStat) uint64 { return s.BytesRecv }},
+		{"network_transmit_bytes_total", "Number of bytes transmitted.", func(s net.IOCountersStat) uint64 { return s.BytesSent }},
+		{"network_receive_packets_total", "Number of packets received.", func(s net.IOCountersStat) uint64 { return s.PacketsRecv }},
+		{"network_transmit_packets_total", "Number of packets transmitted.", func(s net.IOCountersStat) uint64 { return s.PacketsSent }},
+		{"network_receive_errs_total", "Number of receive errors.", func(s net.IOCountersStat) uint64 { return s.Errin }},
+		{"network_transmit_errs_total", "Number of transmit errors.", func(s net.IOCountersStat) uint64 { return s.Errout }},
+		{"network_receive_drop_total", "Number of received packets dropped.", func(s net.IOCountersStat) uint64 { return s.Dropin }},
+		{"network_transmit_drop_total", "Number of transmitted packets dropped.", func(s net.IOCountersStat) uint64 { return s.Dropout }},
+	} {
+		name := metrics.Name(c.name)
+		m.Family(name, c.help, metrics.Counter)
+		for _, s := range counters {
+			m.Sample(name, float64(c.value(s)), "device", s.Name)
+		}
+	}
+
+	return nil
+}
+
+func collectProtocol(ctx context.Context, m *metrics.Writer) error {
+	protocols := []string{"ip", "icmp", "icmpmsg", "tcp", "udp", "udplite"}
+
+	proto, err := net.ProtoCountersWithContext(ctx, protocols)
+	if err != nil {
+		return err
+	}
+
+	name := metrics.Name("netstat")
+	m.Family(name, "Protocol counters from /proc/net/snmp.", metrics.Untyped)
+	for _, p := range proto {
+		keys := make([]string, 0, len(p.Stats))
+		for k := range p.Stats {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			m.Sample(name, float64(p.Stats[k]), "protocol", p.Protocol, "counter", k)
+		}
+	}
+
+	return nil
+}
+
+func collectTemperature(ctx context.Context, m *metrics.Writer) error {
+	temps, err := host.SensorsTemperaturesWithContext(ctx)
+	if err != nil && len(temps) == 0 {
+		return err
+	}
+
+	name := metrics.Name("temperature_celsius")
+	m.Family(name, "Temperature of the hardware sensors.", metrics.Gauge)
+	for _, t := range temps {
+		m.Sample(name, t.Temperature, "sensor", t.SensorKey)
+	}
+
+	return nil
+}
+
+func RegisterMetricsProc() {
+	metrics.RegisterCollector("cpu", collectCPU)
+	metrics.RegisterCollector("memory", collectMemory)
+	metrics.RegisterCollector("disk", collectDisk)
+	metrics.RegisterCollector("netdev", collectNetDev)
+	metrics.RegisterCollector("protocol", collectProtocol)
+	metrics.RegisterCollector("temperature", collectTemperature)
+}
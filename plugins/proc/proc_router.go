@@ -10,6 +10,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -151,19 +152,19 @@ func routerAcquireSystem(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterProc(router *mux.Router) {
 	n := router.PathPrefix("/proc").Subrouter().StrictSlash(false)
 
-	n.HandleFunc("/sys/net/{path}/{property}", routerAcquireProcSysNet).Methods("GET")
-	n.HandleFunc("/sys/net/{path}/{link}/{property}", routerAcquireProcSysNet).Methods("GET")
-	n.HandleFunc("/sys/net/{path}/{property}", configureProcSysNet).Methods("PUT")
-	n.HandleFunc("/sys/net/{path}/{link}/{property}", configureProcSysNet).Methods("PUT")
+	openapi.Describe(n.HandleFunc("/sys/net/{path}/{property}", routerAcquireProcSysNet).Methods("GET"), "Show a network sysctl", nil, nil)
+	openapi.Describe(n.HandleFunc("/sys/net/{path}/{link}/{property}", routerAcquireProcSysNet).Methods("GET"), "Show a network sysctl", nil, nil)
+	openapi.Describe(n.HandleFunc("/sys/net/{path}/{property}", configureProcSysNet).Methods("PUT"), "Set a network sysctl", Proc{}, nil)
+	openapi.Describe(n.HandleFunc("/sys/net/{path}/{link}/{property}", configureProcSysNet).Methods("PUT"), "Set a network sysctl", Proc{}, nil)
 
-	n.HandleFunc("/sys/vm/{property}", routerAcquireProcSysVM).Methods("GET")
-	n.HandleFunc("/sys/vm/{property}", routerConfigureProcSysVM).Methods("PUT")
+	openapi.Describe(n.HandleFunc("/sys/vm/{property}", routerAcquireProcSysVM).Methods("GET"), "Show a VM sysctl", nil, nil)
+	openapi.Describe(n.HandleFunc("/sys/vm/{property}", routerConfigureProcSysVM).Methods("PUT"), "Set a VM sysctl", Proc{}, nil)
 
-	n.HandleFunc("/{system}", routerAcquireSystem).Methods("GET")
+	openapi.Describe(n.HandleFunc("/{system}", routerAcquireSystem).Methods("GET"), "Show system statistics", nil, nil)
 
-	n.HandleFunc("/net/arp", routerAcquireProcNetArp).Methods("GET")
-	n.HandleFunc("/netstat/{protocol}", routerAcquireProcNetStat).Methods("GET")
+	openapi.Describe(n.HandleFunc("/net/arp", routerAcquireProcNetArp).Methods("GET"), "Show the ARP table", nil, []NetARP{})
+	openapi.Describe(n.HandleFunc("/netstat/{protocol}", routerAcquireProcNetStat).Methods("GET"), "Show the sockets of a protocol", nil, nil)
 
-	n.HandleFunc("/process/{pid}/{property}", routerAcquireProcProcess).Methods("GET")
-	n.HandleFunc("/protopidstat/{pid}/{protocol}", routerAcquireProcPidNetStat).Methods("GET")
+	openapi.Describe(n.HandleFunc("/process/{pid}/{property}", routerAcquireProcProcess).Methods("GET"), "Show a property of a process", nil, nil)
+	openapi.Describe(n.HandleFunc("/protopidstat/{pid}/{protocol}", routerAcquireProcPidNetStat).Methods("GET"), "Show the sockets of a process", nil, nil)
 }
@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package systemd
+
+import (
+	"context"
+	"path"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vmware/pmd-next-gen/pkg/bus"
+	"github.com/vmware/pmd-next-gen/pkg/events"
+)
+
+const (
+	dbusManagerName = "org.freedesktop.systemd1"
+	dbusManagerPath = "/org/freedesktop/systemd1"
+	dbusUnitPath    = "/org/freedesktop/systemd1/unit"
+)
+
+type UnitEvent struct {
+	Unit        string                 `json:"Unit"`
+	Interface   string                 `json:"Interface"`
+	Changed     map[string]interface{} `json:"Changed"`
+	Invalidated []string               `json:"Invalidated,omitempty"`
+}
+
+// WatchUnits publishes the property changes of units until ctx is cancelled.
+// systemd emits them only while a client is subscribed to the manager, so
+// the connection stays subscribed for as long as it watches.
+func WatchUnits(ctx context.Context) error {
+	conn, err := bus.SystemBusPrivateConn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	manager := conn.Object(dbusManagerName, dbusManagerPath)
+	if err := manager.CallWithContext(ctx, dbusManagerName+".Manager.Subscribe", 0).Err; err != nil {
+		log.Errorf("Failed to subscribe to systemd manager: %v", err)
+		return err
+	}
+	defer func() {
+		if err := manager.Call(dbusManagerName+".Manager.Unsubscribe", 0).Err; err != nil {
+			log.Debugf("Failed to unsubscribe from systemd manager: %v", err)
+		}
+	}()
+
+	return bus.WatchPropertiesChangedConn(ctx, conn, dbusUnitPath, func(p *bus.PropertiesChanged) {
+		if p.Interface != "org.freedesktop.systemd1.Unit" {
+			return
+		}
+
+		events.Publish(events.TopicSystemd, UnitEvent{
+			Unit:        bus.UnescapePathLabel(path.Base(string(p.Path))),
+			Interface:   p.Interface,
+			Changed:     p.Changed,
+			Invalidated: p.Invalidated,
+		})
+	})
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/events"
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
 
@@ -30,6 +32,7 @@ func routerSystemdManagerDescribe(w http.ResponseWriter, r *http.Request) {
 	d, err := ManagerDescribe(r.Context())
 	if err != nil {
 		web.JSONResponseError(err, w)
+		return
 	}
 
 	web.JSONResponse(d, w)
@@ -52,7 +55,7 @@ func routerConfigureSystemdConf(w http.ResponseWriter, r *http.Request) {
 func routerConfigureUnit(w http.ResponseWriter, r *http.Request) {
 	u := UnitRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
-		http.Error(w, "Error decoding request", http.StatusBadRequest)
+		web.JSONResponseError(web.InvalidRequest(err), w)
 		return
 	}
 
@@ -119,20 +122,22 @@ func routerAcquireUnitTypeProperty(w http.ResponseWriter, r *http.Request) {
 func RegisterRouterSystemd(router *mux.Router) {
 	n := router.PathPrefix("/service").Subrouter()
 
+	events.RegisterSource(events.TopicSystemd, WatchUnits)
+
 	// systemd unit commands
-	n.HandleFunc("/systemd", routerConfigureUnit).Methods("POST")
+	openapi.Describe(n.HandleFunc("/systemd", routerConfigureUnit).Methods("POST"), "Start, stop or configure a unit", UnitRequest{}, nil)
 
 	// systemd unit status and property
-	n.HandleFunc("/systemd/manager/property/{property}", routerAcquireSystemdManagerProperty).Methods("GET")
-	n.HandleFunc("/systemd/manager/describe", routerSystemdManagerDescribe).Methods("GET")
+	openapi.Describe(n.HandleFunc("/systemd/manager/property/{property}", routerAcquireSystemdManagerProperty).Methods("GET"), "Show a property of the service manager", nil, nil)
+	openapi.Describe(n.HandleFunc("/systemd/manager/describe", routerSystemdManagerDescribe).Methods("GET"), "Describe the service manager", nil, Describe{})
 
-	n.HandleFunc("/systemd/units", routerAcquireAllSystemdUnits).Methods("GET")
-	n.HandleFunc("/systemd/{unit}/status", routerAcquireUnitStatus).Methods("GET")
-	n.HandleFunc("/systemd/{unit}/property", routerAcquireUnitProperty).Methods("GET")
-	n.HandleFunc("/systemd/{unit}/propertyall", routerAcquireUnitPropertyAll).Methods("GET")
-	n.HandleFunc("/systemd/{unit}/property/{unittype}", routerAcquireUnitTypeProperty).Methods("GET")
+	openapi.Describe(n.HandleFunc("/systemd/units", routerAcquireAllSystemdUnits).Methods("GET"), "List the units", nil, nil)
+	openapi.Describe(n.HandleFunc("/systemd/{unit}/status", routerAcquireUnitStatus).Methods("GET"), "Show the status of a unit", nil, UnitStatus{})
+	openapi.Describe(n.HandleFunc("/systemd/{unit}/property", routerAcquireUnitProperty).Methods("GET"), "Show the properties of a unit", nil, nil)
+	openapi.Describe(n.HandleFunc("/systemd/{unit}/propertyall", routerAcquireUnitPropertyAll).Methods("GET"), "Show all properties of a unit", nil, nil)
+	openapi.Describe(n.HandleFunc("/systemd/{unit}/property/{unittype}", routerAcquireUnitTypeProperty).Methods("GET"), "Show the properties of a unit type", nil, nil)
 
 	// systemd configuration
-	n.HandleFunc("/systemd/conf", routerConfigureSystemdConf)
-	n.HandleFunc("/systemd/conf/update", routerConfigureSystemdConf)
+	openapi.Describe(n.HandleFunc("/systemd/conf", routerConfigureSystemdConf), "Configure system.conf", nil, nil)
+	openapi.Describe(n.HandleFunc("/systemd/conf/update", routerConfigureSystemdConf), "Configure system.conf", nil, nil)
 }
@@ -5,6 +5,7 @@ package tdnf
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -64,6 +65,13 @@ type AlterResult struct {
 	Obsolete    []ListItem
 }
 
+// AlterJobResult is the result of a transaction job. Planned is the
+// transaction tdnf resolved before running it, nil if there was nothing to
+// do. The packages actually processed are in the job log.
+type AlterJobResult struct {
+	Planned *AlterResult `json:"Planned"`
+}
+
 type RepoQueryResult struct {
 	Nevra       string
 	Name        string
@@ -267,10 +275,10 @@ type ExecResult struct {
 	Err    error
 }
 
-func execWithResult(cmd string, args ...string) *ExecResult {
+func execWithResult(ctx context.Context, cmd string, args ...string) *ExecResult {
 	var result ExecResult
 
-	c := exec.Command(cmd, args...)
+	c := exec.CommandContext(ctx, cmd, args...)
 	c.Stdout = &result.Stdout
 	c.Stderr = &result.Stderr
 	result.Err = c.Run()
@@ -278,27 +286,32 @@ func execWithResult(cmd string, args ...string) *ExecResult {
 }
 
 func TdnfExec(options interface{}, args ...string) (string, error) {
+	return TdnfExecContext(context.Background(), options, args...)
+}
+
+// TdnfExecContext runs tdnf until it completes or ctx is cancelled.
+func TdnfExecContext(ctx context.Context, options interface{}, args ...string) (string, error) {
 	args = append([]string{"-j"}, args...)
 
 	if options != nil {
 		args = append(TdnfOptions(options), args...)
 	}
 	fmt.Printf("calling tdnf %v\n", args)
-	result := execWithResult("tdnf", args...)
+	result := execWithResult(ctx, "tdnf", args...)
 	if result.Err != nil {
 		return "", errors.Wrap(result.Err, result.Stderr.String())
 	}
 	return result.Stdout.String(), nil
 }
 
-func acquireCmdWithDelayedResponse(w http.ResponseWriter, cmd string, pkgs string, options interface{}) error {
-	job := jobs.CreateJob(func() (interface{}, error) {
+func acquireCmdWithDelayedResponse(w http.ResponseWriter, r *http.Request, cmd string, pkgs string, options interface{}) error {
+	job := jobs.CreateJob(r, func(ctx context.Context) (interface{}, error) {
 		var s string
 		var err error
 		if !validator.IsEmpty(pkgs) {
-			s, err = TdnfExec(options, append([]string{cmd}, strings.Split(pkgs, ",")...)...)
+			s, err = TdnfExecContext(ctx, options, append([]string{cmd}, strings.Split(pkgs, ",")...)...)
 		} else {
-			s, err = TdnfExec(options, cmd)
+			s, err = TdnfExecContext(ctx, options, cmd)
 		}
 		var result interface{}
 		if err := json.Unmarshal([]byte(s), &result); err != nil {
@@ -309,16 +322,16 @@ func acquireCmdWithDelayedResponse(w http.ResponseWriter, cmd string, pkgs strin
 	return jobs.AcceptedResponse(w, job)
 }
 
-func acquireCheckUpdate(w http.ResponseWriter, pkgs string, options Options) error {
-	return acquireCmdWithDelayedResponse(w, "check-update", pkgs, &options)
+func acquireCheckUpdate(w http.ResponseWriter, r *http.Request, pkgs string, options Options) error {
+	return acquireCmdWithDelayedResponse(w, r, "check-update", pkgs, &options)
 }
 
-func acquireList(w http.ResponseWriter, pkgs string, options ListOptions) error {
-	return acquireCmdWithDelayedResponse(w, "list", pkgs, &options)
+func acquireList(w http.ResponseWriter, r *http.Request, pkgs string, options ListOptions) error {
+	return acquireCmdWithDelayedResponse(w, r, "list", pkgs, &options)
 }
 
-func acquireSearch(w http.ResponseWriter, pkgs string, options Options) error {
-	return acquireCmdWithDelayedResponse(w, "search", pkgs, &options)
+func acquireSearch(w http.ResponseWriter, r *http.Request, pkgs string, options Options) error {
+	return acquireCmdWithDelayedResponse(w, r, "search", pkgs, &options)
 }
 
 func acquireRepoList(w http.ResponseWriter, options Options) error {
@@ -335,17 +348,17 @@ func acquireRepoList(w http.ResponseWriter, options Options) error {
 	return web.JSONResponse(repoList, w)
 }
 
-func acquireInfoList(w http.ResponseWriter, pkgs string, options ListOptions) error {
-	return acquireCmdWithDelayedResponse(w, "info", pkgs, &options)
+func acquireInfoList(w http.ResponseWriter, r *http.Request, pkgs string, options ListOptions) error {
+	return acquireCmdWithDelayedResponse(w, r, "info", pkgs, &options)
 }
 
-func acquireRepoQuery(w http.ResponseWriter, pkgs string, options RepoQueryOptions) error {
-	return acquireCmdWithDelayedResponse(w, "repoquery", pkgs, &options)
+func acquireRepoQuery(w http.ResponseWriter, r *http.Request, pkgs string, options RepoQueryOptions) error {
+	return acquireCmdWithDelayedResponse(w, r, "repoquery", pkgs, &options)
 }
 
-func acquireMakeCache(w http.ResponseWriter, options Options) error {
-	job := jobs.CreateJob(func() (interface{}, error) {
-		_, err := TdnfExec(&options, "makecache")
+func acquireMakeCache(w http.ResponseWriter, r *http.Request, options Options) error {
+	job := jobs.CreateJob(r, func(ctx context.Context) (interface{}, error) {
+		_, err := TdnfExecContext(ctx, &options, "makecache")
 		return nil, err
 	})
 	return jobs.AcceptedResponse(w, job)
@@ -360,32 +373,19 @@ func acquireClean(w http.ResponseWriter, options Options) error {
 	return web.JSONResponse("cleaned", w)
 }
 
-func acquireAlterCmd(w http.ResponseWriter, cmd string, pkgs string, options Options) error {
-	job := jobs.CreateJob(func() (interface{}, error) {
-		var s string
-		var err error
+func acquireAlterCmd(w http.ResponseWriter, r *http.Request, cmd string, pkgs string, options Options) error {
+	job := jobs.CreateJob(r, func(ctx context.Context) (interface{}, error) {
+		args := []string{cmd}
 		if !validator.IsEmpty(pkgs) {
-			s, err = TdnfExec(&options, append([]string{"-y", cmd}, strings.Split(pkgs, ",")...)...)
-		} else {
-			s, err = TdnfExec(&options, "-y", cmd)
-		}
-		if err != nil {
-			return nil, err
+			args = append(args, strings.Split(pkgs, ",")...)
 		}
-		var alterResult interface{}
-		// An empty response indicates that nothing was to do
-		if s != "" {
-			if err := json.Unmarshal([]byte(s), &alterResult); err != nil {
-				return nil, err
-			}
-		}
-		return alterResult, err
+		return TdnfAlterExec(ctx, &options, args...)
 	})
 	return jobs.AcceptedResponse(w, job)
 }
 
-func acquireUpdateInfo(w http.ResponseWriter, pkgs string, options UpdateInfoOptions) error {
-	return acquireCmdWithDelayedResponse(w, "updateinfo", pkgs, &options)
+func acquireUpdateInfo(w http.ResponseWriter, r *http.Request, pkgs string, options UpdateInfoOptions) error {
+	return acquireCmdWithDelayedResponse(w, r, "updateinfo", pkgs, &options)
 }
 
 func acquireVersion(w http.ResponseWriter, options Options) error {
@@ -423,29 +423,16 @@ func acquireHistoryInit(w http.ResponseWriter, options HistoryCmdOptions) error
 	return web.JSONResponse("history initialized", w)
 }
 
-func acquireHistoryAlterCmd(w http.ResponseWriter, cmd string, options HistoryCmdOptions) error {
-	job := jobs.CreateJob(func() (interface{}, error) {
-		var s string
-		var err error
-		s, err = TdnfExec(&options, "-y", "history", cmd)
-		if err != nil {
-			return nil, err
-		}
-		var alterResult interface{}
-		// An empty response indicates that nothing was to do
-		if s != "" {
-			if err := json.Unmarshal([]byte(s), &alterResult); err != nil {
-				return nil, err
-			}
-		}
-		return alterResult, err
+func acquireHistoryAlterCmd(w http.ResponseWriter, r *http.Request, cmd string, options HistoryCmdOptions) error {
+	job := jobs.CreateJob(r, func(ctx context.Context) (interface{}, error) {
+		return TdnfAlterExec(ctx, &options, "history", cmd)
 	})
 	return jobs.AcceptedResponse(w, job)
 }
 
-func acquireMarkCmd(w http.ResponseWriter, what string, pkgs string, options Options) error {
-	job := jobs.CreateJob(func() (interface{}, error) {
-		_, err := TdnfExec(&options, append([]string{"mark", what}, strings.Split(pkgs, ",")...)...)
+func acquireMarkCmd(w http.ResponseWriter, r *http.Request, what string, pkgs string, options Options) error {
+	job := jobs.CreateJob(r, func(ctx context.Context) (interface{}, error) {
+		_, err := TdnfExecContext(ctx, &options, append([]string{"mark", what}, strings.Split(pkgs, ",")...)...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright 2023 VMware, Inc.
+
+package tdnf
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/vmware/pmd-next-gen/pkg/jobs"
+)
+
+const maxErrorLines = 10
+
+// transactionSteps prefix the lines tdnf prints for each package while the
+// transaction runs.
+var transactionSteps = []string{"Installing/Updating:", "Removing:"}
+
+func (a *AlterResult) count() int {
+	return len(a.Install) + len(a.Upgrade) + len(a.Downgrade) + len(a.Remove) + len(a.Reinstall) + len(a.Obsolete)
+}
+
+func isTransactionStep(line string) bool {
+	for _, s := range transactionSteps {
+		if strings.HasPrefix(line, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// execStream runs cmd and hands each line written to stdout or stderr to f.
+// Lines redrawn with carriage returns, like progress bars, are passed in
+// their final form.
+func execStream(ctx context.Context, f func(line string), cmd string, args ...string) error {
+	pr, pw := io.Pipe()
+
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Stdout = pw
+	c.Stderr = pw
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		err := c.Wait()
+		pw.Close()
+		done <- err
+	}()
+
+	scanner := bufio.NewScanner(pr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		f(line[strings.LastIndex(line, "\r")+1:])
+	}
+
+	// Drain the pipe should the scanner have stopped early.
+	io.Copy(io.Discard, pr)
+
+	return <-done
+}
+
+// TdnfAlterExec runs a tdnf transaction within a job. The transaction is
+// resolved with --assumeno first to learn its size. It is then run with the
+// regular output of tdnf, which is added to the job log line by line while
+// the progress follows the packages processed.
+//
+// The regular output cannot be decoded, so the result of the job only holds
+// the planned transaction, labelled as such.
+func TdnfAlterExec(ctx context.Context, options interface{}, args ...string) (interface{}, error) {
+	plan := execWithResult(ctx, "tdnf", append(append(TdnfOptions(options), "-j", "--assumeno"), args...)...)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result := AlterJobResult{}
+	total := 0
+
+	// An empty response indicates that nothing was to do
+	if plan.Stdout.Len() > 0 {
+		a := AlterResult{}
+		if err := json.Unmarshal(plan.Stdout.Bytes(), &a); err != nil {
+			if plan.Err != nil {
+				return nil, errors.Wrap(plan.Err, plan.Stderr.String())
+			}
+			return nil, errors.Wrap(err, "failed to decode the tdnf transaction")
+		}
+		total = a.count()
+
+		// tdnf exits with an error as --assumeno declines the transaction,
+		// which only counts if no transaction was printed.
+		if plan.Err != nil && total == 0 {
+			return nil, errors.Wrap(plan.Err, plan.Stdout.String()+plan.Stderr.String())
+		}
+
+		result.Planned = &a
+	} else if plan.Err != nil {
+		return nil, errors.Wrap(plan.Err, plan.Stderr.String())
+	}
+
+	done := 0
+	var tail []string
+	err := execStream(ctx, func(line string) {
+		jobs.Log(ctx, line)
+
+		if tail = append(tail, line); len(tail) > maxErrorLines {
+			tail = tail[1:]
+		}
+
+		if total > 0 && isTransactionStep(line) {
+			done++
+			jobs.SetProgress(ctx, min(done*100/total, 99))
+		}
+	}, "tdnf", append(append(TdnfOptions(options), "-y"), args...)...)
+	if err != nil {
+		return nil, errors.Wrap(err, strings.Join(tail, "\n"))
+	}
+
+	return &result, nil
+}
@@ -4,7 +4,6 @@
 package tdnf
 
 import (
-	"errors"
 	"net/http"
 	"reflect"
 	"strconv"
@@ -12,6 +11,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/vmware/pmd-next-gen/pkg/openapi"
 	"github.com/vmware/pmd-next-gen/pkg/validator"
 	"github.com/vmware/pmd-next-gen/pkg/web"
 )
@@ -102,50 +102,51 @@ func routeracquireCommand(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if err = r.ParseForm(); err != nil {
-		web.JSONResponseError(err, w)
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
 	}
 	options := routerParseOptions(r.Form)
 
 	switch cmd := mux.Vars(r)["command"]; cmd {
 	case "autoremove":
-		err = acquireAlterCmd(w, cmd, "", options)
+		err = acquireAlterCmd(w, r, cmd, "", options)
 	case "check-update":
-		err = acquireCheckUpdate(w, "", options)
+		err = acquireCheckUpdate(w, r, "", options)
 	case "clean":
 		err = acquireClean(w, options)
 	case "distro-sync":
-		err = acquireAlterCmd(w, cmd, "", options)
+		err = acquireAlterCmd(w, r, cmd, "", options)
 	case "downgrade":
-		err = acquireAlterCmd(w, cmd, "", options)
+		err = acquireAlterCmd(w, r, cmd, "", options)
 	case "info":
 		listOptions := ListOptions{options, routerParseScopeOptions(r.Form)}
-		err = acquireInfoList(w, "", listOptions)
+		err = acquireInfoList(w, r, "", listOptions)
 	case "list":
 		listOptions := ListOptions{options, routerParseScopeOptions(r.Form)}
-		err = acquireList(w, "", listOptions)
+		err = acquireList(w, r, "", listOptions)
 	case "makecache":
-		err = acquireMakeCache(w, options)
+		err = acquireMakeCache(w, r, options)
 	case "repolist":
 		err = acquireRepoList(w, options)
 	case "repoquery":
 		repoQueryOptions := RepoQueryOptions{options, routerParseQueryOptions(r.Form)}
-		err = acquireRepoQuery(w, "", repoQueryOptions)
+		err = acquireRepoQuery(w, r, "", repoQueryOptions)
 	case "search":
 		q := r.FormValue("q")
 		if q != "" {
-			err = acquireSearch(w, q, options)
+			err = acquireSearch(w, r, q, options)
 		} else {
-			err = errors.New("search needs 'q=str' query")
+			err = web.NewError(web.ErrInvalidArgument, "search needs 'q=str' query").WithField("q", "", "required")
 		}
 	case "update":
-		err = acquireAlterCmd(w, cmd, "", options)
+		err = acquireAlterCmd(w, r, cmd, "", options)
 	case "updateinfo":
 		updateInfoOptions := UpdateInfoOptions{options, routerParseScopeOptions(r.Form), routerParseModeOptions(r.Form)}
-		err = acquireUpdateInfo(w, "", updateInfoOptions)
+		err = acquireUpdateInfo(w, r, "", updateInfoOptions)
 	case "version":
 		err = acquireVersion(w, options)
 	default:
-		err = errors.New("unsupported")
+		err = web.InvalidArgument("command", cmd)
 	}
 
 	if err != nil {
@@ -159,39 +160,40 @@ func routeracquireCommandPkgs(w http.ResponseWriter, r *http.Request) {
 	pkgs := mux.Vars(r)["pkgs"]
 
 	if err = r.ParseForm(); err != nil {
-		web.JSONResponseError(err, w)
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
 	}
 	options := routerParseOptions(r.Form)
 
 	switch cmd := mux.Vars(r)["command"]; cmd {
 	case "autoremove":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "downgrade":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "check-update":
-		err = acquireCheckUpdate(w, pkgs, options)
+		err = acquireCheckUpdate(w, r, pkgs, options)
 	case "erase":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "info":
 		listOptions := ListOptions{options, routerParseScopeOptions(r.Form)}
-		err = acquireInfoList(w, pkgs, listOptions)
+		err = acquireInfoList(w, r, pkgs, listOptions)
 	case "install":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "list":
 		listOptions := ListOptions{options, routerParseScopeOptions(r.Form)}
-		err = acquireList(w, pkgs, listOptions)
+		err = acquireList(w, r, pkgs, listOptions)
 	case "reinstall":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "repoquery":
 		repoQueryOptions := RepoQueryOptions{options, routerParseQueryOptions(r.Form)}
-		err = acquireRepoQuery(w, pkgs, repoQueryOptions)
+		err = acquireRepoQuery(w, r, pkgs, repoQueryOptions)
 	case "update":
-		err = acquireAlterCmd(w, cmd, pkgs, options)
+		err = acquireAlterCmd(w, r, cmd, pkgs, options)
 	case "updateinfo":
 		updateInfoOptions := UpdateInfoOptions{options, routerParseScopeOptions(r.Form), routerParseModeOptions(r.Form)}
-		err = acquireUpdateInfo(w, pkgs, updateInfoOptions)
+		err = acquireUpdateInfo(w, r, pkgs, updateInfoOptions)
 	default:
-		err = errors.New("unsupported")
+		err = web.InvalidArgument("command", cmd)
 	}
 
 	if err != nil {
@@ -203,7 +205,8 @@ func routeracquireHistoryCommand(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if err = r.ParseForm(); err != nil {
-		web.JSONResponseError(err, w)
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
 	}
 	options := routerParseOptions(r.Form)
 	historyCmdOptions := HistoryCmdOptions{options, routerParseHistoryOptions(r.Form)}
@@ -214,13 +217,13 @@ func routeracquireHistoryCommand(w http.ResponseWriter, r *http.Request) {
 	case "list":
 		err = acquireHistoryList(w, historyCmdOptions)
 	case "rollback":
-		err = acquireHistoryAlterCmd(w, cmd, historyCmdOptions)
+		err = acquireHistoryAlterCmd(w, r, cmd, historyCmdOptions)
 	case "undo":
-		err = acquireHistoryAlterCmd(w, cmd, historyCmdOptions)
+		err = acquireHistoryAlterCmd(w, r, cmd, historyCmdOptions)
 	case "redo":
-		err = acquireHistoryAlterCmd(w, cmd, historyCmdOptions)
+		err = acquireHistoryAlterCmd(w, r, cmd, historyCmdOptions)
 	default:
-		err = errors.New("unsupported")
+		err = web.InvalidArgument("command", cmd)
 	}
 
 	if err != nil {
@@ -233,17 +236,18 @@ func routeracquireMarkCommand(w http.ResponseWriter, r *http.Request) {
 
 	pkgs := mux.Vars(r)["pkgs"]
 	if err = r.ParseForm(); err != nil {
-		web.JSONResponseError(err, w)
+		web.JSONResponseError(web.InvalidRequest(err), w)
+		return
 	}
 	options := routerParseOptions(r.Form)
 
 	switch what := mux.Vars(r)["what"]; what {
 	case "install":
-		err = acquireMarkCmd(w, what, pkgs, options)
+		err = acquireMarkCmd(w, r, what, pkgs, options)
 	case "remove":
-		err = acquireMarkCmd(w, what, pkgs, options)
+		err = acquireMarkCmd(w, r, what, pkgs, options)
 	default:
-		err = errors.New("unsupported")
+		err = web.InvalidArgument("what", what)
 	}
 
 	if err != nil {
@@ -253,12 +257,12 @@ func routeracquireMarkCommand(w http.ResponseWriter, r *http.Request) {
 
 func RegisterRouterTdnf(router *mux.Router) {
 	nh := router.PathPrefix("/tdnf/history").Subrouter().StrictSlash(false)
-	nh.HandleFunc("/{command}", routeracquireHistoryCommand).Methods("GET")
+	openapi.Describe(nh.HandleFunc("/{command}", routeracquireHistoryCommand).Methods("GET"), "Run a tdnf history command", nil, nil)
 
 	nm := router.PathPrefix("/tdnf/mark").Subrouter().StrictSlash(false)
-	nm.HandleFunc("/{what}/{pkgs}", routeracquireMarkCommand).Methods("GET")
+	openapi.Describe(nm.HandleFunc("/{what}/{pkgs}", routeracquireMarkCommand).Methods("GET"), "Mark packages as installed by the user or as dependency", nil, nil)
 
 	n := router.PathPrefix("/tdnf").Subrouter().StrictSlash(false)
-	n.HandleFunc("/{command}/{pkgs}", routeracquireCommandPkgs).Methods("GET")
-	n.HandleFunc("/{command}", routeracquireCommand).Methods("GET")
+	openapi.Describe(n.HandleFunc("/{command}/{pkgs}", routeracquireCommandPkgs).Methods("GET"), "Run a tdnf command on packages, long commands create a job", nil, nil)
+	openapi.Describe(n.HandleFunc("/{command}", routeracquireCommand).Methods("GET"), "Run a tdnf command, long commands create a job", nil, nil)
 }